@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+
+	"github.com/fredbi/go-vcsfetch/internal/archive"
+	"github.com/fredbi/go-vcsfetch/internal/download"
+	"github.com/fredbi/go-vcsfetch/internal/git"
+	"github.com/fredbi/go-vcsfetch/internal/giturl"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/iofs"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+)
+
+// mayUseArchive determines if a [Locator] designates a repository archive which may be downloaded
+// without using git, e.g. a github "codeload" tarball.
+func (f *Cloner) mayUseArchive(locator Locator) (*url.URL, bool) {
+	gl, isGitLocator := locator.(*GitLocator)
+	if !isGitLocator || !gl.IsArchive {
+		return nil, false
+	}
+
+	// archives served by SCM platforms do not include submodules
+	if len(f.sparseFilter) > 0 || f.recurseSubModules {
+		return nil, false
+	}
+
+	// archive URLs do not carry the credentials of the repository URL: private repositories are cloned using git
+	if locator.HasAuth() {
+		return nil, false
+	}
+
+	archiveURL, err := giturl.Archive(locator)
+	if err != nil {
+		return nil, false
+	}
+
+	return archiveURL, true
+}
+
+// mayUseZipArchive determines if a [Locator] may be cloned by downloading a zip archive of the repository,
+// when enabled by [CloneWithZipArchive].
+func (f *Cloner) mayUseZipArchive(locator Locator) (*url.URL, bool) {
+	if !f.zipArchive || len(f.sparseFilter) > 0 || f.recurseSubModules || f.requireSigned || f.allTags || len(f.refSpecs) > 0 || f.referenceName != "" {
+		return nil, false
+	}
+
+	if locator.HasAuth() {
+		// see mayUseArchive
+		return nil, false
+	}
+
+	version := locator.Version()
+	if version == "" || version == git.HEAD || !f.mayResolveWithoutGit(version) {
+		return nil, false
+	}
+
+	archiveURL, err := giturl.ZipArchive(locator)
+	if err != nil {
+		return nil, false
+	}
+
+	return archiveURL, true
+}
+
+// cloneArchive downloads and extracts a repository tarball.
+func (f *Cloner) cloneArchive(ctx context.Context, archiveURL *url.URL) (fs.FS, error) {
+	var dst billy.Filesystem
+	if f.isFSBacked && f.dir != "" {
+		dst = osfs.New(f.dir, osfs.WithBoundOS())
+	} else {
+		dst = memfs.New()
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+
+	go func() {
+		err := archive.ExtractTarGz(pr, dst, f.archiveOptions()...)
+		_ = pr.CloseWithError(err)
+		done <- err
+	}()
+
+	err := download.Content(ctx, archiveURL, pw, f.toInternalDownloadOptions())
+	_ = pw.CloseWithError(err)
+	extractErr := <-done
+
+	switch {
+	case extractErr != nil && (err == nil || errors.Is(err, extractErr)):
+		// the download is interrupted when the extraction fails
+		return nil, fmt.Errorf("could not extract archive from %q: %w: %w", redact.URL(archiveURL), extractErr, ErrVCS)
+	case err != nil:
+		return nil, fmt.Errorf("could not download archive from %q: %w: %w", redact.URL(archiveURL), redact.Error(err), ErrVCS)
+	}
+
+	return iofs.New(dst), nil
+}
+
+// cloneZipArchive downloads and extracts a zip archive of a repository.
+//
+// Since a zip archive is read from its end, it is first downloaded to a temporary file.
+func (f *Cloner) cloneZipArchive(ctx context.Context, archiveURL *url.URL) (fs.FS, error) {
+	tmp, err := os.CreateTemp("", "vcsclone-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("could not create a temporary file: %w: %w", err, ErrVCS)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	// the archive is not extracted before it is fully downloaded: its size is capped like the extracted content
+	cw := &countingWriter{w: archive.LimitWriter(tmp, f.archiveOptions()...)}
+	if err = download.Content(ctx, archiveURL, cw, f.toInternalDownloadOptions()); err != nil {
+		return nil, fmt.Errorf("could not download archive from %q: %w: %w", redact.URL(archiveURL), redact.Error(err), ErrVCS)
+	}
+
+	var dst billy.Filesystem
+	if f.isFSBacked && f.dir != "" {
+		dst = osfs.New(f.dir, osfs.WithBoundOS())
+	} else {
+		dst = memfs.New()
+	}
+
+	if err = archive.ExtractZip(tmp, cw.written, dst, f.archiveOptions()...); err != nil {
+		return nil, fmt.Errorf("could not extract archive from %q: %w: %w", redact.URL(archiveURL), err, ErrVCS)
+	}
+
+	return iofs.New(dst), nil
+}
+
+// archiveOptions yields the options to download and extract repository archives.
+func (o cloneOptions) archiveOptions() []archive.Option {
+	if o.archiveMaxSize == 0 {
+		return nil // defaults to archive.DefaultMaxSize
+	}
+
+	return []archive.Option{archive.WithMaxSize(o.archiveMaxSize)}
+}
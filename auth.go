@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"net/url"
+	"os"
+
+	"github.com/fredbi/go-vcsfetch/internal/download"
+	"github.com/fredbi/go-vcsfetch/internal/git"
+	"github.com/fredbi/go-vcsfetch/internal/giturl"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/codecommit"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// basicAuth holds credentials for HTTP basic authentication.
+type basicAuth struct {
+	username string
+	password string
+
+	// gitAuth replaces basic authentication for git operations, e.g. to sign requests to AWS CodeCommit
+	gitAuth transport.AuthMethod
+
+	// header replaces basic authentication for raw-content downloads, e.g. "Authorization: Bearer {token}"
+	header      string
+	headerValue string
+}
+
+// Environment variables holding the tokens used by [FetchWithAuthFromEnv].
+const (
+	githubTokenEnv    = "GITHUB_TOKEN"
+	gitlabTokenEnv    = "GITLAB_TOKEN"
+	bitbucketTokenEnv = "BITBUCKET_TOKEN"
+	giteaTokenEnv     = "GITEA_TOKEN"
+	azureDevOpsPATEnv = "AZURE_DEVOPS_PAT"
+)
+
+// authForRepo yields the credentials configured for a given repository, or nil.
+//
+// Explicitly configured credentials take precedence over those provided by a git credential helper.
+func (o fetchOptions) authForRepo(ctx context.Context, repoURL *url.URL) *basicAuth {
+	if auth := o.providerAuthForRepo(repoURL); auth != nil {
+		return auth
+	}
+
+	if o.authFromEnv {
+		if auth := envAuthForRepo(repoURL, o.githubEnterpriseHosts); auth != nil {
+			return auth
+		}
+	}
+
+	if o.credentialHelper {
+		return credentialFill(ctx, repoURL)
+	}
+
+	return nil
+}
+
+func (o fetchOptions) providerAuthForRepo(repoURL *url.URL) *basicAuth {
+	if o.gitlabDeployToken == nil && o.giteaToken == nil && o.bitbucketAppPassword == nil && o.awsCredentials == nil {
+		return nil
+	}
+
+	// tokens are only sent to a host known for sure to belong to their provider,
+	// not to a host merely looking like one, such as "gitlab.attacker.example"
+	switch provider, _ := giturl.TrustedProvider(repoURL.Host); provider {
+	case giturl.ProviderGitlab:
+		return o.gitlabDeployToken
+	case giturl.ProviderGitea:
+		return o.giteaToken
+	case giturl.ProviderBitBucket:
+		return o.bitbucketAppPassword
+	}
+
+	// CodeCommit hosts are only detected on the AWS domains
+	if provider, _, _ := giturl.AutoDetect(repoURL); provider != giturl.ProviderCodeCommit || o.awsCredentials == nil {
+		return nil
+	}
+
+	return &basicAuth{gitAuth: codecommit.NewAuth(*o.awsCredentials)}
+}
+
+// envAuthForRepo yields the credentials for a repository from the token set in the environment for its provider, or nil.
+//
+// Tokens are only sent to the public instance of their provider (e.g. github.com), or to the hosts explicitly
+// registered for this provider (see [RegisterProvider]), or to the declared GitHub Enterprise hosts:
+// a host merely looking like a provider's, such as "github.attacker.example", never receives a token.
+func envAuthForRepo(repoURL *url.URL, githubEnterpriseHosts []string) *basicAuth {
+	provider, trusted := giturl.TrustedProvider(repoURL.Host)
+	if !trusted && giturl.IsGithubEnterpriseHost(repoURL.Host, githubEnterpriseHosts...) {
+		provider, trusted = giturl.ProviderGithub, true
+	}
+
+	if !trusted {
+		return nil
+	}
+
+	switch provider {
+	case giturl.ProviderGithub:
+		if token := os.Getenv(githubTokenEnv); token != "" {
+			return &basicAuth{username: "x-access-token", password: token, header: "Authorization", headerValue: "Bearer " + token}
+		}
+	case giturl.ProviderGitlab:
+		if token := os.Getenv(gitlabTokenEnv); token != "" {
+			// GitLab accepts access tokens as bearer tokens: unlike its custom PRIVATE-TOKEN header,
+			// the Authorization header is not forwarded when a download is redirected to another host
+			return &basicAuth{username: "oauth2", password: token, header: "Authorization", headerValue: "Bearer " + token}
+		}
+	case giturl.ProviderBitBucket:
+		if token := os.Getenv(bitbucketTokenEnv); token != "" {
+			return &basicAuth{username: "x-token-auth", password: token, header: "Authorization", headerValue: "Bearer " + token}
+		}
+	case giturl.ProviderGitea:
+		if token := os.Getenv(giteaTokenEnv); token != "" {
+			return giteaAuth(token)
+		}
+	case giturl.ProviderAzure:
+		if token := os.Getenv(azureDevOpsPATEnv); token != "" {
+			// Azure DevOps ignores the username of a personal access token
+			return &basicAuth{username: "pat", password: token}
+		}
+	}
+
+	return nil
+}
+
+// giteaAuth yields the credentials for a Gitea access token.
+//
+// Gitea accepts a token as the password of any user for git over http(s).
+func giteaAuth(token string) *basicAuth {
+	return &basicAuth{username: "oauth2", password: token, header: "Authorization", headerValue: "token " + token}
+}
+
+func (a *basicAuth) applyToDownload(o *download.Options) {
+	if a == nil || a.gitAuth != nil {
+		return
+	}
+
+	if a.header != "" {
+		if o.CustomHeaders == nil {
+			o.CustomHeaders = make(map[string]string, 1)
+		}
+		o.CustomHeaders[a.header] = a.headerValue
+
+		return
+	}
+
+	o.BasicAuthUsername = a.username
+	o.BasicAuthPassword = a.password
+}
+
+func (a *basicAuth) applyToGit(o *git.Options) {
+	if a == nil {
+		return
+	}
+
+	if a.gitAuth != nil {
+		o.Auth = a.gitAuth
+
+		return
+	}
+
+	o.Auth = &githttp.BasicAuth{
+		Username: a.username,
+		Password: a.password,
+	}
+}
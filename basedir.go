@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// FetchWithBaseDir resolves the path of the fetched files relative to a subdirectory of the repository,
+// e.g. for monorepos publishing Go modules in subdirectories.
+//
+// With a base directory "services/api", fetching "go.mod" retrieves "services/api/go.mod".
+// The base directory applies to raw-content downloads, git checkouts and [Fetcher.ListDir].
+//
+// NOTE: [FetchWithBaseDir] panics if dir is an absolute path, or if it points outside of the repository.
+func FetchWithBaseDir(dir string) FetchOption {
+	base := path.Clean(strings.ReplaceAll(dir, `\`, "/"))
+	if path.IsAbs(base) || base == ".." || strings.HasPrefix(base, "../") {
+		panic(fmt.Errorf("invalid base directory %q: expected a relative path inside the repository: %w", dir, ErrVCS))
+	}
+
+	if base == "." {
+		base = ""
+	}
+
+	return func(o *fetchOptions) {
+		o.baseDir = base
+	}
+}
+
+// withBaseDir resolves the path of a [Locator] relative to the base directory set by [FetchWithBaseDir].
+func (o fetchOptions) withBaseDir(locator Locator) Locator {
+	if o.baseDir == "" {
+		return locator
+	}
+
+	return &baseDirLocator{
+		Locator: locator,
+		baseDir: o.baseDir,
+	}
+}
+
+// baseDirLocator is a [Locator] which path is resolved relative to a base directory.
+type baseDirLocator struct {
+	Locator
+
+	baseDir string
+}
+
+func (l *baseDirLocator) Path() string {
+	return path.Join(l.baseDir, l.Locator.Path())
+}
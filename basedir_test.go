@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetchWithBaseDir(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should download raw content relative to the base directory", func(t *testing.T) {
+		t.Parallel()
+
+		transport := newStubTransport(func(*http.Request) *http.Response {
+			return stubResponse(http.StatusOK, "module example.com/api")
+		})
+		fetcher := NewFetcher(
+			FetchWithHTTPClient(&http.Client{Transport: transport}),
+			FetchWithBaseDir("services/api"),
+		)
+
+		w := new(bytes.Buffer)
+		require.NoError(t, fetcher.Fetch(t.Context(), w, "https://github.com/fredbi/go-vcsfetch/blob/v1.0.0/go.mod"))
+		require.Equal(t, "module example.com/api", w.String())
+
+		requests := transport.Requests()
+		require.Len(t, requests, 1)
+		require.Equal(t, "https://raw.githubusercontent.com/fredbi/go-vcsfetch/v1.0.0/services/api/go.mod", requests[0].URL.String())
+	})
+
+	repo := testrepo.New(t)
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{
+		"go.mod":              "module example.com/root",
+		"services/api/go.mod": "module example.com/api",
+	}, "initial"))
+
+	t.Run("should check out a file relative to the base directory", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher := NewFetcher(FetchWithManifest(true), FetchWithBaseDir("services/api/"))
+
+		w := new(bytes.Buffer)
+		result, err := fetcher.FetchLocatorWithResult(t.Context(), w, fixtureLocator(repo, "go.mod", "v1.0.0"))
+		require.NoError(t, err)
+		require.Equal(t, "module example.com/api", w.String())
+		require.Equal(t, []string{"services/api/go.mod"}, result.Files)
+
+		// the base directory may be overridden for a single call
+		w.Reset()
+		require.NoError(t, fetcher.FetchLocator(t.Context(), w, fixtureLocator(repo, "go.mod", "v1.0.0"), FetchWithBaseDir("")))
+		require.Equal(t, "module example.com/root", w.String())
+	})
+
+	t.Run("should reject a base directory outside of the repository", func(t *testing.T) {
+		t.Parallel()
+
+		require.Panics(t, func() { FetchWithBaseDir("/services/api") })
+		require.Panics(t, func() { FetchWithBaseDir("services/../../api") })
+		require.NotPanics(t, func() { FetchWithBaseDir("./services/api") })
+	})
+}
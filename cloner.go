@@ -5,12 +5,16 @@ package vcsfetch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/url"
+	"os"
+	"sync"
 
 	"github.com/fredbi/go-vcsfetch/internal/git"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
 )
 
 // Cloner allows for working with vcs repositories to perform cloning or sparse cloning.
@@ -32,6 +36,8 @@ import (
 // Once a repository has been cloned, it becomes accessible via [Cloner.FS].
 //
 // You may use [Cloner.Close] to relinquish memory or temporary disk resources and reuse the [Cloner].
+// [Cloner.Close] may be called from another goroutine: in-flight operations are canceled, and
+// the temporary resources are removed only once these operations have returned.
 //
 // Exception: when using [CloneWithBackingDir] with a non-empty directory, the cloned content
 // is not removed after usage and left up to the caller to leave it or clean it if needed.
@@ -40,12 +46,23 @@ type Cloner struct {
 
 	clonedURL *url.URL
 	clonedFS  fs.FS
+	result    *CloneResult
+
+	mu       sync.Mutex
+	inflight int             // number of in-flight operations
+	idle     chan struct{}   // closed when the last in-flight operation returns
+	closers  int             // number of calls to CloseContext in progress
+	closing  context.Context // canceled when closing the cloner
+	stop     context.CancelFunc
 }
 
 // NewCloner builds a [Cloner] to retrieve an entire vcs repository.
 func NewCloner(opts ...CloneOption) *Cloner {
+	o := optionsWithDefaults(opts)
+	o.withDownloadClient()
+
 	return &Cloner{
-		cloneOptions: optionsWithDefaults(opts),
+		cloneOptions: o,
 	}
 }
 
@@ -55,11 +72,14 @@ func NewCloner(opts ...CloneOption) *Cloner {
 //
 // The URL is detected to be either a valid SPDX locator or a well-known giturl.
 //
+// A giturl pointing to a folder at a given ref, e.g. https://github.com/fredbi/go-vcsfetch/tree/v1.0.0,
+// clones the entire repository checked out at this ref.
+//
 // The clone is accessible as a read-only [fs.FS] using [Cloner.FS].
 func (f *Cloner) CloneRepo(ctx context.Context, repoURL string) error {
 	u, err := url.Parse(repoURL)
 	if err != nil {
-		return fmt.Errorf("expected a valid URL: %w: %w", err, ErrVCS)
+		return fmt.Errorf("expected a valid URL: %w: %w", redact.Error(err), ErrVCS)
 	}
 
 	return f.CloneURL(ctx, u)
@@ -68,16 +88,68 @@ func (f *Cloner) CloneRepo(ctx context.Context, repoURL string) error {
 // CloneLocator clones a vcs repository from a [Locator].
 //
 // The clone is accessible as a read-only [fs.FS] using [Cloner.FS].
+//
+// Any previous clone is discarded first, so a failed clone leaves the [Cloner] in a clean state.
 func (f *Cloner) CloneLocator(ctx context.Context, locator Locator, opts ...CloneOption) error {
-	repo := git.NewRepo(locator.RepoURL(), f.toInternalGitOptions())
-
-	fs, err := repo.Clone(ctx, locator.Version(), f.toInternalGitCloneOptions())
+	ctx, done, err := f.begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer done()
+
+	f.Reset()
+	repoLocator := f.rewriteLocator(locator)
+
+	// short-circuit that avoids the use of git, when the locator designates an archive
+	// of the entire repository that the SCM serves over http (e.g. a github tarball).
+	if archiveURL, ok := f.mayUseArchive(repoLocator); ok {
+		fs, err := f.cloneArchive(ctx, archiveURL)
+		if err != nil {
+			return err
+		}
+
+		f.clonedURL = locator.RepoURL()
+		f.clonedFS = fs
+		f.result = &CloneResult{
+			ShortName: locator.Version(),
+		}
+
+		return nil
+	}
+
+	// short-circuit that avoids the use of git when a snapshot of the repository is enough,
+	// by downloading a zip archive of the repository from the SCM (e.g. github or gitlab).
+	if zipURL, ok := f.mayUseZipArchive(repoLocator); ok {
+		fs, err := f.cloneZipArchive(ctx, zipURL)
+		if err != nil {
+			return err
+		}
+
+		f.clonedURL = locator.RepoURL()
+		f.clonedFS = fs
+		f.result = &CloneResult{
+			ShortName: locator.Version(),
+		}
+
+		return nil
+	}
+
+	repo := git.NewRepo(repoLocator.RepoURL(), f.toInternalGitOptions())
+
+	fs, result, err := repo.Clone(ctx, locator.Version(), f.toInternalGitCloneOptions())
+	if err != nil {
+		return gitError(err)
+	}
 
 	f.clonedURL = locator.RepoURL()
 	f.clonedFS = fs
+	f.result = &CloneResult{
+		ShortName: result.ShortName,
+		Hash:      result.Hash,
+		Objects:   result.Objects,
+		Sparse:    result.Sparse,
+		Cached:    result.Cached,
+	}
 
 	return nil
 }
@@ -106,18 +178,34 @@ func (f *Cloner) FS() fs.FS {
 	return f.clonedFS
 }
 
+// Result reports about the last successful clone, e.g. the resolved ref and commit.
+//
+// It returns nil if no clone is available.
+func (f *Cloner) Result() *CloneResult {
+	return f.result
+}
+
 // FetchFromClone fetches a single file from the cloned repository.
 func (f *Cloner) FetchFromClone(ctx context.Context, w io.Writer, location string) error {
 	u, err := url.Parse(location)
 	if err != nil {
-		return fmt.Errorf("expected a valid URL: %w: %w", err, ErrVCS)
+		return fmt.Errorf("expected a valid URL: %w: %w", redact.Error(err), ErrVCS)
 	}
 
 	return f.FetchURLFromClone(ctx, w, u)
 }
 
 // FetchLocatorFromClone fetches a single file from the cloned repository, using a [Locator].
+//
+// It fails with [ErrFileNotFound] if the file does not exist in the clone,
+// and with [ErrIsDirectory] if the path designates a directory.
 func (f *Cloner) FetchLocatorFromClone(ctx context.Context, w io.Writer, locator Locator) error {
+	_, done, err := f.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	if f.clonedURL == nil || f.clonedFS == nil {
 		return fmt.Errorf("cannot fetch from clone: no clone available yet: %w", ErrVCS)
 	}
@@ -128,12 +216,25 @@ func (f *Cloner) FetchLocatorFromClone(ctx context.Context, w io.Writer, locator
 
 	file, err := f.clonedFS.Open(locator.Path())
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("cannot fetch %q from clone: %w: %w: %w", locator.Path(), err, ErrFileNotFound, ErrVCS)
+		}
+
 		return fmt.Errorf("cannot fetch from clone: %w: %w", err, ErrVCS)
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot fetch from clone: %w: %w", err, ErrVCS)
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("cannot fetch %q from clone: %w: %w", locator.Path(), ErrIsDirectory, ErrVCS)
+	}
+
 	_, err = io.Copy(w, file)
 
 	return err
@@ -156,13 +257,112 @@ func (f *Cloner) FetchURLFromClone(ctx context.Context, w io.Writer, u *url.URL)
 	return f.FetchLocatorFromClone(ctx, w, locator)
 }
 
-// Close resets the state of the cloner.
+// Reset clears the state of the cloner, so it may be reused to clone another repository.
+//
+// The options of the [Cloner] are retained.
+func (f *Cloner) Reset() {
+	f.clonedURL = nil
+	f.clonedFS = nil
+	f.result = nil
+}
+
+// Close resets the state of the cloner and relinquishes its resources.
+//
+// This is equivalent to [Cloner.CloseContext] with a context that is never canceled.
 func (f *Cloner) Close() error {
-	if f.clonedFS == nil {
-		return nil
+	return f.CloseContext(context.Background())
+}
+
+// CloseContext resets the state of the cloner and relinquishes its resources.
+//
+// In-flight operations, e.g. a clone running in another goroutine, are canceled first,
+// and [Cloner.CloseContext] waits for them to return. Only then is the temporary directory
+// created by [CloneWithBackingDir] removed, so it is never removed while still in use.
+//
+// Operations started while the cloner is being closed fail with an error.
+//
+// If ctx is done before in-flight operations return, [Cloner.CloseContext] gives up waiting
+// and returns an error, leaving resources in place.
+//
+// The [Cloner] may be reused after being closed, or after giving up closing.
+func (f *Cloner) CloseContext(ctx context.Context) error {
+	f.mu.Lock()
+	f.closers++
+	if f.stop != nil {
+		f.stop()
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.closers--
+		if f.closers == 0 {
+			// the next operation starts with a fresh context
+			f.closing, f.stop = nil, nil
+		}
+	}()
+
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return fmt.Errorf("could not wait for in-flight operations to return: %w: %w", ctx.Err(), ErrVCS)
+		}
 	}
 
-	f.clonedFS = nil // TODO: relinquish resources?
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Reset()
+
+	if f.isTempDir && f.dir != "" {
+		if err := os.RemoveAll(f.dir); err != nil {
+			return fmt.Errorf("could not remove temporary directory: %w: %w", err, ErrVCS)
+		}
+	}
 
 	return nil
 }
+
+// begin registers an in-flight operation, which context is canceled when the cloner is closed.
+//
+// New operations are rejected while the cloner is being closed.
+//
+// The returned function must be called when the operation returns.
+func (f *Cloner) begin(ctx context.Context) (context.Context, func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closers > 0 {
+		return nil, nil, fmt.Errorf("cannot start an operation while the cloner is being closed: %w", ErrVCS)
+	}
+
+	if f.closing == nil {
+		f.closing, f.stop = context.WithCancel(context.Background())
+	}
+
+	if f.inflight == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.inflight++
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(f.closing, cancel)
+
+	return ctx, func() {
+		stop()
+		cancel()
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.inflight--
+		if f.inflight == 0 {
+			close(f.idle)
+			f.idle = nil
+		}
+	}, nil
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// credentialHelperTimeout bounds the time spent waiting for a git credential helper.
+const credentialHelperTimeout = 10 * time.Second
+
+// credentialFill obtains credentials for a repository from the git credential helpers configured by the user,
+// using "git credential fill".
+//
+// It returns nil whenever git is not installed, no helper knows about the repository or the repository URL is
+// not https. Credentials are never prompted for.
+//
+// URLs with values which would break the line-oriented protocol of the helpers (i.e. containing a newline,
+// a carriage return or a NUL character) are never submitted.
+func credentialFill(ctx context.Context, repoURL *url.URL) *basicAuth {
+	if repoURL == nil || repoURL.Scheme != "https" || repoURL.Host == "" {
+		return nil
+	}
+
+	if _, hasPassword := repoURL.User.Password(); hasPassword {
+		// explicit credentials in the URL take precedence
+		return nil
+	}
+
+	host := repoURL.Host
+	pth := strings.TrimPrefix(repoURL.Path, "/")
+	username := repoURL.User.Username()
+	if !isCredentialValue(host) || !isCredentialValue(pth) || !isCredentialValue(username) {
+		// a crafted URL could otherwise inject attributes, e.g. ask the helper for the credentials of another host
+		return nil
+	}
+
+	var input strings.Builder
+	input.WriteString("protocol=https\n")
+	input.WriteString("host=" + host + "\n")
+	if pth != "" {
+		input.WriteString("path=" + pth + "\n")
+	}
+	if username != "" {
+		input.WriteString("username=" + username + "\n")
+	}
+	input.WriteString("\n")
+
+	ctx, cancel := context.WithTimeout(ctx, credentialHelperTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(input.String())
+	// never fall back to an interactive prompt when no helper provides the credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	return parseCredentials(output)
+}
+
+// isCredentialValue checks that a value may be safely submitted to a git credential helper.
+func isCredentialValue(value string) bool {
+	return !strings.ContainsAny(value, "\n\r\x00")
+}
+
+// parseCredentials parses the key=value output of "git credential fill".
+func parseCredentials(output []byte) *basicAuth {
+	auth := &basicAuth{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "username":
+			auth.username = value
+		case "password":
+			auth.password = value
+		}
+	}
+
+	if auth.password == "" {
+		return nil
+	}
+
+	return auth
+}
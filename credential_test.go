@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/cgi"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetcherCredentialHelper(t *testing.T) {
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("this test requires the git binary")
+	}
+
+	// a fake credential helper, configured in an isolated git config
+	home := t.TempDir()
+	helper := filepath.Join(home, "credential-helper.sh")
+	calls := filepath.Join(home, "calls") // records the queries submitted to the helper
+	require.NoError(t, os.WriteFile(helper, fmt.Appendf(nil, `#!/bin/sh
+test "$1" = get || exit 0
+cat >> %q
+echo username=alice
+echo password=s3cret
+`, calls), 0o700)) //nolint:gosec // the helper must be executable
+	gitConfig := filepath.Join(home, ".gitconfig")
+	require.NoError(t, os.WriteFile(gitConfig, fmt.Appendf(nil, "[credential]\n\thelper = %s\n", helper), 0o600))
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", gitConfig)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	repo := testrepo.New(t)
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{"docs/hello.txt": "hello world\n"}, "initial commit"))
+
+	backend := &cgi.Handler{
+		Path: gitBin,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + filepath.Dir(repo.Dir),
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	server, bundle := newTLSServerWithCA(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "alice" || password != "s3cret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		backend.ServeHTTP(w, r)
+	}))
+	locator := &MockLocator{
+		RepoURLFunc: func() *url.URL {
+			return mustParseTestURL(t, server.URL+"/"+filepath.Base(repo.Dir))
+		},
+		PathFunc:    func() string { return "docs/hello.txt" },
+		VersionFunc: func() string { return "v1.0.0" },
+	}
+
+	t.Run("should authenticate with the credentials of the helper", func(t *testing.T) {
+		fetcher := NewFetcher(FetchWithCABundle(bundle), FetchWithCredentialHelper(true))
+
+		w := new(bytes.Buffer)
+		require.NoError(t, fetcher.FetchLocator(t.Context(), w, locator))
+		require.Equal(t, "hello world\n", w.String())
+	})
+
+	t.Run("should not use the helper unless enabled", func(t *testing.T) {
+		fetcher := NewFetcher(FetchWithCABundle(bundle))
+
+		err := fetcher.FetchLocator(t.Context(), new(bytes.Buffer), locator)
+		require.ErrorIs(t, err, ErrVCS)
+	})
+
+	t.Run("should map the helper credentials to git and download auth", func(t *testing.T) {
+		o := optionsWithDefaults([]FetchOption{FetchWithCredentialHelper(true)})
+		u := mustParseTestURL(t, "https://git.example.com/fredbi/go-vcsfetch")
+
+		gitOptions := o.toInternalGitOptions()
+		o.authForRepo(t.Context(), u).applyToGit(gitOptions)
+		auth, ok := gitOptions.Auth.(*githttp.BasicAuth)
+		require.True(t, ok)
+		require.Equal(t, "alice", auth.Username)
+		require.Equal(t, "s3cret", auth.Password)
+
+		downloadOptions := o.toInternalDownloadOptions()
+		o.authForRepo(t.Context(), u).applyToDownload(downloadOptions)
+		require.Equal(t, "alice", downloadOptions.BasicAuthUsername)
+		require.Equal(t, "s3cret", downloadOptions.BasicAuthPassword)
+	})
+
+	t.Run("should prefer explicit credentials", func(t *testing.T) {
+		o := optionsWithDefaults([]FetchOption{
+			FetchWithCredentialHelper(true),
+			FetchWithGitLabDeployToken("deployer", "token"),
+		})
+
+		auth := o.authForRepo(t.Context(), mustParseTestURL(t, "https://gitlab.com/fredbi/go-vcsfetch"))
+		require.NotNil(t, auth)
+		require.Equal(t, "deployer", auth.username)
+	})
+
+	t.Run("should query the helper once when listing a folder", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(calls))
+		fetcher := NewFetcher(FetchWithCABundle(bundle), FetchWithCredentialHelper(true))
+
+		entries, err := fetcher.ListDirLocator(t.Context(), &MockLocator{
+			RepoURLFunc: locator.RepoURLFunc,
+			PathFunc:    func() string { return "docs" },
+			VersionFunc: locator.VersionFunc,
+		})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		queries, err := os.ReadFile(calls)
+		require.NoError(t, err)
+		require.Equal(t, 1, bytes.Count(queries, []byte("protocol=https\n")))
+	})
+
+	t.Run("should NOT submit values breaking the helper protocol", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(calls))
+		o := optionsWithDefaults([]FetchOption{FetchWithCredentialHelper(true)})
+
+		for _, u := range []string{
+			"https://git.example.com/fredbi/repo%0Ahost=attacker.example.com",
+			"https://git.example.com/fredbi/repo%0Dhost=attacker.example.com",
+			"https://git.example.com/fredbi/repo%00",
+			"https://bob%0Ahost=attacker.example.com@git.example.com/fredbi/repo",
+		} {
+			require.Nil(t, o.authForRepo(t.Context(), mustParseTestURL(t, u)), u)
+		}
+
+		_, err := os.Stat(calls)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("should only query the helper for https", func(t *testing.T) {
+		o := optionsWithDefaults([]FetchOption{FetchWithCredentialHelper(true)})
+
+		require.Nil(t, o.authForRepo(t.Context(), mustParseTestURL(t, "http://git.example.com/fredbi/go-vcsfetch")))
+		require.Nil(t, o.authForRepo(t.Context(), mustParseTestURL(t, "ssh://git@git.example.com/fredbi/go-vcsfetch")))
+	})
+}
+
+func TestParseCredentials(t *testing.T) {
+	t.Parallel()
+
+	auth := parseCredentials([]byte("protocol=https\nhost=example.com\nusername=bob\npassword=pa=ss\n"))
+	require.NotNil(t, auth)
+	require.Equal(t, "bob", auth.username)
+	require.Equal(t, "pa=ss", auth.password)
+
+	require.Nil(t, parseCredentials([]byte("protocol=https\nhost=example.com\n")))
+}
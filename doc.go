@@ -42,6 +42,9 @@
 //
 // - v2 resolves as the latest v2.x.y tag (i.e. <v3)
 // - v2.1 resolves as the latest v2.1.y tag (i.e. <v2.2)
+// - wildcards are tolerated: v2.x and v2.* resolve like v2, and v2.1.x resolves like v2.1
+//
+// A branch or a tag named after a wildcard, e.g. a "v2.x" maintenance branch, wins over the wildcard resolution.
 //
 // Partial version behavior may be disabled with [FetchWithExactTag] (resp. [CloneWithExactTag] when cloning).
 //
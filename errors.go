@@ -3,6 +3,16 @@
 
 package vcsfetch
 
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/git"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+)
+
 type vcsFetchError string
 
 func (e vcsFetchError) Error() string {
@@ -11,3 +21,141 @@ func (e vcsFetchError) Error() string {
 
 // ErrVCS is a sentinel error for all errors that originate from this package.
 const ErrVCS vcsFetchError = "vcsfetch error"
+
+// ErrMaxBytesExceeded is returned when the fetched content exceeds the limit set by [FetchWithMaxBytes].
+//
+// Errors of this kind also match [ErrVCS].
+const ErrMaxBytesExceeded vcsFetchError = "maximum content size exceeded"
+
+// ErrScratchQuotaExceeded is returned when the content spilled to disk exceeds the quota set by [FetchWithScratch].
+//
+// Errors of this kind also match [ErrVCS].
+const ErrScratchQuotaExceeded vcsFetchError = "scratch quota exceeded"
+
+// ErrEmptyContent is returned when the fetched file is empty, and [FetchWithRejectEmpty] is enabled.
+//
+// Errors of this kind also match [ErrVCS].
+const ErrEmptyContent vcsFetchError = "empty content"
+
+// ErrTimeout is returned when a fetch does not complete within the duration set by [FetchWithOverallTimeout].
+//
+// Errors of this kind also match [ErrVCS].
+const ErrTimeout vcsFetchError = "fetch timeout exceeded"
+
+// ErrInvalidLocation is returned by [ValidateLocation] when a location cannot be fetched.
+//
+// Errors of this kind also match [ErrVCS].
+const ErrInvalidLocation vcsFetchError = "invalid location"
+
+// ErrFileNotFound is returned when the requested file does not exist in the repository at the resolved version.
+//
+// Errors of this kind also match [ErrVCS].
+const ErrFileNotFound vcsFetchError = "file not found"
+
+// ErrIsDirectory is returned when the requested file is a directory.
+//
+// Errors of this kind also match [ErrVCS].
+const ErrIsDirectory vcsFetchError = "is a directory"
+
+// ErrSymlinkOutsideRepo is returned when the requested file is a symbolic link which target lies outside of the repository.
+//
+// Symbolic links within the repository are resolved when retrieving content using git: the content of their target is fetched.
+//
+// Errors of this kind also match [ErrVCS].
+const ErrSymlinkOutsideRepo vcsFetchError = "symbolic link points outside of the repository"
+
+// ErrAccessDenied is returned when the SCM denies access to the requested file over http,
+// e.g. because the repository is private or the rate limit of its API is exhausted.
+//
+// Errors of this kind also match [ErrVCS].
+const ErrAccessDenied vcsFetchError = "access denied"
+
+// SSORequiredError is returned when github denies access to a repository of an organization which enforces
+// SAML single sign-on, because the token used is not authorized for this organization.
+//
+// The token must be authorized for the organization, e.g. by visiting AuthorizationURL.
+//
+// Errors of this kind also match [ErrAccessDenied] and [ErrVCS].
+type SSORequiredError struct {
+	// AuthorizationURL is the URL to visit to authorize the token for the organization, if provided by github.
+	AuthorizationURL string
+}
+
+func (e *SSORequiredError) Error() string {
+	if e.AuthorizationURL == "" {
+		return "access denied: the token must be authorized for the SAML single sign-on of the organization"
+	}
+
+	return "access denied: the token must be authorized for the SAML single sign-on of the organization, visit " + e.AuthorizationURL
+}
+
+func (e *SSORequiredError) Is(target error) bool {
+	return target == ErrAccessDenied || target == ErrVCS
+}
+
+// ErrUnsupportedTransport is returned when a location uses a git transport that is not supported,
+// such as a remote helper like "ext::" or "gcrypt::".
+//
+// Errors of this kind also match [ErrVCS].
+const ErrUnsupportedTransport vcsFetchError = "unsupported git transport"
+
+// ErrUnsupportedProtocol is returned when the remote server requires a version of the git wire protocol
+// that is not supported, i.e. a server that only speaks git protocol v2.
+//
+// Errors of this kind also match [ErrVCS].
+const ErrUnsupportedProtocol vcsFetchError = "unsupported git protocol version"
+
+// ErrUnsupportedSignature is returned when [FetchWithRequireSignedCommit] is enabled and the commit,
+// or the annotated tag pointing to it, is signed with a format other than OpenPGP, e.g. an SSH or X.509 signature.
+//
+// Errors of this kind also match [ErrVCS].
+const ErrUnsupportedSignature vcsFetchError = "unsupported signature format"
+
+// AmbiguousCommitError is returned when a version is an abbreviated commit hash that matches several commits.
+//
+// Errors of this kind also match [ErrVCS].
+type AmbiguousCommitError struct {
+	// Prefix is the abbreviated commit hash.
+	Prefix string
+
+	// Candidates are the hashes of all the commits matching the prefix.
+	Candidates []string
+}
+
+func (e *AmbiguousCommitError) Error() string {
+	return fmt.Sprintf("ambiguous abbreviated commit %q: candidates are %s", e.Prefix, strings.Join(e.Candidates, ", "))
+}
+
+func (e *AmbiguousCommitError) Is(target error) bool {
+	return target == ErrVCS
+}
+
+// gitError wraps an error from the git layer, exposing typed errors from this package.
+func gitError(err error) error {
+	var ambiguous *git.AmbiguousCommitError
+	if errors.As(err, &ambiguous) {
+		return &AmbiguousCommitError{
+			Prefix:     ambiguous.Prefix,
+			Candidates: slices.Clone(ambiguous.Candidates),
+		}
+	}
+
+	err = redact.Error(err) // the git layer may report the URL of the remote
+	if errors.Is(err, git.ErrProtocolV2) {
+		return errors.Join(err, ErrUnsupportedProtocol, ErrVCS)
+	}
+
+	if errors.Is(err, git.ErrFileNotFound) {
+		return errors.Join(err, ErrFileNotFound, ErrVCS)
+	}
+
+	if errors.Is(err, git.ErrSymlinkOutsideRepo) {
+		return errors.Join(err, ErrSymlinkOutsideRepo, ErrVCS)
+	}
+
+	if errors.Is(err, git.ErrUnsupportedSignature) {
+		return errors.Join(err, ErrUnsupportedSignature, ErrVCS)
+	}
+
+	return errors.Join(err, ErrVCS)
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"io"
+	"net/url"
+
+	"github.com/fredbi/go-vcsfetch/internal/git"
+)
+
+// fetchExecutor retrieves a single file from a git repository.
+//
+// The default executor is a [git.Repository]. Tests may inject a double with [withFetchExecutor],
+// so as to exercise a [Fetcher] without network access.
+type fetchExecutor interface {
+	FetchWithResult(ctx context.Context, w io.Writer, file, ref string) (*git.FetchResult, error)
+}
+
+// fetchExecutorFactory builds a [fetchExecutor] for a repository.
+type fetchExecutorFactory func(repoURL *url.URL, opts *git.Options) fetchExecutor
+
+// withFetchExecutor replaces the git implementation used by a [Fetcher].
+func withFetchExecutor(factory fetchExecutorFactory) FetchOption {
+	return func(o *fetchOptions) {
+		o.newExecutor = factory
+	}
+}
+
+func (o fetchOptions) fetchExecutor(repoURL *url.URL, opts *git.Options) fetchExecutor {
+	if o.newExecutor != nil {
+		return o.newExecutor(repoURL, opts)
+	}
+
+	return git.NewRepo(repoURL, opts)
+}
@@ -4,17 +4,22 @@
 package vcsfetch
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/url"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/blang/semver/v4"
 	"github.com/fredbi/go-vcsfetch/internal/download"
 	"github.com/fredbi/go-vcsfetch/internal/git"
 	"github.com/fredbi/go-vcsfetch/internal/giturl"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
 )
 
 // Fetcher allows for working with vcs repositories to perform cloning, sparse cloning
@@ -35,8 +40,11 @@ type Fetcher struct {
 
 // NewFetcher builds a [Fetcher] to retrieve single files from a vcs repository.
 func NewFetcher(opts ...FetchOption) *Fetcher {
+	o := optionsWithDefaults(opts)
+	o.withDownloadClient()
+
 	return &Fetcher{
-		fetchOptions: optionsWithDefaults(opts),
+		fetchOptions: o,
 	}
 }
 
@@ -44,14 +52,21 @@ func NewFetcher(opts ...FetchOption) *Fetcher {
 //
 // The content of the fetched file is copied to the passed [io.Writer].
 //
-// The string argument must be a valid URL.
-func (f *Fetcher) Fetch(ctx context.Context, w io.Writer, location string) error {
+// The string argument must be a valid URL, or an scp-like location when [FetchWithPreferHTTPS] is enabled.
+//
+// Options passed to [Fetcher.Fetch] apply to this call only, on top of the options of the [Fetcher].
+func (f *Fetcher) Fetch(ctx context.Context, w io.Writer, location string, opts ...FetchOption) error {
 	u, err := url.Parse(location)
 	if err != nil {
-		return fmt.Errorf("expected a valid URL: %w: %w", err, ErrVCS)
+		scp, isSCP := scpLikeURL(location)
+		if !isSCP || !f.withCallOptions(opts).preferHTTPS {
+			return fmt.Errorf("expected a valid URL: %w: %w", redact.Error(err), ErrVCS)
+		}
+
+		u = scp // e.g. "git@github.com:owner/repo", rewritten to https
 	}
 
-	return f.FetchURL(ctx, w, u)
+	return f.FetchURL(ctx, w, u, opts...)
 }
 
 // FetchLocator fetches a single file specified by a [Locator] from a vcs location.
@@ -62,11 +77,100 @@ func (f *Fetcher) Fetch(ctx context.Context, w io.Writer, location string) error
 //
 // NOTE: this package provides 2 implementations of the [Locator].
 // You may pass your own implementation of this interface to this method.
-func (f *Fetcher) FetchLocator(ctx context.Context, w io.Writer, locator Locator) error {
+//
+// Options passed to [Fetcher.FetchLocator] apply to this call only, on top of the options of the [Fetcher].
+func (f *Fetcher) FetchLocator(ctx context.Context, w io.Writer, locator Locator, opts ...FetchOption) error {
+	_, err := f.FetchLocatorWithResult(ctx, w, locator, opts...)
+
+	return err
+}
+
+// FetchLocatorWithResult fetches a single file specified by a [Locator], like [Fetcher.FetchLocator],
+// and reports about the fetch with a [FetchResult].
+func (f *Fetcher) FetchLocatorWithResult(ctx context.Context, w io.Writer, locator Locator, opts ...FetchOption) (*FetchResult, error) {
+	f = f.withCallOptions(opts)
+	locator = f.withBaseDir(f.rewriteLocator(locator))
+
 	if f.requireVersion && locator.Version() == "" {
-		return fmt.Errorf("an explicit version is required, but %v does not specify a version: %w", locator, ErrVCS)
+		return nil, fmt.Errorf("an explicit version is required, but %v does not specify a version: %w", redact.String(locator.String()), ErrVCS)
+	}
+
+	if f.overallTimeout > 0 {
+		timeoutCtx, cancel := context.WithTimeoutCause(ctx, f.overallTimeout, ErrTimeout)
+		defer cancel()
+		ctx = timeoutCtx
+	}
+
+	tw, wait := transformWriter(w, f.contentTransforms())
+	cw := &countingWriter{w: limitWriter(tw, f.maxBytes)}
+	result, err := f.fetchWithMirrors(ctx, cw, locator)
+	if err = f.checkLimits(ctx, wait(err)); err != nil {
+		return nil, err
+	}
+
+	if f.rejectEmpty && cw.written == 0 {
+		return nil, fmt.Errorf("the fetched file %q is empty: %w: %w", locator.Path(), ErrEmptyContent, ErrVCS)
+	}
+
+	return result, nil
+}
+
+// withCallOptions yields a [Fetcher] with per-call options applied on top of the options of f.
+//
+// f is left unchanged, so it remains safe for concurrent use.
+func (f *Fetcher) withCallOptions(opts []FetchOption) *Fetcher {
+	if len(opts) == 0 {
+		return f
+	}
+
+	o := f.fetchOptions
+	// clip shared slices: appending per-call options must not write into the backing arrays owned by f
+	o.transforms = slices.Clip(o.transforms)
+	o.spdxOpts = slices.Clip(o.spdxOpts)
+	o.gitLocOpts = slices.Clip(o.gitLocOpts)
+	o.urlRewrites = slices.Clip(o.urlRewrites)
+	o.githubEnterpriseHosts = slices.Clip(o.githubEnterpriseHosts)
+
+	for _, apply := range opts {
+		apply(&o)
+	}
+
+	if o.httpClient != f.httpClient || !bytes.Equal(o.locOptions.caBundle, f.locOptions.caBundle) || o.caBundleWithoutSystem != f.caBundleWithoutSystem ||
+		o.dialTimeout != f.dialTimeout || o.responseHeaderTimeout != f.responseHeaderTimeout ||
+		o.maxIdleConnsPerHost != f.maxIdleConnsPerHost || o.forceHTTP2 != f.forceHTTP2 {
+		o.withDownloadClient()
+	}
+
+	return &Fetcher{
+		fetchOptions: o,
+	}
+}
+
+// checkLimits reports errors caused by the limits set by [FetchWithMaxBytes] or [FetchWithOverallTimeout].
+func (f *Fetcher) checkLimits(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(context.Cause(ctx), ErrTimeout) {
+		return fmt.Errorf("the fetch did not complete within %v: %w: %w: %w", f.overallTimeout, err, ErrTimeout, ErrVCS)
+	}
+
+	if errors.Is(err, ErrMaxBytesExceeded) {
+		return fmt.Errorf("the fetched content exceeds %d bytes: %w: %w", f.maxBytes, err, ErrVCS)
 	}
 
+	return err
+}
+
+func (f *Fetcher) fetchLocator(ctx context.Context, w io.Writer, locator Locator) (*FetchResult, error) {
+	if dir, ok := f.localWorkingTree(locator); ok {
+		// short-circuit that reads a file from a local working tree, when explicitly allowed
+		return f.fetchLocalFile(w, dir, locator.Path())
+	}
+
+	auth := f.authForRepo(ctx, locator.RepoURL())
+
 	// short-circuit that avoids the use of git thanks to a direct raw-content download URL from the SCM.
 	//
 	// This works fine on github.com and all gitlab instances.
@@ -74,50 +178,229 @@ func (f *Fetcher) FetchLocator(ctx context.Context, w io.Writer, locator Locator
 	// Skipped when:
 	// - the URL of the repo doesn't support raw content download (e.g. ssh scheme, unrecognized SCM host)
 	// - option set to explicitly skip this optimization
+	// - submodules are resolved
+	// - signatures are verified
+	// - notes are retrieved
+	// - all tags or extra refspecs are fetched
 	// - version is an incomplete semver specification
-	if rawURL, ok := f.mayUseDownload(locator); ok {
-		if e := download.Content(ctx, rawURL, w, f.toInternalDownloadOptions()); e != nil {
-			return fmt.Errorf("could not fetch raw content from %q: %w: %w", rawURL, e, ErrVCS)
+	// - version is a semver tag matched in both its forms, with or without a "v" prefix
+	// - version is an abbreviated commit hash
+	//
+	// When the raw-content endpoint of the SCM doesn't resolve "HEAD", an unspecified version is first resolved
+	// to the default branch of the repository.
+	//
+	// When credentials are configured, the repository is possibly private: a raw-content URL may then respond with
+	// an HTML login page rather than the expected content. Such responses are rejected and we fall back to git.
+	if rawURL, accept, ok := f.mayUseDownload(ctx, locator, auth); ok {
+		downloadOptions := f.toInternalDownloadOptions()
+		isPossiblyPrivate := auth != nil || locator.HasAuth()
+		downloadOptions.RejectHTML = isPossiblyPrivate && !isHTMLFile(locator.Path()) && accept != GithubMediaTypeHTML
+		if accept != "" {
+			downloadOptions.CustomHeaders = map[string]string{"Accept": string(accept)}
+		}
+		auth.applyToDownload(downloadOptions)
+
+		e := download.Content(ctx, rawURL, w, downloadOptions)
+		var ssoErr *download.SSORequiredError
+		switch {
+		case e == nil:
+			result := &FetchResult{Source: FetchSourceRaw}
+			if f.manifest {
+				result.Files = []string{path.Clean(locator.Path())}
+			}
+
+			return result, nil
+		case errors.Is(e, download.ErrUnexpectedContent):
+			// fall back to git
+		case errors.Is(e, download.ErrNotFound), errors.Is(e, download.ErrUnprocessable):
+			// the github contents API responds with 422 when it can't resolve the ref or the path
+			return nil, fmt.Errorf("could not fetch raw content from %q: %w: %w: %w", redact.URL(rawURL), redact.Error(e), ErrFileNotFound, ErrVCS)
+		case errors.As(e, &ssoErr):
+			return nil, fmt.Errorf("could not fetch raw content from %q: %w", redact.URL(rawURL), &SSORequiredError{AuthorizationURL: ssoErr.AuthorizationURL})
+		case errors.Is(e, download.ErrForbidden):
+			return nil, fmt.Errorf("could not fetch raw content from %q: %w: %w: %w", redact.URL(rawURL), redact.Error(e), ErrAccessDenied, ErrVCS)
+		default:
+			return nil, fmt.Errorf("could not fetch raw content from %q: %w: %w", redact.URL(rawURL), redact.Error(e), ErrVCS)
 		}
 	}
 
 	// general-purpose git retrieval
-	repo := git.NewRepo(locator.RepoURL(), f.toInternalGitOptions())
-	if err := repo.Fetch(ctx, w, locator.Path(), locator.Version()); err != nil {
-		return errors.Join(err, ErrVCS)
+	gitOptions := f.toInternalGitOptions()
+	auth.applyToGit(gitOptions)
+
+	repoURL := locator.RepoURL()
+	var (
+		gitResult *git.FetchResult
+		err       error
+	)
+
+	for redirects := 0; ; redirects++ {
+		repo := f.fetchExecutor(repoURL, gitOptions)
+		gitResult, err = repo.FetchWithResult(ctx, w, locator.Path(), locator.Version())
+		if err == nil || errors.Is(err, fs.ErrNotExist) || !f.followGitRedirects || redirects >= maxGitRedirects {
+			break
+		}
+
+		movedURL, ok := f.gitRedirect(ctx, repoURL, auth)
+		if !ok {
+			break
+		}
+
+		repoURL = movedURL
 	}
 
-	return nil
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.Join(err, ErrFileNotFound, ErrVCS)
+		}
+
+		return nil, gitError(err)
+	}
+
+	return &FetchResult{
+		Files:  gitResult.Files,
+		Note:   gitResult.Note,
+		Source: FetchSource(gitResult.Source),
+	}, nil
 }
 
-func (f *Fetcher) mayUseDownload(locator Locator) (*url.URL, bool) {
-	if f.skipRawURL {
-		return nil, false
+func isHTMLFile(pth string) bool {
+	ext := strings.ToLower(path.Ext(pth))
+
+	return ext == ".html" || ext == ".htm" || ext == ".xhtml"
+}
+
+// mayUseDownload yields the URL to download the file of a locator over http, and the media type to request
+// from the SCM if the URL is an API.
+func (f *Fetcher) mayUseDownload(ctx context.Context, locator Locator, auth *basicAuth) (*url.URL, GithubMediaType, bool) {
+	if !f.mayBypassGit(locator) {
+		return nil, "", false
 	}
-	if !download.Supported(locator.RepoURL()) {
-		return nil, false
+
+	if f.rawDefaultRef == "" || locator.Version() != "" {
+		pinned, ok := pinDefaultBranch(ctx, locator, func(ctx context.Context) (string, error) {
+			gitOptions := f.toInternalGitOptions()
+			auth.applyToGit(gitOptions)
+
+			return git.NewRepo(locator.RepoURL(), gitOptions).DefaultBranch(ctx)
+		}, f.rawOptions()...)
+		if !ok {
+			return nil, "", false
+		}
+
+		locator = pinned
+	}
+
+	if f.githubContentsAPI {
+		if contentsURL, err := giturl.Contents(locator); err == nil {
+			return contentsURL, f.githubAccept(), true
+		}
+	}
+
+	rawURL, err := giturl.Raw(locator, f.rawOptions()...)
+	if err != nil {
+		return nil, "", false
+	}
+
+	return rawURL, "", true
+}
+
+// pinDefaultBranch pins a locator without a version (or "HEAD") to the default branch of the repository,
+// whenever the raw-content endpoint of the SCM does not resolve "HEAD" by itself.
+//
+// It returns false if the default branch could not be resolved.
+func pinDefaultBranch(ctx context.Context, locator Locator, defaultBranch func(context.Context) (string, error), rawOpts ...giturl.RawOption) (Locator, bool) {
+	if version := locator.Version(); version != "" && version != git.HEAD {
+		return locator, true
 	}
 
-	rawURL, err := giturl.Raw(locator)
+	if giturl.RawSupportsHEAD(locator.RepoURL(), rawOpts...) {
+		return locator, true
+	}
+
+	branch, err := defaultBranch(ctx)
 	if err != nil {
 		return nil, false
 	}
 
-	if f.resolveExactTag {
-		return rawURL, true
+	return &pinnedLocator{
+		Locator: locator,
+		version: branch,
+	}, true
+}
+
+// pinnedLocator is a [Locator] with a resolved version.
+type pinnedLocator struct {
+	Locator
+
+	version string
+}
+
+func (l *pinnedLocator) Version() string {
+	return l.version
+}
+
+// mayBypassGit tells if a locator may be retrieved over http from the SCM, without using git.
+func (f *Fetcher) mayBypassGit(locator Locator) bool {
+	if f.skipRawURL || f.recurseSubModules || f.requireSigned || f.notes || f.allTags || len(f.refSpecs) > 0 || f.referenceName != "" {
+		return false
+	}
+	if !download.Supported(locator.RepoURL()) {
+		return false
+	}
+
+	return f.mayResolveWithoutGit(locator.Version())
+}
+
+// mayResolveWithoutGit tells if a version is resolved by the endpoints of SCM platforms, e.g. a branch, an exact tag
+// or a commit hash, as opposed to a version that requires a lookup of the remote refs with git, e.g. "v1.2".
+func (o gitOptions) mayResolveWithoutGit(version string) bool {
+	if _, isDate := git.ParseDateRef(version); isDate && o.dateRefs {
+		return false // dates are resolved by walking the git history
+	}
+
+	if o.canonicalTags {
+		if _, err := semver.ParseTolerant(version); err == nil {
+			return false // the raw-content endpoints only know about one form of a tag, e.g. "v1.2.3" but not "1.2.3"
+		}
+	}
+
+	if git.IsCommitHash(version) && !git.IsFullCommitHash(version) {
+		return false // abbreviated hashes are resolved with git, which reports ambiguous hashes
 	}
 
-	_, err = semver.ParseTolerant(locator.Version())
+	if o.resolveExactTag || git.IsFullCommitHash(version) {
+		// raw-content endpoints resolve exact tags and commit hashes, e.g. from a permalink
+		return true
+	}
+
+	version = git.TrimVersionWildcard(version) // e.g. "v2.x" resolves like "v2"
+	_, err := semver.ParseTolerant(version)
 	if err != nil {
-		return rawURL, true // not a semver ref
+		return true // not a semver ref
 	}
 
-	desiredSemverLevel := min(strings.Count(locator.Version(), "."), 2) + 1
-	if desiredSemverLevel != 3 {
-		return nil, false // download does not support version lookup
+	desiredSemverLevel := min(strings.Count(version, "."), 2) + 1
+
+	return desiredSemverLevel == 3 // download does not support version lookup
+}
+
+// Close releases the resources held by the [Fetcher], such as idle connections kept by the
+// http client configured with [FetchWithHTTPClient].
+//
+// Close is a no-op if the [Fetcher] holds no such resources.
+//
+// The [Fetcher] remains usable after Close.
+func (f *Fetcher) Close() error {
+	if f.httpClient != nil {
+		f.httpClient.CloseIdleConnections()
 	}
 
-	return rawURL, true
+	if f.downloadClient != nil && f.downloadClient != f.httpClient {
+		f.downloadClient.CloseIdleConnections()
+	}
+
+	return nil
 }
 
 // FetchURL fetches a single file from a vcs location as an URL.
@@ -128,19 +411,14 @@ func (f *Fetcher) mayUseDownload(locator Locator) (*url.URL, bool) {
 // Otherwise, it falls back to git-url parsing and is equivalent to [Fetcher.FetchLocator] with a [GitLocator].
 //
 // If you want to retrieve an URL representing a folder, use [Cloner.CloneURL] with sparse option instead.
-func (f *Fetcher) FetchURL(ctx context.Context, w io.Writer, u *url.URL) error {
-	var locator Locator
-	spdxLocator, err := SPDXLocatorFromURL(u, f.spdxOpts...)
-	if err == nil {
-		// prioritize spdx locator
-		locator = spdxLocator
-	} else {
-		// fallback on a giturl
-		gitLocator, err := GitLocatorFromURL(u, f.gitLocOpts...)
-		if err != nil {
-			return fmt.Errorf("the provided URL is not a SPDX locator or a recognized git URL: %w: %w", err, ErrVCS)
-		}
-		locator = gitLocator
+//
+// Options passed to [Fetcher.FetchURL] apply to this call only, on top of the options of the [Fetcher].
+func (f *Fetcher) FetchURL(ctx context.Context, w io.Writer, u *url.URL, opts ...FetchOption) error {
+	f = f.withCallOptions(opts)
+
+	locator, err := f.locatorFromURL(u)
+	if err != nil {
+		return err
 	}
 
 	if err := f.FetchLocator(ctx, w, locator); err != nil {
@@ -149,3 +427,49 @@ func (f *Fetcher) FetchURL(ctx context.Context, w io.Writer, u *url.URL) error {
 
 	return nil
 }
+
+// RawURL yields the raw-content URL from which the SCM serves the file designated by a vcs location string,
+// e.g. to hand it over to another tool such as curl or a browser.
+//
+// No network call is performed.
+//
+// An error is returned if the location is not a recognized vcs location, or if its provider does not
+// support raw-content URLs.
+//
+// Notice that the [Fetcher] may still prefer to use git to retrieve this location, e.g. if the version
+// is an incomplete semver specification that requires a lookup of the remote tags.
+func (f *Fetcher) RawURL(location string) (*url.URL, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("expected a valid URL: %w: %w", redact.Error(err), ErrVCS)
+	}
+
+	locator, err := f.locatorFromURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	rawURL, err := giturl.Raw(f.rewriteLocator(locator), f.rawOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("no raw-content URL for %q: %w: %w", redact.String(location), err, ErrVCS)
+	}
+
+	return rawURL, nil
+}
+
+// locatorFromURL detects a [SPDXLocator] or falls back to a [GitLocator].
+func (f *Fetcher) locatorFromURL(u *url.URL) (Locator, error) {
+	spdxLocator, err := SPDXLocatorFromURL(u, f.spdxOpts...)
+	if err == nil {
+		// prioritize spdx locator
+		return spdxLocator, nil
+	}
+
+	// fallback on a giturl
+	gitLocator, err := GitLocatorFromURL(u, f.gitLocOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("the provided URL is not a SPDX locator or a recognized git URL: %w: %w", err, ErrVCS)
+	}
+
+	return gitLocator, nil
+}
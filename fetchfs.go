@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+	"github.com/go-git/go-billy/v5/helper/iofs"
+	"github.com/go-git/go-billy/v5/memfs"
+)
+
+// FetchFS fetches a single file from a vcs location string, like [Fetcher.Fetch], and exposes it as a read-only [fs.FS].
+//
+// The [fs.FS] holds only the fetched file, in memory, at its path relative to the repository,
+// e.g. "docs/README.md". This is convenient for consumers of [io/fs], such as [template.ParseFS].
+//
+// The location must designate a file, not a folder: use [Cloner.Clone] with a sparse filter to retrieve a folder.
+//
+// Options passed to [Fetcher.FetchFS] apply to this call only, on top of the options of the [Fetcher].
+//
+// [template.ParseFS]: https://pkg.go.dev/text/template#ParseFS
+func (f *Fetcher) FetchFS(ctx context.Context, location string, opts ...FetchOption) (fs.FS, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("expected a valid URL: %w: %w", redact.Error(err), ErrVCS)
+	}
+
+	f = f.withCallOptions(opts)
+	locator, err := f.locatorFromURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	name := path.Clean(strings.Trim(locator.Path(), "/"))
+	if !fs.ValidPath(name) || name == "." {
+		return nil, fmt.Errorf("expected %v to designate a file: %w: %w", redact.String(locator.String()), ErrIsDirectory, ErrVCS)
+	}
+
+	dst := memfs.New()
+	if err = dst.MkdirAll(path.Dir(name), outputDirMode); err != nil {
+		return nil, fmt.Errorf("could not create folder for %q: %w: %w", name, err, ErrVCS)
+	}
+
+	file, err := dst.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not create %q: %w: %w", name, err, ErrVCS)
+	}
+
+	if err = f.FetchLocator(ctx, file, locator); err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	if err = file.Close(); err != nil {
+		return nil, fmt.Errorf("could not write %q: %w: %w", name, err, ErrVCS)
+	}
+
+	return iofs.New(dst), nil
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetchFS(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Commit(map[string]string{
+		"docs/README.md":      "content",
+		"templates/hello.tpl": "hello {{ . }}",
+		"LICENSE":             "license",
+	}, "initial commit")
+	fetcher := NewFetcher()
+
+	t.Run("should expose the fetched file at its path in the repository", func(t *testing.T) {
+		t.Parallel()
+
+		fsys, err := fetcher.FetchFS(t.Context(), repo.Dir+"@master/docs/README.md")
+		require.NoError(t, err)
+
+		content, err := fs.ReadFile(fsys, "docs/README.md")
+		require.NoError(t, err)
+		require.Equal(t, "content", string(content))
+
+		entries, err := fs.ReadDir(fsys, ".")
+		require.NoError(t, err)
+		require.Len(t, entries, 1, "the FS should only hold the fetched file")
+
+		_, err = fs.Stat(fsys, "LICENSE")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("should be usable by io/fs consumers", func(t *testing.T) {
+		t.Parallel()
+
+		fsys, err := fetcher.FetchFS(t.Context(), repo.Dir+"@master/templates/hello.tpl")
+		require.NoError(t, err)
+
+		tpl, err := template.ParseFS(fsys, "templates/*.tpl")
+		require.NoError(t, err)
+
+		var w strings.Builder
+		require.NoError(t, tpl.Execute(&w, "world"))
+		require.Equal(t, "hello world", w.String())
+	})
+
+	t.Run("should fail on a missing file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := fetcher.FetchFS(t.Context(), repo.Dir+"@master/docs/missing.md")
+		require.ErrorIs(t, err, ErrVCS)
+	})
+
+	t.Run("should fail on a repository root", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := fetcher.FetchFS(t.Context(), repo.Dir+"@master")
+		require.ErrorIs(t, err, ErrIsDirectory)
+	})
+}
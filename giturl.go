@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	"github.com/fredbi/go-vcsfetch/internal/giturl"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/github"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
 )
 
 var _ Locator = &GitLocator{}
@@ -29,6 +31,10 @@ type GitLocator struct {
 	RepoPath  string
 	Ref       string
 	SubPath   string
+
+	// IsArchive indicates that the URL designates an archive of the entire repository,
+	// e.g. https://github.com/fredbi/go-vcsfetch/archive/refs/tags/v1.0.0.tar.gz
+	IsArchive bool
 }
 
 // ParseGitLocator builds a [GitLocator] from an URL string.
@@ -39,21 +45,32 @@ func ParseGitLocator(location string, opts ...GitLocatorOption) (*GitLocator, er
 
 	u, err := url.Parse(location)
 	if err != nil {
-		return nil, fmt.Errorf("a git locator should be a valid URL: %w: %w", err, ErrVCS)
+		return nil, fmt.Errorf("a git locator should be a valid URL: %w: %w", redact.Error(err), ErrVCS)
 	}
 
 	return GitLocatorFromURL(u, opts...)
 }
 
 // GitLocatorFromURL builds a [GitLocator] from an [url.URL].
+//
+// A path without scheme nor host that points to an existing local git repository (possibly a bare one)
+// is treated as a repository accessed over the "file" transport, e.g. "/srv/git/repo.git@v1.2.3/docs/README.md".
 func GitLocatorFromURL(u *url.URL, opts ...GitLocatorOption) (*GitLocator, error) {
+	if err := checkTransport(u); err != nil {
+		return nil, err
+	}
+
 	ref := ""
 	o := optionsWithDefaults(opts)
 	if o.requireVersion && ref == "" {
 		return nil, fmt.Errorf("a non-empty version is required: %w", ErrVCS)
 	}
 
-	provider, loc, err := giturl.AutoDetect(u)
+	if gl, ok := localGitLocator(u); ok {
+		return gl, nil
+	}
+
+	provider, loc, err := o.parse(u)
 	if err != nil {
 		return nil, fmt.Errorf("invalid git locator: %w: %w", err, ErrVCS)
 	}
@@ -68,14 +85,99 @@ func GitLocatorFromURL(u *url.URL, opts ...GitLocatorOption) (*GitLocator, error
 		Provider:  string(provider),
 		Userinfo:  userinfo,
 		Transport: u.Scheme, // TODO: factorize with spdx
-		Host:      u.Host,
+		Host:      loc.RepoURL().Host,
 		Ref:       loc.Version(),
 		SubPath:   loc.Path(),
 	}
 
+	if archive, ok := loc.(interface{ IsArchive() bool }); ok {
+		gl.IsArchive = archive.IsArchive()
+	}
+
+	if o.keepGitSuffix {
+		gl.repo = withGitSuffix(u, gl.repo)
+	}
+
 	return gl, nil // TODO
 }
 
+// ParseGitHubActionLocator builds a [GitLocator] from a GitHub Actions "uses" reference,
+// like "owner/repo[/path]@ref", e.g. "actions/checkout@v4" or "github/codeql-action/init@v3".
+//
+// The repository is hosted on github.com, unless another root URL is set with [GitWithRootURL],
+// e.g. for a GitHub Enterprise host. The optional path designates the folder of the action in the repository.
+//
+// Local actions (e.g. "./.github/actions/build") and docker images (e.g. "docker://alpine:3.20") are not supported.
+func ParseGitHubActionLocator(uses string, opts ...GitLocatorOption) (*GitLocator, error) {
+	o := optionsWithDefaults(opts)
+
+	loc, err := github.ParseUses(uses, o.rootURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub Actions reference: %w: %w", err, ErrVCS)
+	}
+
+	return &GitLocator{
+		repo:      loc.RepoURL(),
+		Provider:  string(giturl.ProviderGithub),
+		Transport: loc.RepoURL().Scheme,
+		Host:      loc.RepoURL().Host,
+		Ref:       loc.Version(),
+		SubPath:   loc.Path(),
+	}, nil
+}
+
+// parse an URL with the parser of the provider set by [GitWithProvider], or else with the provider detected from the host,
+// i.e. github for a declared GitHub Enterprise host.
+func (o gitLocatorOptions) parse(u *url.URL) (giturl.Provider, giturl.Locator, error) {
+	if o.provider == "" {
+		if len(o.githubEnterpriseHosts) > 0 && giturl.IsGithubEnterpriseHost(u.Host, o.githubEnterpriseHosts...) {
+			loc, err := giturl.ParseAs(giturl.ProviderGithub, u)
+
+			return giturl.ProviderGithub, loc, err
+		}
+
+		return giturl.AutoDetect(u)
+	}
+
+	loc, err := giturl.ParseAs(o.provider, u)
+
+	return o.provider, loc, err
+}
+
+// withGitSuffix restores the trailing ".git" stripped by the parsers from the repository URL,
+// whenever the original URL had one.
+func withGitSuffix(original, repo *url.URL) *url.URL {
+	repoPath := strings.Trim(repo.Path, "/")
+	if repoPath == "" {
+		return repo
+	}
+
+	suffixed := repoPath + ".git"
+	pth := original.Path
+	for offset := 0; ; {
+		idx := strings.Index(pth[offset:], suffixed)
+		if idx < 0 {
+			return repo
+		}
+
+		start := offset + idx
+		end := start + len(suffixed)
+		isStart := start == 0 || pth[start-1] == '/'
+		isEnd := end == len(pth) || pth[end] == '/' || pth[end] == '@'
+		if isStart && isEnd {
+			break
+		}
+
+		offset = start + 1
+	}
+
+	u := *repo
+	u.Path = strings.TrimSuffix(repo.Path, "/") + ".git"
+	u.RawPath = ""
+
+	return &u
+}
+
 func (l *GitLocator) RepoURL() *url.URL {
 	return l.repo
 }
@@ -98,7 +200,7 @@ func (l *GitLocator) HasAuth() bool {
 }
 
 func (l *GitLocator) String() string {
-	u := l.RepoURL()
+	u := *l.RepoURL() // shallow clone: the repository URL of the locator is left unchanged
 	if !strings.HasPrefix(u.Scheme, "git+") {
 		u.Scheme = "git+" + u.Scheme
 	}
@@ -107,3 +209,17 @@ func (l *GitLocator) String() string {
 
 	return u.String()
 }
+
+// WithVersion yields a copy of the [GitLocator], for the same repository and path at another version.
+//
+// This is convenient to fetch the same file at several versions, without parsing the location again.
+func (l *GitLocator) WithVersion(version string) Locator {
+	pinned := *l
+	if l.repo != nil {
+		repo := *l.repo
+		pinned.repo = &repo
+	}
+	pinned.Ref = version
+
+	return &pinned
+}
@@ -0,0 +1,299 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestGitLocator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default ports should be normalized", func(t *testing.T) {
+		for _, pair := range [][2]string{
+			{"https://github.com/fredbi/go-vcsfetch/blob/master/README.md", "https://github.com:443/fredbi/go-vcsfetch/blob/master/README.md"},
+			{"https://gitlab.com/fredbi/go-vcsfetch/-/blob/master/README.md", "https://gitlab.com:443/fredbi/go-vcsfetch/-/blob/master/README.md"},
+			{"ssh://git@github.com/fredbi/go-vcsfetch/blob/master/README.md", "ssh://git@github.com:22/fredbi/go-vcsfetch/blob/master/README.md"},
+		} {
+			l1, err := ParseGitLocator(pair[0])
+			require.NoError(t, err)
+			l2, err := ParseGitLocator(pair[1])
+			require.NoError(t, err)
+
+			require.Equal(t, l1.RepoURL().String(), l2.RepoURL().String())
+			require.Equal(t, l1.Host, l2.Host)
+		}
+	})
+
+	t.Run("should pin a copy at another version", func(t *testing.T) {
+		l, err := ParseGitLocator("https://github.com/fredbi/go-vcsfetch/blob/v1.0.0/docs/README.md")
+		require.NoError(t, err)
+		original := l.String()
+
+		pinned := l.WithVersion("v2.0.0")
+		require.Equal(t, "v2.0.0", pinned.Version())
+		require.Equal(t, l.RepoURL().String(), pinned.RepoURL().String())
+		require.Equal(t, l.Path(), pinned.Path())
+		require.Equal(t, "git+https://github.com/fredbi/go-vcsfetch@v2.0.0#docs/README.md", pinned.String())
+
+		// the original locator is unchanged
+		require.Equal(t, "v1.0.0", l.Version())
+		require.Equal(t, original, l.String())
+		require.NotSame(t, l.RepoURL(), pinned.RepoURL())
+
+		// the string representation round-trips
+		parsed, err := ParseSPDXLocator(pinned.String())
+		require.NoError(t, err)
+		require.Equal(t, pinned.RepoURL().String(), parsed.RepoURL().String())
+		require.Equal(t, "v2.0.0", parsed.Version())
+		require.Equal(t, "docs/README.md", parsed.Path())
+	})
+}
+
+func TestGitLocatorLocalPath(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	hash := repo.Commit(map[string]string{"docs/hello.txt": "hello world\n"}, "initial commit")
+	repo.Tag("v1.0.0", hash)
+
+	bareDir := repo.Bare()
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	relDir, err := filepath.Rel(cwd, repo.Dir)
+	require.NoError(t, err)
+
+	for name, dir := range map[string]string{
+		"absolute path": repo.Dir,
+		"relative path": filepath.ToSlash(relDir),
+		"bare repo":     bareDir,
+	} {
+		t.Run("should fetch from local repo with "+name, func(t *testing.T) {
+			t.Parallel()
+
+			location := filepath.ToSlash(dir) + "@v1.0.0/docs/hello.txt"
+			locator, err := ParseGitLocator(location)
+			require.NoError(t, err)
+			require.True(t, locator.IsLocal())
+			require.Equal(t, "v1.0.0", locator.Version())
+			require.Equal(t, "docs/hello.txt", locator.Path())
+
+			w := new(bytes.Buffer)
+			require.NoError(t, NewFetcher().Fetch(t.Context(), w, location))
+			require.Equal(t, "hello world\n", w.String())
+		})
+	}
+
+	t.Run("should not resolve a path that is not a git repo", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ParseGitLocator(filepath.ToSlash(t.TempDir()) + "/docs/hello.txt")
+		require.ErrorIs(t, err, ErrVCS)
+	})
+}
+
+func TestGitLocatorKeepGitSuffix(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should keep or strip the .git suffix", func(t *testing.T) {
+		for _, tc := range []struct {
+			location string
+			stripped string
+			kept     string
+		}{
+			{
+				location: "https://github.com/fredbi/go-vcsfetch.git",
+				stripped: "https://github.com/fredbi/go-vcsfetch",
+				kept:     "https://github.com/fredbi/go-vcsfetch.git",
+			},
+			{
+				location: "https://gitlab.com/fredbi/go-vcsfetch.git/-/blob/master/README.md",
+				stripped: "https://gitlab.com/fredbi/go-vcsfetch",
+				kept:     "https://gitlab.com/fredbi/go-vcsfetch.git",
+			},
+			{
+				location: "https://github.com/fredbi/go-vcsfetch.git@v1.2.3",
+				stripped: "https://github.com/fredbi/go-vcsfetch",
+				kept:     "https://github.com/fredbi/go-vcsfetch.git",
+			},
+			{
+				location: "https://github.com/fredbi/go-vcsfetch/blob/master/README.md",
+				stripped: "https://github.com/fredbi/go-vcsfetch",
+				kept:     "https://github.com/fredbi/go-vcsfetch",
+			},
+		} {
+			stripped, err := ParseGitLocator(tc.location)
+			require.NoError(t, err)
+			require.Equal(t, tc.stripped, stripped.RepoURL().String())
+
+			kept, err := ParseGitLocator(tc.location, GitWithKeepGitSuffix(true))
+			require.NoError(t, err)
+			require.Equal(t, tc.kept, kept.RepoURL().String())
+			require.Equal(t, stripped.Version(), kept.Version())
+			require.Equal(t, stripped.Path(), kept.Path())
+		}
+	})
+
+	t.Run("should fetch from a repo that requires the .git suffix", func(t *testing.T) {
+		t.Parallel()
+
+		gitBin, err := exec.LookPath("git")
+		if err != nil {
+			t.Skip("this test requires the git binary to serve a repository over http")
+		}
+
+		repo := testrepo.New(t)
+		repo.Tag("v1.0.0", repo.Commit(map[string]string{"README.md": "readme"}, "initial commit"))
+		bareDir := repo.Bare() // e.g. {tmp}/{owner}/repo.git
+		owner := filepath.Base(filepath.Dir(bareDir))
+
+		backend := &cgi.Handler{
+			Path: gitBin,
+			Args: []string{"http-backend"},
+			Env: []string{
+				"GIT_PROJECT_ROOT=" + filepath.Dir(filepath.Dir(bareDir)),
+				"GIT_HTTP_EXPORT_ALL=1",
+			},
+		}
+		// this server requires the .git suffix in the clone URL
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.URL.Path, "/repo.git/") {
+				http.NotFound(w, r)
+
+				return
+			}
+
+			backend.ServeHTTP(w, r)
+		}))
+		t.Cleanup(server.Close)
+
+		serverURL, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		registerProvider(t, "gitea", func(host string) bool {
+			return host == serverURL.Host
+		})
+
+		location := server.URL + "/" + owner + "/repo.git/src/tag/v1.0.0/README.md"
+
+		w := new(bytes.Buffer)
+		require.Error(t, NewFetcher().Fetch(t.Context(), w, location))
+
+		fetcher := NewFetcher(FetchWithGitLocatorOptions(GitWithKeepGitSuffix(true)))
+		require.NoError(t, fetcher.Fetch(t.Context(), w, location))
+		require.Equal(t, "readme", w.String())
+	})
+}
+
+func TestGitLocatorWithProvider(t *testing.T) {
+	t.Parallel()
+
+	const location = "https://code.corp.example/group/subgroup/project/-/blob/v1.2.3/docs/README.md"
+
+	t.Run("should not detect the provider from the host", func(t *testing.T) {
+		_, err := ParseGitLocator(location)
+		require.ErrorIs(t, err, ErrVCS)
+	})
+
+	t.Run("should parse with the forced provider", func(t *testing.T) {
+		gl, err := ParseGitLocator(location, GitWithProvider("gitlab"))
+		require.NoError(t, err)
+		require.Equal(t, "gitlab", gl.Provider)
+		require.Equal(t, "https://code.corp.example/group/subgroup/project", gl.RepoURL().String())
+		require.Equal(t, "v1.2.3", gl.Version())
+		require.Equal(t, "docs/README.md", gl.Path())
+	})
+
+	t.Run("should force the provider over the one detected from the host", func(t *testing.T) {
+		_, err := ParseGitLocator("https://github.example/owner/repo/-/blob/main/README.md")
+		require.Error(t, err)
+
+		gl, err := ParseGitLocator("https://github.example/owner/repo/-/blob/main/README.md", GitWithProvider("gitlab"))
+		require.NoError(t, err)
+		require.Equal(t, "gitlab", gl.Provider)
+		require.Equal(t, "README.md", gl.Path())
+	})
+
+	t.Run("should report an invalid location for the forced provider", func(t *testing.T) {
+		_, err := ParseGitLocator("https://code.corp.example/owner/repo/blob/main/README.md", GitWithProvider("gitlab"))
+		require.ErrorIs(t, err, ErrVCS)
+	})
+
+	t.Run("should reject an unknown provider", func(t *testing.T) {
+		require.Panics(t, func() { _ = GitWithProvider("gitforge") })
+		require.Panics(t, func() { _ = GitWithProvider("unknown") })
+	})
+}
+
+func TestGitHubActionLocator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should parse an action reference", func(t *testing.T) {
+		gl, err := ParseGitHubActionLocator("actions/checkout@v4")
+		require.NoError(t, err)
+		require.Equal(t, "github", gl.Provider)
+		require.Equal(t, "https://github.com/actions/checkout", gl.RepoURL().String())
+		require.Equal(t, "v4", gl.Version())
+		require.Equal(t, "/", gl.Path())
+	})
+
+	t.Run("should parse an action in a subfolder", func(t *testing.T) {
+		gl, err := ParseGitHubActionLocator("github/codeql-action/init@v3")
+		require.NoError(t, err)
+		require.Equal(t, "https://github.com/github/codeql-action", gl.RepoURL().String())
+		require.Equal(t, "v3", gl.Version())
+		require.Equal(t, "init", gl.Path())
+	})
+
+	t.Run("should resolve against the root URL", func(t *testing.T) {
+		gl, err := ParseGitHubActionLocator("actions/checkout@v4", GitWithRootURL("https://ghe.example.com"))
+		require.NoError(t, err)
+		require.Equal(t, "https://ghe.example.com/actions/checkout", gl.RepoURL().String())
+		require.Equal(t, "ghe.example.com", gl.Host)
+	})
+
+	t.Run("should reject references without a ref", func(t *testing.T) {
+		for _, uses := range []string{"actions/checkout", "./.github/actions/build", "docker://alpine:3.20"} {
+			_, err := ParseGitHubActionLocator(uses)
+			require.ErrorIs(t, err, ErrVCS, uses)
+		}
+	})
+}
+
+func TestProviders(t *testing.T) {
+	t.Parallel()
+
+	providers := Providers()
+	require.NotEmpty(t, providers)
+
+	byName := make(map[string]ProviderInfo, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name] = provider
+	}
+
+	github, ok := byName["github"]
+	require.True(t, ok)
+	require.True(t, github.SupportsRaw)
+	require.False(t, github.RawFromAPI)
+	require.True(t, github.SupportsSSH)
+	require.True(t, github.SupportsListing)
+
+	azure, ok := byName["azure"]
+	require.True(t, ok)
+	require.False(t, azure.SupportsRaw)
+
+	require.Contains(t, byName, "codecommit")
+}
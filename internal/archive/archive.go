@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+// Package archive extracts repository archives, such as the tarballs or zip archives served by SCM platforms.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+type archiveError string
+
+func (e archiveError) Error() string {
+	return string(e)
+}
+
+// ErrArchive is a sentinel error to report errors from the archive package.
+const ErrArchive archiveError = "error extracting archive"
+
+// ErrTooLarge is returned when the extracted content of an archive exceeds the maximum size.
+const ErrTooLarge archiveError = "archive exceeds the maximum extracted size"
+
+// DefaultMaxSize is the default maximum total size of the files extracted from an archive (1 GiB).
+const DefaultMaxSize int64 = 1 << 30
+
+// Option customizes the extraction of an archive.
+type Option func(*options)
+
+type options struct {
+	maxSize int64
+}
+
+// WithMaxSize limits the total size of the files extracted from an archive. A size of 0 means no limit.
+//
+// By default, at most [DefaultMaxSize] bytes are extracted.
+func WithMaxSize(size int64) Option {
+	return func(o *options) {
+		o.maxSize = size
+	}
+}
+
+func optionsWithDefaults(opts []Option) options {
+	o := options{maxSize: DefaultMaxSize}
+	for _, apply := range opts {
+		apply(&o)
+	}
+
+	return o
+}
+
+// ExtractTarGz extracts a gzipped tarball into a [billy.Filesystem].
+//
+// The leading path component common to all entries in repository archives (e.g. "repo-v1.0.0/")
+// is stripped.
+//
+// Only regular files and directories are extracted. Entries that would escape the destination are rejected.
+// Extraction fails with [ErrTooLarge] when the extracted files exceed the maximum size (see [WithMaxSize]).
+func ExtractTarGz(r io.Reader, dst billy.Filesystem, opts ...Option) error {
+	o := optionsWithDefaults(opts)
+	q := &quota{max: o.maxSize}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("invalid gzip stream: %w: %w", err, ErrArchive)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar stream: %w: %w", err, ErrArchive)
+		}
+
+		if !filepath.IsLocal(path.Clean(header.Name)) {
+			return fmt.Errorf("archive entry %q escapes the destination: %w", header.Name, ErrArchive)
+		}
+
+		name, ok := stripFirstComponent(header.Name)
+		if !ok {
+			continue // the top-level folder itself, or a global header
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = dst.MkdirAll(name, 0o755); err != nil {
+				return errors.Join(err, ErrArchive)
+			}
+		case tar.TypeReg:
+			if err = writeFile(dst, name, tr, q); err != nil {
+				return errors.Join(err, ErrArchive)
+			}
+		default:
+			// skip symlinks and other special files
+			continue
+		}
+	}
+}
+
+// ExtractZip extracts a zip archive of a given size into a [billy.Filesystem].
+//
+// Like with [ExtractTarGz], the leading path component common to all entries in repository archives is stripped,
+// only regular files and directories are extracted, entries that would escape the destination are rejected
+// and the extracted files are limited to a maximum size.
+func ExtractZip(r io.ReaderAt, size int64, dst billy.Filesystem, opts ...Option) error {
+	o := optionsWithDefaults(opts)
+	q := &quota{max: o.maxSize}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) { // insecure paths are rejected below
+		return fmt.Errorf("invalid zip archive: %w: %w", err, ErrArchive)
+	}
+
+	for _, entry := range zr.File {
+		if !filepath.IsLocal(path.Clean(entry.Name)) {
+			return fmt.Errorf("archive entry %q escapes the destination: %w", entry.Name, ErrArchive)
+		}
+
+		name, ok := stripFirstComponent(entry.Name)
+		if !ok {
+			continue // the top-level folder itself
+		}
+
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			if err = dst.MkdirAll(name, 0o755); err != nil {
+				return errors.Join(err, ErrArchive)
+			}
+		case mode.IsRegular():
+			if err = extractZipFile(dst, name, entry, q); err != nil {
+				return errors.Join(err, ErrArchive)
+			}
+		default:
+			// skip symlinks and other special files
+			continue
+		}
+	}
+
+	return nil
+}
+
+func extractZipFile(dst billy.Filesystem, name string, entry *zip.File, q *quota) error {
+	rc, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	return writeFile(dst, name, rc, q)
+}
+
+func stripFirstComponent(name string) (string, bool) {
+	name = path.Clean(name)
+	_, rest, found := strings.Cut(name, "/")
+	if !found || rest == "" {
+		return "", false
+	}
+
+	return rest, true
+}
+
+func writeFile(dst billy.Filesystem, name string, r io.Reader, q *quota) error {
+	if dir := path.Dir(name); dir != "." {
+		if err := dst.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	f, err := dst.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err = q.copy(f, r); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// LimitWriter caps the size of an archive written to w, e.g. while it is downloaded, to the maximum size
+// of its extraction (see [WithMaxSize]).
+//
+// Writes fail with [ErrTooLarge] once the maximum size is exceeded.
+func LimitWriter(w io.Writer, opts ...Option) io.Writer {
+	o := optionsWithDefaults(opts)
+	if o.maxSize <= 0 {
+		return w
+	}
+
+	return &limitedWriter{w: w, q: &quota{max: o.maxSize}}
+}
+
+// limitedWriter is an [io.Writer] that fails with [ErrTooLarge] once its quota is exceeded.
+type limitedWriter struct {
+	w io.Writer
+	q *quota
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	remaining := l.q.max - l.q.written
+	if int64(len(p)) <= remaining {
+		n, err := l.w.Write(p)
+		l.q.written += int64(n)
+
+		return n, err
+	}
+
+	// write up to the limit, then fail
+	n, err := l.w.Write(p[:remaining])
+	l.q.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	return n, fmt.Errorf("%w (%d bytes)", ErrTooLarge, l.q.max)
+}
+
+// quota tracks the total size of the files extracted from an archive.
+//
+// The size declared by archive headers is not trusted: the content actually written is counted.
+type quota struct {
+	max     int64 // 0 means no limit
+	written int64
+}
+
+func (q *quota) copy(w io.Writer, r io.Reader) error {
+	if q.max <= 0 {
+		_, err := io.Copy(w, r)
+
+		return err
+	}
+
+	// read one byte past the quota to detect oversized content
+	n, err := io.Copy(w, io.LimitReader(r, q.max-q.written+1))
+	q.written += n
+	if q.written > q.max {
+		return fmt.Errorf("%w (%d bytes)", ErrTooLarge, q.max)
+	}
+
+	return err
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestExtractTarGz(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should extract files and strip the top-level folder", func(t *testing.T) {
+		tarball := tarGz(t, map[string]string{
+			"repo-v1.0.0/README.md":   "readme",
+			"repo-v1.0.0/docs/api.md": "api",
+		})
+		dst := memfs.New()
+
+		require.NoError(t, ExtractTarGz(bytes.NewReader(tarball), dst))
+
+		content, err := util.ReadFile(dst, "README.md")
+		require.NoError(t, err)
+		require.Equal(t, "readme", string(content))
+
+		content, err = util.ReadFile(dst, "docs/api.md")
+		require.NoError(t, err)
+		require.Equal(t, "api", string(content))
+	})
+
+	t.Run("should reject entries escaping the destination", func(t *testing.T) {
+		tarball := tarGz(t, map[string]string{
+			"repo-v1.0.0/../../etc/passwd": "root",
+		})
+
+		require.ErrorIs(t, ExtractTarGz(bytes.NewReader(tarball), memfs.New()), ErrArchive)
+	})
+
+	t.Run("should reject invalid content", func(t *testing.T) {
+		require.ErrorIs(t, ExtractTarGz(bytes.NewReader([]byte("not a tarball")), memfs.New()), ErrArchive)
+	})
+
+	t.Run("should reject content exceeding the maximum size", func(t *testing.T) {
+		tarball := tarGz(t, map[string]string{
+			"repo-v1.0.0/a.txt": "0123456789",
+			"repo-v1.0.0/b.txt": "0123456789",
+		})
+
+		require.NoError(t, ExtractTarGz(bytes.NewReader(tarball), memfs.New(), WithMaxSize(20)))
+
+		err := ExtractTarGz(bytes.NewReader(tarball), memfs.New(), WithMaxSize(19))
+		require.ErrorIs(t, err, ErrTooLarge)
+		require.ErrorIs(t, err, ErrArchive)
+	})
+}
+
+func TestExtractZip(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should extract files and strip the top-level folder", func(t *testing.T) {
+		archive := zipArchive(t, map[string]string{
+			"repo-v1.0.0/":            "",
+			"repo-v1.0.0/README.md":   "readme",
+			"repo-v1.0.0/docs/":       "",
+			"repo-v1.0.0/docs/api.md": "api",
+		})
+		dst := memfs.New()
+
+		require.NoError(t, ExtractZip(bytes.NewReader(archive), int64(len(archive)), dst))
+
+		content, err := util.ReadFile(dst, "README.md")
+		require.NoError(t, err)
+		require.Equal(t, "readme", string(content))
+
+		content, err = util.ReadFile(dst, "docs/api.md")
+		require.NoError(t, err)
+		require.Equal(t, "api", string(content))
+
+		_, err = dst.Stat("repo-v1.0.0")
+		require.Error(t, err)
+	})
+
+	t.Run("should reject entries escaping the destination", func(t *testing.T) {
+		archive := zipArchive(t, map[string]string{
+			"repo-v1.0.0/../../etc/passwd": "root",
+		})
+
+		require.ErrorIs(t, ExtractZip(bytes.NewReader(archive), int64(len(archive)), memfs.New()), ErrArchive)
+	})
+
+	t.Run("should reject invalid content", func(t *testing.T) {
+		content := []byte("not a zip archive")
+		require.ErrorIs(t, ExtractZip(bytes.NewReader(content), int64(len(content)), memfs.New()), ErrArchive)
+	})
+
+	t.Run("should reject content exceeding the maximum size", func(t *testing.T) {
+		archive := zipArchive(t, map[string]string{
+			"repo-v1.0.0/a.txt": "0123456789",
+			"repo-v1.0.0/b.txt": "0123456789",
+		})
+
+		require.NoError(t, ExtractZip(bytes.NewReader(archive), int64(len(archive)), memfs.New(), WithMaxSize(20)))
+
+		err := ExtractZip(bytes.NewReader(archive), int64(len(archive)), memfs.New(), WithMaxSize(19))
+		require.ErrorIs(t, err, ErrTooLarge)
+		require.ErrorIs(t, err, ErrArchive)
+	})
+}
+
+func TestLimitWriter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should write up to the maximum size", func(t *testing.T) {
+		var b bytes.Buffer
+		w := LimitWriter(&b, WithMaxSize(10))
+
+		_, err := w.Write([]byte("01234"))
+		require.NoError(t, err)
+		_, err = w.Write([]byte("56789"))
+		require.NoError(t, err)
+		require.Equal(t, "0123456789", b.String())
+	})
+
+	t.Run("should fail once the maximum size is exceeded", func(t *testing.T) {
+		var b bytes.Buffer
+		w := LimitWriter(&b, WithMaxSize(10))
+
+		_, err := w.Write([]byte("01234"))
+		require.NoError(t, err)
+		n, err := w.Write([]byte("56789A"))
+		require.ErrorIs(t, err, ErrTooLarge)
+		require.Equal(t, 5, n)
+		require.Equal(t, "0123456789", b.String())
+	})
+
+	t.Run("should not limit without a maximum size", func(t *testing.T) {
+		var b bytes.Buffer
+		require.Same(t, &b, LimitWriter(&b, WithMaxSize(0)))
+	})
+}
+
+// zipArchive builds a zip archive from files (path: content). Names ending with "/" are folders.
+func zipArchive(t testing.TB, files map[string]string) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	return b.Bytes()
+}
+
+// tarGz builds a gzipped tarball from files (path: content).
+func tarGz(t testing.TB, files map[string]string) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return b.Bytes()
+}
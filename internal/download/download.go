@@ -1,10 +1,12 @@
 package download
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
@@ -25,9 +27,72 @@ func (e downloadError) Error() string {
 // ErrDownload is a sentinel error to report errors from the download content package.
 const ErrDownload downloadError = "error downloading file"
 
+// ErrNotFound is a sentinel error to report that the remote resource does not exist.
+const ErrNotFound downloadError = "resource not found"
+
+// ErrUnexpectedContent is a sentinel error to report that the server responded with content that is not
+// the expected resource, e.g. an HTML login page.
+const ErrUnexpectedContent downloadError = "unexpected content"
+
+// ErrForbidden is a sentinel error to report that the server denied access to the remote resource,
+// e.g. a private resource or an exhausted API rate limit.
+const ErrForbidden downloadError = "access denied"
+
+// ErrUnprocessable is a sentinel error to report that the server rejected the request as invalid,
+// e.g. an API which does not recognize the requested ref.
+const ErrUnprocessable downloadError = "unprocessable request"
+
+const sniffLen = 512 // see [http.DetectContentType]
+
+// headerGithubSSO is the header set by github when access to the resources of an organization
+// requires a token authorized for SAML single sign-on, e.g. "required; url=https://github.com/orgs/...".
+const headerGithubSSO = "X-Github-Sso"
+
+// SSORequiredError is returned when github denies access to a resource of an organization which enforces
+// SAML single sign-on, because the token used is not authorized for this organization.
+//
+// Errors of this kind also match [ErrForbidden] and [ErrDownload].
+type SSORequiredError struct {
+	// AuthorizationURL is the URL to visit to authorize the token, if provided by github.
+	AuthorizationURL string
+}
+
+func (e *SSORequiredError) Error() string {
+	if e.AuthorizationURL == "" {
+		return "the token must be authorized for SAML single sign-on"
+	}
+
+	return "the token must be authorized for SAML single sign-on at " + e.AuthorizationURL
+}
+
+func (e *SSORequiredError) Is(target error) bool {
+	return target == ErrForbidden || target == ErrDownload
+}
+
+// ssoRequired detects a response denying access until the token is authorized for SAML single sign-on.
+func ssoRequired(resp *http.Response) (*SSORequiredError, bool) {
+	value := resp.Header.Get(headerGithubSSO)
+	directive, params, _ := strings.Cut(value, ";")
+	if strings.TrimSpace(directive) != "required" {
+		// e.g. "partial-results; organizations=..." when some results are hidden from a listing
+		return nil, false
+	}
+
+	ssoErr := &SSORequiredError{}
+	for param := range strings.SplitSeq(params, ";") {
+		key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && key == "url" {
+			ssoErr.AuthorizationURL = val
+		}
+	}
+
+	return ssoErr, true
+}
+
 // Supported indicates if the provided URL can be downloaded.
 //
-// This works for http and https URL schemes, but not ssh or git.
+// This works for http and https URL schemes, but not ssh, git or file.
+// Local files are read by the caller, which decides whether to trust local paths.
 func Supported(u *url.URL) bool {
 	scheme, _ := strings.CutPrefix(u.Scheme, "git+")
 
@@ -64,7 +129,14 @@ func httpContent(ctx context.Context, u *url.URL, w io.Writer, opts *Options) er
 	if opts.Client != nil {
 		client = opts.Client
 	} else {
-		client = http.DefaultClient
+		client = DefaultClient()
+	}
+
+	if opts.DialTimeout > 0 || opts.ResponseHeaderTimeout > 0 {
+		// the transport is derived for this download only: callers issuing many downloads should rather
+		// derive their client once with WithTimeouts
+		client = WithTimeouts(client, opts.DialTimeout, opts.ResponseHeaderTimeout)
+		defer client.CloseIdleConnections()
 	}
 
 	if opts.Timeout > 0 {
@@ -99,14 +171,63 @@ func httpContent(ctx context.Context, u *url.URL, w io.Writer, opts *Options) er
 		return errors.Join(err, ErrDownload)
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fmt.Errorf("could not fetch resource at %q [%s]: %w: %w", u.String(), resp.Status, ErrNotFound, ErrDownload)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if ssoErr, ok := ssoRequired(resp); ok {
+			return fmt.Errorf("could not fetch resource at %q [%s]: %w", u.String(), resp.Status, ssoErr)
+		}
+
+		return fmt.Errorf("could not fetch resource at %q [%s]: %w: %w", u.String(), resp.Status, ErrForbidden, ErrDownload)
+	case http.StatusUnprocessableEntity:
+		return fmt.Errorf("could not fetch resource at %q [%s]: %w: %w", u.String(), resp.Status, ErrUnprocessable, ErrDownload)
+	default:
 		return fmt.Errorf("could not fetch resource at %q [%s]: %w", u.String(), resp.Status, ErrDownload)
 	}
 
-	_, err = io.Copy(w, resp.Body)
+	var body io.Reader = resp.Body
+	if opts.RejectHTML {
+		if body, err = rejectHTML(resp); err != nil {
+			return fmt.Errorf("could not fetch resource at %q: %w: %w", u.String(), err, ErrDownload)
+		}
+	}
+
+	_, err = io.Copy(w, body)
 	if err != nil {
 		return errors.Join(err, ErrDownload)
 	}
 
 	return nil
 }
+
+// rejectHTML checks that a response is not an HTML page, by its declared content type or by sniffing its content.
+//
+// No content is consumed from the response: the returned [io.Reader] yields the entire body.
+func rejectHTML(resp *http.Response) (io.Reader, error) {
+	if isHTML(resp.Header.Get("Content-Type")) {
+		return nil, fmt.Errorf("got an HTML page: %w", ErrUnexpectedContent)
+	}
+
+	buffered := bufio.NewReaderSize(resp.Body, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if isHTML(http.DetectContentType(head)) {
+		return nil, fmt.Errorf("got content that looks like an HTML page: %w", ErrUnexpectedContent)
+	}
+
+	return buffered, nil
+}
+
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
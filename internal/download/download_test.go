@@ -2,6 +2,9 @@ package download
 
 import (
 	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -45,6 +48,76 @@ func TestContent(t *testing.T) {
 	})
 }
 
+func TestContentStatus(t *testing.T) {
+	t.Parallel()
+
+	for status, expected := range map[int]error{
+		http.StatusNotFound:            ErrNotFound,
+		http.StatusUnauthorized:        ErrForbidden,
+		http.StatusForbidden:           ErrForbidden,
+		http.StatusUnprocessableEntity: ErrUnprocessable,
+		http.StatusInternalServerError: ErrDownload,
+	} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(status)
+			}))
+			t.Cleanup(server.Close)
+
+			var b bytes.Buffer
+			err := Content(t.Context(), mustURL(t, server.URL), &b, nil)
+			require.ErrorIs(t, err, expected)
+			require.ErrorIs(t, err, ErrDownload)
+			require.Empty(t, b.String())
+		})
+	}
+}
+
+func TestContentSSORequired(t *testing.T) {
+	t.Parallel()
+
+	const authorizationURL = "https://github.com/orgs/acme/sso?authorization_request=abc"
+
+	for header, expected := range map[string]string{
+		"required; url=" + authorizationURL: authorizationURL,
+		"required":                          "",
+	} {
+		t.Run("should detect a token not authorized for SSO with "+header, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("X-GitHub-SSO", header)
+				w.WriteHeader(http.StatusForbidden)
+			}))
+			t.Cleanup(server.Close)
+
+			err := Content(t.Context(), mustURL(t, server.URL), new(bytes.Buffer), nil)
+			var ssoErr *SSORequiredError
+			require.ErrorAs(t, err, &ssoErr)
+			require.Equal(t, expected, ssoErr.AuthorizationURL)
+			require.ErrorIs(t, err, ErrForbidden)
+			require.ErrorIs(t, err, ErrDownload)
+		})
+	}
+
+	t.Run("should not report partial results as SSO required", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("X-GitHub-SSO", "partial-results; organizations=21955855")
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		t.Cleanup(server.Close)
+
+		err := Content(t.Context(), mustURL(t, server.URL), new(bytes.Buffer), nil)
+		var ssoErr *SSORequiredError
+		require.False(t, errors.As(err, &ssoErr))
+		require.ErrorIs(t, err, ErrForbidden)
+	})
+}
+
 func TestSupported(t *testing.T) {
 	t.Parallel()
 
@@ -75,7 +148,7 @@ func TestSupported(t *testing.T) {
 	})
 }
 
-func mustURL(t *testing.T, str string) *url.URL {
+func mustURL(t testing.TB, str string) *url.URL {
 	t.Helper()
 
 	u, err := url.Parse(str)
@@ -13,10 +13,25 @@ type Options struct {
 	BasicAuthUsername string
 	BasicAuthPassword string
 	CustomHeaders     map[string]string
-	Client            *http.Client
+
+	// Client is the [http.Client] used for the download. Defaults to [DefaultClient].
+	Client *http.Client
+
+	// DialTimeout limits the time spent establishing a connection to the server, including the TLS handshake.
+	//
+	// Unlike Timeout, it does not limit the time spent receiving the response.
+	DialTimeout time.Duration
+
+	// ResponseHeaderTimeout limits the time spent waiting for the headers of the response, once the request is sent.
+	//
+	// Unlike Timeout, it does not limit the time spent reading the body of the response.
+	ResponseHeaderTimeout time.Duration
+
+	// RejectHTML fails the download with [ErrUnexpectedContent] whenever the server responds with an HTML page,
+	// e.g. a login page for a private resource.
+	RejectHTML bool
 }
 
 var defaultOptions = Options{
 	Timeout: defaultTimeout,
-	Client:  http.DefaultClient,
 }
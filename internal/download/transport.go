@@ -0,0 +1,122 @@
+package download
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultMaxIdleConnsPerHost is the number of idle connections kept per host by [DefaultClient].
+//
+// Raw-content downloads tend to issue many small requests to the same few hosts: the default of the standard library
+// (2 idle connections per host) causes new connections to be established whenever downloads are concurrent.
+const DefaultMaxIdleConnsPerHost = 16
+
+// DefaultClient yields the [http.Client] used for downloads when none is supplied.
+//
+// The client is built once and shared by all downloads, so connections are reused across downloads.
+// Its transport is derived from [http.DefaultTransport], keeping [DefaultMaxIdleConnsPerHost] idle connections per host,
+// and attempting HTTP/2.
+var DefaultClient = sync.OnceValue(func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tuneConnections(transport, DefaultMaxIdleConnsPerHost, true)
+
+	return &http.Client{Transport: transport}
+})
+
+// WithConnections derives an [http.Client] which transport keeps up to maxIdleConnsPerHost idle connections
+// per host for reuse, and attempts HTTP/2 whenever forceHTTP2 is enabled.
+//
+// A zero maxIdleConnsPerHost leaves the corresponding setting of the transport unchanged.
+//
+// The client is returned unchanged when there is nothing to set, or when its transport is not an [*http.Transport].
+// A nil client stands for [DefaultClient].
+//
+// The derived client has its own pool of connections: it should be derived once and reused across downloads.
+func WithConnections(client *http.Client, maxIdleConnsPerHost int, forceHTTP2 bool) *http.Client {
+	if maxIdleConnsPerHost <= 0 && !forceHTTP2 {
+		return client
+	}
+
+	base := client
+	if base == nil {
+		base = DefaultClient()
+	}
+
+	var transport *http.Transport
+	switch rt := base.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return client
+	}
+
+	tuneConnections(transport, maxIdleConnsPerHost, forceHTTP2)
+	derived := *base // shallow clone
+	derived.Transport = transport
+
+	return &derived
+}
+
+func tuneConnections(transport *http.Transport, maxIdleConnsPerHost int, forceHTTP2 bool) {
+	if maxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		if transport.MaxIdleConns > 0 {
+			transport.MaxIdleConns = max(transport.MaxIdleConns, maxIdleConnsPerHost)
+		}
+	}
+
+	if forceHTTP2 {
+		transport.ForceAttemptHTTP2 = true
+	}
+}
+
+// WithTimeouts derives an [http.Client] which transport limits the time spent establishing a connection
+// (including the TLS handshake) to dialTimeout, and the time spent waiting for the headers of a response
+// to responseHeaderTimeout.
+//
+// A zero timeout leaves the corresponding setting of the transport unchanged.
+//
+// The client is returned unchanged when no timeout is set, or when its transport is not an [*http.Transport].
+// A nil client stands for [DefaultClient].
+func WithTimeouts(client *http.Client, dialTimeout, responseHeaderTimeout time.Duration) *http.Client {
+	if dialTimeout <= 0 && responseHeaderTimeout <= 0 {
+		return client
+	}
+
+	base := client
+	if base == nil {
+		base = DefaultClient()
+	}
+
+	var transport *http.Transport
+	switch rt := base.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return client
+	}
+
+	if dialTimeout > 0 {
+		dialer := &net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second, //nolint:mnd // same as http.DefaultTransport
+		}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = dialTimeout
+	}
+
+	if responseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = responseHeaderTimeout
+	}
+
+	derived := *base // shallow clone
+	derived.Transport = transport
+
+	return &derived
+}
@@ -0,0 +1,257 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestContentTimeouts(t *testing.T) {
+	t.Parallel()
+
+	const (
+		shortTimeout = 100 * time.Millisecond
+		delay        = 3 * shortTimeout
+		expected     = "slow but steady"
+	)
+
+	t.Run("slow-to-connect server trips the dial timeout", func(t *testing.T) {
+		t.Parallel()
+
+		// accepts TCP connections but never completes the TLS handshake
+		addr := newSilentListener(t)
+		remoteURL := mustURL(t, "https://"+addr+"/file.txt")
+
+		var b bytes.Buffer
+		start := time.Now()
+		err := Content(t.Context(), remoteURL, &b, &Options{
+			Timeout:     time.Minute,
+			DialTimeout: shortTimeout,
+		})
+		require.Error(t, err)
+		require.Less(t, time.Since(start), 10*shortTimeout)
+	})
+
+	t.Run("slow-body server does not trip the dial or header timeouts", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(delay)
+			_, _ = w.Write([]byte(expected))
+		}))
+		t.Cleanup(server.Close)
+
+		var b bytes.Buffer
+		require.NoError(t, Content(t.Context(), mustURL(t, server.URL+"/file.txt"), &b, &Options{
+			Timeout:               time.Minute,
+			DialTimeout:           shortTimeout,
+			ResponseHeaderTimeout: shortTimeout,
+		}))
+		require.Equal(t, expected, b.String())
+	})
+
+	t.Run("slow-header server trips the response header timeout", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			time.Sleep(delay)
+			_, _ = w.Write([]byte(expected))
+		}))
+		t.Cleanup(server.Close)
+
+		var b bytes.Buffer
+		err := Content(t.Context(), mustURL(t, server.URL+"/file.txt"), &b, &Options{
+			Timeout:               time.Minute,
+			ResponseHeaderTimeout: shortTimeout,
+		})
+		require.Error(t, err)
+		require.Empty(t, b.String())
+	})
+}
+
+func TestWithTimeouts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("without timeouts, the client is unchanged", func(t *testing.T) {
+		client := &http.Client{}
+		require.Same(t, client, WithTimeouts(client, 0, 0))
+		require.Nil(t, WithTimeouts(nil, 0, 0))
+	})
+
+	t.Run("with a custom round tripper, the client is unchanged", func(t *testing.T) {
+		client := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+		require.Same(t, client, WithTimeouts(client, time.Second, time.Second))
+	})
+
+	t.Run("with timeouts, the transport is cloned", func(t *testing.T) {
+		transport := &http.Transport{}
+		client := &http.Client{Transport: transport, Timeout: time.Hour}
+
+		derived := WithTimeouts(client, time.Second, 2*time.Second)
+		require.NotSame(t, client, derived)
+		require.Equal(t, time.Hour, derived.Timeout)
+
+		derivedTransport, ok := derived.Transport.(*http.Transport)
+		require.True(t, ok)
+		require.NotSame(t, transport, derivedTransport)
+		require.NotNil(t, derivedTransport.DialContext)
+		require.Equal(t, time.Second, derivedTransport.TLSHandshakeTimeout)
+		require.Equal(t, 2*time.Second, derivedTransport.ResponseHeaderTimeout)
+
+		require.Nil(t, transport.DialContext)
+		require.Zero(t, transport.ResponseHeaderTimeout)
+	})
+}
+
+func TestWithConnections(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the default client is tuned and shared", func(t *testing.T) {
+		client := DefaultClient()
+		require.Same(t, client, DefaultClient())
+
+		transport, ok := client.Transport.(*http.Transport)
+		require.True(t, ok)
+		require.Equal(t, DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+		require.True(t, transport.ForceAttemptHTTP2)
+	})
+
+	t.Run("without settings, the client is unchanged", func(t *testing.T) {
+		client := &http.Client{}
+		require.Same(t, client, WithConnections(client, 0, false))
+		require.Nil(t, WithConnections(nil, 0, false))
+	})
+
+	t.Run("with a custom round tripper, the client is unchanged", func(t *testing.T) {
+		client := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+		require.Same(t, client, WithConnections(client, 4, true))
+	})
+
+	t.Run("with settings, the transport is cloned", func(t *testing.T) {
+		transport := &http.Transport{}
+		client := &http.Client{Transport: transport, Timeout: time.Hour}
+
+		derived := WithConnections(client, 32, true)
+		require.NotSame(t, client, derived)
+		require.Equal(t, time.Hour, derived.Timeout)
+
+		derivedTransport, ok := derived.Transport.(*http.Transport)
+		require.True(t, ok)
+		require.NotSame(t, transport, derivedTransport)
+		require.Equal(t, 32, derivedTransport.MaxIdleConnsPerHost)
+		require.True(t, derivedTransport.ForceAttemptHTTP2)
+
+		require.Zero(t, transport.MaxIdleConnsPerHost)
+		require.False(t, transport.ForceAttemptHTTP2)
+	})
+
+	t.Run("a nil client derives from the default client", func(t *testing.T) {
+		derived := WithConnections(nil, 64, false)
+		require.NotSame(t, DefaultClient(), derived)
+
+		derivedTransport, ok := derived.Transport.(*http.Transport)
+		require.True(t, ok)
+		require.Equal(t, 64, derivedTransport.MaxIdleConnsPerHost)
+		require.Equal(t, 100, derivedTransport.MaxIdleConns) //nolint:mnd // as set by http.DefaultTransport
+		require.True(t, derivedTransport.ForceAttemptHTTP2)
+	})
+}
+
+func BenchmarkContentConnectionReuse(b *testing.B) {
+	// the server counts the connections opened by clients
+	var opened atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("raw content"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	server.Start()
+	b.Cleanup(server.Close)
+	remoteURL := mustURL(b, server.URL+"/file.txt")
+
+	for _, shared := range []bool{false, true} {
+		b.Run(fmt.Sprintf("with shared client %t", shared), func(b *testing.B) {
+			opened.Store(0)
+			var w bytes.Buffer
+
+			for b.Loop() {
+				opts := &Options{}
+				if !shared {
+					// a client built for every download cannot reuse connections
+					opts.Client = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+				}
+
+				w.Reset()
+				require.NoError(b, Content(b.Context(), remoteURL, &w, opts))
+
+				if !shared {
+					opts.Client.CloseIdleConnections()
+				}
+			}
+
+			b.ReportMetric(float64(opened.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newSilentListener listens on a local TCP address, accepting connections without ever responding.
+func newSilentListener(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var (
+		mx    sync.Mutex
+		conns []net.Conn
+		wg    sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			mx.Lock()
+			conns = append(conns, conn)
+			mx.Unlock()
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = listener.Close()
+		wg.Wait()
+
+		mx.Lock()
+		defer mx.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+
+	return listener.Addr().String()
+}
@@ -9,6 +9,15 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/transport/client"
 )
 
+// remoteCapabilities probes the capabilities advertised by the remote server, using the configured credentials.
+func (r *Repository) remoteCapabilities(ctx context.Context) (*capability.List, error) {
+	return getRemoteCapabilities(ctx, &gogit.FetchOptions{
+		RemoteURL: r.repoURL.String(),
+		Auth:      r.auth(),
+		CABundle:  r.caBundle(),
+	})
+}
+
 func getRemoteCapabilities(ctx context.Context, o *gogit.FetchOptions) (*capability.List, error) {
 	s, err := newUploadPackSession(o.RemoteURL, o.Auth, o.InsecureSkipTLS, o.ClientCert, o.ClientKey, o.CABundle, o.ProxyOptions)
 	if err != nil {
@@ -17,7 +26,7 @@ func getRemoteCapabilities(ctx context.Context, o *gogit.FetchOptions) (*capabil
 
 	ar, err := s.AdvertisedReferencesContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, checkProtocol(err)
 	}
 
 	return ar.Capabilities, nil
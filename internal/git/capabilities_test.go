@@ -2,9 +2,15 @@ package git
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-openapi/testify/v2/require"
 )
 
@@ -23,3 +29,49 @@ func TestGitlabRepository(t *testing.T) {
 	)
 	t.Logf("%v", w.String())
 }
+
+func TestRemoteCapabilitiesAuth(t *testing.T) {
+	t.Parallel()
+
+	const (
+		username = "deploy"
+		password = "secret"
+	)
+
+	// a private repo only advertises refs to authenticated clients
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != username || pass != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="private"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		_, _ = io.WriteString(w, pktLine("# service=git-upload-pack\n")+"0000"+
+			pktLine("0123456789abcdef0123456789abcdef01234567 refs/heads/master\x00side-band-64k ofs-delta\n")+"0000",
+		)
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL + "/owner/repo")
+	require.NoError(t, err)
+
+	t.Run("should fail to probe a private repo without credentials", func(t *testing.T) {
+		r := NewRepo(u, &Options{})
+		_, err := r.remoteCapabilities(t.Context())
+		require.Error(t, err)
+	})
+
+	t.Run("should probe a private repo with the configured credentials", func(t *testing.T) {
+		r := NewRepo(u, &Options{Auth: &githttp.BasicAuth{Username: username, Password: password}})
+		capabilities, err := r.remoteCapabilities(t.Context())
+		require.NoError(t, err)
+		require.True(t, capabilities.Supports(capability.Sideband64k))
+	})
+}
+
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
@@ -0,0 +1,240 @@
+package git
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/archive"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// errNotCacheable reports a clone which content cannot be restored faithfully from the clone cache.
+var errNotCacheable = errors.New("clone not cacheable")
+
+// cloneCacheEntry locates the clone of a resolved ref in [Options].CloneCacheDir.
+//
+// A clone is cached as a gzipped tarball of its worktree, which gzip header records the hash of the resolved ref
+// (as Name) and the hash of the checked out commit (as Comment). An entry is keyed by the remote, the name of the
+// resolved ref and the sparse filter, but not by the hash: when the ref moves, the stale entry is replaced.
+func (r *Repository) cloneCacheEntry(selectedRef *Ref, filter []string) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, redact.URL(r.repoURL))
+	_, _ = io.WriteString(h, "\x00"+selectedRef.Name().String())
+	for _, dir := range filter {
+		_, _ = io.WriteString(h, "\x00"+dir)
+	}
+
+	return filepath.Join(r.CloneCacheDir, hex.EncodeToString(h.Sum(nil)[:16])+".tar.gz")
+}
+
+// mayUseCloneCache tells if clones are cached.
+//
+// Clones with submodules are not cached, since their content does not depend only on the commit of the superproject.
+func (r *Repository) mayUseCloneCache() bool {
+	return r.Options != nil && r.CloneCacheDir != "" && !r.RecurseSubModules
+}
+
+// restoreClone restores the worktree of a resolved ref from the clone cache.
+//
+// It returns false if the clone is not cached, or if the cached entry is stale or corrupted, in which case it is
+// removed and the worktree is reset, so the ref may be cloned from the remote instead.
+func (r *Repository) restoreClone(repo *gogit.Repository, selectedRef *Ref, filter []string) (billy.Filesystem, *CloneResult, bool) {
+	entry := r.cloneCacheEntry(selectedRef, filter)
+	file, err := os.Open(entry)
+	if err != nil {
+		return nil, nil, false
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil || gz.Name != selectedRef.Hash().String() {
+		// the ref has moved since the clone was cached (or the entry is corrupted)
+		_ = file.Close()
+		_ = os.Remove(entry)
+		r.debug("clone cache: stale entry for %s", selectedRef.Name())
+
+		return nil, nil, false
+	}
+	commit := gz.Comment
+
+	local, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, false
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err == nil {
+		// cache entries are built from clones: their size is not limited
+		err = archive.ExtractTarGz(file, local.Filesystem, archive.WithMaxSize(0))
+	}
+	if err != nil {
+		// the entry is corrupted or partially written: discard it, as well as whatever has been extracted
+		_ = file.Close()
+		_ = os.Remove(entry)
+		r.debug("clone cache: could not restore %s: %v", selectedRef.Name(), err)
+
+		if err = resetWorktree(local.Filesystem); err != nil {
+			r.debug("clone cache: could not reset the worktree: %v", err)
+		}
+
+		return nil, nil, false
+	}
+	r.debug("clone cache: restored %s at %s", selectedRef.Name(), commit)
+
+	return local.Filesystem, &CloneResult{
+		ShortName: selectedRef.ShortName,
+		Hash:      commit,
+		Sparse:    len(filter) > 0,
+		Cached:    true,
+	}, true
+}
+
+// resetWorktree removes all files from a worktree, but the git storage which may be located there.
+func resetWorktree(worktree billy.Filesystem) error {
+	entries, err := worktree.ReadDir("/")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == gogit.GitDirName {
+			continue
+		}
+
+		if err := util.RemoveAll(worktree, entry.Name()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeClone writes the worktree of a cloned commit to the clone cache, from the git objects of the commit.
+//
+// Failures are not reported: the clone cache is an optimization.
+func (r *Repository) storeClone(repo *gogit.Repository, selectedRef *Ref, result *CloneResult, filter []string) {
+	if err := os.MkdirAll(r.CloneCacheDir, 0o755); err != nil {
+		return
+	}
+
+	commit, err := resolveCommit(repo, selectedRef.Hash())
+	if err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(r.CloneCacheDir, ".clone-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err = writeCloneArchive(tmp, commit, selectedRef, filter); err != nil {
+		r.debug("clone cache: could not cache %s: %v", selectedRef.Name(), err)
+
+		return
+	}
+
+	if err = tmp.Close(); err != nil {
+		return
+	}
+
+	// the entry is replaced atomically, so the cache may be shared by concurrent clones
+	if err = os.Rename(tmp.Name(), r.cloneCacheEntry(selectedRef, filter)); err == nil {
+		r.debug("clone cache: cached %s at %s", selectedRef.Name(), result.Hash)
+	}
+}
+
+// writeCloneArchive writes the files of a commit, restricted to the sparse filter, as a gzipped tarball.
+//
+// Like for repository archives served by SCM platforms, all entries are located under a top-level folder.
+func writeCloneArchive(w io.Writer, commit *object.Commit, selectedRef *Ref, filter []string) error {
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	gz.Name = selectedRef.Hash().String()
+	gz.Comment = commit.Hash.String()
+	tw := tar.NewWriter(gz)
+	root := commit.Hash.String() + "/"
+
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if !isSparselyCheckedOut(f.Name, filter) {
+			return nil
+		}
+
+		mode := int64(0o644)
+		switch f.Mode {
+		case filemode.Regular, filemode.Deprecated:
+		case filemode.Executable:
+			mode = 0o755
+		default:
+			// e.g. symbolic links, which are not restored
+			return fmt.Errorf("%q has mode %v: %w", f.Name, f.Mode, errNotCacheable)
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     root + f.Name,
+			Mode:     mode,
+			Size:     f.Size,
+			ModTime:  commit.Committer.When,
+		}); err != nil {
+			return err
+		}
+
+		content, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = content.Close()
+		}()
+
+		_, err = io.Copy(tw, content)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// isSparselyCheckedOut tells if a file is checked out with a sparse filter, like go-git does with a sparse checkout.
+func isSparselyCheckedOut(name string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+
+	for _, pattern := range filter {
+		if strings.HasPrefix(name, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package git
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestCloneCache(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{"README.md": "readme", "docs/guide.md": "guide", "src/main.go": "main"}, "initial"))
+
+	t.Run("should cache a sparse clone separately", func(t *testing.T) {
+		t.Parallel()
+
+		options := &Options{GitSkipAutoDetect: true, CloneCacheDir: t.TempDir()}
+		for _, cached := range []bool{false, true} {
+			fsys, result, err := NewRepo(repo.URL(), options).Clone(t.Context(), "v1.0.0", &CloneOptions{SparseFilter: []string{"docs"}})
+			require.NoError(t, err)
+			require.Equal(t, cached, result.Cached)
+			require.True(t, result.Sparse)
+
+			content, err := fs.ReadFile(fsys, "docs/guide.md")
+			require.NoError(t, err)
+			require.Equal(t, "guide", string(content))
+			_, err = fs.Stat(fsys, "src/main.go")
+			require.Error(t, err)
+		}
+
+		fsys, result, err := NewRepo(repo.URL(), options).Clone(t.Context(), "v1.0.0", nil)
+		require.NoError(t, err)
+		require.False(t, result.Cached)
+		_, err = fs.Stat(fsys, "src/main.go")
+		require.NoError(t, err)
+	})
+
+	t.Run("should not cache a clone with symbolic links", func(t *testing.T) {
+		t.Parallel()
+
+		linked := testrepo.New(t)
+		linked.Commit(map[string]string{"README.md": "readme"}, "initial")
+		linked.Tag("v1.0.0", linked.Symlink("link.md", "README.md", "add link"))
+
+		cacheDir := t.TempDir()
+		options := &Options{GitSkipAutoDetect: true, CloneCacheDir: cacheDir}
+		for range 2 {
+			_, result, err := NewRepo(linked.URL(), options).Clone(t.Context(), "v1.0.0", nil)
+			require.NoError(t, err)
+			require.False(t, result.Cached)
+		}
+
+		entries, err := os.ReadDir(cacheDir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("should clone again when the cached entry is corrupted", func(t *testing.T) {
+		t.Parallel()
+
+		cacheDir := t.TempDir()
+		options := &Options{GitSkipAutoDetect: true, CloneCacheDir: cacheDir}
+		_, result, err := NewRepo(repo.URL(), options).Clone(t.Context(), "v1.0.0", nil)
+		require.NoError(t, err)
+		require.False(t, result.Cached)
+
+		// truncate the entry: its gzip header is intact, but its content is partially written
+		entries, err := os.ReadDir(cacheDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		entry := filepath.Join(cacheDir, entries[0].Name())
+		info, err := os.Stat(entry)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(entry, info.Size()/2))
+
+		fsys, result, err := NewRepo(repo.URL(), options).Clone(t.Context(), "v1.0.0", nil)
+		require.NoError(t, err)
+		require.False(t, result.Cached)
+
+		content, err := fs.ReadFile(fsys, "src/main.go")
+		require.NoError(t, err)
+		require.Equal(t, "main", string(content))
+
+		// the entry has been cached again
+		_, result, err = NewRepo(repo.URL(), options).Clone(t.Context(), "v1.0.0", nil)
+		require.NoError(t, err)
+		require.True(t, result.Cached)
+	})
+}
@@ -0,0 +1,131 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	minAbbrevLength = 4 // like git, abbreviated commits have at least 4 hex digits
+	fullHashLength  = 40
+)
+
+// AmbiguousCommitError is returned when an abbreviated commit hash matches several commits.
+type AmbiguousCommitError struct {
+	// Prefix is the abbreviated commit hash.
+	Prefix string
+
+	// Candidates are the hashes of all the commits matching the prefix, in lexical order.
+	Candidates []string
+}
+
+func (e *AmbiguousCommitError) Error() string {
+	return fmt.Sprintf("ambiguous abbreviated commit %q: candidates are %s", e.Prefix, strings.Join(e.Candidates, ", "))
+}
+
+// IsCommitHash tells if a ref looks like a full or abbreviated commit hash, i.e. 4 to 40 lower-case hex digits.
+func IsCommitHash(ref string) bool {
+	if len(ref) < minAbbrevLength || len(ref) > fullHashLength {
+		return false
+	}
+
+	for _, c := range ref {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsFullCommitHash tells if a ref is a full commit hash, i.e. 40 lower-case hex digits, e.g. as pinned by a permalink.
+func IsFullCommitHash(ref string) bool {
+	return len(ref) == fullHashLength && IsCommitHash(ref)
+}
+
+// resolveCommitRef resolves a ref expressed as a full or abbreviated commit hash.
+//
+// A full hash is fetched as is. Since the git protocol does not resolve abbreviated hashes, all branches and tags
+// are fetched to find the commits matching an abbreviated hash. An [AmbiguousCommitError] is returned
+// if several commits match.
+func (r *Repository) resolveCommitRef(ctx context.Context, repo *gogit.Repository, remote *gogit.Remote, ref string) (*Ref, error) {
+	if len(ref) == fullHashLength {
+		hash := plumbing.NewHash(ref)
+
+		return &Ref{
+			Reference: plumbing.NewHashReference(plumbing.ReferenceName(ref), hash),
+			ShortName: ref,
+		}, nil
+	}
+
+	err := remote.FetchContext(ctx, &gogit.FetchOptions{
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", remote.Config().Name)),
+			"+refs/tags/*:refs/tags/*",
+		},
+		Tags:     gogit.NoTags,
+		Force:    true,
+		Auth:     r.auth(),
+		CABundle: r.caBundle(),
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("could not fetch the history of the remote: %w", err)
+	}
+
+	candidates, err := commitsWithPrefix(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("no commit found for abbreviated hash %q", ref)
+	case 1:
+		r.debug("abbreviated commit %q resolved to %v", ref, candidates[0])
+
+		return &Ref{
+			Reference: plumbing.NewHashReference(plumbing.ReferenceName(candidates[0].String()), candidates[0]),
+			ShortName: ref,
+		}, nil
+	default:
+		ambiguous := &AmbiguousCommitError{
+			Prefix:     ref,
+			Candidates: make([]string, 0, len(candidates)),
+		}
+		for _, hash := range candidates {
+			ambiguous.Candidates = append(ambiguous.Candidates, hash.String())
+		}
+		slices.Sort(ambiguous.Candidates)
+
+		return nil, ambiguous
+	}
+}
+
+func commitsWithPrefix(repo *gogit.Repository, prefix string) ([]plumbing.Hash, error) {
+	iter, err := repo.CommitObjects()
+	if err != nil {
+		return nil, fmt.Errorf("could not list commits: %w", err)
+	}
+
+	var candidates []plumbing.Hash
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if strings.HasPrefix(commit.Hash.String(), prefix) {
+			candidates = append(candidates, commit.Hash)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list commits: %w", err)
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestIsCommitHash(t *testing.T) {
+	t.Parallel()
+
+	for _, ref := range []string{"abcd", "0123456", "0123456789abcdef0123456789abcdef01234567"} {
+		require.True(t, IsCommitHash(ref), ref)
+	}
+
+	for _, ref := range []string{"", "abc", "master", "v1.2.3", "ABCDEF0", "0123456789abcdef0123456789abcdef012345678"} {
+		require.False(t, IsCommitHash(ref), ref)
+	}
+}
+
+func TestIsFullCommitHash(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsFullCommitHash("0123456789abcdef0123456789abcdef01234567"))
+	require.True(t, IsFullCommitHash("1234567890123456789012345678901234567890"), "an all-digits hash is not a semver")
+
+	for _, ref := range []string{"", "0123456", "v1.2.3", "0123456789ABCDEF0123456789ABCDEF01234567", "0123456789abcdef0123456789abcdef012345678"} {
+		require.False(t, IsFullCommitHash(ref), ref)
+	}
+}
+
+func TestCommitRef(t *testing.T) {
+	t.Parallel()
+
+	// commit until two commits share the same abbreviated hash: with 4 hex digits, this takes a few hundred commits
+	repo := testrepo.New(t)
+	byPrefix := make(map[string]plumbing.Hash)
+	var (
+		prefix    string
+		colliding [2]plumbing.Hash
+	)
+	for i := 0; prefix == ""; i++ {
+		hash := repo.Commit(map[string]string{"file.txt": fmt.Sprintf("content %d", i)}, fmt.Sprintf("commit %d", i))
+		short := hash.String()[:minAbbrevLength]
+		if previous, ok := byPrefix[short]; ok {
+			prefix = short
+			colliding = [2]plumbing.Hash{previous, hash}
+		}
+		byPrefix[short] = hash
+	}
+
+	t.Run("should report an ambiguous abbreviated commit", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+
+		var w bytes.Buffer
+		err := r.Fetch(t.Context(), &w, "file.txt", prefix)
+		require.Error(t, err)
+
+		var ambiguous *AmbiguousCommitError
+		require.True(t, errors.As(err, &ambiguous))
+		require.Equal(t, prefix, ambiguous.Prefix)
+		require.ElementsMatch(t, []string{colliding[0].String(), colliding[1].String()}, ambiguous.Candidates)
+		require.ErrorContains(t, err, "ambiguous abbreviated commit")
+	})
+
+	t.Run("should fetch an unambiguous abbreviated commit", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+
+		var w bytes.Buffer
+		require.NoError(t, r.Fetch(t.Context(), &w, "file.txt", colliding[0].String()[:12]))
+		require.NotEmpty(t, w.String())
+	})
+
+	t.Run("should fetch a full commit hash", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+
+		var w bytes.Buffer
+		require.NoError(t, r.Fetch(t.Context(), &w, "file.txt", colliding[1].String()))
+		require.NotEmpty(t, w.String())
+	})
+
+	t.Run("should clone an abbreviated commit", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+
+		_, result, err := r.Clone(t.Context(), colliding[1].String()[:10], nil)
+		require.NoError(t, err)
+		require.Equal(t, colliding[1].String(), result.Hash)
+	})
+
+	t.Run("should not resolve an unknown commit", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+
+		var w bytes.Buffer
+		err := r.Fetch(t.Context(), &w, "file.txt", "0000000000")
+		require.Error(t, err)
+
+		var ambiguous *AmbiguousCommitError
+		require.False(t, errors.As(err, &ambiguous))
+	})
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// dateRefLayouts are the supported formats for a date ref, e.g. "{2024-01-01}" or "{2024-01-01T12:00:00Z}".
+//
+// Dates without a time zone are interpreted as UTC.
+var dateRefLayouts = []string{
+	time.DateOnly,
+	time.DateTime,
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// ParseDateRef parses a ref expressed as a date, e.g. "{2024-01-01}".
+//
+// It returns false if the ref is not a date ref.
+func ParseDateRef(ref string) (time.Time, bool) {
+	value, found := strings.CutPrefix(ref, "{")
+	if !found {
+		return time.Time{}, false
+	}
+
+	value, found = strings.CutSuffix(value, "}")
+	if !found {
+		return time.Time{}, false
+	}
+
+	for _, layout := range dateRefLayouts {
+		date, err := time.Parse(layout, value)
+		if err == nil {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// resolveRef resolves the desired ref to a remote ref, or to a commit when the ref is expressed as a date
+// or as a commit hash.
+func (r *Repository) resolveRef(ctx context.Context, repo *gogit.Repository, remote *gogit.Remote, ref string) (*Ref, error) {
+	if r.Options != nil && r.ReferenceName != "" {
+		// an explicit full ref name: neither dates nor commit hashes are resolved
+		return r.selectRef(ctx, remote, ref)
+	}
+
+	if r.Options != nil && r.DateRefs {
+		if date, isDate := ParseDateRef(ref); isDate {
+			return r.resolveDateRef(ctx, repo, remote, ref, date)
+		}
+	}
+
+	if IsFullCommitHash(ref) {
+		// a pinned commit, e.g. from a permalink: there is no need to list the remote refs
+		return r.resolveCommitRef(ctx, repo, remote, ref)
+	}
+
+	selectedRef, err := r.selectRef(ctx, remote, ref)
+	if err != nil && IsCommitHash(ref) {
+		// no branch or tag matches: the ref is a commit hash
+		return r.resolveCommitRef(ctx, repo, remote, ref)
+	}
+
+	return selectedRef, err
+}
+
+// resolveDateRef resolves a date to the last commit on the default branch committed at or before this date.
+//
+// The history of the default branch is fetched, then walked following first parents,
+// so that commits merged from other branches are not considered.
+func (r *Repository) resolveDateRef(ctx context.Context, repo *gogit.Repository, remote *gogit.Remote, ref string, date time.Time) (*Ref, error) {
+	branch, err := r.defaultBranch(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	head := branch.Hash()
+
+	if err = r.fetch(ctx, remote, head, ""); err != nil {
+		return nil, fmt.Errorf("could not fetch the history of the default branch: %w", err)
+	}
+
+	commit, err := resolveCommit(repo, head)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve commit %v: %w", head, err)
+	}
+
+	for commit.Committer.When.After(date) {
+		if commit.NumParents() == 0 {
+			return nil, fmt.Errorf("no commit found on the default branch at or before %v", date)
+		}
+
+		if commit, err = commit.Parent(0); err != nil {
+			return nil, fmt.Errorf("could not walk the commit history: %w", err)
+		}
+	}
+	r.debug("date %v resolved to commit %v", date, commit.Hash)
+
+	return &Ref{
+		Reference: plumbing.NewHashReference(plumbing.ReferenceName(commit.Hash.String()), commit.Hash),
+		ShortName: ref,
+	}, nil
+}
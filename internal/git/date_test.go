@@ -0,0 +1,83 @@
+package git
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestParseDateRef(t *testing.T) {
+	t.Parallel()
+
+	for ref, expected := range map[string]time.Time{
+		"{2024-01-01}":                time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"{2024-01-01 12:30:00}":       time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+		"{2024-01-01T12:30:00}":       time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+		"{2024-01-01T12:30:00+02:00}": time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC),
+	} {
+		date, ok := ParseDateRef(ref)
+		require.True(t, ok, ref)
+		require.True(t, expected.Equal(date), "%s: expected %v, got %v", ref, expected, date)
+	}
+
+	for _, ref := range []string{"", "2024-01-01", "{2024-01-01", "{yesterday}", "v1.2.3", "{}"} {
+		_, ok := ParseDateRef(ref)
+		require.False(t, ok, ref)
+	}
+}
+
+func TestDateRef(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.CommitAt(map[string]string{"file.txt": "december 1st"}, "first", time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC))
+	expected := repo.CommitAt(map[string]string{"file.txt": "december 20th"}, "second", time.Date(2023, 12, 20, 10, 0, 0, 0, time.UTC))
+	repo.CommitAt(map[string]string{"file.txt": "january 15th"}, "third", time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+
+	t.Run("should fetch a file as of a date", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true, DateRefs: true})
+
+		var w bytes.Buffer
+		require.NoError(t, r.Fetch(t.Context(), &w, "file.txt", "{2024-01-01}"))
+		require.Equal(t, "december 20th", w.String())
+	})
+
+	t.Run("should clone as of a date", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true, DateRefs: true})
+
+		cloned, result, err := r.Clone(t.Context(), "{2023-12-20 10:00:00}", nil)
+		require.NoError(t, err)
+		require.Equal(t, expected.String(), result.Hash)
+		require.Equal(t, "{2023-12-20 10:00:00}", result.ShortName)
+
+		content, err := fs.ReadFile(cloned, "file.txt")
+		require.NoError(t, err)
+		require.Equal(t, "december 20th", string(content))
+	})
+
+	t.Run("should fail with a date before the first commit", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true, DateRefs: true})
+
+		var w bytes.Buffer
+		require.Error(t, r.Fetch(t.Context(), &w, "file.txt", "{2023-01-01}"))
+	})
+
+	t.Run("should not resolve a date when not enabled", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+
+		var w bytes.Buffer
+		require.Error(t, r.Fetch(t.Context(), &w, "file.txt", "{2024-01-01}"))
+	})
+}
@@ -0,0 +1,426 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/objfile"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// smartHTTPContentType is the prefix of the content type of the responses of a smart HTTP git server.
+const smartHTTPContentType = "application/x-git-"
+
+// dumbRemote retrieves objects from a remote served over the dumb HTTP protocol, i.e. as static files.
+//
+// See https://git-scm.com/book/en/v2/Git-Internals-Transfer-Protocols#_the_dumb_protocol
+type dumbRemote struct {
+	*Repository
+
+	client      *http.Client
+	repo        *gogit.Repository
+	packsLoaded bool
+}
+
+func isHTTP(u *url.URL) bool {
+	scheme := strings.TrimPrefix(u.Scheme, "git+")
+
+	return scheme == "http" || scheme == "https"
+}
+
+// probeDumbHTTP tells if the remote is served over the dumb HTTP protocol, and if so, returns the remote refs.
+//
+// The remote is considered a dumb HTTP server whenever the ref discovery does not yield a smart HTTP response.
+// Any error is left for the smart HTTP protocol to report.
+func (r *Repository) probeDumbHTTP(ctx context.Context) (*dumbRemote, []*plumbing.Reference, bool) {
+	d := &dumbRemote{
+		Repository: r,
+		client:     r.dumbHTTPClient(),
+	}
+
+	resp, err := d.get(ctx, "info/refs?service=git-upload-pack")
+	if err != nil {
+		return nil, nil, false
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK || strings.HasPrefix(resp.Header.Get("Content-Type"), smartHTTPContentType) {
+		return nil, nil, false
+	}
+
+	refs, err := parseDumbRefs(resp.Body)
+	if err != nil {
+		r.debug("not a dumb HTTP server: %v", err)
+
+		return nil, nil, false
+	}
+
+	if head, ok := d.head(ctx, refs); ok {
+		refs = append(refs, head)
+	}
+
+	return d, refs, true
+}
+
+// fetchDumbHTTP fetches a file at a given ref from a remote served over the dumb HTTP protocol.
+//
+// All the objects of the tree of the selected commit are retrieved, but not its history.
+func (r *Repository) fetchDumbHTTP(ctx context.Context, d *dumbRemote, repo *gogit.Repository, w io.Writer, file, ref string, allRefs []*plumbing.Reference) (*FetchResult, error) {
+	selectedRef, err := pickRef(allRefs, ref, r.Options)
+	if err != nil {
+		if !IsCommitHash(ref) || len(ref) != fullHashLength {
+			return nil, fmt.Errorf("could not resolve remote ref: %w", err)
+		}
+
+		// a full commit hash is fetched as is
+		hash := plumbing.NewHash(ref)
+		selectedRef = &Ref{
+			Reference: plumbing.NewHashReference(plumbing.ReferenceName(ref), hash),
+			ShortName: ref,
+		}
+	}
+
+	d.repo = repo
+	if err = d.fetchTree(ctx, selectedRef.Hash()); err != nil {
+		return nil, fmt.Errorf("could not fetch %v over the dumb HTTP protocol: %w", selectedRef.Hash(), err)
+	}
+
+	result := &FetchResult{Source: SourceDumbHTTP}
+	if err = r.checkoutFile(ctx, repo, w, file, selectedRef, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// dumbHTTPClient yields the client used to retrieve static files: the client set with [Options].HTTPClient,
+// or else a client which trusts [Options].CABundle.
+func (r *Repository) dumbHTTPClient() *http.Client {
+	if r.Options != nil && r.HTTPClient != nil {
+		return r.HTTPClient
+	}
+
+	bundle := r.caBundle()
+	if len(bundle) == 0 {
+		return http.DefaultClient
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil || roots == nil {
+		roots = x509.NewCertPool()
+	}
+	roots.AppendCertsFromPEM(bundle)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots}
+
+	return &http.Client{Transport: transport}
+}
+
+// get a static file, relative to the URL of the remote.
+func (d *dumbRemote) get(ctx context.Context, pth string) (*http.Response, error) {
+	u := *d.repoURL
+	u.Scheme = strings.TrimPrefix(u.Scheme, "git+")
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/"
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	target, err := u.Parse(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth, ok := d.auth().(githttp.AuthMethod); ok {
+		auth.SetAuth(req)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+
+	return resp, nil
+}
+
+// parseDumbRefs parses the refs listed by the "info/refs" file, e.g. "<hash>\trefs/heads/master".
+//
+// Peeled tags (e.g. "refs/tags/v1.0.0^{}") are ignored.
+func parseDumbRefs(r io.Reader) ([]*plumbing.Reference, error) {
+	var refs []*plumbing.Reference
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		hash, name, ok := strings.Cut(line, "\t")
+		if !ok || len(hash) != fullHashLength || !IsCommitHash(hash) {
+			return nil, fmt.Errorf("unexpected line in info/refs: %q", line)
+		}
+
+		if strings.HasSuffix(name, "^{}") {
+			continue
+		}
+
+		refs = append(refs, plumbing.NewHashReference(plumbing.ReferenceName(name), plumbing.NewHash(hash)))
+	}
+
+	return refs, scanner.Err()
+}
+
+// head resolves the HEAD of the remote, from the "HEAD" file, e.g. "ref: refs/heads/master".
+func (d *dumbRemote) head(ctx context.Context, refs []*plumbing.Reference) (*plumbing.Reference, bool) {
+	resp, err := d.get(ctx, "HEAD")
+	if err != nil {
+		return nil, false
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+
+	const maxHeadSize = 1024
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxHeadSize))
+	if err != nil {
+		return nil, false
+	}
+
+	target := strings.TrimSpace(string(content))
+	if name, isSymbolic := strings.CutPrefix(target, "ref: "); isSymbolic {
+		for _, ref := range refs {
+			if ref.Name().String() == name {
+				return plumbing.NewHashReference(plumbing.HEAD, ref.Hash()), true
+			}
+		}
+
+		return nil, false
+	}
+
+	if len(target) == fullHashLength && IsCommitHash(target) {
+		return plumbing.NewHashReference(plumbing.HEAD, plumbing.NewHash(target)), true
+	}
+
+	return nil, false
+}
+
+// fetchTree retrieves a commit (possibly designated by an annotated tag) and all the objects of its tree.
+func (d *dumbRemote) fetchTree(ctx context.Context, hash plumbing.Hash) error {
+	obj, err := d.object(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	switch obj.Type() {
+	case plumbing.TagObject:
+		tag, err := object.DecodeTag(d.repo.Storer, obj)
+		if err != nil {
+			return err
+		}
+
+		return d.fetchTree(ctx, tag.Target)
+	case plumbing.CommitObject:
+		commit, err := object.DecodeCommit(d.repo.Storer, obj)
+		if err != nil {
+			return err
+		}
+
+		return d.fetchSubtree(ctx, commit.TreeHash)
+	default:
+		return fmt.Errorf("expected %v to be a commit, but got a %v", hash, obj.Type())
+	}
+}
+
+func (d *dumbRemote) fetchSubtree(ctx context.Context, hash plumbing.Hash) error {
+	obj, err := d.object(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	tree, err := object.DecodeTree(d.repo.Storer, obj)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range tree.Entries {
+		switch entry.Mode {
+		case filemode.Submodule:
+			// submodules live in other repositories
+			continue
+		case filemode.Dir:
+			err = d.fetchSubtree(ctx, entry.Hash)
+		default:
+			_, err = d.object(ctx, entry.Hash)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// object retrieves an object, either as a loose object, or from the packs of the remote.
+func (d *dumbRemote) object(ctx context.Context, hash plumbing.Hash) (plumbing.EncodedObject, error) {
+	if obj, err := d.repo.Storer.EncodedObject(plumbing.AnyObject, hash); err == nil {
+		return obj, nil
+	}
+
+	found, err := d.looseObject(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found && !d.packsLoaded {
+		if err = d.loadPacks(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	obj, err := d.repo.Storer.EncodedObject(plumbing.AnyObject, hash)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("object %v not found on the remote: %w", hash, err)
+	}
+
+	return obj, err
+}
+
+// looseObject retrieves a loose object, e.g. "objects/ab/cdef...".
+func (d *dumbRemote) looseObject(ctx context.Context, hash plumbing.Hash) (bool, error) {
+	hex := hash.String()
+	resp, err := d.get(ctx, "objects/"+hex[:2]+"/"+hex[2:])
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, nil
+	}
+
+	reader, err := objfile.NewReader(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("invalid loose object %v: %w", hash, err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	typ, size, err := reader.Header()
+	if err != nil {
+		return false, fmt.Errorf("invalid loose object %v: %w", hash, err)
+	}
+
+	obj := d.repo.Storer.NewEncodedObject()
+	obj.SetType(typ)
+	obj.SetSize(size)
+
+	ow, err := obj.Writer()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = io.Copy(ow, reader); err != nil {
+		_ = ow.Close()
+
+		return false, fmt.Errorf("invalid loose object %v: %w", hash, err)
+	}
+
+	if err = ow.Close(); err != nil {
+		return false, err
+	}
+
+	if obj.Hash() != hash {
+		return false, fmt.Errorf("corrupted loose object %v: got %v", hash, obj.Hash())
+	}
+
+	_, err = d.repo.Storer.SetEncodedObject(obj)
+
+	return err == nil, err
+}
+
+// loadPacks retrieves all the packs listed by the "objects/info/packs" file, e.g. "P pack-<hash>.pack".
+func (d *dumbRemote) loadPacks(ctx context.Context) error {
+	d.packsLoaded = true
+
+	resp, err := d.get(ctx, "objects/info/packs")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		// no packs
+		return nil
+	}
+
+	var packs []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if pack, ok := strings.CutPrefix(scanner.Text(), "P "); ok {
+			packs = append(packs, strings.TrimSpace(pack))
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, pack := range packs {
+		if err = d.loadPack(ctx, pack); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *dumbRemote) loadPack(ctx context.Context, pack string) error {
+	resp, err := d.get(ctx, "objects/pack/"+url.PathEscape(pack))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not retrieve pack %q: %s", pack, resp.Status)
+	}
+
+	if err = packfile.UpdateObjectStorage(d.repo.Storer, resp.Body); err != nil {
+		return fmt.Errorf("invalid pack %q: %w", pack, err)
+	}
+
+	return nil
+}
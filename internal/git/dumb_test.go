@@ -0,0 +1,145 @@
+package git
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-billy/v5/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/serverinfo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestDumbHTTP(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	first := repo.Commit(map[string]string{"README.md": "v1", "docs/guide.md": "guide v1"}, "initial")
+	repo.Tag("v1.0.0", first)
+	second := repo.Commit(map[string]string{"README.md": "v2"}, "update")
+	repo.AnnotatedTag("v2.0.0", second, "release v2")
+
+	for _, tc := range []struct {
+		name string
+		dir  string
+	}{
+		{name: "with loose objects", dir: filepath.Join(repo.Dir, gogit.GitDirName)},
+		{name: "with packed objects", dir: repo.Bare()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repoURL := serveDumbHTTP(t, tc.dir)
+			r := NewRepo(repoURL, &Options{GitSkipAutoDetect: true, DumbHTTP: true})
+
+			for _, fetch := range []struct {
+				file, ref, want string
+			}{
+				{"README.md", HEAD, "v2"},
+				{"README.md", "v1.0.0", "v1"},
+				{"docs/guide.md", "v1.0.0", "guide v1"},
+				{"README.md", "v2.0.0", "v2"},
+				{"README.md", "master", "v2"},
+				{"README.md", first.String(), "v1"},
+			} {
+				var w bytes.Buffer
+				require.NoErrorf(t, r.Fetch(t.Context(), &w, fetch.file, fetch.ref), "fetching %q at %q", fetch.file, fetch.ref)
+				require.Equal(t, fetch.want, w.String())
+			}
+
+			t.Run("should not resolve an unknown ref", func(t *testing.T) {
+				require.Error(t, r.Fetch(t.Context(), new(bytes.Buffer), "README.md", "develop"))
+			})
+
+			t.Run("should not fetch from a dumb HTTP server unless enabled", func(t *testing.T) {
+				smartOnly := NewRepo(repoURL, &Options{GitSkipAutoDetect: true})
+
+				require.Error(t, smartOnly.Fetch(t.Context(), new(bytes.Buffer), "README.md", "v1.0.0"))
+			})
+		})
+	}
+}
+
+func TestDumbHTTPSmartServer(t *testing.T) {
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("this test requires the git binary to serve repositories over http")
+	}
+
+	t.Parallel()
+
+	backend := &cgi.Handler{
+		Path: gitBin,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=/",
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+
+	repo := testrepo.New(t)
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{"README.md": "smart"}, "initial"))
+	repoURL, err := url.Parse(server.URL + filepath.ToSlash(repo.Bare()))
+	require.NoError(t, err)
+
+	r := NewRepo(repoURL, &Options{GitSkipAutoDetect: true, DumbHTTP: true})
+
+	_, _, isDumb := r.probeDumbHTTP(t.Context())
+	require.False(t, isDumb)
+
+	var w bytes.Buffer
+	require.NoError(t, r.Fetch(t.Context(), &w, "README.md", "v1.0.0"))
+	require.Equal(t, "smart", w.String())
+}
+
+func TestParseDumbRefs(t *testing.T) {
+	t.Parallel()
+
+	hash := "0123456789abcdef0123456789abcdef01234567"
+	peeled := "89abcdef0123456789abcdef0123456789abcdef"
+
+	t.Run("should parse info/refs", func(t *testing.T) {
+		refs, err := parseDumbRefs(strings.NewReader(
+			hash + "\trefs/heads/master\n" +
+				hash + "\trefs/tags/v1.0.0\n" +
+				peeled + "\trefs/tags/v1.0.0^{}\n",
+		))
+		require.NoError(t, err)
+		require.Equal(t, []*plumbing.Reference{
+			plumbing.NewHashReference("refs/heads/master", plumbing.NewHash(hash)),
+			plumbing.NewHashReference("refs/tags/v1.0.0", plumbing.NewHash(hash)),
+		}, refs)
+	})
+
+	t.Run("should reject a smart HTTP response", func(t *testing.T) {
+		_, err := parseDumbRefs(strings.NewReader("001e# service=git-upload-pack\n0000"))
+		require.Error(t, err)
+	})
+}
+
+// serveDumbHTTP serves a git directory as static files, after generating the files needed by the dumb HTTP protocol.
+func serveDumbHTTP(t *testing.T, gitDir string) *url.URL {
+	t.Helper()
+
+	fs := osfs.New(gitDir)
+	repo, err := gogit.PlainOpenWithOptions(gitDir, &gogit.PlainOpenOptions{})
+	require.NoError(t, err)
+	require.NoError(t, serverinfo.UpdateServerInfo(repo.Storer, fs))
+
+	server := httptest.NewServer(http.FileServer(http.Dir(gitDir)))
+	t.Cleanup(server.Close)
+
+	repoURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+
+	return repoURL
+}
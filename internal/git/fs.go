@@ -17,8 +17,8 @@ type fsWrapper struct { // this is needed until go-billy/v6 and go-git/v6 are re
 }
 
 func (f *fsWrapper) Open(path string) (fs.File, error) {
-	info, _ := f.Filesystem.Stat(path)
-	if info.IsDir() {
+	info, err := f.Filesystem.Stat(path)
+	if err == nil && info.IsDir() {
 		dir, err := f.Filesystem.ReadDir(path)
 		if err != nil {
 			return nil, err
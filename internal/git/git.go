@@ -2,16 +2,20 @@ package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log"
 	"net/url"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/blang/semver/v4"
-	"github.com/davecgh/go-spew/spew"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-billy/v5/osfs"
@@ -19,11 +23,17 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage"
 	"github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/go-git/go-git/v5/storage/memory"
 )
 
+// ErrFileNotFound is returned when the fetched file does not exist in the tree of the selected commit.
+//
+// Errors of this kind also match [fs.ErrNotExist].
+var ErrFileNotFound = errors.New("file not found in the repository tree")
+
 // Ref wraps a git [plumbing.Reference].
 type Ref struct {
 	*plumbing.Reference
@@ -40,9 +50,10 @@ type Repository struct {
 
 	repoURL  *url.URL
 	repo     *gogit.Repository
-	store    func() storage.Storer
+	store    func() (storage.Storer, error)
 	worktree func() billy.Filesystem
 	debug    func(string, ...any)
+	command  func(context.Context, ...string) *exec.Cmd // runs the git command, defaults to exec.CommandContext
 }
 
 // NewRepo initializes a new git repository for a given URL.
@@ -62,10 +73,15 @@ func NewRepo(repoURL *url.URL, opts *Options) *Repository {
 		fs := osfs.New(opts.Dir, osfs.WithBoundOS())
 		lru := cache.NewObjectLRUDefault()
 
-		initStoreFunc := func() storage.Storer {
+		initStoreFunc := func() (storage.Storer, error) {
 			lru.Clear()
+			// the git storage is kept apart from the worktree, so a forced checkout does not clobber it
+			dotGit, err := fs.Chroot(gogit.GitDirName)
+			if err != nil {
+				return nil, err
+			}
 
-			return filesystem.NewStorage(fs, lru)
+			return filesystem.NewStorage(dotGit, lru), nil
 		}
 		initWorktreeFunc := func() billy.Filesystem {
 			fs.(*osfs.BoundOS).RemoveAll(fs.Root())
@@ -83,7 +99,12 @@ func NewRepo(repoURL *url.URL, opts *Options) *Repository {
 	}
 
 	// default is MemFS backend
-	initStoreFunc := func() storage.Storer { return memory.NewStorage() }
+	initStoreFunc := func() (storage.Storer, error) { return memory.NewStorage(), nil }
+	if opts != nil && opts.ObjectCacheDir != "" {
+		initStoreFunc = func() (storage.Storer, error) {
+			return newObjectCacheStorage(memory.NewStorage(), opts.ObjectCacheDir, repoURL), nil
+		}
+	}
 	initWorktreeFunc := memfs.New
 
 	return &Repository{
@@ -99,36 +120,114 @@ func NewRepo(repoURL *url.URL, opts *Options) *Repository {
 //
 // The file is copied to the given [io.Writer].
 func (r *Repository) Fetch(ctx context.Context, w io.Writer, file, ref string) error {
+	_, err := r.FetchWithResult(ctx, w, file, ref)
+
+	return err
+}
+
+// FetchWithResult fetches a file at a given ref from the [Repository], like [Repository.Fetch],
+// and reports about the fetch.
+//
+// If the fetch fails before any content is written, it is tried again against [Options].FallbackURL, if any.
+func (r *Repository) FetchWithResult(ctx context.Context, w io.Writer, file, ref string) (*FetchResult, error) {
+	counter := &writeCounter{Writer: w}
+	result, err := r.fetchWithResult(ctx, counter, file, ref)
+	if !r.shouldFallback(ctx, err) || counter.n > 0 {
+		return result, err
+	}
+
+	result, fallbackErr := r.fallback(err).fetchWithResult(ctx, w, file, ref)
+	if fallbackErr != nil {
+		return nil, r.fallbackError(err, fallbackErr)
+	}
+
+	return result, nil
+}
+
+func (r *Repository) fetchWithResult(ctx context.Context, w io.Writer, file, ref string) (*FetchResult, error) {
+	result := &FetchResult{}
+
 	// initialize git with proper remote
 	repo, remote, err := r.init()
 	if err != nil {
-		return fmt.Errorf("could not initialize git repo: %w", err)
+		return nil, fmt.Errorf("could not initialize git repo: %w", err)
+	}
+
+	if r.Options != nil && r.DumbHTTP && isHTTP(r.repoURL) {
+		if dumb, allRefs, isDumb := r.probeDumbHTTP(ctx); isDumb {
+			r.debug("remote %v is served over the dumb HTTP protocol", redact.URL(r.repoURL))
+
+			return r.fetchDumbHTTP(ctx, dumb, repo, w, file, ref, allRefs)
+		}
 	}
 
 	// figure out the hash for the desired ref
-	selectedRef, err := r.selectRef(ctx, remote, ref)
+	selectedRef, err := r.resolveRef(ctx, repo, remote, ref)
 	if err != nil {
-		return fmt.Errorf("could not resolve remote ref: %w", err)
+		slashedRef, slashedFile, ok := r.resolveSlashedRef(ctx, remote, ref, file)
+		if !ok {
+			return nil, fmt.Errorf("could not resolve remote ref: %w", err)
+		}
+
+		selectedRef, file = slashedRef, slashedFile
 	}
 
-	remoteCapabilities, err := getRemoteCapabilities(ctx, &gogit.FetchOptions{
-		RemoteURL: r.repoURL.String(),
-	})
+	remoteCapabilities, err := r.remoteCapabilities(ctx)
 	if err != nil {
-		return fmt.Errorf("unable to retrieve the git protocol capabilities for the remote server: %w", err)
+		return nil, fmt.Errorf("unable to retrieve the git protocol capabilities for the remote server: %w", err)
 	}
-	spew.Dump(remoteCapabilities)
+	r.debug("remote capabilities: %v", remoteCapabilities)
+
+	if r.mayUseNativeArchive() {
+		// use installed git command, unless the remote turns out not to support git archive
+		handled, err := r.tryNativeArchive(ctx, w, file, selectedRef)
+		if err != nil {
+			return nil, err
+		}
+
+		if handled {
+			result.Source = SourceGitArchive
+			r.addFile(result, file)
 
-	if r.Options == nil || !r.GitSkipAutoDetect {
-		if r.supportArchive() && isGitInstalled() {
-			r.debug("git is installed")
-			// use installed git command
-			return r.nativeExtractGitArchive(ctx, w, file, selectedRef)
+			return result, nil
 		}
 	}
 
 	// use go-git implementation
-	return r.fetchAndSparseCheckout(ctx, repo, remote, w, file, selectedRef)
+	if err = r.fetchAndSparseCheckout(ctx, repo, remote, w, file, selectedRef, result); err != nil {
+		return nil, err
+	}
+
+	if r.Options != nil && r.Notes {
+		if result.Note, err = r.fetchNote(ctx, repo, remote, selectedRef.Hash()); err != nil {
+			return nil, fmt.Errorf("could not retrieve notes: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// mayUseNativeArchive tells if the file may be extracted with "git archive", using the installed git command.
+func (r *Repository) mayUseNativeArchive() bool {
+	// NOTE: git archive does not extract files from submodules, nor does it verify signatures or retrieve notes.
+	// The git command does not trust a CA bundle passed to go-git either, nor does it populate the object cache.
+	if r.Options != nil && (r.GitSkipAutoDetect || r.RecurseSubModules || r.RequireSignedCommit || r.Notes || len(r.CABundle) > 0 || r.ObjectCacheDir != "") {
+		return false
+	}
+
+	if !r.supportArchive() || !isGitInstalled() {
+		return false
+	}
+
+	if _, unsupported := archiveUnsupported.Load(archiveKey(r.repoURL)); unsupported {
+		r.debug("git archive is not supported by %s", redact.URL(r.repoURL))
+
+		return false
+	}
+
+	r.debug("git is installed")
+
+	return true
 }
 
 func (r *Repository) supportArchive() bool {
@@ -139,21 +238,67 @@ func (r *Repository) supportArchive() bool {
 	return true
 }
 
-func (r *Repository) fetchAndSparseCheckout(ctx context.Context, repo *gogit.Repository, remote *gogit.Remote, w io.Writer, file string, selectedRef *Ref) error {
+func (r *Repository) fetchAndSparseCheckout(ctx context.Context, repo *gogit.Repository, remote *gogit.Remote, w io.Writer, file string, selectedRef *Ref, result *FetchResult) error {
 	// fetch ref
 	t2 := time.Now()
 	hash := selectedRef.Hash()
+	result.Source = SourceGit
+	if repo.Storer.HasEncodedObject(hash) == nil {
+		// the commit is already held locally, e.g. by the object cache
+		result.Source = SourceCache
+	}
+
 	if err := r.fetch(ctx, remote, hash, file); err != nil {
 		return fmt.Errorf("could not fetch remote ref: %w", err)
 	}
+	r.debug("fetch: elapsed: %v", time.Since(t2))
+
+	return r.checkoutFile(ctx, repo, w, file, selectedRef, result)
+}
+
+// checkoutFile checks out a file from a commit already fetched into the local repository, and copies it to w.
+func (r *Repository) checkoutFile(ctx context.Context, repo *gogit.Repository, w io.Writer, file string, selectedRef *Ref, result *FetchResult) error {
 	t3 := time.Now()
-	r.debug("fetch: elapsed: %v", t3.Sub(t2))
+	hash := selectedRef.Hash()
 
-	local, err := repo.Worktree()
+	if r.Options != nil && r.RequireSignedCommit {
+		if err := r.verifySignature(repo, hash); err != nil {
+			return fmt.Errorf("could not verify signature: %w", err)
+		}
+	}
+
+	if r.Options != nil && r.RecurseSubModules {
+		sub, isInSubmodule, err := r.submoduleFor(repo, hash, file)
+		if err != nil {
+			return fmt.Errorf("could not resolve submodule for %q: %w", file, err)
+		}
+
+		if isInSubmodule {
+			r.debug("%q is located in submodule %v at %v", file, redact.URL(sub.repoURL), sub.hash)
+
+			return sub.fetch(ctx, w, r.repoURL, r.Options, result)
+		}
+	}
+
+	file, err := r.resolveFileSymlinks(repo, hash, file)
 	if err != nil {
 		return err
 	}
 
+	if err = lookupFile(repo, hash, file); err != nil {
+		return err
+	}
+
+	streamed, err := r.streamLargeFile(repo, hash, file, w)
+	if err != nil {
+		return err
+	}
+	if streamed {
+		r.addFile(result, file)
+
+		return nil
+	}
+
 	// sparse checkout of the file.
 	// At this point we should only have a hash
 	var filter []string
@@ -162,23 +307,21 @@ func (r *Repository) fetchAndSparseCheckout(ctx context.Context, repo *gogit.Rep
 		filter = []string{file}
 	}
 
-	err = local.Checkout(&gogit.CheckoutOptions{
-		Hash:                      hash,
-		Branch:                    selectedRef.Name(),
-		Create:                    true,
-		Force:                     true,
-		SparseCheckoutDirectories: filter,
-	})
+	local, err := r.checkout(repo, selectedRef, filter)
 	if err != nil {
 		return err
 	}
 	t4 := time.Now()
 	r.debug("checkout: elapsed: %v", t4.Sub(t3))
 
+	if err = r.addCheckedOutFiles(repo, result); err != nil {
+		return fmt.Errorf("could not list checked out files: %w", err)
+	}
+
 	path := filepath.Join(local.Filesystem.Root(), file)
 	fd, err := local.Filesystem.Open(path)
 	if err != nil {
-		return fmt.Errorf("did not find %q on checkout: %w", path, err)
+		return fmt.Errorf("could not open %q on checkout: %w", path, err)
 	}
 
 	_, err = io.Copy(w, fd)
@@ -188,104 +331,155 @@ func (r *Repository) fetchAndSparseCheckout(ctx context.Context, repo *gogit.Rep
 	return err
 }
 
-// Clone the repository defined by an URL.
-func (r *Repository) Clone(ctx context.Context, ref string, opts *CloneOptions) (fs.FS, error) {
-	// TODO: clone repo as fs.FS
-	return nil, nil
-	/*
-		// Branches and tags are safe to fetch when cloning. This is not the case
-		// of notes, for example so we only pass a reference to clone if we're
-		// dealing with a brach or tag.
-		var reference plumbing.ReferenceName
-		switch {
-		case components.Branch != "":
-			reference = plumbing.NewBranchReferenceName(components.Branch)
-		case components.Tag != "":
-			reference = plumbing.NewTagReferenceName(components.Tag)
-		}
+// lookupFile checks that a file exists in the tree of a commit before it is checked out,
+// so a file missing from the tree is told apart from an I/O error on checkout.
+func lookupFile(repo *gogit.Repository, hash plumbing.Hash, file string) error {
+	commit, err := resolveCommit(repo, hash)
+	if err != nil {
+		return err
+	}
 
-		var fsobj billy.Filesystem
-		if opts.ClonePath == "" {
-			fsobj = memfs.New()
-		} else {
-			fsobj = osfs.New(opts.ClonePath)
-		}
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
 
-		// Handle cloning from repos with file: transport
-		repourl := components.RepoURL()
-		if components.Transport == "file" {
-			repourl = components.RepoPath
+	if _, err = tree.FindEntry(strings.Trim(path.Clean("/"+file), "/")); err != nil {
+		if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+			return fmt.Errorf("%q at %v: %w: %w", file, hash, ErrFileNotFound, fs.ErrNotExist)
 		}
 
-		// Make a shallow clone of the repo to memory
-		if len(opts.Filter) > 0 {
+		return err
+	}
 
-		}
-		repo, err := git.Clone(memory.NewStorage(), fsobj, &git.CloneOptions{
-			URL: repourl,
-			// Progress:      os.Stdout,
-			ReferenceName: reference,
-			SingleBranch:  true,
-			// Depth:         1,
-			// RecurseSubmodules: 0,
-			// ShallowSubmodules: false,
-			// TODO(fred): depth
-			// TODO(fred): how to achieve sparse checkout?
-		})
-		if err != nil {
-			return nil, fmt.Errorf("cloning repo: %w", err)
-		}
+	return nil
+}
 
-		commitHash := components.Commit
-		// Here we handle commits and other references (not tags or branches)
-		if reference == "" && components.Commit == "" {
-			// But also ensuring we are note refetching a previous commit
-			if components.RefString != "" && components.RefString != components.Commit {
-				// Since this ref was not fetched at clone time, we do a fetch here
-				// to make sure it is available. This is especially important for
-				// git notes that are never transferred by default and cannot be
-				// fetched at clone time, I thing because of a bug that somewhere
-				// changes the ref string from refs/notes/commits to refs/heads/notes/commits
-				//
-				if err := repo.Fetch(&git.FetchOptions{
-					RefSpecs: []config.RefSpec{
-						config.RefSpec(fmt.Sprintf("%s:%s", components.RefString, components.RefString)),
-					},
-				}); err != nil {
-					return nil, fmt.Errorf("late fetching ref %q: %w", components.RefString, err)
-				}
-
-				// Resolve the reference, it should not fail as we fetched it already
-				ref, err := repo.Reference(plumbing.ReferenceName(components.RefString), true)
-				if err != nil {
-					return nil, fmt.Errorf("resolving reference %q: %w", components.RefString, err)
-				}
-
-				// Resolve the reference to a commit hash
-				hach, err := repo.ResolveRevision(plumbing.Revision(ref.Name().String()))
-				if err != nil {
-					return nil, fmt.Errorf("resolving latest revision on %q to commit: %w", ref.Name().String(), err)
-				}
-				commitHash = hach.String()
-			}
+// Clone the repository defined by an URL.
+//
+// The worktree is checked out at the given ref and exposed as a read-only [fs.FS].
+//
+// If the clone fails, it is tried again against [Options].FallbackURL, if any.
+func (r *Repository) Clone(ctx context.Context, ref string, opts *CloneOptions) (fs.FS, *CloneResult, error) {
+	fsys, result, err := r.clone(ctx, ref, opts)
+	if !r.shouldFallback(ctx, err) {
+		return fsys, result, err
+	}
+
+	fsys, result, fallbackErr := r.fallback(err).clone(ctx, ref, opts)
+	if fallbackErr != nil {
+		return nil, nil, r.fallbackError(err, fallbackErr)
+	}
+
+	return fsys, result, nil
+}
+
+func (r *Repository) clone(ctx context.Context, ref string, opts *CloneOptions) (fs.FS, *CloneResult, error) {
+	repo, remote, err := r.init()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not initialize git repo: %w", err)
+	}
+
+	selectedRef, err := r.resolveRef(ctx, repo, remote, ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not resolve remote ref: %w", err)
+	}
+
+	var filter []string
+	if opts != nil {
+		filter = opts.SparseFilter
+	}
+
+	useCache := r.mayUseCloneCache()
+	if useCache {
+		if cached, result, ok := r.restoreClone(repo, selectedRef, filter); ok {
+			return &fsWrapper{Filesystem: cached}, result, nil
 		}
+	}
 
-		// If a revision was specified, check it out
-		if commitHash != "" {
-			wt, err := repo.Worktree()
-			if err != nil {
-				return nil, fmt.Errorf("getting repository worktree: %w", err)
-			}
+	local, result, err := r.cloneRef(ctx, repo, remote, selectedRef, opts)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			if err = wt.Checkout(&git.CheckoutOptions{
-				Hash: plumbing.NewHash(commitHash),
-			}); err != nil {
-				return nil, fmt.Errorf("checking out commit %s: %w", commitHash, err)
-			}
+	if useCache {
+		r.storeClone(repo, selectedRef, result, filter)
+	}
+
+	return &fsWrapper{Filesystem: local.Filesystem}, result, nil
+}
+
+// cloneRef fetches and checks out a resolved ref, then clones submodules if required.
+func (r *Repository) cloneRef(ctx context.Context, repo *gogit.Repository, remote *gogit.Remote, selectedRef *Ref, opts *CloneOptions) (*gogit.Worktree, *CloneResult, error) {
+	hash := selectedRef.Hash()
+	if err := r.fetch(ctx, remote, hash, ""); err != nil {
+		return nil, nil, fmt.Errorf("could not fetch remote ref: %w", err)
+	}
+
+	var (
+		filter      []string
+		concurrency int
+	)
+	if opts != nil {
+		filter = opts.SparseFilter
+		concurrency = opts.SubmoduleConcurrency
+	}
+
+	local, err := r.checkout(repo, selectedRef, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not checkout %v: %w", hash, err)
+	}
+
+	commit, err := resolveCommit(repo, hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not resolve commit %v: %w", hash, err)
+	}
+
+	if r.Options != nil && r.RecurseSubModules {
+		if err = r.cloneSubmodules(ctx, commit, local.Filesystem, filter, concurrency); err != nil {
+			return nil, nil, err
 		}
+	}
 
-		return iofs.New(fsobj), nil
-	*/
+	objects, err := countObjects(repo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not count objects: %w", err)
+	}
+
+	result := &CloneResult{
+		ShortName: selectedRef.ShortName,
+		Hash:      commit.Hash.String(),
+		Objects:   objects,
+		Sparse:    len(filter) > 0,
+	}
+
+	return local, result, nil
+}
+
+// checkout the worktree at the selected ref, optionally restricted to a sparse filter.
+func (r *Repository) checkout(repo *gogit.Repository, selectedRef *Ref, filter []string) (*gogit.Worktree, error) {
+	local, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	checkoutOptions := &gogit.CheckoutOptions{
+		Hash:                      selectedRef.Hash(),
+		Force:                     true,
+		SparseCheckoutDirectories: filter,
+	}
+	if name := selectedRef.Name(); name.IsBranch() || name.IsTag() {
+		if _, err = repo.Reference(name, false); err != nil {
+			checkoutOptions.Branch = name
+			checkoutOptions.Create = true
+		} // otherwise, the ref is already held, e.g. with all tags fetched: detached HEAD
+	} // otherwise, detached HEAD
+
+	if err = local.Checkout(checkoutOptions); err != nil {
+		return nil, err
+	}
+
+	return local, nil
 }
 
 func (r *Repository) init() (*gogit.Repository, *gogit.Remote, error) {
@@ -293,7 +487,12 @@ func (r *Repository) init() (*gogit.Repository, *gogit.Remote, error) {
 		return nil, nil, fmt.Errorf("cannot init repo with empty URL")
 	}
 
-	repo, err := gogit.Init(r.store(), r.worktree())
+	store, err := r.store()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not initialize the git storage: %w", err)
+	}
+
+	repo, err := gogit.Init(store, r.worktree())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -301,7 +500,7 @@ func (r *Repository) init() (*gogit.Repository, *gogit.Remote, error) {
 	// TODO: config (auth, ...)
 
 	remote, err := repo.CreateRemote(&config.RemoteConfig{
-		Name: "origin",
+		Name: r.remoteName(),
 		URLs: []string{r.repoURL.String()},
 	})
 	if err != nil {
@@ -313,10 +512,12 @@ func (r *Repository) init() (*gogit.Repository, *gogit.Remote, error) {
 
 func (r *Repository) selectRef(ctx context.Context, remote *gogit.Remote, ref string) (*Ref, error) {
 	allRefs, err := remote.ListContext(ctx, &gogit.ListOptions{ // NOTE: unfortunately, there is no way to filter refs
-		// Auth / TLS/ Proxy
+		Auth:     r.auth(),
+		CABundle: r.caBundle(),
+		// Proxy
 	})
 	if err != nil {
-		return nil, err
+		return nil, checkProtocol(err)
 	}
 
 	// pick the best matching ref depending on chosen options
@@ -328,17 +529,18 @@ func (r *Repository) fetch(ctx context.Context, remote *gogit.Remote, hash plumb
 
 	refSpec := config.RefSpec(fmt.Sprintf("+%[1]v:%[1]v", hash)) // build a hash ref
 	err := remote.FetchContext(ctx, &gogit.FetchOptions{         // TODO: bug if repo maps HEAD to main (see gitlab test)
-		RefSpecs: []config.RefSpec{refSpec},
+		RefSpecs: r.refSpecs(refSpec),
 		Depth:    0,
-		Tags:     gogit.NoTags,
+		Tags:     r.tagMode(),
 		Force:    true,
-		// Auth: / TLS / Proxy
+		Auth:     r.auth(),
+		CABundle: r.caBundle(),
+		// Proxy
 	})
-	if err != nil {
-		return fmt.Errorf("fetch remote hash ref %v: %w", hash, err)
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch remote hash ref %v: %w", hash, checkProtocol(err))
 	}
 
-	// TODO: if local fs, use Storer.AddAlternate?
 	// RecurseSubModules???
 
 	/*
@@ -355,14 +557,33 @@ func (r *Repository) fetch(ctx context.Context, remote *gogit.Remote, hash plumb
 func noDebug(format string, args ...any) {
 }
 
-/*
-	if err = fs.WalkDir(&fsWrapper{Filesystem: local.Filesystem}, "/", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		log.Printf("DEBUG: %s", path)
-		return nil
-	}); err != nil {
-		return fmt.Errorf("DEBUG: walkdir error: %w", err)
+// resolveSlashedRef resolves a ref which name contains slashes, e.g. the tag "release/2024.01",
+// whenever the URL of the file did not tell apart the ref from the path, e.g. ref "release" and file "2024.01/README.md".
+//
+// The longest branch or tag name made of the ref and the leading segments of the file path wins.
+func (r *Repository) resolveSlashedRef(ctx context.Context, remote *gogit.Remote, ref, file string) (*Ref, string, bool) {
+	if ref == "" || ref == HEAD || isSpecialRef(ref) || !strings.Contains(strings.Trim(file, "/"), "/") {
+		return nil, "", false
 	}
-*/
+
+	allRefs, err := remote.ListContext(ctx, &gogit.ListOptions{
+		Auth:     r.auth(),
+		CABundle: r.caBundle(),
+	})
+	if err != nil {
+		return nil, "", false
+	}
+
+	slashedRef, slashedFile, ok := splitSlashedRef(allRefs, ref, file)
+	if !ok {
+		return nil, "", false
+	}
+
+	selectedRef, err := pickRef(allRefs, slashedRef, r.Options)
+	if err != nil {
+		return nil, "", false
+	}
+	r.debug("ref %q with file %q resolved to ref %q with file %q", ref, file, slashedRef, slashedFile)
+
+	return selectedRef, slashedFile, true
+}
@@ -3,8 +3,13 @@ package git
 import (
 	"bytes"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-openapi/testify/v2/require"
 )
 
@@ -30,3 +35,107 @@ func TestRepository(t *testing.T) {
 
 	t.Logf("%v", w.String())
 }
+
+func TestFetchTags(t *testing.T) {
+	t.Parallel()
+
+	fixture := testrepo.New(t)
+	fixture.Tag("v1.0.0", fixture.Commit(map[string]string{"README.md": "v1.0.0"}, "initial commit"))
+	fixture.Tag("v1.1.0", fixture.Commit(map[string]string{"README.md": "v1.1.0"}, "minor"))
+	fixture.Tag("v2.0.0", fixture.Commit(map[string]string{"README.md": "v2.0.0"}, "major"))
+
+	fetchTags := func(t *testing.T, fetchTags bool) []string {
+		t.Helper()
+
+		dir := t.TempDir()
+		r := NewRepo(fixture.URL(), &Options{
+			GitSkipAutoDetect: true,
+			IsFSBacked:        true,
+			Dir:               dir,
+			FetchTags:         fetchTags,
+		})
+
+		// the semver constraint is resolved from the refs advertised by the remote
+		var w bytes.Buffer
+		require.NoError(t, r.Fetch(t.Context(), &w, "README.md", "v1"))
+		require.Equal(t, "v1.1.0", w.String())
+
+		repo, err := gogit.PlainOpen(dir)
+		require.NoError(t, err)
+		iter, err := repo.Tags()
+		require.NoError(t, err)
+
+		var tags []string
+		require.NoError(t, iter.ForEach(func(ref *plumbing.Reference) error {
+			tags = append(tags, ref.Name().Short())
+
+			return nil
+		}))
+
+		return tags
+	}
+
+	t.Run("should resolve a semver constraint without fetching tags", func(t *testing.T) {
+		// only the checked out tag is held
+		require.Equal(t, []string{"v1.1.0"}, fetchTags(t, false))
+	})
+
+	t.Run("should fetch all tags when required", func(t *testing.T) {
+		require.ElementsMatch(t, []string{"v1.0.0", "v1.1.0", "v2.0.0"}, fetchTags(t, true))
+	})
+}
+
+func TestBackedDirLayout(t *testing.T) {
+	t.Parallel()
+
+	fixture := testrepo.New(t)
+	fixture.Tag("v1.0.0", fixture.Commit(map[string]string{"README.md": "readme", "docs/api.md": "api"}, "initial commit"))
+
+	dir := t.TempDir()
+	r := NewRepo(fixture.URL(), &Options{GitSkipAutoDetect: true, IsFSBacked: true, Dir: dir})
+	_, _, err := r.Clone(t.Context(), "v1.0.0", nil)
+	require.NoError(t, err)
+
+	t.Run("should keep the git storage under .git", func(t *testing.T) {
+		require.DirExists(t, filepath.Join(dir, gogit.GitDirName, "objects"))
+		require.NoDirExists(t, filepath.Join(dir, "objects"))
+		require.NoFileExists(t, filepath.Join(dir, "HEAD"))
+	})
+
+	t.Run("should check out the worktree at the root", func(t *testing.T) {
+		content, err := os.ReadFile(filepath.Join(dir, "docs", "api.md"))
+		require.NoError(t, err)
+		require.Equal(t, "api", string(content))
+	})
+
+	t.Run("should leave a regular git repository", func(t *testing.T) {
+		repo, err := gogit.PlainOpen(dir)
+		require.NoError(t, err)
+
+		_, err = repo.Tag("v1.0.0")
+		require.NoError(t, err)
+	})
+}
+
+func TestFetchFileNotFound(t *testing.T) {
+	t.Parallel()
+
+	fixture := testrepo.New(t)
+	fixture.Tag("v1.0.0", fixture.Commit(map[string]string{"docs/README.md": "readme"}, "initial commit"))
+	r := NewRepo(fixture.URL(), &Options{GitSkipAutoDetect: true})
+
+	for _, file := range []string{"missing.md", "docs/missing.md", "missing/README.md"} {
+		t.Run("should report a file missing from the tree: "+file, func(t *testing.T) {
+			var w bytes.Buffer
+			err := r.Fetch(t.Context(), &w, file, "v1.0.0")
+			require.ErrorIs(t, err, ErrFileNotFound)
+			require.Empty(t, w.String())
+		})
+	}
+
+	t.Run("should fetch an existing file", func(t *testing.T) {
+		var w bytes.Buffer
+		require.NoError(t, r.Fetch(t.Context(), &w, "docs/README.md", "v1.0.0"))
+		require.Equal(t, "readme", w.String())
+	})
+}
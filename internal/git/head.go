@@ -0,0 +1,126 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// DefaultBranch yields the short name of the default branch of the remote repository, i.e. the branch HEAD points to.
+//
+// Only the refs advertised by the remote are listed: no object is fetched.
+// On failure, the default branch is resolved against [Options].FallbackURL, if any.
+func (r *Repository) DefaultBranch(ctx context.Context) (string, error) {
+	branch, err := r.defaultBranchName(ctx)
+	if !r.shouldFallback(ctx, err) {
+		return branch, err
+	}
+
+	branch, fallbackErr := r.fallback(err).defaultBranchName(ctx)
+	if fallbackErr != nil {
+		return "", r.fallbackError(err, fallbackErr)
+	}
+
+	return branch, nil
+}
+
+func (r *Repository) defaultBranchName(ctx context.Context) (string, error) {
+	if r.repoURL == nil || r.repoURL.String() == "" {
+		return "", fmt.Errorf("cannot resolve the default branch of a repo with empty URL")
+	}
+
+	branch, err := r.defaultBranch(ctx, r.detachedRemote())
+	if err != nil {
+		return "", err
+	}
+
+	if !branch.Name().IsBranch() {
+		return "", fmt.Errorf("the HEAD of the remote is detached: no default branch")
+	}
+
+	return branch.Name().Short(), nil
+}
+
+// LatestCommit yields the hash of the commit at the tip of a branch of the remote repository.
+//
+// An empty branch, or "HEAD", designates the default branch.
+//
+// Only the refs advertised by the remote are listed: no object is fetched.
+// On failure, the commit is resolved against [Options].FallbackURL, if any.
+func (r *Repository) LatestCommit(ctx context.Context, branch string) (plumbing.Hash, error) {
+	hash, err := r.latestCommit(ctx, branch)
+	if !r.shouldFallback(ctx, err) {
+		return hash, err
+	}
+
+	hash, fallbackErr := r.fallback(err).latestCommit(ctx, branch)
+	if fallbackErr != nil {
+		return plumbing.ZeroHash, r.fallbackError(err, fallbackErr)
+	}
+
+	return hash, nil
+}
+
+func (r *Repository) latestCommit(ctx context.Context, branch string) (plumbing.Hash, error) {
+	if r.repoURL == nil || r.repoURL.String() == "" {
+		return plumbing.ZeroHash, fmt.Errorf("cannot resolve the latest commit of a repo with empty URL")
+	}
+
+	remote := r.detachedRemote()
+	if branch == "" || branch == HEAD {
+		head, err := r.defaultBranch(ctx, remote)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		return head.Hash(), nil
+	}
+
+	allRefs, err := remote.ListContext(ctx, &gogit.ListOptions{
+		Auth:     r.auth(),
+		CABundle: r.caBundle(),
+	})
+	if err != nil {
+		return plumbing.ZeroHash, checkProtocol(err)
+	}
+
+	name := plumbing.NewBranchReferenceName(strings.TrimPrefix(branch, "refs/heads/"))
+	for _, rf := range allRefs {
+		if rf.Name() == name && rf.Type() == plumbing.HashReference {
+			return rf.Hash(), nil
+		}
+	}
+
+	return plumbing.ZeroHash, fmt.Errorf("branch %q not found on the remote", branch)
+}
+
+// detachedRemote builds a remote which is not attached to any local repository, to list the refs it advertises.
+func (r *Repository) detachedRemote() *gogit.Remote {
+	return gogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: r.remoteName(),
+		URLs: []string{r.repoURL.String()},
+	})
+}
+
+// defaultBranch yields the reference to the default branch of the remote, i.e. the branch HEAD points to.
+func (r *Repository) defaultBranch(ctx context.Context, remote *gogit.Remote) (*plumbing.Reference, error) {
+	allRefs, err := remote.ListContext(ctx, &gogit.ListOptions{
+		Auth:     r.auth(),
+		CABundle: r.caBundle(),
+	})
+	if err != nil {
+		return nil, checkProtocol(err)
+	}
+
+	head, ok := resolveHead(allRefs)
+	if !ok {
+		return nil, fmt.Errorf("could not resolve the default branch of the remote")
+	}
+
+	return head, nil
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestDefaultBranch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should resolve the default branch", func(t *testing.T) {
+		t.Parallel()
+
+		repo := testrepo.New(t)
+		repo.Commit(map[string]string{"file.txt": "content"}, "initial")
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+		branch, err := r.DefaultBranch(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "master", branch)
+	})
+
+	t.Run("should follow HEAD to a branch other than master", func(t *testing.T) {
+		t.Parallel()
+
+		repo := testrepo.New(t)
+		hash := repo.Commit(map[string]string{"file.txt": "content"}, "initial")
+		main := plumbing.NewBranchReferenceName("main")
+		require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(main, hash)))
+		require.NoError(t, repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, main)))
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+		branch, err := r.DefaultBranch(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "main", branch)
+	})
+
+	t.Run("should NOT resolve the default branch of an empty repo", func(t *testing.T) {
+		t.Parallel()
+
+		repo := testrepo.New(t)
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+		_, err := r.DefaultBranch(t.Context())
+		require.Error(t, err)
+	})
+}
+
+func TestLatestCommit(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	tip := repo.Commit(map[string]string{"file.txt": "content"}, "initial")
+	r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+
+	for _, branch := range []string{"master", "refs/heads/master", HEAD, ""} {
+		hash, err := r.LatestCommit(t.Context(), branch)
+		require.NoError(t, err)
+		require.Equal(t, tip, hash)
+	}
+
+	_, err := r.LatestCommit(t.Context(), "no-such-branch")
+	require.Error(t, err)
+}
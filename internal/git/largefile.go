@@ -0,0 +1,109 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	// DefaultLargeFileThreshold is the size above which a file is streamed from the object store
+	// rather than checked out.
+	DefaultLargeFileThreshold int64 = 16 << 20
+
+	lfsPointerPrefix  = "version https://git-lfs.github.com/spec/v1"
+	lfsPointerMaxSize = 1024
+)
+
+// largeFileThreshold yields the size above which a file is streamed, or 0 if streaming is disabled.
+func (o *Options) largeFileThreshold() int64 {
+	switch {
+	case o == nil || o.LargeFileThreshold == 0:
+		return DefaultLargeFileThreshold
+	case o.LargeFileThreshold < 0:
+		return 0
+	default:
+		return o.LargeFileThreshold
+	}
+}
+
+// streamLargeFile checks the size of a file before it is checked out.
+//
+// If the file is larger than the configured threshold, the content is streamed directly from the git object store,
+// and the checkout is skipped: with the default in-memory worktree, a checkout would hold yet another copy of the file.
+//
+// It returns true if the file has been streamed. Files that can't be found are reported by [lookupFile].
+func (r *Repository) streamLargeFile(repo *gogit.Repository, hash plumbing.Hash, file string, w io.Writer) (bool, error) {
+	commit, err := resolveCommit(repo, hash)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := commit.File(file)
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if f.Size <= lfsPointerMaxSize {
+		if isLFS, err := isLFSPointer(f); err == nil && isLFS {
+			r.debug("warning: %q is a git LFS pointer: the actual content is stored outside of the git repository", file)
+		}
+	}
+
+	threshold := r.Options.largeFileThreshold()
+	if threshold == 0 || f.Size <= threshold {
+		return false, nil
+	}
+
+	r.debug("warning: %q is a large file (%d bytes): streaming content and skipping checkout", file, f.Size)
+
+	reader, err := f.Reader()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	if _, err = io.Copy(w, reader); err != nil {
+		return true, fmt.Errorf("could not stream %q: %w", file, err)
+	}
+
+	return true, nil
+}
+
+func isLFSPointer(f *object.File) (bool, error) {
+	reader, err := f.Reader()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	prefix, err := bufio.NewReader(reader).Peek(len(lfsPointerPrefix))
+	if err != nil {
+		return false, nil //nolint:nilerr // too short to be a LFS pointer
+	}
+
+	return bytes.Equal(prefix, []byte(lfsPointerPrefix)), nil
+}
+
+// resolveCommit yields the commit for a hash, which may point to a commit or to an annotated tag.
+func resolveCommit(repo *gogit.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	if tag, err := repo.TagObject(hash); err == nil {
+		return tag.Commit()
+	}
+
+	return repo.CommitObject(hash)
+}
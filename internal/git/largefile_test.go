@@ -0,0 +1,82 @@
+package git
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestLargeFile(t *testing.T) {
+	large := strings.Repeat("x", 64<<10)
+	lfsPointer := lfsPointerPrefix + "\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+
+	fixture := testrepo.New(t)
+	fixture.Tag("v1.0.0", fixture.Commit(map[string]string{
+		"data/large.bin": large,
+		"data/small.txt": "small",
+		"data/model.bin": lfsPointer,
+	}, "initial commit"))
+
+	fetchInDir := func(t *testing.T, threshold int64, file string) (string, string) {
+		t.Helper()
+
+		dir := t.TempDir()
+		r := NewRepo(fixture.URL(), &Options{
+			GitSkipAutoDetect:  true,
+			IsFSBacked:         true,
+			Dir:                dir,
+			LargeFileThreshold: threshold,
+		})
+
+		var w bytes.Buffer
+		require.NoError(t, r.Fetch(t.Context(), &w, file, "v1.0.0"))
+
+		return w.String(), dir
+	}
+
+	t.Run("should stream a file above the threshold, without checkout", func(t *testing.T) {
+		content, dir := fetchInDir(t, 1024, "data/large.bin")
+		require.Equal(t, large, content)
+		require.NoFileExists(t, filepath.Join(dir, "data", "large.bin"))
+	})
+
+	t.Run("should checkout a file below the threshold", func(t *testing.T) {
+		content, dir := fetchInDir(t, 1024, "data/small.txt")
+		require.Equal(t, "small", content)
+		require.FileExists(t, filepath.Join(dir, "data", "small.txt"))
+	})
+
+	t.Run("should checkout a large file when streaming is disabled", func(t *testing.T) {
+		content, dir := fetchInDir(t, -1, "data/large.bin")
+		require.Equal(t, large, content)
+		require.FileExists(t, filepath.Join(dir, "data", "large.bin"))
+	})
+
+	t.Run("should fetch a LFS pointer as is", func(t *testing.T) {
+		content, _ := fetchInDir(t, 0, "data/model.bin")
+		require.Equal(t, lfsPointer, content)
+	})
+
+	t.Run("should detect a LFS pointer", func(t *testing.T) {
+		head, err := fixture.Head()
+		require.NoError(t, err)
+		commit, err := fixture.CommitObject(head.Hash())
+		require.NoError(t, err)
+
+		for file, expected := range map[string]bool{
+			"data/model.bin": true,
+			"data/small.txt": false,
+		} {
+			f, err := commit.File(file)
+			require.NoError(t, err)
+
+			isLFS, err := isLFSPointer(f)
+			require.NoError(t, err)
+			require.Equal(t, expected, isLFS)
+		}
+	})
+}
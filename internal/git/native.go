@@ -2,40 +2,166 @@ package git
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
+	"net/url"
 	"os/exec"
 	"strings"
+	"sync"
+
+	"github.com/fredbi/go-vcsfetch/internal/redact"
 )
 
 // isGitInstalled indicates if the git command is installed.
-// TODO: check that version supports git archive
+//
+// Whether the git command and the remote actually support "git archive" is only known when running it:
+// see [Repository.tryNativeArchive].
 func isGitInstalled() bool {
 	_, err := exec.LookPath("git")
 
-	// TODO: check version / capabilities and cache result
 	return err == nil
 }
 
+// errArchiveSymlink tells that the file extracted by "git archive" is a symbolic link,
+// which target is resolved by the go-git implementation.
+var errArchiveSymlink = errors.New("the archived file is a symbolic link")
+
+// archiveUnsupported remembers the remotes which do not support "git archive", keyed by [archiveKey],
+// so that subsequent fetches go straight to the go-git implementation.
+var archiveUnsupported sync.Map
+
+func archiveKey(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+// archiveUnsupportedMessages are found in the error output of "git archive" whenever the git command
+// or the remote server does not support archives.
+var archiveUnsupportedMessages = []string{
+	"is not a git command",                // git built without archive
+	"operation not supported by protocol", // e.g. over http(s)
+	"service not enabled",                 // git daemon without upload-archive
+	"invalid command",                     // e.g. github over ssh
+	"upload-archive",                      // servers rejecting git-upload-archive
+}
+
+func isArchiveUnsupported(err error) bool {
+	msg := strings.ToLower(err.Error())
+
+	for _, unsupported := range archiveUnsupportedMessages {
+		if strings.Contains(msg, unsupported) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tryNativeArchive extracts a file with "git archive", using the installed git command.
+//
+// It returns false without error whenever "git archive" turns out not to be supported, before any content
+// is written, so that the caller falls back to the go-git implementation. Such remotes are remembered.
+// It also returns false when the file is a symbolic link, which is only resolved by the go-git implementation.
+func (r *Repository) tryNativeArchive(ctx context.Context, w io.Writer, file string, selectedRef *Ref) (bool, error) {
+	cw := &countingWriter{w: w}
+	err := r.nativeExtractGitArchive(ctx, cw, file, selectedRef)
+	if err == nil {
+		return true, nil
+	}
+
+	if cw.written > 0 || ctx.Err() != nil {
+		return false, err
+	}
+
+	if errors.Is(err, errArchiveSymlink) {
+		r.debug("falling back to go-git to resolve a symbolic link: %v", err)
+
+		return false, nil
+	}
+
+	if !isArchiveUnsupported(err) {
+		return false, err
+	}
+
+	r.debug("falling back to go-git: %v", err)
+	archiveUnsupported.Store(archiveKey(r.repoURL), struct{}{})
+
+	return false, nil
+}
+
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+
+	return n, err
+}
+
+func (r *Repository) gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	if r.command != nil {
+		return r.command(ctx, args...)
+	}
+
+	return exec.CommandContext(ctx, "git", args...)
+}
+
+// ArchiveFormat is an archive format supported by "git archive".
+type ArchiveFormat string
+
+const (
+	// ArchiveTar is an uncompressed tarball. This is the cheapest format to extract a single file.
+	ArchiveTar ArchiveFormat = "tar"
+	// ArchiveTgz is a gzip-compressed tarball.
+	ArchiveTgz ArchiveFormat = "tgz"
+	// ArchiveZip is a zip archive.
+	ArchiveZip ArchiveFormat = "zip"
+)
+
+func (r *Repository) archiveFormat() ArchiveFormat {
+	if r.Options == nil || r.ArchiveFormat == "" {
+		return ArchiveTar
+	}
+
+	return r.ArchiveFormat
+}
+
 func (r *Repository) nativeExtractGitArchive(ctx context.Context, w io.Writer, file string, selectedRef *Ref) (err error) {
 	// attention credential auth etc
 	/*
-		git archive --remote=$REPO_URL HEAD:path/to -- file.xz |
+		git archive --remote=$REPO_URL $REF path/to/file |
 		tar xO > /where/you/want/to/have.it
 	*/
-	hash := selectedRef.Hash()
-	args := []string{"archive",
-		"--format=tgz",
-		fmt.Sprintf("--remote=%v", r.repoURL),
-		fmt.Sprintf("%s:%s", hash, file),
+	if strings.HasPrefix(file, "-") {
+		// never let a path be interpreted as an option by git
+		return fmt.Errorf("invalid path in repository: %q", file)
+	}
+
+	treeish := selectedRef.Hash().String()
+	if name := selectedRef.Name(); name.IsBranch() || name.IsTag() {
+		// servers usually only accept ref names, unless configured with uploadArchive.allowUnreachable
+		treeish = name.String()
 	}
-	r.debug("running git %s", strings.Join(args, " "))
-	cmd := exec.CommandContext(ctx, "git", args...)
+	format := r.archiveFormat()
+
+	args := append(r.protocolArgs(), "archive",
+		"--format="+string(format),
+		fmt.Sprintf("--remote=%v", r.repoURL),
+		treeish,
+		"--",
+		file,
+	)
+	r.debug("running git %s", redact.String(strings.Join(args, " ")))
+	cmd := r.gitCommand(ctx, args...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -73,58 +199,121 @@ func (r *Repository) nativeExtractGitArchive(ctx context.Context, w io.Writer, f
 		case err == nil && errCommand != nil:
 			err = errCommand
 			if b.Len() > 0 {
-				err = errors.Join(errCommand, errors.New(b.String()))
+				err = errors.Join(errCommand, errors.New(redact.String(b.String())))
 			}
 		case err != nil && errCommand == nil:
 			if b.Len() > 0 {
-				err = errors.Join(err, errors.New(b.String()))
+				err = errors.Join(err, errors.New(redact.String(b.String())))
 			}
 		case err != nil && errCommand != nil:
 			err = errors.Join(err, errCommand)
 			if b.Len() > 0 {
-				err = errors.Join(err, errors.New(b.String()))
+				err = errors.Join(err, errors.New(redact.String(b.String())))
 			}
 		case err == nil && errCommand == nil:
 			fallthrough
 		default:
 		}
-		log.Printf("DEBUG: %s", b.String())
+		log.Printf("DEBUG: %s", redact.String(b.String()))
 	}()
 	r.debug("cmd running in the background")
 
-	// copy piped tgz to writer
-	gzipReader, err := gzip.NewReader(stdout)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = gzipReader.Close()
-	}()
-	r.debug("got gzip reader")
+	r.debug("reading %s archive", format)
+	err = extractFile(format, stdout, w)
+	r.debug("end of reading err=%v", err)
 
-	tarReader := tar.NewReader(gzipReader)
-	r.debug("got tar reader")
+	return err
+}
 
-	r.debug("starting command")
+// extractFile copies the content of the files held in an archive to the writer.
+func extractFile(format ArchiveFormat, archive io.Reader, w io.Writer) error {
+	switch format {
+	case ArchiveTar:
+		return extractTarFile(archive, w)
+	case ArchiveTgz:
+		gzipReader, err := gzip.NewReader(archive)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = gzipReader.Close()
+		}()
+
+		return extractTarFile(gzipReader, w)
+	case ArchiveZip:
+		return extractZipFile(archive, w)
+	default:
+		return fmt.Errorf("unsupported archive format: %q", format)
+	}
+}
+
+func extractTarFile(archive io.Reader, w io.Writer) error {
+	tarReader := tar.NewReader(archive)
 
-	r.debug("reading tar")
 	for {
-		_, err := tarReader.Next()
-		if err == io.EOF {
-			break
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
 		}
 
 		if err != nil {
-			r.debug("tar read error: %v", err)
-			break
+			return err
 		}
 
-		_, err = io.Copy(w, tarReader)
-		if err != nil {
-			break
+		if header.Typeflag == tar.TypeSymlink {
+			return fmt.Errorf("%q: %w", header.Name, errArchiveSymlink)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			// skip folders and the global header with the commit id
+			continue
+		}
+
+		if _, err = io.Copy(w, tarReader); err != nil {
+			return err
 		}
 	}
+}
+
+func extractZipFile(archive io.Reader, w io.Writer) error {
+	// the central directory of a zip archive comes last: the archive is buffered
+	buf, err := io.ReadAll(archive)
+	if err != nil {
+		return err
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range zipReader.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+
+		if entry.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%q: %w", entry.Name, errArchiveSymlink)
+		}
+
+		if err = copyZipEntry(entry, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyZipEntry(entry *zip.File, w io.Writer) error {
+	rdr, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rdr.Close()
+	}()
+
+	_, err = io.Copy(w, rdr)
 
-	r.debug("end of reading err=%v", err)
 	return err
 }
@@ -2,9 +2,14 @@ package git
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"net/url"
+	"os/exec"
 	"testing"
 
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-openapi/testify/v2/require"
 )
 
@@ -52,3 +57,160 @@ func TestNativeGitlabRepository(t *testing.T) {
 	)
 	t.Logf("%v", w.String())
 }
+
+func TestNativeArchiveFormats(t *testing.T) {
+	t.Parallel()
+
+	if !isGitInstalled() {
+		t.Skip("git is not installed")
+	}
+
+	repo := testrepo.New(t)
+	hash := repo.Commit(map[string]string{
+		"README.md":       "top-level",
+		"docs/nested.txt": "nested",
+	}, "initial")
+	repo.Tag("v1.0.0", hash)
+	ref := &Ref{
+		Reference: plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.0.0"), hash),
+		ShortName: "v1.0.0",
+		IsTag:     true,
+	}
+
+	for _, format := range []ArchiveFormat{"", ArchiveTar, ArchiveTgz, ArchiveZip} {
+		for file, expected := range map[string]string{
+			"README.md":       "top-level",
+			"docs/nested.txt": "nested",
+		} {
+			t.Run(fmt.Sprintf("should extract %s with format %q", file, format), func(t *testing.T) {
+				t.Parallel()
+
+				r := NewRepo(repo.URL(), &Options{ArchiveFormat: format})
+
+				var w bytes.Buffer
+				require.NoError(t, r.nativeExtractGitArchive(t.Context(), &w, file, ref))
+				require.Equal(t, expected, w.String())
+			})
+		}
+	}
+
+	t.Run("should NOT extract with an unsupported format", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{ArchiveFormat: "rar"})
+
+		var w bytes.Buffer
+		require.Error(t, r.nativeExtractGitArchive(t.Context(), &w, "README.md", ref))
+	})
+}
+
+func TestNativeArchiveFallback(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available to stub the git command")
+	}
+
+	repo := testrepo.New(t)
+	hash := repo.Commit(map[string]string{"README.md": "top-level"}, "initial")
+	ref := &Ref{
+		Reference: plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), hash),
+		ShortName: "master",
+	}
+
+	// stubGit simulates a git command failing with the given error output
+	stubGit := func(stderr string) func(context.Context, ...string) *exec.Cmd {
+		return func(ctx context.Context, _ ...string) *exec.Cmd {
+			return exec.CommandContext(ctx, "sh", "-c", fmt.Sprintf("echo %q >&2; exit 128", stderr))
+		}
+	}
+
+	t.Run("should fall back when the remote does not support git archive", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := url.Parse("ssh://git@archive-unsupported.example.com/owner/repo")
+		require.NoError(t, err)
+		r := NewRepo(u, &Options{})
+		r.command = stubGit("fatal: remote error: 'git-upload-archive': service not enabled")
+
+		var w bytes.Buffer
+		handled, err := r.tryNativeArchive(t.Context(), &w, "README.md", ref)
+		require.NoError(t, err)
+		require.False(t, handled)
+		require.Zero(t, w.Len())
+
+		// the remote is remembered: later fetches skip git archive
+		require.False(t, r.mayUseNativeArchive())
+	})
+
+	t.Run("should fall back when the git command does not support archive", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{})
+		r.command = stubGit("git: 'archive' is not a git command. See 'git --help'.")
+
+		handled, err := r.tryNativeArchive(t.Context(), new(bytes.Buffer), "README.md", ref)
+		require.NoError(t, err)
+		require.False(t, handled)
+	})
+
+	t.Run("should fail on other errors", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{})
+		r.command = stubGit("fatal: pathspec 'missing.md' did not match any files")
+
+		handled, err := r.tryNativeArchive(t.Context(), new(bytes.Buffer), "missing.md", ref)
+		require.Error(t, err)
+		require.False(t, handled)
+	})
+
+	t.Run("should separate the path from options", func(t *testing.T) {
+		t.Parallel()
+
+		var captured []string
+		r := NewRepo(repo.URL(), &Options{})
+		r.command = func(ctx context.Context, args ...string) *exec.Cmd {
+			captured = args
+
+			return stubGit("fatal: pathspec 'README.md' did not match any files")(ctx, args...)
+		}
+
+		_, _ = r.tryNativeArchive(t.Context(), new(bytes.Buffer), "README.md", ref)
+		require.GreaterOrEqual(t, len(captured), 2)
+		require.Equal(t, []string{"--", "README.md"}, captured[len(captured)-2:])
+	})
+
+	t.Run("should NOT pass a path looking like an option", func(t *testing.T) {
+		t.Parallel()
+
+		var called bool
+		r := NewRepo(repo.URL(), &Options{})
+		r.command = func(ctx context.Context, args ...string) *exec.Cmd {
+			called = true
+
+			return stubGit("")(ctx, args...)
+		}
+
+		handled, err := r.tryNativeArchive(t.Context(), new(bytes.Buffer), "--output=/tmp/pwned", ref)
+		require.Error(t, err)
+		require.False(t, handled)
+		require.False(t, called)
+	})
+
+	t.Run("should extract with a git command supporting archive", func(t *testing.T) {
+		t.Parallel()
+
+		if !isGitInstalled() {
+			t.Skip("git is not installed")
+		}
+
+		r := NewRepo(repo.URL(), &Options{})
+
+		var w bytes.Buffer
+		handled, err := r.tryNativeArchive(t.Context(), &w, "README.md", ref)
+		require.NoError(t, err)
+		require.True(t, handled)
+		require.Equal(t, "top-level", w.String())
+	})
+}
@@ -0,0 +1,90 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// NotesRef is the default ref under which git stores notes.
+const NotesRef = "refs/notes/commits"
+
+// fetchNote retrieves the note attached to a commit, under [NotesRef].
+//
+// Notes are never transferred by default: the notes ref is fetched explicitly.
+// It returns an empty string if the remote holds no notes, or if no note is attached to this commit.
+func (r *Repository) fetchNote(ctx context.Context, repo *gogit.Repository, remote *gogit.Remote, hash plumbing.Hash) (string, error) {
+	commit, err := resolveCommit(repo, hash)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve commit %v: %w", hash, err)
+	}
+
+	refSpec := config.RefSpec(fmt.Sprintf("+%[1]s:%[1]s", NotesRef))
+	err = remote.FetchContext(ctx, &gogit.FetchOptions{
+		RefSpecs: []config.RefSpec{refSpec},
+		Tags:     gogit.NoTags,
+		Force:    true,
+		Auth:     r.auth(),
+		CABundle: r.caBundle(),
+	})
+	var noMatch gogit.NoMatchingRefSpecError
+	switch {
+	case errors.As(err, &noMatch):
+		r.debug("no notes on the remote")
+
+		return "", nil
+	case err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate):
+		return "", fmt.Errorf("fetch remote notes ref %v: %w", NotesRef, err)
+	}
+
+	notesRef, err := repo.Reference(plumbing.ReferenceName(NotesRef), true)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve notes ref %v: %w", NotesRef, err)
+	}
+
+	notesCommit, err := repo.CommitObject(notesRef.Hash())
+	if err != nil {
+		return "", fmt.Errorf("could not resolve notes commit %v: %w", notesRef.Hash(), err)
+	}
+
+	tree, err := notesCommit.Tree()
+	if err != nil {
+		return "", fmt.Errorf("could not resolve notes tree: %w", err)
+	}
+
+	return noteFor(tree, commit.Hash)
+}
+
+// noteFor finds the note attached to a commit in a notes tree.
+//
+// Notes are blobs named after the hash of the annotated commit. The hash may be split into folders
+// (e.g. "ab/cdef...") when the notes tree is large.
+func noteFor(tree *object.Tree, hash plumbing.Hash) (string, error) {
+	target := hash.String()
+	var note *object.File
+
+	err := tree.Files().ForEach(func(f *object.File) error {
+		if strings.ReplaceAll(f.Name, "/", "") != target {
+			return nil
+		}
+		note = f
+
+		return io.EOF // stop iterating
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("could not walk notes tree: %w", err)
+	}
+
+	if note == nil {
+		return "", nil
+	}
+
+	return note.Contents()
+}
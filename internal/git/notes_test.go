@@ -0,0 +1,64 @@
+package git
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestNotes(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	first := repo.Commit(map[string]string{"file.txt": "first"}, "first")
+	repo.AddNote(first, "reviewed-by: someone\n")
+	repo.Tag("v1.0.0", first)
+	repo.AnnotatedTag("v1.0.1", first, "annotated")
+	second := repo.Commit(map[string]string{"file.txt": "second"}, "second")
+	repo.Tag("v2.0.0", second)
+
+	for ref, expected := range map[string]string{
+		"v1.0.0": "reviewed-by: someone\n",
+		"v1.0.1": "reviewed-by: someone\n", // the note is attached to the commit, not to the tag
+		"v2.0.0": "",
+	} {
+		t.Run("should fetch the note attached to "+ref, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true, Notes: true})
+
+			var w bytes.Buffer
+			result, err := r.FetchWithResult(t.Context(), &w, "file.txt", ref)
+			require.NoError(t, err)
+			require.Equal(t, expected, result.Note)
+		})
+	}
+
+	t.Run("should not fetch notes unless enabled", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+
+		var w bytes.Buffer
+		result, err := r.FetchWithResult(t.Context(), &w, "file.txt", "v1.0.0")
+		require.NoError(t, err)
+		require.Empty(t, result.Note)
+	})
+
+	t.Run("should fetch from a repo without notes", func(t *testing.T) {
+		t.Parallel()
+
+		plain := testrepo.New(t)
+		plain.Tag("v1.0.0", plain.Commit(map[string]string{"file.txt": "content"}, "initial"))
+
+		r := NewRepo(plain.URL(), &Options{GitSkipAutoDetect: true, Notes: true})
+
+		var w bytes.Buffer
+		result, err := r.FetchWithResult(t.Context(), &w, "file.txt", "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "content", w.String())
+		require.Empty(t, result.Note)
+	})
+}
@@ -0,0 +1,159 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/url"
+	"path/filepath"
+
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// cachedRefPrefix is the namespace of the refs that remember the commits held by an object cache.
+const cachedRefPrefix = "refs/vcsfetch/cached/"
+
+// objectCacheStorage layers the storage of a repository over a persistent object store, shared by all
+// fetches from the same remote, in the fashion of git alternates.
+//
+// Objects are read from and written to the object cache, whereas refs, config and index remain private to the repository.
+// Every commit fetched is remembered by the object cache, so that later fetches only retrieve missing objects.
+type objectCacheStorage struct {
+	storage.Storer
+
+	objects *filesystem.Storage
+}
+
+var (
+	_ storage.Storer         = &objectCacheStorage{}
+	_ storer.PackfileWriter  = &objectCacheStorage{}
+	_ storer.ReferenceStorer = &objectCacheStorage{}
+)
+
+// newObjectCacheStorage layers a storage over the object cache for a remote, located in a subfolder of dir.
+//
+// Objects and refs are written atomically to the object cache, so it may be shared by concurrent fetches.
+func newObjectCacheStorage(private storage.Storer, dir string, repoURL *url.URL) *objectCacheStorage {
+	fs := osfs.New(filepath.Join(dir, objectCacheKey(repoURL)), osfs.WithBoundOS())
+	objects := filesystem.NewStorage(fs, cache.NewObjectLRUDefault())
+
+	// creates the layout of the object cache, if not already there: errors are reported when the cache is written to
+	_ = objects.Init()
+
+	return &objectCacheStorage{
+		Storer:  private,
+		objects: objects,
+	}
+}
+
+// objectCacheKey derives the name of the object cache for a remote, which does not depend on credentials.
+func objectCacheKey(repoURL *url.URL) string {
+	sum := sha256.Sum256([]byte(redact.URL(repoURL)))
+
+	return hex.EncodeToString(sum[:16]) + ".git"
+}
+
+func (s *objectCacheStorage) NewEncodedObject() plumbing.EncodedObject {
+	return s.objects.NewEncodedObject()
+}
+
+func (s *objectCacheStorage) SetEncodedObject(o plumbing.EncodedObject) (plumbing.Hash, error) {
+	return s.objects.SetEncodedObject(o)
+}
+
+func (s *objectCacheStorage) EncodedObject(t plumbing.ObjectType, h plumbing.Hash) (plumbing.EncodedObject, error) {
+	return s.objects.EncodedObject(t, h)
+}
+
+func (s *objectCacheStorage) IterEncodedObjects(t plumbing.ObjectType) (storer.EncodedObjectIter, error) {
+	return s.objects.IterEncodedObjects(t)
+}
+
+func (s *objectCacheStorage) HasEncodedObject(h plumbing.Hash) error {
+	return s.objects.HasEncodedObject(h)
+}
+
+func (s *objectCacheStorage) EncodedObjectSize(h plumbing.Hash) (int64, error) {
+	return s.objects.EncodedObjectSize(h)
+}
+
+// PackfileWriter writes fetched packfiles directly to the object cache.
+func (s *objectCacheStorage) PackfileWriter() (io.WriteCloser, error) {
+	return s.objects.PackfileWriter()
+}
+
+// SetReference sets a private reference, and remembers in the object cache the commit it points to.
+func (s *objectCacheStorage) SetReference(ref *plumbing.Reference) error {
+	if err := s.Storer.SetReference(ref); err != nil {
+		return err
+	}
+
+	return s.remember(ref)
+}
+
+// CheckAndSetReference sets a private reference, and remembers in the object cache the commit it points to.
+func (s *objectCacheStorage) CheckAndSetReference(ref, old *plumbing.Reference) error {
+	if err := s.Storer.CheckAndSetReference(ref, old); err != nil {
+		return err
+	}
+
+	return s.remember(ref)
+}
+
+// IterReferences iterates over private references and the commits remembered by the object cache.
+//
+// The latter are advertised as "haves" to the remote when fetching.
+func (s *objectCacheStorage) IterReferences() (storer.ReferenceIter, error) {
+	private, err := s.Storer.IterReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := s.objects.IterReferences()
+	if err != nil {
+		private.Close()
+
+		return nil, err
+	}
+
+	var refs []*plumbing.Reference
+	collect := func(ref *plumbing.Reference) error {
+		refs = append(refs, ref)
+
+		return nil
+	}
+
+	if err = private.ForEach(collect); err != nil {
+		return nil, err
+	}
+
+	if err = cached.ForEach(collect); err != nil {
+		return nil, err
+	}
+
+	return storer.NewReferenceSliceIter(refs), nil
+}
+
+func (s *objectCacheStorage) remember(ref *plumbing.Reference) error {
+	if ref.Type() != plumbing.HashReference || ref.Hash().IsZero() {
+		return nil
+	}
+
+	if _, err := s.objects.EncodedObject(plumbing.CommitObject, ref.Hash()); err != nil {
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			// e.g. an annotated tag
+			return nil
+		}
+
+		return err
+	}
+
+	return s.objects.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(cachedRefPrefix+ref.Hash().String()), ref.Hash()))
+}
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestObjectCache(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	first := repo.Commit(map[string]string{"README.md": "v1", "LICENSE": "license"}, "initial")
+	repo.Tag("v1.0.0", first)
+	second := repo.Commit(map[string]string{"README.md": "v2"}, "update")
+	repo.Tag("v2.0.0", second)
+
+	cacheDir := t.TempDir()
+	options := &Options{GitSkipAutoDetect: true, ObjectCacheDir: cacheDir}
+
+	t.Run("should fetch through the object cache", func(t *testing.T) {
+		for _, tc := range []struct {
+			file, ref, want string
+		}{
+			{"README.md", "v1.0.0", "v1"},
+			{"LICENSE", "v1.0.0", "license"},
+			{"README.md", "v2.0.0", "v2"},
+			{"LICENSE", "v2.0.0", "license"},
+		} {
+			var w bytes.Buffer
+			require.NoError(t, NewRepo(repo.URL(), options).Fetch(t.Context(), &w, tc.file, tc.ref))
+			require.Equal(t, tc.want, w.String())
+		}
+	})
+
+	t.Run("should keep objects and fetched commits in the cache", func(t *testing.T) {
+		store := newObjectCacheStorage(nil, cacheDir, repo.URL())
+
+		require.NoError(t, store.objects.HasEncodedObject(first))
+		require.NoError(t, store.objects.HasEncodedObject(second))
+
+		ref, err := store.objects.Reference(plumbing.ReferenceName(cachedRefPrefix + second.String()))
+		require.NoError(t, err)
+		require.Equal(t, second, ref.Hash())
+	})
+
+	t.Run("should not share the cache across remotes", func(t *testing.T) {
+		other := testrepo.New(t)
+		other.Tag("v1.0.0", other.Commit(map[string]string{"README.md": "other"}, "initial"))
+
+		var w bytes.Buffer
+		require.NoError(t, NewRepo(other.URL(), options).Fetch(t.Context(), &w, "README.md", "v1.0.0"))
+		require.Equal(t, "other", w.String())
+		require.NotEqual(t, objectCacheKey(repo.URL()), objectCacheKey(other.URL()))
+	})
+}
+
+func BenchmarkObjectCache(b *testing.B) {
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		b.Skip("this benchmark requires the git binary to serve repositories over http")
+	}
+
+	// the server counts the bytes sent to the client
+	var served atomic.Int64
+	backend := &cgi.Handler{
+		Path: gitBin,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=/",
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		backend.ServeHTTP(&countingResponseWriter{ResponseWriter: w, n: &served}, req)
+	}))
+	b.Cleanup(server.Close)
+
+	repo := testrepo.New(b)
+	files := make(map[string]string, 100)
+	for i := range 100 {
+		files[fmt.Sprintf("docs/file%03d.md", i)] = fmt.Sprintf("content of file %d: %s", i, bytes.Repeat([]byte{'x'}, 1024))
+	}
+	repo.Tag("v1.0.0", repo.Commit(files, "initial"))
+
+	repoURL, err := url.Parse(server.URL + filepath.ToSlash(repo.Bare()))
+	require.NoError(b, err)
+
+	for _, withCache := range []bool{false, true} {
+		b.Run(fmt.Sprintf("with object cache %t", withCache), func(b *testing.B) {
+			options := &Options{GitSkipAutoDetect: true}
+			if withCache {
+				options.ObjectCacheDir = b.TempDir()
+			}
+
+			// the first fetch populates the cache
+			var w bytes.Buffer
+			require.NoError(b, NewRepo(repoURL, options).Fetch(b.Context(), &w, "docs/file000.md", "v1.0.0"))
+			served.Store(0)
+			b.ResetTimer()
+
+			for i := range b.N {
+				w.Reset()
+				require.NoError(b, NewRepo(repoURL, options).Fetch(b.Context(), &w, fmt.Sprintf("docs/file%03d.md", 1+i%99), "v1.0.0"))
+			}
+
+			b.ReportMetric(float64(served.Load())/float64(b.N), "served-bytes/op")
+		})
+	}
+}
+
+type countingResponseWriter struct {
+	http.ResponseWriter
+
+	n *atomic.Int64
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n.Add(int64(n))
+
+	return n, err
+}
@@ -1,20 +1,163 @@
 package git
 
+import (
+	"net/http"
+	"net/url"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
 // Options for a git [Repository]
 type Options struct {
 	IsFSBacked        bool
 	Dir               string
 	ResolveExactTag   bool
-	RecurseSubModules bool // TODO
+	RecurseSubModules bool
+
+	// CanonicalTags considers semver tags that only differ by a leading "v", e.g. "v0.2.0" and "0.2.0",
+	// as the same tag when resolving an exact tag. The "v"-prefixed tag is preferred.
+	CanonicalTags bool
+
 	AllowPreReleases  bool
 	Debug             bool
 	GitSkipAutoDetect bool
-	// Auth
-	// TLS
+	Auth              transport.AuthMethod
+
+	// RequireSignedCommit requires the fetched commit, or the annotated tag pointing to it,
+	// to be signed by a key from ArmoredKeyRing.
+	RequireSignedCommit bool
+	ArmoredKeyRing      string
+
+	// LargeFileThreshold is the size in bytes above which a fetched file is streamed rather than checked out.
+	//
+	// Defaults to [DefaultLargeFileThreshold]. A negative value disables streaming.
+	LargeFileThreshold int64
+
+	// DateRefs resolves refs expressed as a date, e.g. "{2024-01-01}", to the last commit on the default branch
+	// at or before this date.
+	DateRefs bool
+
+	// Manifest reports the files materialized by a fetch in [FetchResult].
+	Manifest bool
+
+	// ArchiveFormat is the format of the archive produced by "git archive" when fetching with the native git command.
+	//
+	// Defaults to [ArchiveTar], which is the cheapest format to extract a single file.
+	ArchiveFormat ArchiveFormat
+
+	// ProtocolVersion forces the version of the git wire protocol used by the native git command.
+	//
+	// The go-git implementation always speaks [ProtocolV0]. Defaults to [ProtocolAuto].
+	ProtocolVersion ProtocolVersion
+
+	// RemoteName is the name given to the remote in the local repository.
+	//
+	// Defaults to [DefaultRemoteName].
+	RemoteName string
+
+	// FallbackURL is the URL of a secondary remote, e.g. a mirror, tried when an operation fails against the
+	// remote of the repository.
+	FallbackURL *url.URL
+
+	// Notes retrieves the note attached to the fetched commit under [NotesRef], and reports it in [FetchResult].
+	Notes bool
+
+	// CABundle holds PEM-encoded certificates of additional certificate authorities to trust over https.
+	//
+	// The bundle is trusted together with the system roots.
+	CABundle []byte
+
+	// ObjectCacheDir is the folder of a persistent object cache, shared across fetches.
+	//
+	// Objects fetched from a remote are kept in the cache, so that later fetches from the same remote,
+	// e.g. at another ref, only retrieve missing objects.
+	//
+	// The object cache only applies to repositories held in memory, i.e. when [Options].IsFSBacked is disabled.
+	ObjectCacheDir string
+
+	// CloneCacheDir is the folder of a persistent cache of clones, as compressed archives of the worktree.
+	//
+	// Clones of a ref resolving to the same commit as a cached clone are restored from the cache,
+	// without fetching any object. A cached clone is replaced when its ref resolves to another commit.
+	// Clones with submodules, or with symbolic links, are not cached.
+	CloneCacheDir string
+
+	// DumbHTTP detects remotes served over the dumb HTTP protocol, i.e. as static files, and fetches from them.
+	//
+	// Detection costs an extra request to the remote. Only the tree of the fetched commit is retrieved, not its history.
+	DumbHTTP bool
+
+	// HTTPClient is the client used to retrieve static files from remotes served over the dumb HTTP protocol,
+	// e.g. with a proxy, timeouts or a custom transport.
+	//
+	// The client is used as is: it should already trust [Options].CABundle. Defaults to a client trusting CABundle.
+	HTTPClient *http.Client
+
+	// ReferenceName is the full name of the ref to fetch, e.g. "refs/heads/main" or "refs/tags/v1.2.3".
+	//
+	// When set, the requested ref is ignored: the remote ref with exactly this name is fetched, bypassing
+	// the resolution of branches, tags, semver constraints, dates and commit hashes.
+	ReferenceName plumbing.ReferenceName
+
+	// FetchTags fetches all the tags of the remote along with the fetched commit, so that the full set of tags
+	// is held by the local repository, e.g. in [Options].Dir.
+	//
+	// Tags are not needed to resolve a version: refs are resolved from those advertised by the remote,
+	// and only the objects of the selected ref are fetched.
+	FetchTags bool
+
+	// ExtraRefSpecs are additional refspecs fetched along with the commit, e.g. "+refs/notes/*:refs/notes/*",
+	// so that notes, keep-around refs or refs in custom namespaces are held by the local repository.
+	ExtraRefSpecs []config.RefSpec
+
 	// Proxy
 }
 
+func (o *Options) auth() transport.AuthMethod {
+	if o == nil {
+		return nil
+	}
+
+	return o.Auth
+}
+
+// refSpecs yields the refspecs to fetch a commit, with any extra refspecs.
+func (o *Options) refSpecs(refSpec config.RefSpec) []config.RefSpec {
+	if o == nil || len(o.ExtraRefSpecs) == 0 {
+		return []config.RefSpec{refSpec}
+	}
+
+	return append([]config.RefSpec{refSpec}, o.ExtraRefSpecs...)
+}
+
+// tagMode tells which tags are fetched along with a commit.
+func (o *Options) tagMode() gogit.TagMode {
+	if o == nil || !o.FetchTags {
+		return gogit.NoTags
+	}
+
+	return gogit.AllTags
+}
+
+func (o *Options) caBundle() []byte {
+	if o == nil {
+		return nil
+	}
+
+	return o.CABundle
+}
+
 // / CloneOptions to tune the behavior of git clone.
 type CloneOptions struct {
 	SparseFilter []string
+
+	// SubmoduleConcurrency is the maximum number of submodules cloned in parallel,
+	// when [Options].RecurseSubModules is enabled.
+	//
+	// Defaults to [DefaultSubmoduleConcurrency]. The limit applies to the submodules of each repository:
+	// nested submodules are cloned with the same limit.
+	SubmoduleConcurrency int
 }
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+)
+
+// ErrProtocolV2 is returned when the remote server only speaks version 2 of the git wire protocol.
+//
+// go-git speaks the original protocol. Servers that support protocol v2 still serve it to clients which
+// do not explicitly ask for v2, but some hosts enforce v2 and cannot be used.
+var ErrProtocolV2 = errors.New("the remote server requires git protocol version 2, which is not supported")
+
+// ProtocolVersion is a version of the git wire protocol.
+type ProtocolVersion string
+
+const (
+	// ProtocolAuto lets the client and the server negotiate the protocol version.
+	ProtocolAuto ProtocolVersion = ""
+	// ProtocolV0 is the original git wire protocol.
+	ProtocolV0 ProtocolVersion = "0"
+	// ProtocolV1 is the original git wire protocol, with an initial version announcement.
+	ProtocolV1 ProtocolVersion = "1"
+	// ProtocolV2 is the version 2 of the git wire protocol.
+	ProtocolV2 ProtocolVersion = "2"
+)
+
+// IsValid tells if the protocol version is known.
+func (v ProtocolVersion) IsValid() bool {
+	switch v {
+	case ProtocolAuto, ProtocolV0, ProtocolV1, ProtocolV2:
+		return true
+	default:
+		return false
+	}
+}
+
+// protocolArgs yields the arguments of the git command which force the version of the wire protocol, if any.
+func (r *Repository) protocolArgs() []string {
+	if r.Options == nil || r.ProtocolVersion == ProtocolAuto {
+		return nil
+	}
+
+	return []string{"-c", "protocol.version=" + string(r.ProtocolVersion)}
+}
+
+// protocolV2Advertisement is the first line of the reference advertisement of a protocol v2 server.
+var protocolV2Advertisement = []byte("version 2")
+
+// checkProtocol detects a failed negotiation with a server that answered with protocol v2.
+func checkProtocol(err error) error {
+	var unexpected *packp.ErrUnexpectedData
+	if errors.As(err, &unexpected) && bytes.HasPrefix(unexpected.Data, protocolV2Advertisement) {
+		return fmt.Errorf("%w: %w", ErrProtocolV2, err)
+	}
+
+	return err
+}
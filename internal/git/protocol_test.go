@@ -0,0 +1,156 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestProtocolV2Only(t *testing.T) {
+	t.Parallel()
+
+	// a server enforcing protocol v2 answers with a capability advertisement instead of refs
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		_, _ = io.WriteString(w, pktLine("# service=git-upload-pack\n")+"0000"+
+			pktLine("version 2\n")+pktLine("agent=git/2.45.0\n")+pktLine("ls-refs=unborn\n")+pktLine("fetch=shallow\n")+"0000",
+		)
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL + "/owner/repo")
+	require.NoError(t, err)
+	r := NewRepo(u, &Options{GitSkipAutoDetect: true})
+
+	t.Run("should report a protocol v2 server when probing capabilities", func(t *testing.T) {
+		_, err := r.remoteCapabilities(t.Context())
+		require.ErrorIs(t, err, ErrProtocolV2)
+	})
+
+	t.Run("should report a protocol v2 server when listing refs", func(t *testing.T) {
+		_, err := r.DefaultBranch(t.Context())
+		require.ErrorIs(t, err, ErrProtocolV2)
+	})
+
+	t.Run("should report a protocol v2 server when fetching", func(t *testing.T) {
+		_, err := r.FetchWithResult(t.Context(), io.Discard, "README.md", "master")
+		require.ErrorIs(t, err, ErrProtocolV2)
+	})
+}
+
+func TestProtocolV2Server(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration test against a git server configured for protocol v2")
+	}
+
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("this test requires the git binary to serve a repository over http")
+	}
+
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	hash := repo.Commit(map[string]string{"README.md": "# protocol v2"}, "initial commit")
+	repo.Tag("v1.0.0", hash)
+	bare := repo.Bare()
+
+	// the server prefers protocol v2, and falls back to the original protocol for clients that do not ask for v2
+	server := httptest.NewServer(&cgi.Handler{
+		Path: gitBin,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + filepath.Dir(bare),
+			"GIT_HTTP_EXPORT_ALL=1",
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=protocol.version",
+			"GIT_CONFIG_VALUE_0=2",
+		},
+	})
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL + "/" + filepath.Base(bare))
+	require.NoError(t, err)
+	r := NewRepo(u, &Options{GitSkipAutoDetect: true})
+
+	t.Run("should list refs", func(t *testing.T) {
+		branch, err := r.DefaultBranch(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "master", branch)
+	})
+
+	t.Run("should fetch a file at a tag", func(t *testing.T) {
+		var w bytes.Buffer
+		require.NoError(t, r.Fetch(t.Context(), &w, "README.md", "v1.0.0"))
+		require.Equal(t, "# protocol v2", w.String())
+	})
+
+	t.Run("should fetch a file at a single commit hash", func(t *testing.T) {
+		var w bytes.Buffer
+		require.NoError(t, r.Fetch(t.Context(), &w, "README.md", hash.String()))
+		require.Equal(t, "# protocol v2", w.String())
+	})
+}
+
+func TestProtocolVersion(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available to stub the git command")
+	}
+
+	t.Run("should validate protocol versions", func(t *testing.T) {
+		for _, version := range []ProtocolVersion{ProtocolAuto, ProtocolV0, ProtocolV1, ProtocolV2} {
+			require.True(t, version.IsValid())
+		}
+		require.False(t, ProtocolVersion("3").IsValid())
+		require.False(t, ProtocolVersion("v2").IsValid())
+	})
+
+	repo := testrepo.New(t)
+	hash := repo.Commit(map[string]string{"README.md": "readme"}, "initial commit")
+	ref := &Ref{
+		Reference: plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), hash),
+		ShortName: "master",
+	}
+
+	// archiveArgs captures the arguments passed to "git archive"
+	archiveArgs := func(t *testing.T, version ProtocolVersion) []string {
+		t.Helper()
+
+		var captured []string
+		r := NewRepo(repo.URL(), &Options{ProtocolVersion: version})
+		r.command = func(ctx context.Context, args ...string) *exec.Cmd {
+			captured = args
+
+			return exec.CommandContext(ctx, "sh", "-c", "exit 0")
+		}
+		require.NoError(t, r.nativeExtractGitArchive(t.Context(), io.Discard, "README.md", ref))
+
+		return captured
+	}
+
+	t.Run("should negotiate the protocol by default", func(t *testing.T) {
+		args := archiveArgs(t, ProtocolAuto)
+		require.Equal(t, "archive", args[0])
+		require.NotContains(t, args, "-c")
+	})
+
+	for _, version := range []ProtocolVersion{ProtocolV0, ProtocolV1, ProtocolV2} {
+		t.Run("should force protocol version "+string(version), func(t *testing.T) {
+			args := archiveArgs(t, version)
+			require.Equal(t, []string{"-c", "protocol.version=" + string(version), "archive"}, args[:3])
+		})
+	}
+}
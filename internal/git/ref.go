@@ -2,7 +2,7 @@ package git
 
 import (
 	"fmt"
-	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/blang/semver/v4"
@@ -11,12 +11,178 @@ import (
 
 const HEAD = "HEAD"
 
+// specialRefPrefixes are namespaces of refs that are neither branches nor tags, but may be explicitly requested,
+// e.g. "refs/pull/42/head" for a github pull request or "refs/merge-requests/42/head" for a gitlab merge request.
+var specialRefPrefixes = []string{
+	"refs/pull/",
+	"refs/merge-requests/",
+}
+
+func isSpecialRef(ref string) bool {
+	for _, prefix := range specialRefPrefixes {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pickRef selects the ref designated by a ref spec among all the refs of a remote.
+//
+// A semver constraint with wildcards, e.g. "v2.x", resolves like the incomplete version "v2",
+// unless a branch or a tag is named after it, e.g. a "v2.x" maintenance branch.
+//
+// An empty ref, or "HEAD", resolves to the tip of the default branch.
 func pickRef(allRefs []*plumbing.Reference, ref string, opts *Options) (*Ref, error) {
+	if opts != nil && opts.ReferenceName != "" {
+		return pickReferenceName(allRefs, opts.ReferenceName)
+	}
+
+	if ref == "" || ref == HEAD {
+		return pickHead(allRefs)
+	}
+
+	constraint := TrimVersionWildcard(ref)
+	if constraint == ref || opts != nil && opts.ResolveExactTag {
+		return pickRefSpec(allRefs, ref, opts)
+	}
+
+	if selectedRef, err := pickRefSpec(allRefs, ref, opts); err == nil {
+		return selectedRef, nil
+	}
+
+	selectedRef, err := pickRefSpec(allRefs, constraint, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve any remote reference for ref spec: %q", ref)
+	}
+
+	return selectedRef, nil
+}
+
+// TrimVersionWildcard removes the trailing wildcard segments of a semver constraint, e.g. "v2.x" and "v2.*" become "v2",
+// and "v2.3.x" becomes "v2.3".
+//
+// Other refs are returned unchanged.
+func TrimVersionWildcard(ref string) string {
+	trimmed := ref
+	for {
+		idx := strings.LastIndexByte(trimmed, '.')
+		if idx < 0 || !isVersionWildcard(trimmed[idx+1:]) {
+			break
+		}
+
+		trimmed = trimmed[:idx]
+	}
+
+	if trimmed == ref || !maybeSemver(trimmed) {
+		return ref
+	}
+
+	return trimmed
+}
+
+// pickReferenceName selects the ref with exactly the given full name, without any heuristic.
+func pickReferenceName(allRefs []*plumbing.Reference, name plumbing.ReferenceName) (*Ref, error) {
+	for _, rf := range allRefs {
+		if rf.Name() != name || rf.Type() != plumbing.HashReference {
+			continue
+		}
+
+		selectedRef := Ref{
+			Reference: rf,
+			ShortName: shortName(name),
+			IsTag:     name.IsTag(),
+		}
+		if selectedRef.IsTag && maybeSemver(selectedRef.ShortName) {
+			if version, err := semver.ParseTolerant(selectedRef.ShortName); err == nil {
+				selectedRef.IsSemver = true
+				selectedRef.Version = version
+			}
+		}
+
+		return &selectedRef, nil
+	}
+
+	return nil, fmt.Errorf("could not find the remote reference %q", name)
+}
+
+// pickHead selects the commit HEAD points to.
+//
+// Remotes may advertise HEAD as a symbolic ref to the default branch, as the hash of the commit it points to, or both.
+// When HEAD points to a branch, the branch is selected. Otherwise, HEAD is selected as a detached ref.
+func pickHead(allRefs []*plumbing.Reference) (*Ref, error) {
+	head, ok := resolveHead(allRefs)
+	if !ok {
+		return nil, fmt.Errorf("could not resolve any remote reference for ref spec: %q", HEAD)
+	}
+
+	return &Ref{
+		Reference: head,
+		ShortName: shortName(head.Name()),
+	}, nil
+}
+
+// resolveHead dereferences the HEAD advertised by a remote to a hash reference.
+//
+// A symbolic HEAD resolves to the branch it points to. It prevails over a HEAD advertised as a hash, which resolves
+// to itself, so the resolution does not depend on the order of the advertised refs.
+func resolveHead(allRefs []*plumbing.Reference) (*plumbing.Reference, bool) {
+	var (
+		symbolicHeads []*plumbing.Reference
+		hashHead      *plumbing.Reference
+	)
+	refs := make(map[plumbing.ReferenceName]*plumbing.Reference, len(allRefs))
+	for _, rf := range allRefs {
+		name := rf.Name()
+		if name != plumbing.HEAD {
+			if rf.Type() == plumbing.HashReference || rf.Type() == plumbing.SymbolicReference {
+				refs[name] = rf
+			}
+
+			continue
+		}
+
+		switch rf.Type() {
+		case plumbing.SymbolicReference:
+			symbolicHeads = append(symbolicHeads, rf)
+		case plumbing.HashReference:
+			if hashHead == nil && !rf.Hash().IsZero() {
+				hashHead = rf
+			}
+		}
+	}
+
+	const maxSymbolicDepth = 5 // guards against cycles
+	for _, head := range symbolicHeads {
+		target, ok := head, true
+		for depth := 0; ok && target.Type() == plumbing.SymbolicReference && depth < maxSymbolicDepth; depth++ {
+			target, ok = refs[target.Target()]
+		}
+
+		if ok && target.Type() == plumbing.HashReference && !target.Hash().IsZero() {
+			return target, true
+		}
+	}
+
+	if hashHead == nil {
+		return nil, false
+	}
+
+	return hashHead, true
+}
+
+func isVersionWildcard(segment string) bool {
+	return segment == "x" || segment == "X" || segment == "*"
+}
+
+func pickRefSpec(allRefs []*plumbing.Reference, ref string, opts *Options) (*Ref, error) {
 	desiredVersion, err := semver.ParseTolerant(ref) // incomplete version specification is completed, e.g. "v2" becomes "2.0.0"
 	isDesiredSemver := err == nil
 	var versionUpperBound semver.Version
 	allowPrereleases := opts != nil && opts.AllowPreReleases
 	resolveExactTag := opts != nil && opts.ResolveExactTag
+	canonicalTags := opts != nil && opts.CanonicalTags
 
 	if isDesiredSemver {
 		var allow bool
@@ -27,73 +193,78 @@ func pickRef(allRefs []*plumbing.Reference, ref string, opts *Options) (*Ref, er
 
 	ctx := &refFilterContext{
 		ref:               ref,
+		branchName:        plumbing.NewBranchReferenceName(ref),
+		tagName:           plumbing.NewTagReferenceName(ref),
 		resolveExactTag:   resolveExactTag,
 		isDesiredSemver:   isDesiredSemver,
 		allowPrereleases:  allowPrereleases,
 		versionUpperBound: versionUpperBound,
 	}
+	if canonicalTags && isDesiredSemver {
+		// "v0.2.0" and "0.2.0" designate the same tag: the semver ordering prefers the "v" prefix
+		ctx.canonicalTagName = plumbing.NewTagReferenceName(toggleVPrefix(ref))
+	}
 
-	refs := make([]Ref, 0, len(allRefs))
-	var selectedRef *Ref
+	// refs are not collected: the selected ref is updated as candidates are found
+	var (
+		selectedRef Ref
+		candidates  int
+	)
 	for _, rf := range allRefs {
 		localRef, ok := filterRef(ctx, rf)
 		if !ok {
 			continue
 		}
-		refs = append(refs, localRef)
 
-		if ref == "" || ref == HEAD || resolveExactTag {
-			selectedRef = &localRef
-			break
-		}
-	}
+		if resolveExactTag && ctx.canonicalTagName == "" {
+			// exact match
+			selectedRef = localRef
 
-	if len(refs) == 0 {
-		return nil, fmt.Errorf("could not resolve any remote reference for ref spec: %q", ref)
-	}
+			return &selectedRef, nil
+		}
 
-	if selectedRef != nil {
-		// exact tag match
-		return selectedRef, nil
+		candidates++
+		if candidates == 1 || isDesiredSemver && semverLess(selectedRef, localRef) {
+			// when selecting among semver candidates, the latest wins
+			selectedRef = localRef
+		}
 	}
 
-	if len(refs) == 1 {
-		selectedRef = &refs[0]
-		return selectedRef, nil
+	if candidates == 0 {
+		return nil, fmt.Errorf("could not resolve any remote reference for ref spec: %q", ref)
 	}
 
-	if !isDesiredSemver {
-		// this is possible because of semver tolerance, e.g. we may have both tags "v0.2.0" and "0.2.0"
+	if candidates > 1 && !isDesiredSemver {
+		// e.g. a branch and a tag with the same name
 		return nil, fmt.Errorf("ref spec resolved ambiguously to multiple refs: %q", ref)
 	}
 
-	// now for selecting among semver candidates
-	return latestSemver(refs)
+	return &selectedRef, nil
 }
 
-func latestSemver(refs []Ref) (*Ref, error) {
-	eligibleTags := make([]Ref, 0, len(refs))
-	for _, rf := range refs {
-		if !rf.IsSemver {
-			continue
-		}
-		eligibleTags = append(eligibleTags, rf)
+// semverLess orders semver tags by precedence.
+//
+// Tags with the same precedence (e.g. "v1.0.0", "1.0.0" and "v1.0.0+build") are ordered
+// deterministically: a tag with a "v" prefix wins, then the lexically lowest name.
+func semverLess(a, b Ref) bool {
+	if cmp := a.Version.Compare(b.Version); cmp != 0 {
+		return cmp < 0
 	}
 
-	if len(eligibleTags) == 0 {
-		return nil, fmt.Errorf("no tag did match the version constraint")
+	aHasPrefix := strings.HasPrefix(a.ShortName, "v")
+	bHasPrefix := strings.HasPrefix(b.ShortName, "v")
+	if aHasPrefix != bHasPrefix {
+		return bHasPrefix
 	}
 
-	sort.Slice(eligibleTags, func(i, j int) bool {
-		return eligibleTags[i].Version.GT(eligibleTags[j].Version) // latest comes first
-	})
-
-	tag := eligibleTags[0]
-	return &tag, nil
+	return a.ShortName > b.ShortName
 }
 
 type refFilterContext struct {
 	ref               string
+	branchName        plumbing.ReferenceName
+	tagName           plumbing.ReferenceName
+	canonicalTagName  plumbing.ReferenceName // the tag name with or without a "v" prefix, when tags are canonicalized
 	resolveExactTag   bool
 	isDesiredSemver   bool
 	allowPrereleases  bool
@@ -107,14 +278,21 @@ func filterRef(filter *refFilterContext, rf *plumbing.Reference) (localRef Ref,
 	}
 
 	name := rf.Name()
-	isTag := name.IsTag()
-	if !name.IsBranch() && !isTag && name != plumbing.HEAD {
-		// only consider branch, tag and HEAD refs
-		return localRef, false
+	if isSpecialRef(filter.ref) {
+		// a special ref is only retained when explicitly requested, with an exact match on its full name
+		if name.String() != filter.ref {
+			return localRef, false
+		}
+
+		return Ref{
+			Reference: rf,
+			ShortName: filter.ref,
+		}, true
 	}
 
-	if (filter.ref == "" || filter.ref == HEAD) && name != plumbing.HEAD {
-		// if the desired ref is empty of HEAD, only pick the HEAD branch
+	isTag := name.IsTag()
+	if !name.IsBranch() && !isTag {
+		// only consider branch and tag refs: HEAD is resolved by pickHead
 		return localRef, false
 	}
 
@@ -123,19 +301,27 @@ func filterRef(filter *refFilterContext, rf *plumbing.Reference) (localRef Ref,
 		return localRef, false
 	}
 
-	short := name.Short() // removes the "refs/xxxx/" prefix
-	if (filter.resolveExactTag || !filter.isDesiredSemver) && short != filter.ref {
+	if (filter.resolveExactTag || !filter.isDesiredSemver) && !filter.isExactMatch(name) {
 		// if tags must be resolved exactly only consider an exact match
 		return localRef, false
 	}
 
+	short := shortName(name)
+
 	localRef = Ref{
 		Reference: rf,
 		ShortName: short,
 		IsTag:     isTag,
 	}
 
-	if isTag {
+	if !filter.resolveExactTag && filter.isDesiredSemver {
+		if major, ok := leadingMajor(short); !ok || major > filter.versionUpperBound.Major {
+			// reject tags that are not semver or above the upper bound, without parsing
+			return localRef, false
+		}
+	}
+
+	if isTag && maybeSemver(short) {
 		version, isVersionErr := semver.ParseTolerant(short)
 		if isVersionErr == nil {
 			localRef.IsSemver = true
@@ -154,8 +340,9 @@ func filterRef(filter *refFilterContext, rf *plumbing.Reference) (localRef Ref,
 			return localRef, false
 		}
 
-		// if we allow to resolve compatible version tags, reject versions higher than the upper bound
-		if localRef.Version.GE(filter.versionUpperBound) {
+		// if we allow to resolve compatible version tags, reject versions higher than the upper bound.
+		// Pre-releases are compared by their release version, e.g. "v3.0.0-rc1" does not resolve "v2".
+		if releaseGE(localRef.Version, filter.versionUpperBound) {
 			return localRef, false
 		}
 	}
@@ -163,19 +350,77 @@ func filterRef(filter *refFilterContext, rf *plumbing.Reference) (localRef Ref,
 	return localRef, true
 }
 
+// isExactMatch tells if a ref name is the desired ref, without allocating a short name.
+func (filter *refFilterContext) isExactMatch(name plumbing.ReferenceName) bool {
+	return name == filter.branchName || name == filter.tagName || filter.canonicalTagName != "" && name == filter.canonicalTagName
+}
+
+// toggleVPrefix adds a "v" prefix to a tag, or removes it, e.g. "v0.2.0" becomes "0.2.0" and "0.2.0" becomes "v0.2.0".
+func toggleVPrefix(tag string) string {
+	if trimmed, ok := strings.CutPrefix(tag, "v"); ok {
+		return trimmed
+	}
+
+	return "v" + tag
+}
+
+// shortName removes the "refs/heads/" or "refs/tags/" prefix from a branch or tag name.
+//
+// This yields the same result as [plumbing.ReferenceName.Short] for branches, tags and HEAD,
+// which is much more expensive.
+func shortName(name plumbing.ReferenceName) string {
+	if short, ok := strings.CutPrefix(string(name), "refs/heads/"); ok {
+		return short
+	}
+
+	if short, ok := strings.CutPrefix(string(name), "refs/tags/"); ok {
+		return short
+	}
+
+	return string(name)
+}
+
+// leadingMajor reads the major version of a tag, e.g. 12 for "v12.3". It returns false if the tag
+// does not start with a number after an optional "v".
+func leadingMajor(tag string) (uint64, bool) {
+	tag = strings.TrimPrefix(tag, "v")
+	end := strings.IndexFunc(tag, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(tag)
+	}
+
+	major, err := strconv.ParseUint(tag[:end], 10, 64)
+
+	return major, err == nil
+}
+
+// maybeSemver tells if a tag may be parsed as a tolerant semver, i.e. starts with a digit after an optional "v".
+func maybeSemver(tag string) bool {
+	tag = strings.TrimPrefix(tag, "v")
+
+	return tag != "" && tag[0] >= '0' && tag[0] <= '9'
+}
+
+// releaseGE tells if the release version of v, i.e. without pre-release or build metadata,
+// is greater than or equal to the bound.
+func releaseGE(v, bound semver.Version) bool {
+	if v.Major != bound.Major {
+		return v.Major > bound.Major
+	}
+
+	if v.Minor != bound.Minor {
+		return v.Minor > bound.Minor
+	}
+
+	return v.Patch >= bound.Patch
+}
+
 func getVersionUpperBound(desiredVersion semver.Version, desiredSemverLevel int) (semver.Version, bool) {
-	var allowPrereleases bool
-	versionUpperBound := desiredVersion // shallow clone: upper bound (excluded) for select tagged version
+	allowPrereleases := len(desiredVersion.Pre) > 0 // the ref spec contains a pre-release: imply that we accept those
+	versionUpperBound := desiredVersion             // shallow clone: upper bound (excluded) for select tagged version
 	versionUpperBound.Pre = nil
 	versionUpperBound.Build = nil
 
-	finalized := desiredVersion
-	finalized.Pre = nil
-	finalized.Build = nil
-	if desiredVersion.GE(finalized) {
-		allowPrereleases = true // the ref spec containes a pre-release: imply that we accept those
-	}
-
 	switch desiredSemverLevel {
 	case 3: // fully specified
 		_ = versionUpperBound.IncrementPatch()
@@ -187,3 +432,26 @@ func getVersionUpperBound(desiredVersion semver.Version, desiredSemverLevel int)
 
 	return versionUpperBound, allowPrereleases
 }
+
+// splitSlashedRef extends a ref with the leading segments of a file path, whenever they make up the name of a branch or a tag,
+// e.g. ref "release" and file "2024.01/README.md" for the tag "release/2024.01".
+//
+// The longest matching name wins. At least one segment is left for the file.
+func splitSlashedRef(allRefs []*plumbing.Reference, ref, file string) (string, string, bool) {
+	names := make(map[string]struct{}, len(allRefs))
+	for _, rf := range allRefs {
+		if name := rf.Name(); name.IsBranch() || name.IsTag() {
+			names[name.Short()] = struct{}{}
+		}
+	}
+
+	parts := strings.Split(strings.Trim(file, "/"), "/")
+	for i := len(parts) - 1; i > 0; i-- {
+		candidate := ref + "/" + strings.Join(parts[:i], "/")
+		if _, found := names[candidate]; found {
+			return candidate, strings.Join(parts[i:], "/"), true
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,488 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestPickRefEqualPrecedence(t *testing.T) {
+	t.Parallel()
+
+	hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	tags := []string{"1.0.0", "v1.0.0+build", "v1.0.0", "v0.9.0", "1.0.0+build"}
+
+	// every rotation of the candidates must yield the same pick
+	for i := range tags {
+		rotated := append(append([]string{}, tags[i:]...), tags[:i]...)
+
+		t.Run(fmt.Sprintf("with tags %v", rotated), func(t *testing.T) {
+			t.Parallel()
+
+			refs := make([]*plumbing.Reference, 0, len(rotated))
+			for _, tag := range rotated {
+				refs = append(refs, plumbing.NewHashReference(plumbing.NewTagReferenceName(tag), hash))
+			}
+
+			selected, err := pickRef(refs, "v1", nil)
+			require.NoError(t, err)
+			require.Equal(t, "v1.0.0", selected.ShortName)
+		})
+	}
+}
+
+func TestPickRefSpecialRefs(t *testing.T) {
+	t.Parallel()
+
+	hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	refs := []*plumbing.Reference{
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), hash),
+		plumbing.NewHashReference(plumbing.ReferenceName("refs/pull/42/head"), hash),
+		plumbing.NewHashReference(plumbing.ReferenceName("refs/pull/42/merge"), hash),
+		plumbing.NewHashReference(plumbing.ReferenceName("refs/merge-requests/7/head"), hash),
+	}
+
+	for _, ref := range []string{"refs/pull/42/head", "refs/merge-requests/7/head"} {
+		t.Run("should pick explicitly requested "+ref, func(t *testing.T) {
+			selected, err := pickRef(refs, ref, nil)
+			require.NoError(t, err)
+			require.Equal(t, ref, selected.Name().String())
+			require.Equal(t, ref, selected.ShortName)
+		})
+	}
+
+	t.Run("should not resolve an unknown pull ref", func(t *testing.T) {
+		_, err := pickRef(refs, "refs/pull/43/head", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("should not resolve a pull ref by its short name", func(t *testing.T) {
+		_, err := pickRef(refs, "pull/42/head", nil)
+		require.Error(t, err)
+	})
+}
+
+func TestPickRef(t *testing.T) {
+	t.Parallel()
+
+	refs := syntheticRefs(3, 4, 5)
+	refs = append(refs,
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("v2.1.0-rc1"), plumbing.ZeroHash),
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("1.2.3"), plumbing.ZeroHash),
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("release"), plumbing.ZeroHash),
+	)
+
+	for _, tc := range []struct {
+		ref      string
+		exact    bool
+		expected string
+		isTag    bool
+		isSemver bool
+	}{
+		{ref: "feature-2", expected: "feature-2"},
+		{ref: "release", expected: "release", isTag: true},
+		{ref: "v1", expected: "v1.3.4", isTag: true, isSemver: true},
+		{ref: "v1.2", expected: "v1.2.4", isTag: true, isSemver: true},
+		{ref: "v1.2.3", expected: "v1.2.3", isTag: true, isSemver: true},
+		{ref: "2", expected: "v2.3.4", isTag: true, isSemver: true},
+		{ref: "v2.1", expected: "v2.1.4", isTag: true, isSemver: true},
+		{ref: "v2.1.0-rc1", exact: true, expected: "v2.1.0-rc1", isTag: true, isSemver: true},
+		{ref: "1.2.3", exact: true, expected: "1.2.3", isTag: true, isSemver: true},
+	} {
+		t.Run(fmt.Sprintf("should resolve %q (exact: %t)", tc.ref, tc.exact), func(t *testing.T) {
+			t.Parallel()
+
+			selected, err := pickRef(refs, tc.ref, &Options{ResolveExactTag: tc.exact})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, selected.ShortName)
+			require.Equal(t, tc.isTag, selected.IsTag)
+			require.Equal(t, tc.isSemver, selected.IsSemver)
+		})
+	}
+
+	for _, tc := range []struct {
+		ref   string
+		exact bool
+	}{
+		{ref: "unknown"},
+		{ref: "v1", exact: true},
+		{ref: "heads/feature-2"},
+		{ref: "refs/heads/feature-2"},
+	} {
+		t.Run(fmt.Sprintf("should NOT resolve %q (exact: %t)", tc.ref, tc.exact), func(t *testing.T) {
+			t.Parallel()
+
+			_, err := pickRef(refs, tc.ref, &Options{ResolveExactTag: tc.exact})
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("should NOT resolve a ref matching both a branch and a tag", func(t *testing.T) {
+		t.Parallel()
+
+		ambiguous := append(refs[:len(refs):len(refs)], plumbing.NewHashReference(plumbing.NewBranchReferenceName("release"), plumbing.ZeroHash))
+		_, err := pickRef(ambiguous, "release", nil)
+		require.ErrorContains(t, err, "ambiguously")
+	})
+}
+
+func TestPickRefWildcards(t *testing.T) {
+	t.Parallel()
+
+	refs := syntheticRefs(3, 4, 5)
+
+	for ref, expected := range map[string]string{
+		"v1.x":   "v1.3.4",
+		"v1.*":   "v1.3.4",
+		"1.X":    "v1.3.4",
+		"v1.x.x": "v1.3.4",
+		"v2.1.x": "v2.1.4",
+		"v2.1.*": "v2.1.4",
+	} {
+		t.Run(fmt.Sprintf("should resolve %q", ref), func(t *testing.T) {
+			t.Parallel()
+
+			selected, err := pickRef(refs, ref, nil)
+			require.NoError(t, err)
+			require.Equal(t, expected, selected.ShortName)
+			require.True(t, selected.IsSemver)
+		})
+	}
+
+	t.Run("should prefer a branch named after the wildcard", func(t *testing.T) {
+		t.Parallel()
+
+		withBranch := append(refs[:len(refs):len(refs)], plumbing.NewHashReference(plumbing.NewBranchReferenceName("v1.x"), plumbing.ZeroHash))
+		selected, err := pickRef(withBranch, "v1.x", nil)
+		require.NoError(t, err)
+		require.Equal(t, "v1.x", selected.ShortName)
+		require.False(t, selected.IsTag)
+	})
+
+	t.Run("should NOT resolve wildcards with exact tags", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := pickRef(refs, "v1.x", &Options{ResolveExactTag: true})
+		require.Error(t, err)
+	})
+
+	t.Run("should report the wildcard when no tag matches", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := pickRef(refs[:2], "v1.x", nil) // no tags
+		require.ErrorContains(t, err, `"v1.x"`)
+	})
+}
+
+func TestTrimVersionWildcard(t *testing.T) {
+	t.Parallel()
+
+	for ref, expected := range map[string]string{
+		"v2.x":      "v2",
+		"v2.*":      "v2",
+		"2.X":       "2",
+		"v2.x.x":    "v2",
+		"v2.3.x":    "v2.3",
+		"v2.3.4.x":  "v2.3.4",
+		"v2":        "v2",
+		"v2.x.1":    "v2.x.1",
+		"release.x": "release.x",
+		"v.x":       "v.x",
+		"x":         "x",
+		"":          "",
+	} {
+		require.Equal(t, expected, TrimVersionWildcard(ref), ref)
+	}
+}
+
+// syntheticRefs builds branches "feature-x" and tags "vM.m.p" for each major, minor and patch version.
+func syntheticRefs(majors, minors, patches int) []*plumbing.Reference {
+	hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	refs := []*plumbing.Reference{
+		plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("master")),
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), hash),
+	}
+
+	for major := range majors {
+		refs = append(refs, plumbing.NewHashReference(plumbing.NewBranchReferenceName(fmt.Sprintf("feature-%d", major)), hash))
+
+		for minor := range minors {
+			for patch := range patches {
+				tag := plumbing.NewTagReferenceName(fmt.Sprintf("v%d.%d.%d", major, minor, patch))
+				refs = append(refs, plumbing.NewHashReference(tag, hash))
+			}
+		}
+	}
+
+	return refs
+}
+
+func BenchmarkPickRef(b *testing.B) {
+	refs := syntheticRefs(10, 20, 25) // 5000 tags
+
+	for _, bc := range []struct {
+		name string
+		ref  string
+		opts *Options
+	}{
+		{name: "branch", ref: "feature-5"},
+		{name: "exact semver", ref: "v5.10.20"},
+		{name: "partial semver", ref: "v5"},
+		{name: "exact tag", ref: "v5.10.20", opts: &Options{ResolveExactTag: true}},
+		{name: "HEAD", ref: HEAD},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for b.Loop() {
+				if _, err := pickRef(refs, bc.ref, bc.opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestPickRefPreReleases(t *testing.T) {
+	t.Parallel()
+
+	hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	refs := make([]*plumbing.Reference, 0, 3)
+	for _, tag := range []string{"v2.0.0", "v2.1.0-rc1", "v3.0.0-rc1"} {
+		refs = append(refs, plumbing.NewHashReference(plumbing.NewTagReferenceName(tag), hash))
+	}
+
+	for _, tc := range []struct {
+		ref      string
+		allow    bool
+		expected string
+	}{
+		{ref: "v2", expected: "v2.0.0"},
+		{ref: "v2.0", expected: "v2.0.0"},
+		{ref: "v2", allow: true, expected: "v2.1.0-rc1"},
+		{ref: "v2.1.0-rc", expected: "v2.1.0-rc1"},
+		{ref: "v3", allow: true, expected: "v3.0.0-rc1"},
+	} {
+		t.Run(fmt.Sprintf("should resolve %q (allow pre-releases: %t)", tc.ref, tc.allow), func(t *testing.T) {
+			t.Parallel()
+
+			selected, err := pickRef(refs, tc.ref, &Options{AllowPreReleases: tc.allow})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, selected.ShortName)
+		})
+	}
+
+	for _, ref := range []string{"v2.1", "v3"} {
+		t.Run(fmt.Sprintf("should ignore pre-releases when resolving %q", ref), func(t *testing.T) {
+			t.Parallel()
+
+			_, err := pickRef(refs[1:], ref, nil) // only pre-releases
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestPickRefCanonicalTags(t *testing.T) {
+	t.Parallel()
+
+	hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	tags := []string{"0.2.0", "v0.2.0", "0.3.0", "v0.2.0+build"}
+
+	// every rotation of the candidates must yield the same pick
+	for i := range tags {
+		rotated := append(append([]string{}, tags[i:]...), tags[:i]...)
+		refs := make([]*plumbing.Reference, 0, len(rotated))
+		for _, tag := range rotated {
+			refs = append(refs, plumbing.NewHashReference(plumbing.NewTagReferenceName(tag), hash))
+		}
+
+		t.Run(fmt.Sprintf("with tags %v", rotated), func(t *testing.T) {
+			t.Parallel()
+
+			for ref, expected := range map[string]string{
+				"0.2.0":  "v0.2.0",
+				"v0.2.0": "v0.2.0",
+				"v0.3.0": "0.3.0",
+				"0.3.0":  "0.3.0",
+			} {
+				selected, err := pickRef(refs, ref, &Options{ResolveExactTag: true, CanonicalTags: true})
+				require.NoError(t, err)
+				require.Equal(t, expected, selected.ShortName, ref)
+			}
+
+			selected, err := pickRef(refs, "0.2.0", &Options{ResolveExactTag: true})
+			require.NoError(t, err)
+			require.Equal(t, "0.2.0", selected.ShortName)
+
+			_, err = pickRef(refs, "v0.3.0", &Options{ResolveExactTag: true})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSplitSlashedRef(t *testing.T) {
+	t.Parallel()
+
+	hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	refs := []*plumbing.Reference{
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), hash),
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("release/2024.01"), hash),
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("release/2024.01/rc1"), hash),
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature/x"), hash),
+		plumbing.NewHashReference(plumbing.ReferenceName("refs/pull/42/head"), hash),
+	}
+
+	for _, tc := range []struct {
+		ref, file         string
+		wantRef, wantFile string
+		wantOK            bool
+	}{
+		{"release", "2024.01/README.md", "release/2024.01", "README.md", true},
+		{"release", "/2024.01/docs/README.md", "release/2024.01", "docs/README.md", true},
+		{"release", "2024.01/rc1/README.md", "release/2024.01/rc1", "README.md", true},
+		{"feature", "x/README.md", "feature/x", "README.md", true},
+		{"release", "2024.01", "", "", false}, // no file left
+		{"release", "2025.01/README.md", "", "", false},
+		{"refs", "pull/42/head/README.md", "", "", false},
+		{"master", "README.md", "", "", false},
+	} {
+		t.Run(fmt.Sprintf("with ref %q and file %q", tc.ref, tc.file), func(t *testing.T) {
+			ref, file, ok := splitSlashedRef(refs, tc.ref, tc.file)
+			require.Equal(t, tc.wantOK, ok)
+			require.Equal(t, tc.wantRef, ref)
+			require.Equal(t, tc.wantFile, file)
+		})
+	}
+}
+
+func TestSlashedRef(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	first := repo.Commit(map[string]string{"docs/README.md": "january"}, "initial")
+	repo.Tag("release/2024.01", first)
+	second := repo.Commit(map[string]string{"docs/README.md": "february"}, "update")
+	repo.AnnotatedTag("release/2024.02", second, "february release")
+
+	r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true, Manifest: true})
+
+	for _, tc := range []struct {
+		ref, file, want string
+	}{
+		{"release/2024.01", "docs/README.md", "january"},
+		{"release", "2024.01/docs/README.md", "january"},
+		{"release", "2024.02/docs/README.md", "february"},
+	} {
+		t.Run(fmt.Sprintf("should fetch %q at ref %q", tc.file, tc.ref), func(t *testing.T) {
+			var w bytes.Buffer
+			result, err := r.FetchWithResult(t.Context(), &w, tc.file, tc.ref)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, w.String())
+			require.Equal(t, []string{"docs/README.md"}, result.Files)
+		})
+	}
+
+	t.Run("should not resolve an unknown slashed ref", func(t *testing.T) {
+		var w bytes.Buffer
+		require.Error(t, r.Fetch(t.Context(), &w, "2023.12/docs/README.md", "release"))
+	})
+}
+
+func TestPickRefHead(t *testing.T) {
+	t.Parallel()
+
+	tip := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	other := plumbing.NewHash("89abcdef0123456789abcdef0123456789abcdef")
+	main := plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), tip)
+	master := plumbing.NewHashReference(plumbing.NewBranchReferenceName("master"), other)
+	symbolicHead := plumbing.NewSymbolicReference(plumbing.HEAD, main.Name())
+	hashHead := plumbing.NewHashReference(plumbing.HEAD, tip)
+
+	for _, tc := range []struct {
+		name     string
+		refs     []*plumbing.Reference
+		expected string
+	}{
+		{name: "symbolic HEAD", refs: []*plumbing.Reference{master, symbolicHead, main}, expected: "main"},
+		{name: "hash HEAD", refs: []*plumbing.Reference{master, hashHead, main}, expected: HEAD},
+		{name: "symbolic then hash HEAD", refs: []*plumbing.Reference{symbolicHead, hashHead, master, main}, expected: "main"},
+		{name: "hash then symbolic HEAD", refs: []*plumbing.Reference{hashHead, master, main, symbolicHead}, expected: "main"},
+		{
+			name:     "symbolic HEAD to an unknown branch, with a hash HEAD",
+			refs:     []*plumbing.Reference{plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/gone"), hashHead, master},
+			expected: HEAD,
+		},
+	} {
+		for _, ref := range []string{"", HEAD} {
+			t.Run(fmt.Sprintf("should resolve %q with a %s", ref, tc.name), func(t *testing.T) {
+				t.Parallel()
+
+				selected, err := pickRef(tc.refs, ref, nil)
+				require.NoError(t, err)
+				require.Equal(t, tip, selected.Hash())
+				require.Equal(t, tc.expected, selected.ShortName)
+				require.False(t, selected.IsTag)
+			})
+		}
+	}
+
+	t.Run("should NOT resolve HEAD when not advertised", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := pickRef([]*plumbing.Reference{master, main}, "", nil)
+		require.Error(t, err)
+	})
+
+	t.Run("should NOT resolve a branch named after HEAD for another ref", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := pickRef([]*plumbing.Reference{hashHead, main}, "feature", nil)
+		require.Error(t, err)
+	})
+}
+
+func TestPickRefReferenceName(t *testing.T) {
+	t.Parallel()
+
+	branchHash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	tagHash := plumbing.NewHash("89abcdef0123456789abcdef0123456789abcdef")
+	refs := append(syntheticRefs(2, 2, 2),
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("release"), branchHash),
+		plumbing.NewHashReference(plumbing.NewTagReferenceName("release"), tagHash),
+	)
+
+	for _, tc := range []struct {
+		name     plumbing.ReferenceName
+		hash     plumbing.Hash
+		isTag    bool
+		isSemver bool
+	}{
+		{name: "refs/heads/release", hash: branchHash},
+		{name: "refs/tags/release", hash: tagHash, isTag: true},
+		{name: "refs/tags/v1.0.1", hash: branchHash, isTag: true, isSemver: true},
+	} {
+		t.Run(fmt.Sprintf("should pick exactly %q", tc.name), func(t *testing.T) {
+			t.Parallel()
+
+			// the requested ref is ignored
+			selected, err := pickRef(refs, "v1", &Options{ReferenceName: tc.name})
+			require.NoError(t, err)
+			require.Equal(t, tc.name, selected.Name())
+			require.Equal(t, tc.hash, selected.Hash())
+			require.Equal(t, tc.name.Short(), selected.ShortName)
+			require.Equal(t, tc.isTag, selected.IsTag)
+			require.Equal(t, tc.isSemver, selected.IsSemver)
+		})
+	}
+
+	t.Run("should NOT resolve an unknown reference name", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := pickRef(refs, "release", &Options{ReferenceName: "refs/heads/v1.0.1"})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+)
+
+// DefaultRemoteName is the name of the remote when [Options].RemoteName is not set.
+const DefaultRemoteName = "origin"
+
+func (r *Repository) remoteName() string {
+	if r.Options == nil || r.RemoteName == "" {
+		return DefaultRemoteName
+	}
+
+	return r.RemoteName
+}
+
+// shouldFallback tells if an operation that failed against the remote should be tried again against
+// the fallback remote.
+//
+// Missing files are not retried: the remote has been reached and the answer would be the same on a mirror.
+func (r *Repository) shouldFallback(ctx context.Context, err error) bool {
+	return err != nil &&
+		r.Options != nil && r.FallbackURL != nil &&
+		!errors.Is(err, fs.ErrNotExist) &&
+		ctx.Err() == nil
+}
+
+// fallback yields a [Repository] for the fallback remote, with the same options.
+func (r *Repository) fallback(err error) *Repository {
+	r.debug("falling back to remote %v: %v", redact.URL(r.FallbackURL), err)
+
+	opts := *r.Options
+	opts.FallbackURL = nil
+
+	return NewRepo(r.FallbackURL, &opts)
+}
+
+func (r *Repository) fallbackError(err, fallbackErr error) error {
+	return errors.Join(err, fmt.Errorf("fallback remote %v: %w", redact.URL(r.FallbackURL), fallbackErr))
+}
+
+// writeCounter tells if some content has been written, in which case a failed fetch may not be retried.
+type writeCounter struct {
+	io.Writer
+
+	n int64
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+
+	return n, err
+}
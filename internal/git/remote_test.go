@@ -0,0 +1,96 @@
+package git
+
+import (
+	"bytes"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestRemoteName(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	hash := repo.Commit(map[string]string{"README.md": "content"}, "initial")
+	repo.Tag("v1.0.0", hash)
+
+	r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true, RemoteName: "upstream"})
+
+	t.Run("should name the remote", func(t *testing.T) {
+		_, remote, err := r.init()
+		require.NoError(t, err)
+		require.Equal(t, "upstream", remote.Config().Name)
+	})
+
+	t.Run("should fetch from the named remote", func(t *testing.T) {
+		for _, ref := range []string{"v1.0.0", hash.String()[:7]} {
+			var w bytes.Buffer
+			require.NoError(t, r.Fetch(t.Context(), &w, "README.md", ref))
+			require.Equal(t, "content", w.String())
+		}
+	})
+
+	t.Run("should default to origin", func(t *testing.T) {
+		_, remote, err := NewRepo(repo.URL(), nil).init()
+		require.NoError(t, err)
+		require.Equal(t, DefaultRemoteName, remote.Config().Name)
+	})
+}
+
+func TestFallbackRemote(t *testing.T) {
+	t.Parallel()
+
+	mirror := testrepo.New(t)
+	mirror.Tag("v1.0.0", mirror.Commit(map[string]string{"README.md": "mirrored"}, "initial"))
+
+	unreachable := &url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(t.TempDir(), "missing.git"))}
+	options := func() *Options {
+		return &Options{GitSkipAutoDetect: true, FallbackURL: mirror.URL()}
+	}
+
+	t.Run("should fetch from the fallback remote", func(t *testing.T) {
+		var w bytes.Buffer
+		require.NoError(t, NewRepo(unreachable, options()).Fetch(t.Context(), &w, "README.md", "v1.0.0"))
+		require.Equal(t, "mirrored", w.String())
+	})
+
+	t.Run("should clone from the fallback remote", func(t *testing.T) {
+		fsys, result, err := NewRepo(unreachable, options()).Clone(t.Context(), "v1.0.0", nil)
+		require.NoError(t, err)
+		require.Equal(t, "v1.0.0", result.ShortName)
+
+		content, err := fs.ReadFile(fsys, "README.md")
+		require.NoError(t, err)
+		require.Equal(t, "mirrored", string(content))
+	})
+
+	t.Run("should resolve the default branch from the fallback remote", func(t *testing.T) {
+		branch, err := NewRepo(unreachable, options()).DefaultBranch(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "master", branch)
+	})
+
+	t.Run("should not fall back when the file does not exist", func(t *testing.T) {
+		primary := testrepo.New(t)
+		primary.Tag("v1.0.0", primary.Commit(map[string]string{"other.txt": "content"}, "initial"))
+
+		var w bytes.Buffer
+		err := NewRepo(primary.URL(), options()).Fetch(t.Context(), &w, "README.md", "v1.0.0")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		require.Empty(t, w.String())
+	})
+
+	t.Run("should report errors from both remotes", func(t *testing.T) {
+		opts := options()
+		opts.FallbackURL = &url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(t.TempDir(), "also-missing.git"))}
+
+		var w bytes.Buffer
+		err := NewRepo(unreachable, opts).Fetch(t.Context(), &w, "README.md", "v1.0.0")
+		require.Error(t, err)
+		require.ErrorContains(t, err, "fallback remote")
+	})
+}
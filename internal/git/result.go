@@ -0,0 +1,111 @@
+package git
+
+import (
+	"path"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Source tells how the content of a fetch was retrieved.
+type Source string
+
+const (
+	// SourceGit is a fetch from the remote, using the go-git implementation.
+	SourceGit Source = "git"
+	// SourceGitArchive is a fetch using "git archive" with the installed git command.
+	SourceGitArchive Source = "git-archive"
+	// SourceDumbHTTP is a fetch from a remote served over the dumb HTTP protocol.
+	SourceDumbHTTP Source = "dumb-http"
+	// SourceCache is a fetch using the go-git implementation, which found all the objects needed locally,
+	// e.g. in the object cache.
+	SourceCache Source = "cache"
+)
+
+// FetchResult reports about a completed fetch.
+type FetchResult struct {
+	// Files lists the paths of the files materialized by the fetch, relative to the root of the repository.
+	//
+	// Only populated when [Options].Manifest is enabled.
+	Files []string
+
+	// Note is the git note attached to the fetched commit under [NotesRef].
+	//
+	// Only populated when [Options].Notes is enabled.
+	Note string
+
+	// Source tells how the content was retrieved.
+	Source Source
+}
+
+// CloneResult reports about a completed clone.
+type CloneResult struct {
+	// ShortName is the short name of the resolved ref, e.g. "v1.2.3" or "master".
+	ShortName string
+
+	// Hash is the hash of the checked out commit.
+	Hash string
+
+	// Objects is the number of git objects held by the clone.
+	Objects int
+
+	// Sparse indicates that a sparse filter was applied to the checkout.
+	Sparse bool
+
+	// Cached indicates that the clone was restored from [Options].CloneCacheDir.
+	Cached bool
+}
+
+// countObjects counts all git objects held in the storage of a repository.
+func countObjects(repo *gogit.Repository) (int, error) {
+	iter, err := repo.Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = iter.ForEach(func(plumbing.EncodedObject) error {
+		count++
+
+		return nil
+	})
+
+	return count, err
+}
+
+func (r *Repository) manifestEnabled() bool {
+	return r.Options != nil && r.Manifest
+}
+
+// addFile records a file fetched without a checkout.
+func (r *Repository) addFile(result *FetchResult, file string) {
+	if !r.manifestEnabled() {
+		return
+	}
+
+	result.Files = append(result.Files, path.Clean(file))
+}
+
+// addCheckedOutFiles records the files materialized by a (possibly sparse) checkout.
+//
+// Files excluded by a sparse checkout are flagged as such in the index and are not reported.
+func (r *Repository) addCheckedOutFiles(repo *gogit.Repository, result *FetchResult) error {
+	if !r.manifestEnabled() {
+		return nil
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range idx.Entries {
+		if entry.SkipWorktree {
+			continue
+		}
+
+		result.Files = append(result.Files, entry.Name)
+	}
+
+	return nil
+}
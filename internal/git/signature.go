@@ -0,0 +1,88 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ErrUnsupportedSignature is returned when an object is signed with a format other than OpenPGP,
+// such as an SSH or X.509 signature.
+var ErrUnsupportedSignature = errors.New("unsupported signature format: only OpenPGP signatures are verified")
+
+// unsupportedSignatureFormats maps the header of signature formats that cannot be verified to their name.
+var unsupportedSignatureFormats = map[string]string{
+	"-----BEGIN SSH SIGNATURE-----":  "SSH",
+	"-----BEGIN SIGNED MESSAGE-----": "X.509",
+	"-----BEGIN CERTIFICATE-----":    "X.509",
+}
+
+// unsupportedSignature returns the name of the format of a signature that cannot be verified,
+// or an empty string for an OpenPGP signature.
+func unsupportedSignature(signature string) string {
+	for header, format := range unsupportedSignatureFormats {
+		if strings.HasPrefix(signature, header) {
+			return format
+		}
+	}
+
+	return ""
+}
+
+// verifySignature checks that the fetched object carries a valid signature from the configured keyring.
+//
+// The hash may point to an annotated tag or to a commit. A valid signature on an annotated tag is sufficient.
+// Otherwise, the signature of the commit is verified.
+func (r *Repository) verifySignature(repo *gogit.Repository, hash plumbing.Hash) error {
+	if r.ArmoredKeyRing == "" {
+		return errors.New("a keyring is required to verify signatures")
+	}
+
+	commitHash := hash
+	tag, err := repo.TagObject(hash)
+	if err == nil {
+		if tag.PGPSignature != "" {
+			if format := unsupportedSignature(tag.PGPSignature); format != "" {
+				return fmt.Errorf("%s signature for tag %q: %w", format, tag.Name, ErrUnsupportedSignature)
+			}
+
+			if _, err = tag.Verify(r.ArmoredKeyRing); err != nil {
+				return fmt.Errorf("invalid signature for tag %q: %w", tag.Name, err)
+			}
+
+			r.debug("tag %q has a valid signature", tag.Name)
+
+			return nil
+		}
+
+		commit, err := tag.Commit()
+		if err != nil {
+			return fmt.Errorf("tag %q does not point to a commit: %w", tag.Name, err)
+		}
+		commitHash = commit.Hash
+	}
+
+	commit, err := repo.CommitObject(commitHash)
+	if err != nil {
+		return err
+	}
+
+	if commit.PGPSignature == "" {
+		return fmt.Errorf("commit %v is not signed", commit.Hash)
+	}
+
+	if format := unsupportedSignature(commit.PGPSignature); format != "" {
+		return fmt.Errorf("%s signature for commit %v: %w", format, commit.Hash, ErrUnsupportedSignature)
+	}
+
+	if _, err = commit.Verify(r.ArmoredKeyRing); err != nil {
+		return fmt.Errorf("invalid signature for commit %v: %w", commit.Hash, err)
+	}
+
+	r.debug("commit %v has a valid signature", commit.Hash)
+
+	return nil
+}
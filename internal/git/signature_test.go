@@ -0,0 +1,108 @@
+package git
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestSignature(t *testing.T) {
+	key := newSignKey(t, "signer")
+	keyRing := armoredPublicKey(t, key)
+
+	signed := testrepo.New(t)
+	signed.SignWith(key)
+	signed.AnnotatedTag("v1.0.0", signed.Commit(map[string]string{"README.md": "signed tag"}, "initial"), "release v1.0.0")
+	signed.Tag("v2.0.0", signed.Commit(map[string]string{"README.md": "signed commit"}, "signed commit"))
+
+	unsigned := testrepo.New(t)
+	unsigned.Tag("v1.0.0", unsigned.Commit(map[string]string{"README.md": "unsigned"}, "initial"))
+
+	opts := &Options{GitSkipAutoDetect: true, RequireSignedCommit: true, ArmoredKeyRing: keyRing}
+
+	t.Run("should fetch with a signed tag", func(t *testing.T) {
+		var w bytes.Buffer
+		require.NoError(t,
+			NewRepo(signed.URL(), opts).Fetch(t.Context(), &w, "README.md", "v1.0.0"),
+		)
+		require.Equal(t, "signed tag", w.String())
+	})
+
+	t.Run("should fetch with a signed commit", func(t *testing.T) {
+		var w bytes.Buffer
+		require.NoError(t,
+			NewRepo(signed.URL(), opts).Fetch(t.Context(), &w, "README.md", "v2.0.0"),
+		)
+		require.Equal(t, "signed commit", w.String())
+	})
+
+	t.Run("should fail with an unsigned commit", func(t *testing.T) {
+		var w bytes.Buffer
+		err := NewRepo(unsigned.URL(), opts).Fetch(t.Context(), &w, "README.md", "v1.0.0")
+		require.ErrorContains(t, err, "is not signed")
+		require.Empty(t, w.String())
+	})
+
+	t.Run("should fail with a signature from an unknown key", func(t *testing.T) {
+		otherOpts := *opts
+		otherOpts.ArmoredKeyRing = armoredPublicKey(t, newSignKey(t, "other"))
+
+		var w bytes.Buffer
+		err := NewRepo(signed.URL(), &otherOpts).Fetch(t.Context(), &w, "README.md", "v2.0.0")
+		require.ErrorContains(t, err, "invalid signature")
+		require.Empty(t, w.String())
+	})
+
+	t.Run("should fail with an unsupported SSH signature", func(t *testing.T) {
+		sshSigned := testrepo.New(t)
+		sshSigned.SignWithSigner(sshSigner{})
+		sshSigned.Tag("v1.0.0", sshSigned.Commit(map[string]string{"README.md": "ssh signed"}, "initial"))
+
+		var w bytes.Buffer
+		err := NewRepo(sshSigned.URL(), opts).Fetch(t.Context(), &w, "README.md", "v1.0.0")
+		require.ErrorIs(t, err, ErrUnsupportedSignature)
+		require.ErrorContains(t, err, "SSH signature")
+		require.Empty(t, w.String())
+	})
+
+	t.Run("should not verify signatures by default", func(t *testing.T) {
+		var w bytes.Buffer
+		require.NoError(t,
+			NewRepo(unsigned.URL(), &Options{GitSkipAutoDetect: true}).Fetch(t.Context(), &w, "README.md", "v1.0.0"),
+		)
+		require.Equal(t, "unsigned", w.String())
+	})
+}
+
+// sshSigner produces a signature which looks like an SSH signature.
+type sshSigner struct{}
+
+func (sshSigner) Sign(io.Reader) ([]byte, error) {
+	return []byte("-----BEGIN SSH SIGNATURE-----\nU1NIU0lHAAAAAQ==\n-----END SSH SIGNATURE-----\n"), nil
+}
+
+func newSignKey(t *testing.T, name string) *openpgp.Entity {
+	t.Helper()
+
+	key, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	require.NoError(t, err)
+
+	return key
+}
+
+func armoredPublicKey(t *testing.T, key *openpgp.Entity) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, key.Serialize(w))
+	require.NoError(t, w.Close())
+
+	return b.String()
+}
@@ -0,0 +1,425 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const gitModulesFile = ".gitmodules"
+
+// ErrLocalSubmodule is returned when a remote repository declares a submodule located on the local file system.
+var ErrLocalSubmodule = errors.New("remote repository declares a local submodule")
+
+// DefaultSubmoduleConcurrency is the default maximum number of submodules cloned in parallel.
+const DefaultSubmoduleConcurrency = 4
+
+// submodule locates a file inside a git submodule.
+type submodule struct {
+	repoURL *url.URL
+	hash    plumbing.Hash // the commit pinned by the parent repository
+	path    string        // the path of the submodule, relative to the parent repository
+	file    string        // the path of the file, relative to the submodule
+}
+
+// submoduleFor finds the submodule that contains a file, in the tree of a given commit.
+//
+// It returns false if the file does not live in a submodule.
+func (r *Repository) submoduleFor(repo *gogit.Repository, hash plumbing.Hash, file string) (*submodule, bool, error) {
+	commit, err := resolveCommit(repo, hash)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, false, err
+	}
+
+	parts := strings.Split(strings.Trim(file, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		prefix := path.Join(parts[:i]...)
+		entry, err := tree.FindEntry(prefix)
+		if err != nil {
+			// not found: let the checkout report about the missing file
+			return nil, false, nil //nolint:nilerr
+		}
+
+		switch entry.Mode {
+		case filemode.Dir:
+			continue
+		case filemode.Submodule:
+			repoURL, err := r.submoduleURL(tree, prefix)
+			if err != nil {
+				return nil, false, err
+			}
+
+			return &submodule{
+				repoURL: repoURL,
+				hash:    entry.Hash,
+				path:    prefix,
+				file:    path.Join(parts[i:]...),
+			}, true, nil
+		default:
+			return nil, false, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// submoduleURL resolves the remote URL of the submodule declared at a given path in .gitmodules.
+func (r *Repository) submoduleURL(tree *object.Tree, submodulePath string) (*url.URL, error) {
+	file, err := tree.File(gitModulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("submodule %q is not declared in %s: %w", submodulePath, gitModulesFile, err)
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	modules := config.NewModules()
+	if err = modules.Unmarshal([]byte(content)); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", gitModulesFile, err)
+	}
+
+	for _, module := range modules.Submodules {
+		if path.Clean(module.Path) != submodulePath {
+			continue
+		}
+
+		repoURL, err := ResolveSubmoduleURL(r.repoURL, module.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		if isLocalURL(repoURL) && !isLocalURL(r.repoURL) {
+			// a remote repository must not direct us to read repositories on the local file system
+			return nil, fmt.Errorf("submodule %q refers to a local repository %v, but the parent repository is remote: %w",
+				submodulePath, redact.URL(repoURL), ErrLocalSubmodule,
+			)
+		}
+
+		return repoURL, nil
+	}
+
+	return nil, fmt.Errorf("submodule %q is not declared in %s", submodulePath, gitModulesFile)
+}
+
+// ResolveSubmoduleURL resolves the URL declared for a submodule.
+//
+// Relative URLs (e.g. "../other.git") are resolved against the URL of the parent repository.
+// scp-like URLs (e.g. "git@github.com:owner/repo.git") are converted into ssh URLs.
+func ResolveSubmoduleURL(parent *url.URL, raw string) (*url.URL, error) {
+	if strings.HasPrefix(raw, "./") || strings.HasPrefix(raw, "../") {
+		u := *parent
+		u.Path = path.Join(u.Path, raw)
+
+		return &u, nil
+	}
+
+	if !strings.Contains(raw, "://") {
+		if userHost, repoPath, isSCP := strings.Cut(raw, ":"); isSCP && !strings.Contains(userHost, "/") {
+			raw = "ssh://" + userHost + "/" + strings.TrimPrefix(repoPath, "/")
+		}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid submodule URL %q: %w", redact.String(raw), redact.Error(err))
+	}
+
+	return u, nil
+}
+
+// isLocalURL tells if a repository URL designates a repository on the local file system.
+func isLocalURL(u *url.URL) bool {
+	return u.Scheme == "file" || u.Scheme == ""
+}
+
+// options yields the options to retrieve a submodule from the options of its parent repository.
+//
+// Credentials and additional certificate authorities are only passed on to submodules
+// served from the same origin as the parent repository, i.e. with the same scheme, host and port:
+// credentials are never sent in cleartext to a "http://" submodule of a "https://" parent.
+func (s *submodule) options(parent *url.URL, opts *Options) *Options {
+	subOptions := *opts
+	if !sameOrigin(s.repoURL, parent) {
+		subOptions.Auth = nil
+		subOptions.CABundle = nil
+	}
+
+	return &subOptions
+}
+
+// sameOrigin tells if two URLs have the same scheme, host and port.
+func sameOrigin(u, v *url.URL) bool {
+	return strings.EqualFold(u.Scheme, v.Scheme) &&
+		strings.EqualFold(u.Hostname(), v.Hostname()) &&
+		originPort(u) == originPort(v)
+}
+
+// originPort yields the port of an URL, or the default port of its scheme.
+func originPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	case "ssh":
+		return "22"
+	case "git":
+		return "9418"
+	default:
+		return ""
+	}
+}
+
+// fetch a file from the submodule, at the commit pinned by the parent repository.
+//
+// Files fetched from the submodule are reported relative to the parent repository.
+func (s *submodule) fetch(ctx context.Context, w io.Writer, parent *url.URL, opts *Options, result *FetchResult) error {
+	sub := NewRepo(s.repoURL, s.options(parent, opts))
+
+	repo, remote, err := sub.init()
+	if err != nil {
+		return fmt.Errorf("could not initialize git submodule: %w", err)
+	}
+
+	pinned := &Ref{
+		Reference: plumbing.NewHashReference(plumbing.HEAD, s.hash),
+	}
+
+	subResult := &FetchResult{}
+	if err = sub.fetchAndSparseCheckout(ctx, repo, remote, w, s.file, pinned, subResult); err != nil {
+		return err
+	}
+
+	for _, file := range subResult.Files {
+		result.Files = append(result.Files, path.Join(s.path, file))
+	}
+
+	return nil
+}
+
+// submodules lists the submodules declared in the tree of a commit.
+func (r *Repository) submodules(tree *object.Tree) ([]*submodule, error) {
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	var submodules []*submodule
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Mode != filemode.Submodule {
+			continue
+		}
+
+		repoURL, err := r.submoduleURL(tree, name)
+		if err != nil {
+			return nil, err
+		}
+
+		submodules = append(submodules, &submodule{
+			repoURL: repoURL,
+			hash:    entry.Hash,
+			path:    name,
+		})
+	}
+
+	return submodules, nil
+}
+
+// cloneSubmodules clones the submodules of a commit into the worktree of the parent repository.
+//
+// Submodules are fetched in parallel, with at most concurrency fetches in flight.
+// Errors from all submodules are reported.
+func (r *Repository) cloneSubmodules(ctx context.Context, commit *object.Commit, worktree billy.Filesystem, filter []string, concurrency int) error {
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	submodules, err := r.submodules(tree)
+	if err != nil {
+		return fmt.Errorf("could not list submodules: %w", err)
+	}
+
+	submodules = slices.DeleteFunc(submodules, func(s *submodule) bool {
+		return !inSparseFilter(s.path, filter)
+	})
+
+	if concurrency < 1 {
+		concurrency = DefaultSubmoduleConcurrency
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mx        sync.Mutex // serializes writes to the worktree
+		semaphore = make(chan struct{}, concurrency)
+		errs      = make([]error, len(submodules))
+	)
+
+	for i, sub := range submodules {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("could not clone submodule %q: %w", sub.path, ctx.Err())
+
+				return
+			}
+
+			r.debug("cloning submodule %q from %v at %v", sub.path, redact.URL(sub.repoURL), sub.hash)
+			fsys, err := sub.clone(ctx, r.repoURL, r.Options, concurrency)
+			<-semaphore
+
+			if err != nil {
+				errs[i] = fmt.Errorf("could not clone submodule %q: %w", sub.path, err)
+
+				return
+			}
+
+			mx.Lock()
+			defer mx.Unlock()
+
+			if err := copyTree(fsys, worktree, sub.path); err != nil {
+				errs[i] = fmt.Errorf("could not copy submodule %q: %w", sub.path, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// clone the submodule at the commit pinned by the parent repository.
+//
+// The submodule is cloned in memory, with the options of the parent repository.
+func (s *submodule) clone(ctx context.Context, parent *url.URL, opts *Options, concurrency int) (billy.Filesystem, error) {
+	subOptions := s.options(parent, opts)
+	subOptions.IsFSBacked = false
+	sub := NewRepo(s.repoURL, subOptions)
+
+	repo, remote, err := sub.init()
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize git submodule: %w", err)
+	}
+
+	pinned := &Ref{
+		Reference: plumbing.NewHashReference(plumbing.HEAD, s.hash),
+	}
+
+	local, _, err := sub.cloneRef(ctx, repo, remote, pinned, &CloneOptions{SubmoduleConcurrency: concurrency})
+	if err != nil {
+		return nil, err
+	}
+
+	return local.Filesystem, nil
+}
+
+// inSparseFilter tells if a submodule is retained by a sparse filter.
+//
+// A submodule is retained if it is located under a filtered path, or contains a filtered path.
+func inSparseFilter(submodulePath string, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+
+	for _, filtered := range filter {
+		filtered = strings.Trim(filtered, "/")
+		if filtered == submodulePath ||
+			strings.HasPrefix(submodulePath, filtered+"/") ||
+			strings.HasPrefix(filtered, submodulePath+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyTree copies the worktree of a submodule to a folder of the worktree of its parent.
+func copyTree(src, dst billy.Filesystem, prefix string) error {
+	return util.Walk(src, "/", func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == gogit.GitDirName {
+				return filepath.SkipDir
+			}
+
+			return dst.MkdirAll(path.Join(prefix, pth), 0o755)
+		}
+
+		target := path.Join(prefix, pth)
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := src.Readlink(pth)
+			if err != nil {
+				return err
+			}
+
+			return dst.Symlink(link, target)
+		}
+
+		return copyFile(src, dst, pth, target, info.Mode())
+	})
+}
+
+func copyFile(src, dst billy.Filesystem, from, to string, mode os.FileMode) error {
+	in, err := src.Open(from)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	out, err := dst.OpenFile(to, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
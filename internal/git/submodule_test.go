@@ -0,0 +1,332 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestSubmodule(t *testing.T) {
+	sub := testrepo.New(t)
+	pinned := sub.Commit(map[string]string{"docs/README.md": "pinned content"}, "pinned")
+	sub.Commit(map[string]string{"docs/README.md": "later content"}, "later")
+
+	parent := testrepo.New(t)
+	parent.Commit(map[string]string{"main.go": "package main"}, "initial")
+	parent.Tag("v1.0.0", parent.AddSubmodule("vendor/sub", sub, pinned))
+
+	t.Run("should fetch a file inside a submodule at the pinned commit", func(t *testing.T) {
+		r := NewRepo(parent.URL(), &Options{GitSkipAutoDetect: true, RecurseSubModules: true})
+
+		var w bytes.Buffer
+		require.NoError(t,
+			r.Fetch(t.Context(), &w, "vendor/sub/docs/README.md", "v1.0.0"),
+		)
+		require.Equal(t, "pinned content", w.String())
+	})
+
+	t.Run("should still fetch a file outside submodules", func(t *testing.T) {
+		r := NewRepo(parent.URL(), &Options{GitSkipAutoDetect: true, RecurseSubModules: true})
+
+		var w bytes.Buffer
+		require.NoError(t,
+			r.Fetch(t.Context(), &w, "main.go", "v1.0.0"),
+		)
+		require.Equal(t, "package main", w.String())
+	})
+
+	t.Run("should not recurse into submodules by default", func(t *testing.T) {
+		r := NewRepo(parent.URL(), &Options{GitSkipAutoDetect: true})
+
+		var w bytes.Buffer
+		require.Error(t,
+			r.Fetch(t.Context(), &w, "vendor/sub/docs/README.md", "v1.0.0"),
+		)
+	})
+}
+
+func TestResolveSubmoduleURL(t *testing.T) {
+	parent, err := url.Parse("https://github.com/owner/repo.git")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		raw      string
+		expected string
+	}{
+		{"../other.git", "https://github.com/owner/other.git"},
+		{"./nested.git", "https://github.com/owner/repo.git/nested.git"},
+		{"git@github.com:owner/other.git", "ssh://git@github.com/owner/other.git"},
+		{"https://gitlab.com/group/other.git", "https://gitlab.com/group/other.git"},
+	} {
+		u, err := ResolveSubmoduleURL(parent, tc.raw)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, u.String())
+	}
+}
+
+func TestSubmoduleURLLocal(t *testing.T) {
+	t.Parallel()
+
+	sub := testrepo.New(t)
+	pinned := sub.Commit(map[string]string{"README.md": "local content"}, "pinned")
+
+	parent := testrepo.New(t)
+	parent.Commit(map[string]string{"main.go": "package main"}, "initial")
+	hash := parent.AddSubmodule("vendor/local", sub, pinned)
+
+	repo, err := gogit.PlainOpen(parent.Dir)
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(hash)
+	require.NoError(t, err)
+	tree, err := commit.Tree()
+	require.NoError(t, err)
+
+	t.Run("should resolve a local submodule of a local repository", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRepo(parent.URL(), &Options{})
+		u, err := r.submoduleURL(tree, "vendor/local")
+		require.NoError(t, err)
+		require.Equal(t, "file", u.Scheme)
+	})
+
+	t.Run("should NOT resolve a local submodule of a remote repository", func(t *testing.T) {
+		t.Parallel()
+
+		remote, err := url.Parse("https://git.example.com/owner/repo.git")
+		require.NoError(t, err)
+
+		r := NewRepo(remote, &Options{})
+		_, err = r.submoduleURL(tree, "vendor/local")
+		require.ErrorIs(t, err, ErrLocalSubmodule)
+	})
+}
+
+func TestSubmoduleOptions(t *testing.T) {
+	t.Parallel()
+
+	parent, err := url.Parse("https://git.example.com/owner/repo.git")
+	require.NoError(t, err)
+	opts := &Options{
+		Auth:     &githttp.BasicAuth{Username: "user", Password: "secret"},
+		CABundle: []byte("bundle"),
+	}
+
+	t.Run("should pass on credentials to a submodule on the same host", func(t *testing.T) {
+		t.Parallel()
+
+		subURL, err := url.Parse("https://GIT.example.com/owner/other.git")
+		require.NoError(t, err)
+
+		s := &submodule{repoURL: subURL}
+		subOptions := s.options(parent, opts)
+		require.Equal(t, opts.Auth, subOptions.Auth)
+		require.Equal(t, opts.CABundle, subOptions.CABundle)
+	})
+
+	t.Run("should NOT pass on credentials to a submodule on another host", func(t *testing.T) {
+		t.Parallel()
+
+		subURL, err := url.Parse("https://attacker.example.com/owner/other.git")
+		require.NoError(t, err)
+
+		s := &submodule{repoURL: subURL}
+		subOptions := s.options(parent, opts)
+		require.Nil(t, subOptions.Auth)
+		require.Nil(t, subOptions.CABundle)
+
+		// the options of the parent are left unchanged
+		require.NotNil(t, opts.Auth)
+		require.NotNil(t, opts.CABundle)
+	})
+
+	t.Run("should pass on credentials to a submodule on the default port", func(t *testing.T) {
+		t.Parallel()
+
+		subURL, err := url.Parse("https://git.example.com:443/owner/other.git")
+		require.NoError(t, err)
+
+		s := &submodule{repoURL: subURL}
+		require.Equal(t, opts.Auth, s.options(parent, opts).Auth)
+	})
+
+	t.Run("should NOT pass on credentials to a submodule served over another scheme or port", func(t *testing.T) {
+		t.Parallel()
+
+		for _, location := range []string{
+			"http://git.example.com/owner/other.git",
+			"https://git.example.com:8443/owner/other.git",
+		} {
+			subURL, err := url.Parse(location)
+			require.NoError(t, err)
+
+			s := &submodule{repoURL: subURL}
+			subOptions := s.options(parent, opts)
+			require.Nil(t, subOptions.Auth, location)
+			require.Nil(t, subOptions.CABundle, location)
+		}
+	})
+}
+
+func TestCloneSubmodules(t *testing.T) {
+	nested := testrepo.New(t)
+	nestedHash := nested.Commit(map[string]string{"nested.txt": "nested content"}, "nested")
+
+	first := testrepo.New(t)
+	first.Commit(map[string]string{"first.txt": "first content"}, "first")
+	firstHash := first.AddSubmodule("deps/nested", nested, nestedHash)
+
+	second := testrepo.New(t)
+	secondHash := second.Commit(map[string]string{"docs/second.txt": "second content"}, "second")
+
+	parent := testrepo.New(t)
+	parent.Commit(map[string]string{"main.go": "package main"}, "initial")
+	parent.AddSubmodule("vendor/first", first, firstHash)
+	parent.Tag("v1.0.0", parent.AddSubmodule("vendor/second", second, secondHash))
+
+	t.Run("should clone submodules at the pinned commit, recursively", func(t *testing.T) {
+		r := NewRepo(parent.URL(), &Options{GitSkipAutoDetect: true, RecurseSubModules: true})
+
+		fsys, _, err := r.Clone(t.Context(), "v1.0.0", &CloneOptions{SubmoduleConcurrency: 1})
+		require.NoError(t, err)
+
+		for file, expected := range map[string]string{
+			"main.go":                             "package main",
+			"vendor/first/first.txt":              "first content",
+			"vendor/first/deps/nested/nested.txt": "nested content",
+			"vendor/second/docs/second.txt":       "second content",
+		} {
+			content, err := fs.ReadFile(fsys, file)
+			require.NoError(t, err)
+			require.Equal(t, expected, string(content))
+		}
+	})
+
+	t.Run("should only clone submodules retained by the sparse filter", func(t *testing.T) {
+		r := NewRepo(parent.URL(), &Options{GitSkipAutoDetect: true, RecurseSubModules: true})
+
+		fsys, _, err := r.Clone(t.Context(), "v1.0.0", &CloneOptions{SparseFilter: []string{"vendor/second/docs"}})
+		require.NoError(t, err)
+
+		_, err = fs.Stat(fsys, "vendor/second/docs/second.txt")
+		require.NoError(t, err)
+		_, err = fs.Stat(fsys, "vendor/first/first.txt")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("should not clone submodules by default", func(t *testing.T) {
+		r := NewRepo(parent.URL(), &Options{GitSkipAutoDetect: true})
+
+		fsys, _, err := r.Clone(t.Context(), "v1.0.0", nil)
+		require.NoError(t, err)
+
+		_, err = fs.Stat(fsys, "vendor/first/first.txt")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("should report errors from all submodules", func(t *testing.T) {
+		broken := testrepo.New(t)
+		broken.Commit(map[string]string{"main.go": "package main"}, "initial")
+		broken.AddSubmoduleURL("vendor/missing-a", &url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(t.TempDir(), "a"))}, secondHash)
+		broken.AddSubmodule("vendor/second", second, secondHash)
+		broken.Tag("v1.0.0", broken.AddSubmoduleURL("vendor/missing-b", &url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(t.TempDir(), "b"))}, secondHash))
+
+		r := NewRepo(broken.URL(), &Options{GitSkipAutoDetect: true, RecurseSubModules: true})
+
+		_, _, err := r.Clone(t.Context(), "v1.0.0", nil)
+		require.Error(t, err)
+		require.ErrorContains(t, err, `"vendor/missing-a"`)
+		require.ErrorContains(t, err, `"vendor/missing-b"`)
+		require.NotContains(t, err.Error(), `"vendor/second"`)
+	})
+}
+
+func TestCloneSubmodulesConcurrency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration test against a git server")
+	}
+
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("this test requires the git binary to serve repositories over http")
+	}
+
+	t.Parallel()
+
+	// the server delays responses and records the maximum number of requests in flight
+	var inFlight, maxInFlight atomic.Int32
+	backend := &cgi.Handler{
+		Path: gitBin,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=/",
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			highest := maxInFlight.Load()
+			if current <= highest || maxInFlight.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		backend.ServeHTTP(w, req)
+	}))
+	t.Cleanup(server.Close)
+
+	const submodules = 5
+	parent := testrepo.New(t)
+	parent.Commit(map[string]string{"main.go": "package main"}, "initial")
+
+	var head plumbing.Hash
+	for i := range submodules {
+		sub := testrepo.New(t)
+		hash := sub.Commit(map[string]string{"README.md": fmt.Sprintf("submodule %d", i)}, "initial")
+
+		subURL, err := url.Parse(server.URL + filepath.ToSlash(sub.Bare()))
+		require.NoError(t, err)
+		head = parent.AddSubmoduleURL(fmt.Sprintf("vendor/sub%d", i), subURL, hash)
+	}
+	parent.Tag("v1.0.0", head)
+
+	for _, limit := range []int{1, 2, 3} {
+		t.Run(fmt.Sprintf("should clone submodules with at most %d in parallel", limit), func(t *testing.T) {
+			maxInFlight.Store(0)
+			r := NewRepo(parent.URL(), &Options{GitSkipAutoDetect: true, RecurseSubModules: true})
+
+			fsys, _, err := r.Clone(t.Context(), "v1.0.0", &CloneOptions{SubmoduleConcurrency: limit})
+			require.NoError(t, err)
+
+			for i := range submodules {
+				content, err := fs.ReadFile(fsys, fmt.Sprintf("vendor/sub%d/README.md", i))
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprintf("submodule %d", i), string(content))
+			}
+
+			require.LessOrEqual(t, maxInFlight.Load(), int32(limit))
+			if limit > 1 {
+				require.Greater(t, maxInFlight.Load(), int32(1), "expected submodules to be fetched in parallel")
+			}
+		})
+	}
+}
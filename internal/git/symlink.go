@@ -0,0 +1,137 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrSymlinkOutsideRepo is returned when the fetched file is a symbolic link which target lies outside of the repository.
+var ErrSymlinkOutsideRepo = errors.New("symbolic link points outside of the repository")
+
+const maxSymlinkHops = 40 // like the limit of the linux kernel
+
+// resolveSymlinks resolves the symbolic links along the path of a file in a git tree, so that fetching a link
+// yields the content of its target, like reading the file from a checkout on disk would.
+//
+// Links are resolved relative to the folder holding them. Links pointing outside of the repository,
+// including absolute links, are rejected with [ErrSymlinkOutsideRepo]. Dangling links yield an error
+// matching [fs.ErrNotExist].
+//
+// A path which does not exist in the tree is returned unchanged.
+func resolveSymlinks(tree *object.Tree, file string) (string, error) {
+	segments := strings.Split(strings.Trim(file, "/"), "/")
+	resolved := make([]string, 0, len(segments))
+	hops := 0
+
+	for len(segments) > 0 {
+		segment := segments[0]
+		segments = segments[1:]
+
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", fmt.Errorf("%q: %w", file, ErrSymlinkOutsideRepo)
+			}
+			resolved = resolved[:len(resolved)-1]
+
+			continue
+		}
+
+		current := path.Join(append(resolved, segment)...)
+		entry, err := tree.FindEntry(current)
+		if err != nil {
+			if hops == 0 {
+				// not a link: let the caller report about a missing file
+				return file, nil
+			}
+
+			return "", fmt.Errorf("%q is a dangling symbolic link: %q does not exist: %w", file, current, fs.ErrNotExist)
+		}
+
+		if entry.Mode != filemode.Symlink {
+			resolved = append(resolved, segment)
+
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return "", fmt.Errorf("too many levels of symbolic links while resolving %q", file)
+		}
+
+		target, err := symlinkTarget(tree, current)
+		if err != nil {
+			return "", err
+		}
+
+		if path.IsAbs(target) {
+			return "", fmt.Errorf("%q links to %q: %w", current, target, ErrSymlinkOutsideRepo)
+		}
+
+		// the target is relative to the folder of the link
+		segments = append(strings.Split(target, "/"), segments...)
+	}
+
+	if hops == 0 {
+		return file, nil
+	}
+
+	return path.Join(resolved...), nil
+}
+
+// resolveFileSymlinks resolves the symbolic links along the path of a file in the tree of a commit.
+func (r *Repository) resolveFileSymlinks(repo *gogit.Repository, hash plumbing.Hash, file string) (string, error) {
+	commit, err := resolveCommit(repo, hash)
+	if err != nil {
+		return "", err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := resolveSymlinks(tree, file)
+	if err != nil {
+		return "", err
+	}
+
+	if resolved != file {
+		r.debug("%q is a symbolic link to %q", file, resolved)
+	}
+
+	return resolved, nil
+}
+
+func symlinkTarget(tree *object.Tree, link string) (string, error) {
+	f, err := tree.File(link)
+	if err != nil {
+		return "", fmt.Errorf("could not read symbolic link %q: %w", link, err)
+	}
+
+	reader, err := f.Reader()
+	if err != nil {
+		return "", fmt.Errorf("could not read symbolic link %q: %w", link, err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	target, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("could not read symbolic link %q: %w", link, err)
+	}
+
+	return string(target), nil
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestSymlink(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Commit(map[string]string{"docs/guide.md": "guide"}, "initial")
+	repo.Symlink("README.md", "docs/guide.md", "link to a file")
+	repo.Symlink("docs/latest.md", "guide.md", "link relative to its folder")
+	repo.Symlink("current", "docs", "link to a folder")
+	repo.Symlink("chain.md", "README.md", "link to a link")
+	repo.Symlink("docs/up.md", "../README.md", "link to a parent folder")
+	repo.Symlink("dangling.md", "missing.md", "dangling link")
+	repo.Symlink("docs/escape.md", "../../outside.md", "link escaping the repository")
+	repo.Symlink("absolute.md", "/etc/hostname", "absolute link")
+	repo.Symlink("loop.md", "loop.md", "self-referencing link")
+	hash := repo.Commit(map[string]string{"other.md": "other"}, "final")
+	repo.Tag("v1.0.0", hash)
+
+	fetch := func(t *testing.T, file string) (string, error) {
+		t.Helper()
+
+		r := NewRepo(repo.URL(), &Options{GitSkipAutoDetect: true})
+		var w bytes.Buffer
+		err := r.Fetch(t.Context(), &w, file, "v1.0.0")
+
+		return w.String(), err
+	}
+
+	for _, file := range []string{"README.md", "docs/latest.md", "current/guide.md", "chain.md", "docs/up.md"} {
+		t.Run("should resolve "+file, func(t *testing.T) {
+			t.Parallel()
+
+			content, err := fetch(t, file)
+			require.NoError(t, err)
+			require.Equal(t, "guide", content)
+		})
+	}
+
+	t.Run("should fail on a dangling link", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := fetch(t, "dangling.md")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	for _, file := range []string{"docs/escape.md", "absolute.md"} {
+		t.Run("should reject "+file, func(t *testing.T) {
+			t.Parallel()
+
+			content, err := fetch(t, file)
+			require.ErrorIs(t, err, ErrSymlinkOutsideRepo)
+			require.Empty(t, content)
+		})
+	}
+
+	t.Run("should fail on a loop", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := fetch(t, "loop.md")
+		require.ErrorContains(t, err, "too many levels of symbolic links")
+	})
+
+	t.Run("should fallback to go-git when git archive yields a link", func(t *testing.T) {
+		t.Parallel()
+
+		if !isGitInstalled() {
+			t.Skip("git is not installed")
+		}
+
+		r := NewRepo(repo.URL(), &Options{})
+		ref := &Ref{
+			Reference: plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.0.0"), hash),
+			ShortName: "v1.0.0",
+			IsTag:     true,
+		}
+
+		handled, err := r.tryNativeArchive(t.Context(), new(bytes.Buffer), "README.md", ref)
+		require.NoError(t, err)
+		require.False(t, handled)
+	})
+}
@@ -227,7 +227,7 @@
 // # TODO
 //
 // Future implementation tasks:
-//   - [ ] Implement Parse function for Azure DevOps URLs
+//   - [x] Implement Parse function for Azure DevOps URLs
 //   - [ ] Implement Raw function using Items API
 //   - [ ] Add comprehensive test coverage
 //   - [ ] Handle authentication requirements
@@ -0,0 +1,13 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package azure
+
+type azureError string
+
+func (e azureError) Error() string {
+	return string(e)
+}
+
+// ErrAzure is a sentinel error for all errors that originate from this package.
+const ErrAzure azureError = "azure error"
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package azure
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
+)
+
+// URL is an azure-style URL to a vcs resource hosted by Azure DevOps.
+type URL struct {
+	repoURL     *url.URL
+	path        string
+	version     string
+	versionType VersionType
+}
+
+// VersionType tells how Azure DevOps should interpret a version: as a branch, a tag or a commit.
+type VersionType string
+
+const (
+	VersionUnknown VersionType = ""
+	VersionBranch  VersionType = "branch"
+	VersionTag     VersionType = "tag"
+	VersionCommit  VersionType = "commit"
+)
+
+const (
+	defaultScheme = "https"
+	defaultHost   = "dev.azure.com"
+
+	gitKeyword   = "_git"
+	apisKeyword  = "_apis"
+	itemsKeyword = "items"
+	browseParts  = 4 // {owner}/{project}/_git/{repo}
+	apiParts     = 7 // {owner}/{project}/_apis/git/repositories/{repo}/items
+
+	pathParam        = "path"
+	versionParam     = "version"
+	descriptorParam  = "versionDescriptor.version"
+	descriptorType   = "versionDescriptor.versionType"
+	branchPrefix     = "GB"
+	tagPrefix        = "GT"
+	commitPrefix     = "GC"
+	repositoriesPath = "git/repositories"
+)
+
+// Parse an Azure DevOps URL.
+//
+// Azure DevOps URL formats:
+//   - Browse: https://dev.azure.com/{owner}/{project}/_git/{repo}?path={path}&version=GB{branch}
+//   - Items API: https://dev.azure.com/{owner}/{project}/_apis/git/repositories/{repo}/items?path={path}&versionDescriptor.version={ref}&versionDescriptor.versionType=branch
+//   - Repo: https://dev.azure.com/{owner}/{project}/_git/{repo}
+//
+// Both the browser and the Items API URLs yield the same repository URL, e.g. https://dev.azure.com/{owner}/{project}/_git/{repo}.
+func Parse(azureURL *url.URL) (*URL, error) {
+	u := common.NormalizeURL(azureURL, defaultScheme, defaultHost)
+	pth, parts := common.SplitPath(u.Path)
+	query := u.Query()
+
+	var (
+		owner, project, repo string
+		version              string
+		versionType          VersionType
+	)
+
+	switch {
+	case len(parts) == browseParts && parts[2] == gitKeyword:
+		owner, project, repo = parts[0], parts[1], parts[3]
+		version, versionType = parseBrowseVersion(query.Get(versionParam))
+	case len(parts) == apiParts && parts[2] == apisKeyword && strings.Join(parts[3:5], "/") == repositoriesPath && parts[6] == itemsKeyword:
+		owner, project, repo = parts[0], parts[1], parts[5]
+		version, versionType = parseAPIVersion(query)
+	default:
+		return nil, fmt.Errorf("expected URL path to be {owner}/{project}/_git/{repo} or an Items API path, but got %q: %w", pth, ErrAzure)
+	}
+
+	repoPath := strings.Trim(query.Get(pathParam), "/")
+	if repoPath == "" {
+		repoPath = "/"
+	}
+
+	u.Path = "/" + strings.Join([]string{owner, project, gitKeyword, strings.TrimSuffix(repo, ".git")}, "/")
+	common.ClearQuery(u)
+
+	return &URL{
+		repoURL:     u,
+		path:        repoPath,
+		version:     version,
+		versionType: versionType,
+	}, nil
+}
+
+// parseBrowseVersion strips the "GB", "GT" or "GC" prefix of the version of a browser URL.
+func parseBrowseVersion(version string) (string, VersionType) {
+	for prefix, versionType := range map[string]VersionType{
+		branchPrefix: VersionBranch,
+		tagPrefix:    VersionTag,
+		commitPrefix: VersionCommit,
+	} {
+		if ref, ok := strings.CutPrefix(version, prefix); ok {
+			return ref, versionType
+		}
+	}
+
+	return version, VersionUnknown
+}
+
+// parseAPIVersion reads the version descriptor of an Items API URL.
+func parseAPIVersion(query url.Values) (string, VersionType) {
+	version := query.Get(descriptorParam)
+	if version == "" {
+		version = query.Get(versionParam)
+	}
+
+	switch versionType := VersionType(strings.ToLower(query.Get(descriptorType))); versionType {
+	case VersionBranch, VersionTag, VersionCommit:
+		return version, versionType
+	default:
+		return version, VersionUnknown
+	}
+}
+
+// RepoURL yields the base URL of the vcs repository,
+// e.g. https://dev.azure.com/owner/project/_git/repo
+func (az *URL) RepoURL() *url.URL {
+	return az.repoURL
+}
+
+// Version yields the ref identifying the desired version of a file,
+// e.g. "main" in https://dev.azure.com/owner/project/_git/repo?path=/README.md&version=GBmain
+func (az *URL) Version() string {
+	return az.version
+}
+
+// Path yields the file path relative to the repository,
+// e.g. "README.md" in https://dev.azure.com/owner/project/_git/repo?path=/README.md&version=GBmain
+func (az *URL) Path() string {
+	return az.path
+}
+
+// VersionType yields how the version should be interpreted, when known,
+// e.g. [VersionTag] for https://dev.azure.com/owner/project/_git/repo?path=/README.md&version=GTv1.0.0
+func (az *URL) VersionType() VersionType {
+	return az.versionType
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package azure
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		browser     string
+		api         string
+		wantRepo    string
+		wantPath    string
+		wantVersion string
+		wantType    VersionType
+	}{
+		{
+			browser:     "https://dev.azure.com/owner/project/_git/repo?path=/docs/README.md&version=GBmain&_a=contents",
+			api:         "https://dev.azure.com/owner/project/_apis/git/repositories/repo/items?path=%2Fdocs%2FREADME.md&versionDescriptor.version=main&versionDescriptor.versionType=branch&api-version=7.0&download=true",
+			wantRepo:    "https://dev.azure.com/owner/project/_git/repo",
+			wantPath:    "docs/README.md",
+			wantVersion: "main",
+			wantType:    VersionBranch,
+		},
+		{
+			browser:     "https://dev.azure.com/owner/project/_git/repo?path=/README.md&version=GTv1.0.1",
+			api:         "https://dev.azure.com/owner/project/_apis/git/repositories/repo/items?path=/README.md&versionDescriptor.version=v1.0.1&versionDescriptor.versionType=tag",
+			wantRepo:    "https://dev.azure.com/owner/project/_git/repo",
+			wantPath:    "README.md",
+			wantVersion: "v1.0.1",
+			wantType:    VersionTag,
+		},
+		{
+			browser:     "https://dev.azure.com/owner/project/_git/repo?path=/README.md&version=GC0123456789abcdef0123456789abcdef01234567",
+			api:         "https://dev.azure.com/owner/project/_apis/git/repositories/repo/items?path=/README.md&versionDescriptor.version=0123456789abcdef0123456789abcdef01234567&versionDescriptor.versionType=Commit",
+			wantRepo:    "https://dev.azure.com/owner/project/_git/repo",
+			wantPath:    "README.md",
+			wantVersion: "0123456789abcdef0123456789abcdef01234567",
+			wantType:    VersionCommit,
+		},
+		{
+			browser:  "https://dev.azure.com/owner/project/_git/repo?path=/README.md",
+			api:      "https://dev.azure.com/owner/project/_apis/git/repositories/repo/items?path=/README.md&api-version=7.0",
+			wantRepo: "https://dev.azure.com/owner/project/_git/repo",
+			wantPath: "README.md",
+			wantType: VersionUnknown,
+		},
+		{
+			browser:  "https://dev.azure.com/owner/project/_git/repo",
+			api:      "https://dev.azure.com/owner/project/_apis/git/repositories/repo/items",
+			wantRepo: "https://dev.azure.com/owner/project/_git/repo",
+			wantPath: "/",
+			wantType: VersionUnknown,
+		},
+	} {
+		t.Run(tc.browser, func(t *testing.T) {
+			t.Parallel()
+
+			for _, input := range []string{tc.browser, tc.api} {
+				u, err := url.Parse(input)
+				require.NoError(t, err)
+
+				az, err := Parse(u)
+				require.NoError(t, err, input)
+				require.Equal(t, tc.wantRepo, az.RepoURL().String(), input)
+				require.Equal(t, tc.wantPath, az.Path(), input)
+				require.Equal(t, tc.wantVersion, az.Version(), input)
+				require.Equal(t, tc.wantType, az.VersionType(), input)
+			}
+		})
+	}
+
+	t.Run("should accept a plain version in an Items API URL", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := url.Parse("https://dev.azure.com/owner/project/_apis/git/repositories/repo/items?path=/README.md&version=main")
+		require.NoError(t, err)
+
+		az, err := Parse(u)
+		require.NoError(t, err)
+		require.Equal(t, "main", az.Version())
+		require.Equal(t, VersionUnknown, az.VersionType())
+	})
+
+	for _, input := range []string{
+		"https://dev.azure.com/owner/project/repo",
+		"https://dev.azure.com/owner/project/_apis/git/repositories/repo",
+		"https://dev.azure.com/owner/project/_apis/git/repositories/repo/commits",
+	} {
+		t.Run("should reject "+input, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(input)
+			require.NoError(t, err)
+
+			_, err = Parse(u)
+			require.ErrorIs(t, err, ErrAzure)
+		})
+	}
+}
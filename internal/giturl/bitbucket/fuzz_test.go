@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package bitbucket
+
+import (
+	"net/url"
+	"testing"
+)
+
+// FuzzBitbucketParse asserts that the parser never panics, whatever the input.
+//
+// The seed corpus in testdata/fuzz is made of the URLs used by the unit tests.
+func FuzzBitbucketParse(f *testing.F) {
+	f.Fuzz(func(t *testing.T, input string) {
+		u, err := url.Parse(input)
+		if err != nil {
+			return
+		}
+
+		parsed, err := Parse(u)
+		if err != nil {
+			return
+		}
+
+		if parsed.RepoURL() == nil {
+			t.Fatalf("expected a non-nil repo URL for %q", input)
+		}
+
+		_, _ = Raw(parsed)
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
 )
 
 // URL is a bitbucket-style URL to a vcs resource hosted by Bitbucket SCM.
@@ -14,13 +16,34 @@ type URL struct {
 	repoURL *url.URL
 	path    string
 	version string
+	refType RefType
 }
 
 const (
 	defaultScheme = "https"
 	defaultHost   = "bitbucket.org"
+
+	scmKeyword       = "scm"
+	projectsKeyword  = "projects"
+	usersKeyword     = "users"
+	reposKeyword     = "repos"
+	serverCloneParts = 3 // scm/{project}/{repo}
+	serverRepoParts  = 4 // projects/{project}/repos/{repo}
 )
 
+// config describes bitbucket URLs: "src" or "raw", then the ref (bitbucket doesn't tell the ref type).
+var config = common.Config{
+	DefaultScheme: defaultScheme,
+	DefaultHost:   defaultHost,
+	Layout: common.Layout{
+		Keywords: map[string]common.Keyword{
+			"src": {IsTree: true},
+			"raw": {IsTree: true},
+		},
+		Err: ErrBitbucket,
+	},
+}
+
 // Parse a bitbucket URL.
 //
 // Bitbucket URL formats:
@@ -28,99 +51,87 @@ const (
 //   - Raw: https://bitbucket.org/{workspace}/{repo}/raw/{ref}/{path}
 //   - Repo: https://bitbucket.org/{workspace}/{repo}
 //
+// Bitbucket Server (self-hosted) URL formats:
+//   - Browse: https://bitbucket.example.com/projects/{project}/repos/{repo}/browse/{path}?at={ref}
+//   - Raw: https://bitbucket.example.com/projects/{project}/repos/{repo}/raw/{path}?at={ref}
+//   - Repo: https://bitbucket.example.com/scm/{project}/{repo}
+//
+// Bitbucket Server URLs yield the clone URL of the repository, e.g. https://bitbucket.example.com/scm/{project}/{repo}.
+//
 // Note: Bitbucket uses "workspace" terminology instead of "owner".
 func Parse(bitbucketURL *url.URL) (*URL, error) {
-	u := &url.URL{}
-	*u = *bitbucketURL // shallow clone
-
-	if u.Scheme == "" {
-		u.Scheme = defaultScheme
-	}
-
-	if u.Hostname() == "" {
-		if u.Port() == "" {
-			u.Host = defaultHost
-		} else {
-			u.Host = defaultHost + ":" + u.Port()
-		}
+	if bb, isServer, err := parseServer(bitbucketURL); isServer {
+		return bb, err
 	}
 
-	u.Host = strings.ToLower(u.Host)
-	pth := strings.Trim(u.Path, "/")
-
-	const (
-		repoIndex = 2
-	)
-
-	parts := strings.Split(pth, "/")
-	if len(parts) < repoIndex {
-		return nil, fmt.Errorf("expected the URL path component to contain at least %d parts, but got %q: %w", repoIndex, pth, ErrBitbucket)
+	parsed, err := common.Parse(bitbucketURL, config)
+	if err != nil {
+		return nil, err
 	}
 
-	repo := strings.Join(parts[:repoIndex], "/")
-	repo = strings.TrimSuffix(repo, ".git")
-	u.Path = repo
-
-	if len(parts) == repoIndex {
-		// entire repo
-		u.RawFragment = ""
-		u.Fragment = ""
-		u.RawQuery = ""
-
-		bb := &URL{
-			repoURL: u,
-			path:    "/",
-			version: "",
-		}
-
-		return bb, nil
+	_, refType := InferRefType(parsed.Version)
+	bb := &URL{
+		repoURL: parsed.RepoURL,
+		path:    parsed.Path,
+		version: parsed.Version,
+		refType: refType,
 	}
 
-	parts = parts[repoIndex:]
+	return bb, nil
+}
 
-	// Bitbucket uses "src" or "raw" as first part (simpler than gitea - no branch/tag/commit discriminator)
-	const neededPartsAfterRepo = 2
-	if len(parts) < neededPartsAfterRepo {
-		return nil, fmt.Errorf(`expected URL path to contain at least %d parts after repo but got %q: %w`, neededPartsAfterRepo, pth, ErrBitbucket)
+// parseServer parses the URL of a Bitbucket Server instance.
+//
+// It returns false if the URL does not follow the layout of Bitbucket Server.
+// Bitbucket Cloud (bitbucket.org) is never considered.
+func parseServer(bitbucketURL *url.URL) (*URL, bool, error) {
+	u := common.NormalizeURL(bitbucketURL, defaultScheme, defaultHost)
+	if u.Hostname() == defaultHost {
+		return nil, false, nil
 	}
 
-	discriminator := strings.ToLower(parts[0])
-	switch discriminator {
-	case "src":
-		// Browse URL: /src/{ref}/{path}
-	case "raw":
-		// Raw URL: /raw/{ref}/{path}
+	pth, parts := common.SplitPath(u.Path)
+
+	var project, repo string
+	switch {
+	case len(parts) == serverCloneParts && parts[0] == scmKeyword:
+		// clone URL: /scm/{project}/{repo}
+		project, repo = parts[1], parts[2]
+		parts = nil
+	case len(parts) >= serverRepoParts && parts[2] == reposKeyword && (parts[0] == projectsKeyword || parts[0] == usersKeyword):
+		// /projects/{project}/repos/{repo} or /users/{user}/repos/{repo}
+		project, repo = parts[1], parts[3]
+		if parts[0] == usersKeyword {
+			project = "~" + project // personal repository
+		}
+		parts = parts[serverRepoParts:]
 	default:
-		return nil, fmt.Errorf(`expected URL path to contain "src" or "raw" but got %q in %q: %w`, parts[0], pth, ErrBitbucket)
-	}
-
-	parts = parts[1:]
-
-	// Next part is the ref (branch, tag, or commit - Bitbucket doesn't require specifying which)
-	if len(parts) < 1 {
-		return nil, fmt.Errorf(`expected URL path to contain ref name but got %q: %w`, pth, ErrBitbucket)
+		return nil, false, nil
 	}
 
-	ref := parts[0]
-	parts = parts[1:]
+	repoPath := "/"
+	if len(parts) > 0 {
+		switch strings.ToLower(parts[0]) {
+		case "browse", "raw":
+		default:
+			return nil, true, fmt.Errorf(`expected URL path to contain "browse" or "raw" but got %q in %q: %w`, parts[0], pth, ErrBitbucket)
+		}
 
-	if len(parts) == 0 {
-		// No file path - this is a tree/directory view
-		parts = []string{"/"}
+		if len(parts) > 1 {
+			repoPath = strings.Join(parts[1:], "/")
+		}
 	}
 
-	repoPath := strings.Join(parts, "/")
-	u.RawFragment = ""
-	u.Fragment = ""
-	u.RawQuery = ""
+	version, refType := InferRefType(u.Query().Get("at"))
+	u.Path = strings.Join([]string{scmKeyword, project, strings.TrimSuffix(repo, ".git")}, "/")
+	common.ClearQuery(u)
 
-	bb := &URL{
+	return &URL{
 		repoURL: u,
 		path:    repoPath,
-		version: ref,
-	}
-
-	return bb, nil
+		version: version,
+		refType: refType,
+	}, true, nil
 }
 
 // RepoURL yields the base URL of the vcs repository,
@@ -140,3 +151,9 @@ func (bb *URL) Version() string {
 func (bb *URL) Path() string {
 	return bb.path
 }
+
+// RefType yields the type of the ref identifying the desired version, when known,
+// e.g. [RefTag] for https://bitbucket.example.com/projects/PRJ/repos/repo/browse/README.md?at=refs/tags/v1.0.0
+func (bb *URL) RefType() RefType {
+	return bb.refType
+}
@@ -8,6 +8,8 @@ import (
 	"net/url"
 	"path"
 	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
 )
 
 // Locator redefines locally the common minimal locator interface.
@@ -21,17 +23,33 @@ type Locator interface {
 	Version() string
 }
 
+// RefTyper is implemented by locators that know the type of their ref, such as [URL].
+type RefTyper interface {
+	RefType() RefType
+}
+
+// RawSupportsHEAD tells that Bitbucket resolves "HEAD" to the default branch.
+//
+// Bitbucket Server serves the default branch when the "at" parameter is omitted, which is the case
+// for "HEAD".
+const RawSupportsHEAD = true
+
 // Raw returns the raw content URL for a [Locator] hosted on Bitbucket.
 //
 // Only https URL's are supported.
 //
 // For self-hosted Bitbucket Server instances, this only works for instances
-// accessible via standard https (port 443 or unspecified).
+// accessible via standard https (port 443 or unspecified). Bitbucket Server repositories are recognized
+// by their clone URL, e.g. https://bitbucket.example.com/scm/{project}/{repo}.
+//
+// Bitbucket Server requires the full name of a tag or a branch. The type of the ref is given by the locator
+// if it implements [RefTyper], or inferred with [InferRefType].
 //
 // Examples:
 //
 //   - https://bitbucket.org/workspace/repo/raw/master/README.md
 //   - https://bitbucket.org/atlassian/python-bitbucket/raw/main/setup.py
+//   - https://bitbucket.example.com/projects/PRJ/repos/repo/raw/README.md?at=refs%2Ftags%2Fv1.0.0
 func Raw(locator Locator) (*url.URL, error) {
 	repo := locator.RepoURL()
 	pth := strings.Trim(locator.Path(), "/")
@@ -39,9 +57,9 @@ func Raw(locator Locator) (*url.URL, error) {
 		return nil, fmt.Errorf("returning a raw content url requires a non empty path to a file: %w", ErrBitbucket)
 	}
 
-	version := locator.Version()
-	if version == "" {
-		version = "HEAD"
+	version, refType := InferRefType(locator.Version())
+	if typer, ok := locator.(RefTyper); ok && typer.RefType() != RefUnknown {
+		refType = typer.RefType()
 	}
 
 	scheme, _ := strings.CutSuffix(repo.Scheme, "+git")
@@ -57,6 +75,23 @@ func Raw(locator Locator) (*url.URL, error) {
 	u := &url.URL{}
 	*u = *repo // shallow clone
 
+	if project, repoName, isServer := serverRepo(repo); isServer {
+		// Bitbucket Server raw URL format: /projects/{project}/repos/{repo}/raw/{path}?at={ref}
+		u.Path = path.Join("/", projectsPrefix(project), reposKeyword, repoName, "raw", pth)
+		u.RawQuery = ""
+		if version != "" && version != "HEAD" {
+			u.RawQuery = url.Values{"at": []string{fullRef(version, refType)}}.Encode()
+		}
+		u.Fragment = ""
+		u.RawFragment = ""
+
+		return u, nil
+	}
+
+	if version == "" {
+		version = "HEAD"
+	}
+
 	// Bitbucket raw URL format: /{workspace}/{repo}/raw/{ref}/{path}
 	u.Path = path.Join(u.Path, "raw", version, pth)
 	u.Fragment = ""
@@ -64,3 +99,26 @@ func Raw(locator Locator) (*url.URL, error) {
 
 	return u, nil
 }
+
+// serverRepo recognizes the clone URL of a repository hosted by Bitbucket Server, e.g. https://bitbucket.example.com/scm/{project}/{repo}.
+func serverRepo(repo *url.URL) (project, repoName string, isServer bool) {
+	if repo.Hostname() == defaultHost {
+		return "", "", false
+	}
+
+	_, parts := common.SplitPath(repo.Path)
+	if len(parts) != serverCloneParts || parts[0] != scmKeyword {
+		return "", "", false
+	}
+
+	return parts[1], strings.TrimSuffix(parts[2], ".git"), true
+}
+
+// projectsPrefix yields the path to a project, or to the personal space of a user, e.g. "~user".
+func projectsPrefix(project string) string {
+	if user, isPersonal := strings.CutPrefix(project, "~"); isPersonal {
+		return path.Join(usersKeyword, user)
+	}
+
+	return path.Join(projectsKeyword, project)
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package bitbucket
+
+import "strings"
+
+// RefType is the type of the git ref designated by a bitbucket URL.
+type RefType uint8
+
+const (
+	// RefUnknown is a ref that may be a branch or a tag.
+	RefUnknown RefType = iota
+	// RefBranch is a branch.
+	RefBranch
+	// RefTag is a tag.
+	RefTag
+	// RefCommit is a commit sha.
+	RefCommit
+)
+
+const (
+	tagsPrefix  = "refs/tags/"
+	headsPrefix = "refs/heads/"
+)
+
+func (t RefType) String() string {
+	switch t {
+	case RefBranch:
+		return "branch"
+	case RefTag:
+		return "tag"
+	case RefCommit:
+		return "commit"
+	default:
+		return "unknown"
+	}
+}
+
+// InferRefType infers the type of a ref, and yields its short name.
+//
+// A full ref name is a hint for a tag or a branch, e.g. "refs/tags/v1.0.0" or "refs/heads/main".
+// A ref that looks like a (possibly abbreviated) sha is a commit.
+func InferRefType(ref string) (string, RefType) {
+	if name, isTag := strings.CutPrefix(ref, tagsPrefix); isTag {
+		return name, RefTag
+	}
+
+	if name, isBranch := strings.CutPrefix(ref, headsPrefix); isBranch {
+		return name, RefBranch
+	}
+
+	if isSHALike(ref) {
+		return ref, RefCommit
+	}
+
+	return ref, RefUnknown
+}
+
+// fullRef yields the full name of a ref, as expected by the "at" query parameter of Bitbucket Server.
+func fullRef(name string, refType RefType) string {
+	switch refType {
+	case RefTag:
+		return tagsPrefix + name
+	case RefBranch:
+		return headsPrefix + name
+	default:
+		// commits and short names are resolved by Bitbucket Server
+		return name
+	}
+}
+
+func isSHALike(ref string) bool {
+	const (
+		minSHALength = 7
+		maxSHALength = 40
+	)
+
+	if len(ref) < minSHALength || len(ref) > maxSHALength {
+		return false
+	}
+
+	for _, c := range ref {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package bitbucket
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestInferRefType(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		ref      string
+		wantName string
+		wantType RefType
+	}{
+		{ref: "refs/tags/v1.0.0", wantName: "v1.0.0", wantType: RefTag},
+		{ref: "refs/heads/main", wantName: "main", wantType: RefBranch},
+		{ref: "refs/heads/feature/x", wantName: "feature/x", wantType: RefBranch},
+		{ref: "abc123d", wantName: "abc123d", wantType: RefCommit},
+		{ref: "0123456789abcdef0123456789abcdef01234567", wantName: "0123456789abcdef0123456789abcdef01234567", wantType: RefCommit},
+		{ref: "abc123", wantName: "abc123", wantType: RefUnknown},       // too short for a sha
+		{ref: "ABC123DEF", wantName: "ABC123DEF", wantType: RefUnknown}, // not lower case hex
+		{ref: "main", wantName: "main", wantType: RefUnknown},
+		{ref: "v1.0.0", wantName: "v1.0.0", wantType: RefUnknown},
+		{ref: "", wantName: "", wantType: RefUnknown},
+	} {
+		name, refType := InferRefType(tc.ref)
+		require.Equal(t, tc.wantName, name, tc.ref)
+		require.Equal(t, tc.wantType, refType, "%s: expected %v, got %v", tc.ref, tc.wantType, refType)
+	}
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input       string
+		wantRepo    string
+		wantVersion string
+		wantPath    string
+		wantType    RefType
+		wantRaw     string
+	}{
+		{
+			input:       "https://bitbucket.example.com/projects/PRJ/repos/repo/browse/docs/README.md?at=refs%2Ftags%2Fv1.0.0",
+			wantRepo:    "https://bitbucket.example.com/scm/PRJ/repo",
+			wantVersion: "v1.0.0",
+			wantPath:    "docs/README.md",
+			wantType:    RefTag,
+			wantRaw:     "https://bitbucket.example.com/projects/PRJ/repos/repo/raw/docs/README.md?at=refs%2Ftags%2Fv1.0.0",
+		},
+		{
+			input:       "https://bitbucket.example.com/projects/PRJ/repos/repo/raw/README.md?at=refs/heads/main",
+			wantRepo:    "https://bitbucket.example.com/scm/PRJ/repo",
+			wantVersion: "main",
+			wantPath:    "README.md",
+			wantType:    RefBranch,
+			wantRaw:     "https://bitbucket.example.com/projects/PRJ/repos/repo/raw/README.md?at=refs%2Fheads%2Fmain",
+		},
+		{
+			input:       "https://bitbucket.example.com/projects/PRJ/repos/repo/browse/README.md?at=0123456789abcdef0123456789abcdef01234567",
+			wantRepo:    "https://bitbucket.example.com/scm/PRJ/repo",
+			wantVersion: "0123456789abcdef0123456789abcdef01234567",
+			wantPath:    "README.md",
+			wantType:    RefCommit,
+			wantRaw:     "https://bitbucket.example.com/projects/PRJ/repos/repo/raw/README.md?at=0123456789abcdef0123456789abcdef01234567",
+		},
+		{
+			input:    "https://bitbucket.example.com/users/jdoe/repos/repo/browse/README.md",
+			wantRepo: "https://bitbucket.example.com/scm/~jdoe/repo",
+			wantPath: "README.md",
+			wantType: RefUnknown,
+			wantRaw:  "https://bitbucket.example.com/users/jdoe/repos/repo/raw/README.md",
+		},
+		{
+			input:    "https://bitbucket.example.com/scm/PRJ/repo.git",
+			wantRepo: "https://bitbucket.example.com/scm/PRJ/repo",
+			wantPath: "/",
+			wantType: RefUnknown,
+		},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.input)
+			require.NoError(t, err)
+
+			bb, err := Parse(u)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRepo, bb.RepoURL().String())
+			require.Equal(t, tc.wantVersion, bb.Version())
+			require.Equal(t, tc.wantPath, bb.Path())
+			require.Equal(t, tc.wantType, bb.RefType())
+
+			if tc.wantRaw == "" {
+				return
+			}
+
+			raw, err := Raw(bb)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRaw, raw.String())
+		})
+	}
+
+	t.Run("should reject an unknown Bitbucket Server page", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := url.Parse("https://bitbucket.example.com/projects/PRJ/repos/repo/commits")
+		require.NoError(t, err)
+
+		_, err = Parse(u)
+		require.ErrorIs(t, err, ErrBitbucket)
+	})
+
+	t.Run("should use the ref type of a locator", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := url.Parse("https://bitbucket.example.com/scm/PRJ/repo")
+		require.NoError(t, err)
+
+		for refType, expected := range map[RefType]string{
+			RefTag:     "at=refs%2Ftags%2Fv1",
+			RefBranch:  "at=refs%2Fheads%2Fv1",
+			RefUnknown: "at=v1",
+		} {
+			raw, err := Raw(&URL{repoURL: repo, path: "README.md", version: "v1", refType: refType})
+			require.NoError(t, err)
+			require.Equal(t, expected, raw.RawQuery)
+		}
+	})
+
+	t.Run("should resolve HEAD to the default branch", func(t *testing.T) {
+		t.Parallel()
+
+		for repo, expected := range map[string]string{
+			"https://bitbucket.example.com/scm/PRJ/repo": "https://bitbucket.example.com/projects/PRJ/repos/repo/raw/README.md",
+			"https://bitbucket.org/workspace/repo":       "https://bitbucket.org/workspace/repo/raw/HEAD/README.md",
+		} {
+			repoURL, err := url.Parse(repo)
+			require.NoError(t, err)
+
+			raw, err := Raw(&URL{repoURL: repoURL, path: "README.md", version: "HEAD"})
+			require.NoError(t, err)
+			require.Equal(t, expected, raw.String())
+		}
+	})
+
+	t.Run("should keep Bitbucket Cloud raw URLs for all ref types", func(t *testing.T) {
+		t.Parallel()
+
+		for input, expected := range map[string]string{
+			"https://bitbucket.org/workspace/repo/src/v1.0.0/README.md":       "https://bitbucket.org/workspace/repo/raw/v1.0.0/README.md",
+			"https://bitbucket.org/workspace/repo/src/main/README.md":         "https://bitbucket.org/workspace/repo/raw/main/README.md",
+			"https://bitbucket.org/workspace/repo/src/abc123def456/README.md": "https://bitbucket.org/workspace/repo/raw/abc123def456/README.md",
+		} {
+			u, err := url.Parse(input)
+			require.NoError(t, err)
+
+			bb, err := Parse(u)
+			require.NoError(t, err)
+
+			raw, err := Raw(bb)
+			require.NoError(t, err)
+			require.Equal(t, expected, raw.String())
+		}
+	})
+}
@@ -0,0 +1,13 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package codecommit
+
+type codecommitError string
+
+func (e codecommitError) Error() string {
+	return string(e)
+}
+
+// ErrCodeCommit is a sentinel error for all errors that originate from this package.
+const ErrCodeCommit codecommitError = "codecommit error"
@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package codecommit
+
+import (
+	"net/url"
+	"testing"
+)
+
+// FuzzCodeCommitParse asserts that the parser never panics, whatever the input.
+func FuzzCodeCommitParse(f *testing.F) {
+	f.Add("https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo@v1.2.3")
+	f.Add("https://us-east-1.console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse/refs/heads/main/--/README.md")
+	f.Add("https://console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse/--?region=eu-west-1")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		u, err := url.Parse(input)
+		if err != nil {
+			return
+		}
+
+		parsed, err := Parse(u)
+		if err != nil {
+			return
+		}
+
+		if parsed.RepoURL() == nil {
+			t.Fatalf("expected a non-nil repo URL for %q", input)
+		}
+	})
+}
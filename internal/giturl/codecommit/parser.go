@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package codecommit
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
+)
+
+// URL is a CodeCommit URL to a vcs resource hosted by AWS CodeCommit.
+type URL struct {
+	repoURL    *url.URL
+	path       string
+	version    string
+	region     string
+	repository string
+}
+
+const (
+	defaultScheme = "https"
+
+	gitHostPrefix     = "git-codecommit"
+	gitHostSuffix     = "amazonaws.com"
+	consoleHostSuffix = "console.aws.amazon.com"
+
+	reposPrefix    = "v1/repos"
+	consolePrefix  = "codesuite/codecommit/repositories"
+	browseKeyword  = "browse"
+	pathSeparator  = "--"
+	headsRefPrefix = "refs/heads/"
+	tagsRefPrefix  = "refs/tags/"
+)
+
+// IsHost tells if a lower-cased host is a CodeCommit git endpoint, e.g. "git-codecommit.us-east-1.amazonaws.com",
+// or the AWS console, e.g. "eu-west-1.console.aws.amazon.com".
+func IsHost(host string) bool {
+	hostname, _, _ := strings.Cut(host, ":")
+
+	return RegionFromHost(hostname) != "" || hostname == consoleHostSuffix || strings.HasSuffix(hostname, "."+consoleHostSuffix)
+}
+
+// RegionFromHost yields the AWS region of a CodeCommit git endpoint, e.g. "us-east-1" for
+// "git-codecommit.us-east-1.amazonaws.com", or an empty string if the host is not a CodeCommit git endpoint.
+//
+// FIPS endpoints (e.g. "git-codecommit-fips.us-east-1.amazonaws.com") and the China partition
+// (e.g. "git-codecommit.cn-north-1.amazonaws.com.cn") are supported.
+func RegionFromHost(host string) string {
+	hostname, _, _ := strings.Cut(strings.ToLower(host), ":")
+	labels := strings.Split(hostname, ".")
+
+	const minLabels = 4 // git-codecommit.{region}.amazonaws.com
+	if len(labels) < minLabels || !strings.HasPrefix(labels[0], gitHostPrefix) {
+		return ""
+	}
+
+	if domain := strings.Join(labels[2:], "."); domain != gitHostSuffix && domain != gitHostSuffix+".cn" {
+		return ""
+	}
+
+	return labels[1]
+}
+
+// Parse a CodeCommit URL.
+//
+// CodeCommit URL formats:
+//   - Repo: https://git-codecommit.{region}.amazonaws.com/v1/repos/{repo}
+//   - Repo at a version: https://git-codecommit.{region}.amazonaws.com/v1/repos/{repo}@{ref}
+//   - Browse (AWS console): https://{region}.console.aws.amazon.com/codesuite/codecommit/repositories/{repo}/browse/refs/heads/{branch}/--/{path}
+//
+// URLs from the AWS console are mapped to the https git endpoint of the region of the repository.
+func Parse(codecommitURL *url.URL) (*URL, error) {
+	u := common.NormalizeURL(codecommitURL, defaultScheme, "")
+
+	if region := RegionFromHost(u.Host); region != "" {
+		return parseGit(u, region)
+	}
+
+	hostname := u.Hostname()
+	if hostname == consoleHostSuffix || strings.HasSuffix(hostname, "."+consoleHostSuffix) {
+		return parseConsole(u)
+	}
+
+	return nil, fmt.Errorf("expected a CodeCommit git endpoint or an AWS console URL, but got host %q: %w", u.Host, ErrCodeCommit)
+}
+
+func parseGit(u *url.URL, region string) (*URL, error) {
+	pth, parts := common.SplitPath(u.Path)
+
+	const repoParts = 3 // v1/repos/{repo}
+	if len(parts) != repoParts || path.Join(parts[:2]...) != reposPrefix {
+		return nil, fmt.Errorf("expected the URL path to be %s/{repo}, but got %q: %w", reposPrefix, pth, ErrCodeCommit)
+	}
+
+	repository, version, _ := strings.Cut(parts[2], "@")
+	repository = strings.TrimSuffix(repository, ".git")
+	if repository == "" {
+		return nil, fmt.Errorf("expected a non-empty repository name in %q: %w", pth, ErrCodeCommit)
+	}
+
+	repoURL := &url.URL{}
+	*repoURL = *u // shallow clone
+	repoURL.Path = "/" + path.Join(reposPrefix, repository)
+	repoURL.RawPath = ""
+	common.ClearQuery(repoURL)
+
+	return &URL{
+		repoURL:    repoURL,
+		path:       "/",
+		version:    version,
+		region:     region,
+		repository: repository,
+	}, nil
+}
+
+func parseConsole(u *url.URL) (*URL, error) {
+	region := u.Query().Get("region")
+	if region == "" {
+		region, _, _ = strings.Cut(u.Hostname(), ".")
+		if region+"."+consoleHostSuffix != u.Hostname() {
+			return nil, fmt.Errorf("expected the AWS console URL to specify a region: %w", ErrCodeCommit)
+		}
+	}
+
+	pth, parts := common.SplitEscapedPath(u)
+	prefix := strings.Split(consolePrefix, "/")
+	if len(parts) <= len(prefix) || !slices.Equal(parts[:len(prefix)], prefix) {
+		return nil, fmt.Errorf("expected the URL path to start with %s/{repo}, but got %q: %w", consolePrefix, pth, ErrCodeCommit)
+	}
+
+	repository := parts[len(prefix)]
+	parts = parts[len(prefix)+1:]
+
+	cc := &URL{
+		repoURL: &url.URL{
+			Scheme: defaultScheme,
+			Host:   gitHostPrefix + "." + region + "." + gitHostSuffix,
+			Path:   "/" + path.Join(reposPrefix, repository),
+		},
+		path:       "/",
+		region:     region,
+		repository: repository,
+	}
+
+	if len(parts) == 0 {
+		return cc, nil
+	}
+
+	if parts[0] != browseKeyword {
+		return nil, fmt.Errorf("expected %q after the repository, but got %q: %w", browseKeyword, pth, ErrCodeCommit)
+	}
+	parts = parts[1:]
+
+	// the ref may contain slashes, up to the "--" separator which precedes the path
+	idx := slices.Index(parts, pathSeparator)
+	if idx < 0 {
+		idx = len(parts)
+	}
+
+	ref := path.Join(parts[:idx]...)
+	if trimmed, ok := strings.CutPrefix(ref, headsRefPrefix); ok {
+		ref = trimmed
+	} else if trimmed, ok := strings.CutPrefix(ref, tagsRefPrefix); ok {
+		ref = trimmed
+	}
+	cc.version = ref
+
+	if idx+1 < len(parts) {
+		cc.path = path.Join(parts[idx+1:]...)
+	}
+
+	return cc, nil
+}
+
+// RepoURL yields the base URL of the vcs repository,
+// e.g. https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo
+func (cc *URL) RepoURL() *url.URL {
+	return cc.repoURL
+}
+
+// Version yields the ref identifying the desired version of a file,
+// e.g. "main" in https://us-east-1.console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse/refs/heads/main/--/README.md
+func (cc *URL) Version() string {
+	return cc.version
+}
+
+// Path yields the file path relative to the repository,
+// e.g. "README.md" in https://us-east-1.console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse/refs/heads/main/--/README.md
+func (cc *URL) Path() string {
+	return cc.path
+}
+
+// Region yields the AWS region hosting the repository, e.g. "us-east-1".
+func (cc *URL) Region() string {
+	return cc.region
+}
+
+// Repository yields the name of the repository, e.g. "my-repo".
+func (cc *URL) Repository() string {
+	return cc.repository
+}
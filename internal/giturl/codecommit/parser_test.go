@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package codecommit
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		wantRepo    string
+		wantVersion string
+		wantPath    string
+		wantRegion  string
+		wantErr     bool
+	}{
+		{
+			name:       "git endpoint",
+			input:      "https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo",
+			wantRepo:   "https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo",
+			wantPath:   "/",
+			wantRegion: "us-east-1",
+		},
+		{
+			name:        "git endpoint with version and .git suffix",
+			input:       "https://git-codecommit.eu-west-3.amazonaws.com/v1/repos/my-repo.git@v1.2.3",
+			wantRepo:    "https://git-codecommit.eu-west-3.amazonaws.com/v1/repos/my-repo",
+			wantVersion: "v1.2.3",
+			wantPath:    "/",
+			wantRegion:  "eu-west-3",
+		},
+		{
+			name:       "fips git endpoint",
+			input:      "https://git-codecommit-fips.us-west-2.amazonaws.com/v1/repos/my-repo",
+			wantRepo:   "https://git-codecommit-fips.us-west-2.amazonaws.com/v1/repos/my-repo",
+			wantPath:   "/",
+			wantRegion: "us-west-2",
+		},
+		{
+			name:       "china git endpoint",
+			input:      "https://git-codecommit.cn-north-1.amazonaws.com.cn/v1/repos/my-repo",
+			wantRepo:   "https://git-codecommit.cn-north-1.amazonaws.com.cn/v1/repos/my-repo",
+			wantPath:   "/",
+			wantRegion: "cn-north-1",
+		},
+		{
+			name:       "ssh git endpoint",
+			input:      "ssh://APKAEIBAERJR2EXAMPLE@git-codecommit.us-east-2.amazonaws.com/v1/repos/my-repo",
+			wantRepo:   "ssh://APKAEIBAERJR2EXAMPLE@git-codecommit.us-east-2.amazonaws.com/v1/repos/my-repo",
+			wantPath:   "/",
+			wantRegion: "us-east-2",
+		},
+		{
+			name:        "console browse URL with a branch and a file",
+			input:       "https://us-east-1.console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse/refs/heads/main/--/docs/README.md?region=us-east-1",
+			wantRepo:    "https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo",
+			wantVersion: "main",
+			wantPath:    "docs/README.md",
+			wantRegion:  "us-east-1",
+		},
+		{
+			name:        "console browse URL with a tag containing slashes",
+			input:       "https://eu-west-1.console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse/refs/tags/release/v1/--/README.md",
+			wantRepo:    "https://git-codecommit.eu-west-1.amazonaws.com/v1/repos/my-repo",
+			wantVersion: "release/v1",
+			wantPath:    "README.md",
+			wantRegion:  "eu-west-1",
+		},
+		{
+			name:        "console browse URL with a commit",
+			input:       "https://console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse/0123456789abcdef0123456789abcdef01234567/--/main.go?region=ap-south-1",
+			wantRepo:    "https://git-codecommit.ap-south-1.amazonaws.com/v1/repos/my-repo",
+			wantVersion: "0123456789abcdef0123456789abcdef01234567",
+			wantPath:    "main.go",
+			wantRegion:  "ap-south-1",
+		},
+		{
+			name:       "console repository URL",
+			input:      "https://us-east-1.console.aws.amazon.com/codesuite/codecommit/repositories/my-repo",
+			wantRepo:   "https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo",
+			wantPath:   "/",
+			wantRegion: "us-east-1",
+		},
+		{
+			name:    "git endpoint without repository",
+			input:   "https://git-codecommit.us-east-1.amazonaws.com/v1/repos",
+			wantErr: true,
+		},
+		{
+			name:    "git endpoint with an unexpected path",
+			input:   "https://git-codecommit.us-east-1.amazonaws.com/owner/repo/blob/main/README.md",
+			wantErr: true,
+		},
+		{
+			name:    "console URL without region",
+			input:   "https://console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse",
+			wantErr: true,
+		},
+		{
+			name:    "console URL to another service",
+			input:   "https://us-east-1.console.aws.amazon.com/s3/buckets/my-bucket",
+			wantErr: true,
+		},
+		{
+			name:    "not a CodeCommit host",
+			input:   "https://example.com/v1/repos/my-repo",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.input)
+			require.NoError(t, err)
+
+			cc, err := Parse(u)
+			if tc.wantErr {
+				require.ErrorIs(t, err, ErrCodeCommit)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRepo, cc.RepoURL().String())
+			require.Equal(t, tc.wantVersion, cc.Version())
+			require.Equal(t, tc.wantPath, cc.Path())
+			require.Equal(t, tc.wantRegion, cc.Region())
+			require.Equal(t, "my-repo", cc.Repository())
+		})
+	}
+}
+
+func TestIsHost(t *testing.T) {
+	t.Parallel()
+
+	for host, expected := range map[string]bool{
+		"git-codecommit.us-east-1.amazonaws.com":      true,
+		"git-codecommit-fips.us-east-1.amazonaws.com": true,
+		"git-codecommit.cn-north-1.amazonaws.com.cn":  true,
+		"eu-west-1.console.aws.amazon.com":            true,
+		"console.aws.amazon.com":                      true,
+		"s3.us-east-1.amazonaws.com":                  false,
+		"git-codecommit.example.com":                  false,
+		"github.com":                                  false,
+	} {
+		require.Equalf(t, expected, IsHost(host), "host %q", host)
+	}
+}
@@ -0,0 +1,5 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+// Package common exposes helpers shared by all git-url providers.
+package common
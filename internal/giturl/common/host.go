@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"net"
+	"strings"
+)
+
+// defaultPorts maps URL schemes to their default port.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ssh":   "22",
+	"git":   "9418",
+}
+
+// NormalizeHost lowercases the host part of an URL and removes the port if it is the default one for the scheme.
+//
+// This way, URLs such as https://github.com:443/owner/repo and https://github.com/owner/repo compare equal.
+//
+// Schemes prefixed with "git+" (e.g. "git+https") are considered like the scheme without this prefix.
+func NormalizeHost(scheme, host string) string {
+	host = strings.ToLower(host)
+
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil || port == "" {
+		// no port
+		return host
+	}
+
+	scheme, _ = strings.CutPrefix(strings.ToLower(scheme), "git+")
+	if defaultPorts[scheme] != port {
+		return host
+	}
+
+	if strings.Contains(hostname, ":") {
+		// IPv6 literal
+		return "[" + hostname + "]"
+	}
+
+	return hostname
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		scheme   string
+		host     string
+		expected string
+	}{
+		{scheme: "https", host: "github.com", expected: "github.com"},
+		{scheme: "https", host: "GitHub.com", expected: "github.com"},
+		{scheme: "https", host: "github.com:443", expected: "github.com"},
+		{scheme: "git+https", host: "github.com:443", expected: "github.com"},
+		{scheme: "http", host: "github.com:80", expected: "github.com"},
+		{scheme: "ssh", host: "github.com:22", expected: "github.com"},
+		{scheme: "git", host: "github.com:9418", expected: "github.com"},
+		{scheme: "ssh", host: "github.com:443", expected: "github.com:443"},
+		{scheme: "https", host: "github.com:8443", expected: "github.com:8443"},
+		{scheme: "https", host: "[::1]:443", expected: "[::1]"},
+		{scheme: "https", host: "[::1]:8443", expected: "[::1]:8443"},
+		{scheme: "", host: "github.com:443", expected: "github.com:443"},
+		{scheme: "https", host: "", expected: ""},
+	} {
+		t.Run(tc.scheme+"://"+tc.host, func(t *testing.T) {
+			require.Equal(t, tc.expected, NormalizeHost(tc.scheme, tc.host))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+// DirEntry describes an entry of a folder, as listed by the API of a SCM provider.
+type DirEntry struct {
+	// Name is the base name of the entry.
+	Name string
+
+	// Path is the path of the entry, relative to the root of the repository.
+	Path string
+
+	// IsDir indicates that the entry is a folder.
+	IsDir bool
+
+	// Size is the size in bytes of a file, or 0 when unknown.
+	Size int64
+}
@@ -0,0 +1,269 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// RepoIndex is the number of path segments that designate a repository, e.g. "owner/repo".
+const RepoIndex = 2
+
+// Config describes the URL format of a SCM provider, for use by [Parse].
+type Config struct {
+	// DefaultScheme applies whenever the URL comes without a scheme.
+	DefaultScheme string
+
+	// DefaultHost applies whenever the URL comes without a host.
+	DefaultHost string
+
+	// RepoVersion accepts a version suffix on URLs to an entire repository, like in go modules,
+	// e.g. owner/repo@v1.2.3
+	RepoVersion bool
+
+	// NestedRepo accepts repositories nested in several namespaces, e.g. "group/subgroup/project" for gitlab:
+	// the repository spans all the segments before the [Layout] separator.
+	//
+	// The full path of the repository may also be escaped as a single segment, e.g. "group%2Fsubgroup%2Fproject".
+	NestedRepo bool
+
+	Layout
+}
+
+// Layout describes how the path of an URL is organized after the repository part,
+// e.g. "/blob/{ref}/{path}" for github.
+type Layout struct {
+	// Separator is an optional path segment expected between the repository and the keyword,
+	// e.g. "-" for gitlab.
+	Separator string
+
+	// Keywords maps the (lower case) keywords that may follow the repository, e.g. "blob" or "tree",
+	// to the way the remainder of the path is handled.
+	Keywords map[string]Keyword
+
+	// Rejected maps (lower case) keywords that designate unsupported resources to the reason why they are rejected,
+	// e.g. "compare".
+	Rejected map[string]string
+
+	// Err is the sentinel error wrapped by all parsing errors.
+	Err error
+}
+
+// Keyword describes how the path following a keyword is handled.
+type Keyword struct {
+	// IsTree indicates that the URL may designate a folder: a missing file path then designates the root of the repository.
+	IsTree bool
+
+	// RefTypes lists the (lower case) ref types expected before the ref, e.g. "branch" or "tag" for gitea.
+	RefTypes []string
+
+	// IgnorePath indicates that any path following the ref is ignored, e.g. for commit URLs.
+	IgnorePath bool
+
+	// PathMarker is an optional path segment expected between the ref and the path, e.g. "item" for sourcehut.
+	PathMarker string
+}
+
+// Parsed is the result of [Parse].
+type Parsed struct {
+	RepoURL *url.URL
+	Path    string
+	Version string
+}
+
+// Parse an URL according to the format of a SCM provider.
+func Parse(input *url.URL, cfg Config) (*Parsed, error) {
+	u := NormalizeURL(input, cfg.DefaultScheme, cfg.DefaultHost)
+	pth, parts := SplitEscapedPath(u)
+	repoParts, rest := cfg.splitRepoParts(parts)
+
+	if len(repoParts) < RepoIndex {
+		return nil, fmt.Errorf("expected the URL path component to contain at least %d parts, but got %q: %w", RepoIndex, pth, cfg.Err)
+	}
+
+	isEntireRepo := len(rest) == 0 || cfg.Separator != "" && len(rest) == 1 && rest[0] == cfg.Separator
+	repo, version := joinRepo(repoParts, isEntireRepo && cfg.RepoVersion)
+	u.Path = repo
+	u.RawPath = ""
+	ClearQuery(u)
+
+	if isEntireRepo {
+		return &Parsed{
+			RepoURL: u,
+			Path:    "/",
+			Version: version,
+		}, nil
+	}
+
+	ref, repoPath, err := cfg.ParseRef(pth, rest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parsed{
+		RepoURL: u,
+		Path:    repoPath,
+		Version: ref,
+	}, nil
+}
+
+// NormalizeURL clones an URL, applies default scheme and host, and normalizes the host.
+func NormalizeURL(input *url.URL, defaultScheme, defaultHost string) *url.URL {
+	u := &url.URL{}
+	*u = *input // shallow clone
+
+	if u.Scheme == "" {
+		u.Scheme = defaultScheme
+	}
+
+	if u.Hostname() == "" {
+		if u.Port() == "" {
+			u.Host = defaultHost
+		} else {
+			u.Host = defaultHost + ":" + u.Port()
+		}
+	}
+
+	u.Host = NormalizeHost(u.Scheme, u.Host)
+
+	return u
+}
+
+// SplitRepo splits the segments of an URL path into the repository (without ".git" suffix) and the remaining segments.
+//
+// If withVersion is true, a version suffix on the repository is extracted, e.g. owner/repo@v1.2.3.
+//
+// The caller must ensure that parts contains at least [RepoIndex] segments.
+func SplitRepo(parts []string, withVersion bool) (repo, version string, rest []string) {
+	repo, version = joinRepo(parts[:RepoIndex], withVersion)
+
+	return repo, version, parts[RepoIndex:]
+}
+
+// splitRepoParts splits the segments of an URL path into the segments designating the repository and the remaining segments.
+//
+// Unless [Config].NestedRepo is enabled, the repository is made of the first [RepoIndex] segments.
+func (cfg Config) splitRepoParts(parts []string) (repoParts, rest []string) {
+	if cfg.NestedRepo {
+		if end := slices.Index(parts, cfg.Separator); cfg.Separator != "" && end >= 0 {
+			return splitNamespaces(parts[:end]), parts[end:]
+		}
+
+		if len(parts) > 0 && strings.Contains(parts[0], "/") {
+			// an escaped full path, e.g. "group%2Fsubgroup%2Fproject"
+			return splitNamespaces(parts[:1]), parts[1:]
+		}
+	}
+
+	if len(parts) < RepoIndex {
+		return parts, nil
+	}
+
+	return parts[:RepoIndex], parts[RepoIndex:]
+}
+
+// splitNamespaces splits unescaped segments further on slashes, skipping empty segments.
+func splitNamespaces(parts []string) []string {
+	_, segments := SplitPath(strings.Join(parts, "/"))
+
+	return segments
+}
+
+// joinRepo joins the segments of a repository (without ".git" suffix).
+//
+// If withVersion is true, a version suffix on the repository is extracted, e.g. owner/repo@v1.2.3.
+func joinRepo(parts []string, withVersion bool) (repo, version string) {
+	repoParts := slices.Clone(parts)
+	if withVersion {
+		last := len(repoParts) - 1
+		repoParts[last], version, _ = strings.Cut(repoParts[last], "@")
+	}
+
+	repo = strings.Join(repoParts, "/")
+	repo = strings.TrimSuffix(repo, ".git")
+
+	return repo, version
+}
+
+// ClearQuery removes the query and the fragment from an URL.
+func ClearQuery(u *url.URL) {
+	u.RawFragment = ""
+	u.Fragment = ""
+	u.RawQuery = ""
+}
+
+// ParseRef parses the segments of an URL path that follow the repository, and yields the ref and the path in the repository.
+//
+// The pth argument is only used in error messages.
+func (l Layout) ParseRef(pth string, parts []string) (ref, repoPath string, err error) {
+	if l.Separator != "" {
+		if len(parts) == 0 || parts[0] != l.Separator {
+			return "", "", fmt.Errorf(`expected URL path to contain a %q separator: %w`, l.Separator, l.Err)
+		}
+
+		parts = parts[1:]
+	}
+
+	if len(parts) > 0 {
+		if reason, isRejected := l.Rejected[strings.ToLower(parts[0])]; isRejected {
+			return "", "", fmt.Errorf(`%s: %q: %w`, reason, pth, l.Err)
+		}
+	}
+
+	const neededParts = 2 // keyword and ref
+	if len(parts) < neededParts {
+		return "", "", fmt.Errorf(`expected URL path to contain at least %d parts but got %q: %w`, neededParts, pth, l.Err)
+	}
+
+	keyword, ok := l.Keywords[strings.ToLower(parts[0])]
+	if !ok {
+		return "", "", fmt.Errorf(`expected URL path to contain one of %v but got %q in %q: %w`, l.keywords(), parts[0], pth, l.Err)
+	}
+	parts = parts[1:]
+
+	if len(keyword.RefTypes) > 0 {
+		if len(parts) < neededParts {
+			return "", "", fmt.Errorf(`expected URL path to contain a ref type (%s) and a ref name but got %q: %w`, strings.Join(keyword.RefTypes, "/"), pth, l.Err)
+		}
+
+		if !slices.Contains(keyword.RefTypes, strings.ToLower(parts[0])) {
+			return "", "", fmt.Errorf(`expected URL path to contain one of %v but got %q in %q: %w`, keyword.RefTypes, parts[0], pth, l.Err)
+		}
+		parts = parts[1:]
+	}
+
+	ref = parts[0]
+	parts = parts[1:]
+
+	if keyword.PathMarker != "" && len(parts) > 0 && parts[0] == keyword.PathMarker {
+		parts = parts[1:]
+	}
+
+	if keyword.IgnorePath {
+		parts = nil
+	}
+
+	if len(parts) == 0 {
+		if !keyword.IsTree {
+			return "", "", fmt.Errorf(`expected URL path to contain a path to a file in %q: %w`, pth, l.Err)
+		}
+
+		return ref, "/", nil
+	}
+
+	return ref, strings.Join(parts, "/"), nil
+}
+
+func (l Layout) keywords() []string {
+	keywords := make([]string, 0, len(l.Keywords))
+	for keyword := range l.Keywords {
+		keywords = append(keywords, keyword)
+	}
+	slices.Sort(keywords)
+
+	return keywords
+}
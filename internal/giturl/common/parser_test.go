@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+var errTest = errors.New("test provider")
+
+var testConfig = Config{
+	DefaultScheme: "https",
+	DefaultHost:   "example.com",
+	RepoVersion:   true,
+	Layout: Layout{
+		Separator: "-",
+		Keywords: map[string]Keyword{
+			"blob":   {},
+			"tree":   {IsTree: true},
+			"commit": {IsTree: true, IgnorePath: true},
+			"src":    {IsTree: true, RefTypes: []string{"branch", "tag"}},
+			"browse": {IsTree: true, PathMarker: "item"},
+		},
+		Rejected: map[string]string{
+			"compare": "compare URLs are not supported",
+		},
+		Err: errTest,
+	},
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input       string
+		wantRepo    string
+		wantPath    string
+		wantVersion string
+		wantErr     bool
+	}{
+		{input: "https://example.com/owner/repo", wantRepo: "https://example.com/owner/repo", wantPath: "/"},
+		{input: "https://example.com/owner/repo.git", wantRepo: "https://example.com/owner/repo", wantPath: "/"},
+		{input: "https://example.com/owner/repo@v1.2.3", wantRepo: "https://example.com/owner/repo", wantPath: "/", wantVersion: "v1.2.3"},
+		{input: "https://example.com/owner/repo@v1.2.3/-", wantRepo: "https://example.com/owner/repo", wantPath: "/", wantVersion: "v1.2.3"},
+		{input: "owner/repo/-/blob/main/README.md", wantRepo: "https://example.com/owner/repo", wantPath: "README.md", wantVersion: "main"},
+		{input: "//:8443/owner/repo", wantRepo: "https://example.com:8443/owner/repo", wantPath: "/"},
+		{input: "https://Example.com:443/owner/repo/-/BLOB/main/docs/README.md?raw=true#L1", wantRepo: "https://example.com/owner/repo", wantPath: "docs/README.md", wantVersion: "main"},
+		{input: "https://example.com/owner/repo/-/tree/v1", wantRepo: "https://example.com/owner/repo", wantPath: "/", wantVersion: "v1"},
+		{input: "https://example.com/owner/repo/-/tree/v1/docs", wantRepo: "https://example.com/owner/repo", wantPath: "docs", wantVersion: "v1"},
+		{input: "https://example.com/owner/repo/-/commit/abc/docs", wantRepo: "https://example.com/owner/repo", wantPath: "/", wantVersion: "abc"},
+		{input: "https://example.com/owner/repo/-/src/tag/v1/README.md", wantRepo: "https://example.com/owner/repo", wantPath: "README.md", wantVersion: "v1"},
+		{input: "https://example.com/owner/repo/-/browse/v1/item/docs/README.md", wantRepo: "https://example.com/owner/repo", wantPath: "docs/README.md", wantVersion: "v1"},
+		{input: "https://example.com/owner/repo/-/browse/v1/docs", wantRepo: "https://example.com/owner/repo", wantPath: "docs", wantVersion: "v1"},
+		{input: "https://example.com/owner", wantErr: true},
+		{input: "https://example.com/owner/repo/blob/main/README.md", wantErr: true},     // missing separator
+		{input: "https://example.com/owner/repo/-/blob/main", wantErr: true},             // missing file
+		{input: "https://example.com/owner/repo/-/blob", wantErr: true},                  // missing ref
+		{input: "https://example.com/owner/repo/-/wiki/main/page", wantErr: true},        // unknown keyword
+		{input: "https://example.com/owner/repo/-/compare/v1...v2", wantErr: true},       // rejected keyword
+		{input: "https://example.com/owner/repo/-/src/commit/abc/README", wantErr: true}, // unknown ref type
+		{input: "https://example.com/owner/repo/-/src/branch", wantErr: true},            // missing ref after ref type
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.input)
+			require.NoError(t, err)
+
+			parsed, err := Parse(u, testConfig)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.ErrorIs(t, err, errTest)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRepo, parsed.RepoURL.String())
+			require.Equal(t, tc.wantPath, parsed.Path)
+			require.Equal(t, tc.wantVersion, parsed.Version)
+		})
+	}
+
+	t.Run("should not mutate the input URL", func(t *testing.T) {
+		t.Parallel()
+
+		const input = "owner/repo@v1/-/blob/main/README.md?x=y"
+		u, err := url.Parse(input)
+		require.NoError(t, err)
+
+		_, err = Parse(u, testConfig)
+		require.NoError(t, err)
+		require.Equal(t, input, u.String())
+	})
+}
+
+func TestParseNestedRepo(t *testing.T) {
+	t.Parallel()
+
+	nested := testConfig
+	nested.NestedRepo = true
+
+	for _, tc := range []struct {
+		input       string
+		wantRepo    string
+		wantPath    string
+		wantVersion string
+		wantErr     bool
+	}{
+		{input: "https://example.com/owner/repo", wantRepo: "https://example.com/owner/repo", wantPath: "/"},
+		{input: "https://example.com/group/sub/repo/-/blob/main/README.md", wantRepo: "https://example.com/group/sub/repo", wantPath: "README.md", wantVersion: "main"},
+		{input: "https://example.com/group%2Fsub%2Frepo/-/blob/main/README.md", wantRepo: "https://example.com/group/sub/repo", wantPath: "README.md", wantVersion: "main"},
+		{input: "https://example.com/group%2Fsub/repo/-/tree/v1", wantRepo: "https://example.com/group/sub/repo", wantPath: "/", wantVersion: "v1"},
+		{input: "https://example.com/group/sub/repo.git@v1/-", wantRepo: "https://example.com/group/sub/repo", wantPath: "/", wantVersion: "v1"},
+		{input: "https://example.com/group%2Fsub%2Frepo", wantRepo: "https://example.com/group/sub/repo", wantPath: "/"},
+		{input: "https://example.com/owner%2Frepo/-/blob/release%2Fv1/README.md", wantRepo: "https://example.com/owner/repo", wantPath: "README.md", wantVersion: "release/v1"},
+		{input: "https://example.com/group/sub/repo", wantErr: true},                 // ambiguous without separator
+		{input: "https://example.com/owner%2F/-/blob/main/README.md", wantErr: true}, // single namespace
+		{input: "https://example.com/group%2Fsub%2Frepo/blob/main", wantErr: true},   // missing separator
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.input)
+			require.NoError(t, err)
+
+			parsed, err := Parse(u, nested)
+			if tc.wantErr {
+				require.ErrorIs(t, err, errTest)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRepo, parsed.RepoURL.String())
+			require.Equal(t, tc.wantPath, parsed.Path)
+			require.Equal(t, tc.wantVersion, parsed.Version)
+		})
+	}
+}
+
+func TestSplitRepo(t *testing.T) {
+	t.Parallel()
+
+	parts := []string{"owner", "repo.git@v1", "blob", "main"}
+
+	repo, version, rest := SplitRepo(parts, false)
+	require.Equal(t, "owner/repo.git@v1", repo)
+	require.Empty(t, version)
+	require.Equal(t, []string{"blob", "main"}, rest)
+
+	repo, version, rest = SplitRepo(parts, true)
+	require.Equal(t, "owner/repo", repo)
+	require.Equal(t, "v1", version)
+	require.Equal(t, []string{"blob", "main"}, rest)
+	require.Equal(t, "repo.git@v1", parts[1], "the input segments should not be altered")
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SplitPath splits the path component of an URL into its non-empty segments.
+//
+// Leading and trailing slashes are trimmed first, so that URLs such as https://github.com/owner/repo/
+// and https://github.com/owner/repo yield the same segments. Empty segments (e.g. "owner//repo") are skipped.
+//
+// It returns the trimmed path, to be used in error messages, and the segments.
+func SplitPath(pth string) (string, []string) {
+	trimmed := strings.Trim(pth, "/")
+	parts := strings.Split(trimmed, "/")
+
+	segments := parts[:0]
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		segments = append(segments, part)
+	}
+
+	return trimmed, segments
+}
+
+// SplitEscapedPath splits the path component of an URL into its non-empty, unescaped segments, like [SplitPath].
+//
+// Unlike [SplitPath], escaped slashes ("%2F") do not split segments, so that a ref containing slashes
+// may be designated unambiguously, e.g. "release%2F2024.01" for the tag "release/2024.01".
+func SplitEscapedPath(u *url.URL) (string, []string) {
+	trimmed, parts := SplitPath(u.EscapedPath())
+
+	for i, part := range parts {
+		unescaped, err := url.PathUnescape(part)
+		if err != nil {
+			// not expected from a parsed URL
+			return SplitPath(u.Path)
+		}
+
+		parts[i] = unescaped
+	}
+
+	if unescaped, err := url.PathUnescape(trimmed); err == nil {
+		trimmed = unescaped
+	}
+
+	return trimmed, parts
+}
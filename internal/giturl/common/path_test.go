@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestSplitPath(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input        string
+		wantTrimmed  string
+		wantSegments []string
+	}{
+		{"", "", []string{}},
+		{"/", "", []string{}},
+		{"/owner/repo", "owner/repo", []string{"owner", "repo"}},
+		{"/owner/repo/", "owner/repo", []string{"owner", "repo"}},
+		{"/owner/repo//", "owner/repo", []string{"owner", "repo"}},
+		{"owner//repo/blob/main/", "owner//repo/blob/main", []string{"owner", "repo", "blob", "main"}},
+	} {
+		trimmed, segments := SplitPath(tc.input)
+		require.Equal(t, tc.wantTrimmed, trimmed)
+		require.Equal(t, tc.wantSegments, segments)
+	}
+}
+
+func TestSplitEscapedPath(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input        string
+		wantTrimmed  string
+		wantSegments []string
+	}{
+		{"https://example.com/owner/repo", "owner/repo", []string{"owner", "repo"}},
+		{"https://example.com/owner/repo/src/tag/release%2F2024.01/docs/README.md", "owner/repo/src/tag/release/2024.01/docs/README.md", []string{"owner", "repo", "src", "tag", "release/2024.01", "docs", "README.md"}},
+		{"https://example.com/owner/repo/blob/main/my%20file.md", "owner/repo/blob/main/my file.md", []string{"owner", "repo", "blob", "main", "my file.md"}},
+	} {
+		u, err := url.Parse(tc.input)
+		require.NoError(t, err)
+
+		trimmed, segments := SplitEscapedPath(u)
+		require.Equal(t, tc.wantTrimmed, trimmed)
+		require.Equal(t, tc.wantSegments, segments)
+	}
+}
@@ -13,6 +13,6 @@ const (
 	// ErrUnknownProvider is raised whenever a URL cannot be associated with a well-known SCM provider.
 	ErrUnknownProvider providerError = "unrecognized git-url provider in URL"
 
-	// ErrNotImplementedProvider is currently raised for the azure provider.
+	// ErrNotImplementedProvider is raised when a detected provider does not support an operation, e.g. raw-content URLs for azure.
 	ErrNotImplementedProvider providerError = "provider is detected but not implemented yet"
 )
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
+)
+
+// Listing returns the URL of the gitea contents API that lists a folder, for a [Locator] hosted on a Gitea instance.
+//
+// Only https URL's are supported.
+//
+// Example:
+//
+//   - https://gitea.com/api/v1/repos/fredbi/go-vcsfetch/contents/docs?ref=master
+func Listing(locator Locator) (*url.URL, error) {
+	repo := locator.RepoURL()
+	scheme, _ := strings.CutPrefix(repo.Scheme, "git+")
+	if scheme != "https" {
+		return nil, fmt.Errorf("returning a listing url requires a https URL scheme: %w", ErrGitea)
+	}
+
+	u := &url.URL{
+		Scheme: scheme,
+		Host:   repo.Host,
+		Path: path.Join(
+			"/api/v1/repos", strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git"),
+			"contents", strings.Trim(locator.Path(), "/"),
+		),
+	}
+
+	if version := locator.Version(); version != "" {
+		u.RawQuery = url.Values{"ref": []string{version}}.Encode()
+	}
+
+	return u, nil
+}
+
+type contentEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+}
+
+// DecodeListing decodes the response of the gitea contents API for a folder.
+func DecodeListing(r io.Reader) ([]common.DirEntry, error) {
+	var contents []contentEntry
+	if err := json.NewDecoder(r).Decode(&contents); err != nil {
+		return nil, fmt.Errorf("unexpected response from the contents API: %w: %w", err, ErrGitea)
+	}
+
+	entries := make([]common.DirEntry, 0, len(contents))
+	for _, content := range contents {
+		isDir := content.Type == "dir"
+		entry := common.DirEntry{
+			Name:  content.Name,
+			Path:  content.Path,
+			IsDir: isDir,
+		}
+		if !isDir {
+			entry.Size = content.Size
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
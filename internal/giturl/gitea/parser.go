@@ -4,9 +4,9 @@
 package gitea
 
 import (
-	"fmt"
 	"net/url"
-	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
 )
 
 // URL is a gitea-style URL to a vcs resource hosted by gitea SCM.
@@ -21,6 +21,21 @@ const (
 	defaultHost   = "gitea.com"
 )
 
+// config describes gitea URLs: "src" or "raw", then "branch", "tag" or "commit", then the ref.
+var config = common.Config{
+	DefaultScheme: defaultScheme,
+	DefaultHost:   defaultHost,
+	Layout: common.Layout{
+		Keywords: map[string]common.Keyword{
+			"src": {IsTree: true, RefTypes: refTypes},
+			"raw": {IsTree: true, RefTypes: refTypes},
+		},
+		Err: ErrGitea,
+	},
+}
+
+var refTypes = []string{"branch", "tag", "commit"}
+
 // Parse a gitea URL.
 //
 // Gitea URL formats:
@@ -28,112 +43,17 @@ const (
 //   - Raw: https://gitea.com/{owner}/{repo}/raw/branch/{ref}/{path}
 //   - Repo: https://gitea.com/{owner}/{repo}
 func Parse(giteaURL *url.URL) (*URL, error) {
-	u := &url.URL{}
-	*u = *giteaURL // shallow clone
-
-	if u.Scheme == "" {
-		u.Scheme = defaultScheme
-	}
-
-	if u.Hostname() == "" {
-		if u.Port() == "" {
-			u.Host = defaultHost
-		} else {
-			u.Host = defaultHost + ":" + u.Port()
-		}
-	}
-
-	u.Host = strings.ToLower(u.Host)
-	pth := strings.Trim(u.Path, "/")
-
-	const (
-		repoIndex = 2
-	)
-
-	parts := strings.Split(pth, "/")
-	if len(parts) < repoIndex {
-		return nil, fmt.Errorf("expected the URL path component to contain at least %d parts, but got %q: %w", repoIndex, pth, ErrGitea)
-	}
-
-	repo := strings.Join(parts[:repoIndex], "/")
-	repo = strings.TrimSuffix(repo, ".git")
-	u.Path = repo
-
-	if len(parts) == repoIndex {
-		// entire repo
-		u.RawFragment = ""
-		u.Fragment = ""
-		u.RawQuery = ""
-
-		gt := &URL{
-			repoURL: u,
-			path:    "/",
-			version: "",
-		}
-
-		return gt, nil
+	parsed, err := common.Parse(giteaURL, config)
+	if err != nil {
+		return nil, err
 	}
 
-	parts = parts[repoIndex:]
-
-	var (
-		ref    string
-		isTree bool
-	)
-
-	// Gitea uses "src" or "raw" as first part
-	const neededPartsAfterRepo = 2
-	if len(parts) < neededPartsAfterRepo {
-		return nil, fmt.Errorf(`expected URL path to contain at least %d parts after repo but got %q: %w`, neededPartsAfterRepo, pth, ErrGitea)
-	}
-
-	discriminator := strings.ToLower(parts[0])
-	switch discriminator {
-	case "src":
-		// Browse URL: /src/branch/{ref}/{path}
-	case "raw":
-		// Raw URL: /raw/branch/{ref}/{path}
-	default:
-		return nil, fmt.Errorf(`expected URL path to contain "src" or "raw" but got %q in %q: %w`, parts[0], pth, ErrGitea)
-	}
-
-	parts = parts[1:]
-
-	// Next part should be "branch", "tag", "commit"
-	const minExpectedParts = 2
-	if len(parts) < minExpectedParts {
-		return nil, fmt.Errorf(`expected URL path to contain ref type (branch/tag/commit) and ref name but got %q: %w`, pth, ErrGitea)
-	}
-
-	refType := strings.ToLower(parts[0])
-	switch refType {
-	case "branch", "tag", "commit":
-		// Valid ref types
-		ref = parts[1]
-		parts = parts[2:]
-	default:
-		return nil, fmt.Errorf(`expected URL path to contain "branch", "tag", or "commit" but got %q in %q: %w`, parts[0], pth, ErrGitea)
-	}
-
-	if len(parts) == 0 {
-		// No file path - this is a tree view
-		isTree = true
-		parts = []string{"/"}
-	}
-
-	repoPath := strings.Join(parts, "/")
-	u.RawFragment = ""
-	u.Fragment = ""
-	u.RawQuery = ""
-
 	gt := &URL{
-		repoURL: u,
-		path:    repoPath,
-		version: ref,
+		repoURL: parsed.RepoURL,
+		path:    parsed.Path,
+		version: parsed.Version,
 	}
 
-	_ = isTree // may be used for validation in the future
-
 	return gt, nil
 }
 
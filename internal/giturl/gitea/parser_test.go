@@ -60,6 +60,30 @@ func TestParse(t *testing.T) {
 			wantPath:    "LICENSE",
 			wantErr:     false,
 		},
+		{
+			name:        "gitea.com with escaped slashed tag",
+			input:       "https://gitea.com/owner/repo/src/tag/release%2F2024.01/docs/README.md",
+			wantRepo:    "https://gitea.com/owner/repo",
+			wantVersion: "release/2024.01",
+			wantPath:    "docs/README.md",
+			wantErr:     false,
+		},
+		{
+			name:        "gitea.com with unescaped slashed tag (resolved against the remote refs when fetching)",
+			input:       "https://gitea.com/owner/repo/raw/tag/release/2024.01/docs/README.md",
+			wantRepo:    "https://gitea.com/owner/repo",
+			wantVersion: "release",
+			wantPath:    "2024.01/docs/README.md",
+			wantErr:     false,
+		},
+		{
+			name:        "gitea.com with escaped slashed branch",
+			input:       "https://gitea.com/owner/repo/src/branch/feature%2Fx/main.go",
+			wantRepo:    "https://gitea.com/owner/repo",
+			wantVersion: "feature/x",
+			wantPath:    "main.go",
+			wantErr:     false,
+		},
 		{
 			name:        "gitea.com with commit",
 			input:       "https://gitea.com/owner/repo/src/commit/abc123/file.txt",
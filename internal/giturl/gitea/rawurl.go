@@ -21,6 +21,10 @@ type Locator interface {
 	Version() string
 }
 
+// RawSupportsHEAD tells that the raw endpoint of gitea does not resolve "HEAD":
+// the "raw/branch" route expects the name of an actual branch.
+const RawSupportsHEAD = false
+
 // Raw returns the raw content URL for a [Locator] hosted on a Gitea instance.
 //
 // Only https URL's are supported.
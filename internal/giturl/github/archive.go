@@ -0,0 +1,71 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Archive returns the codeload URL to download a tarball of the entire repository
+// for a [Locator] hosted on github.com.
+//
+// An explicit version is required.
+//
+// Example:
+//
+//   - https://codeload.github.com/fredbi/go-vcsfetch/tar.gz/v1.0.0
+func Archive(locator Locator) (*url.URL, error) {
+	repo := locator.RepoURL()
+	version := locator.Version()
+	if version == "" {
+		return nil, fmt.Errorf("returning an archive url requires an explicit version: %w", ErrGithub)
+	}
+
+	scheme, _ := strings.CutPrefix(repo.Scheme, "git+")
+	if scheme != "https" {
+		return nil, fmt.Errorf("returning an archive url requires a https URL scheme: %w", ErrGithub)
+	}
+
+	if host := repo.Host; host != defaultHost && host != codeloadHost {
+		return nil, fmt.Errorf("no way to guess the archive host for github not hosted by github.com: %q: %w", host, ErrGithub)
+	}
+
+	return &url.URL{
+		Scheme: scheme,
+		Host:   codeloadHost,
+		Path:   path.Join("/", strings.TrimSuffix(repo.Path, ".git"), "tar.gz", version),
+	}, nil
+}
+
+// ZipArchive returns the URL to download a zip archive of the entire repository
+// for a [Locator] hosted on github.com.
+//
+// An explicit version is required: github resolves it as a branch, a tag or a commit.
+// All the entries of the archive are located under a top-level folder, e.g. "go-vcsfetch-1.0.0/".
+//
+// Example:
+//
+//   - https://github.com/fredbi/go-vcsfetch/archive/v1.0.0.zip
+func ZipArchive(locator Locator) (*url.URL, error) {
+	repo := locator.RepoURL()
+	version := locator.Version()
+	if version == "" {
+		return nil, fmt.Errorf("returning an archive url requires an explicit version: %w", ErrGithub)
+	}
+
+	scheme, _ := strings.CutPrefix(repo.Scheme, "git+")
+	if scheme != "https" {
+		return nil, fmt.Errorf("returning an archive url requires a https URL scheme: %w", ErrGithub)
+	}
+
+	if host := repo.Host; host != defaultHost && host != codeloadHost {
+		return nil, fmt.Errorf("no way to guess the archive host for github not hosted by github.com: %q: %w", host, ErrGithub)
+	}
+
+	return &url.URL{
+		Scheme: scheme,
+		Host:   defaultHost,
+		Path:   path.Join("/", strings.TrimSuffix(repo.Path, ".git"), "archive", version+".zip"),
+	}, nil
+}
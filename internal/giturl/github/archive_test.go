@@ -0,0 +1,99 @@
+package github
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should build a codeload URL", func(t *testing.T) {
+		for _, input := range []string{
+			"https://github.com/fredbi/go-vcsfetch/archive/refs/tags/v1.0.0.tar.gz",
+			"https://codeload.github.com/fredbi/go-vcsfetch/tar.gz/v1.0.0",
+			"https://github.com/fredbi/go-vcsfetch.git@v1.0.0",
+		} {
+			u, err := url.Parse(input)
+			require.NoError(t, err)
+
+			gh, err := Parse(u)
+			require.NoError(t, err)
+
+			archive, err := Archive(gh)
+			require.NoError(t, err)
+			require.Equal(t, "https://codeload.github.com/fredbi/go-vcsfetch/tar.gz/v1.0.0", archive.String())
+		}
+	})
+
+	t.Run("should flag archive URLs", func(t *testing.T) {
+		u, err := url.Parse("https://github.com/fredbi/go-vcsfetch/archive/refs/tags/v1.0.0.tar.gz")
+		require.NoError(t, err)
+		gh, err := Parse(u)
+		require.NoError(t, err)
+		require.True(t, gh.IsArchive())
+
+		u, err = url.Parse("https://github.com/fredbi/go-vcsfetch/tree/v1.0.0")
+		require.NoError(t, err)
+		gh, err = Parse(u)
+		require.NoError(t, err)
+		require.False(t, gh.IsArchive())
+	})
+
+	t.Run("should NOT build a codeload URL", func(t *testing.T) {
+		for _, input := range []string{
+			"https://github.com/fredbi/go-vcsfetch",                         // no version
+			"ssh://git@github.com/fredbi/go-vcsfetch@v1.0.0",                // not https
+			"https://github.example.com/fredbi/go-vcsfetch/tree/v1.0.0/pkg", // github enterprise
+		} {
+			u, err := url.Parse(input)
+			require.NoError(t, err)
+
+			gh, err := Parse(u)
+			require.NoError(t, err)
+
+			_, err = Archive(gh)
+			require.ErrorIs(t, err, ErrGithub)
+		}
+	})
+}
+
+func TestZipArchive(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should build a zip archive URL", func(t *testing.T) {
+		for _, input := range []string{
+			"https://github.com/fredbi/go-vcsfetch/tree/v1.0.0",
+			"https://github.com/fredbi/go-vcsfetch.git@v1.0.0",
+		} {
+			u, err := url.Parse(input)
+			require.NoError(t, err)
+
+			gh, err := Parse(u)
+			require.NoError(t, err)
+
+			archive, err := ZipArchive(gh)
+			require.NoError(t, err)
+			require.Equal(t, "https://github.com/fredbi/go-vcsfetch/archive/v1.0.0.zip", archive.String())
+		}
+	})
+
+	t.Run("should NOT build a zip archive URL", func(t *testing.T) {
+		for _, input := range []string{
+			"https://github.com/fredbi/go-vcsfetch",                         // no version
+			"ssh://git@github.com/fredbi/go-vcsfetch@v1.0.0",                // not https
+			"https://github.example.com/fredbi/go-vcsfetch/tree/v1.0.0/pkg", // github enterprise
+		} {
+			u, err := url.Parse(input)
+			require.NoError(t, err)
+
+			gh, err := Parse(u)
+			require.NoError(t, err)
+
+			_, err = ZipArchive(gh)
+			require.ErrorIs(t, err, ErrGithub)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package github
+
+import (
+	"net/url"
+	"testing"
+)
+
+// FuzzGithubParse asserts that the parser never panics, whatever the input.
+//
+// The seed corpus in testdata/fuzz is made of the URLs used by the unit tests.
+func FuzzGithubParse(f *testing.F) {
+	f.Fuzz(func(t *testing.T, input string) {
+		u, err := url.Parse(input)
+		if err != nil {
+			return
+		}
+
+		parsed, err := Parse(u)
+		if err != nil {
+			return
+		}
+
+		if parsed.RepoURL() == nil {
+			t.Fatalf("expected a non-nil repo URL for %q", input)
+		}
+
+		_, _ = Raw(parsed)
+	})
+}
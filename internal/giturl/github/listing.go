@@ -0,0 +1,103 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
+)
+
+const apiHost = "api.github.com"
+
+// Listing returns the URL of the github contents API that lists a folder, for a [Locator] hosted on github.com.
+//
+// Only https URL's are supported.
+//
+// For Github Enterprise, there is no way to guess the API host: this only works on github.com
+//
+// Example:
+//
+//   - https://api.github.com/repos/fredbi/go-vcsfetch/contents/docs?ref=master
+func Listing(locator Locator) (*url.URL, error) {
+	return contentsURL(locator)
+}
+
+// Contents returns the URL of the github contents API that retrieves a file, for a [Locator] hosted on github.com.
+//
+// Only https URL's are supported.
+//
+// For Github Enterprise, there is no way to guess the API host: this only works on github.com
+//
+// Example:
+//
+//   - https://api.github.com/repos/fredbi/go-vcsfetch/contents/README.md?ref=master
+func Contents(locator Locator) (*url.URL, error) {
+	if strings.Trim(locator.Path(), "/") == "" {
+		return nil, fmt.Errorf("returning a contents url requires a non empty path to a file: %w", ErrGithub)
+	}
+
+	return contentsURL(locator)
+}
+
+func contentsURL(locator Locator) (*url.URL, error) {
+	repo := locator.RepoURL()
+	scheme, _ := strings.CutPrefix(repo.Scheme, "git+")
+	if scheme != "https" {
+		return nil, fmt.Errorf("returning a contents API url requires a https URL scheme: %w", ErrGithub)
+	}
+
+	if host := repo.Hostname(); host != defaultHost {
+		return nil, fmt.Errorf("no way to guess the API host for github not hosted by github.com: %q: %w", host, ErrGithub)
+	}
+
+	u := &url.URL{
+		Scheme: scheme,
+		Host:   apiHost,
+		Path: path.Join(
+			"/repos", strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git"),
+			"contents", strings.Trim(locator.Path(), "/"),
+		),
+	}
+
+	if version := locator.Version(); version != "" && version != "HEAD" { // the API defaults to the default branch
+		u.RawQuery = url.Values{"ref": []string{version}}.Encode()
+	}
+
+	return u, nil
+}
+
+type contentEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+}
+
+// DecodeListing decodes the response of the github contents API for a folder.
+func DecodeListing(r io.Reader) ([]common.DirEntry, error) {
+	var contents []contentEntry
+	if err := json.NewDecoder(r).Decode(&contents); err != nil {
+		return nil, fmt.Errorf("unexpected response from the contents API: %w: %w", err, ErrGithub)
+	}
+
+	entries := make([]common.DirEntry, 0, len(contents))
+	for _, content := range contents {
+		isDir := content.Type == "dir"
+		entry := common.DirEntry{
+			Name:  content.Name,
+			Path:  content.Path,
+			IsDir: isDir,
+		}
+		if !isDir {
+			entry.Size = content.Size
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
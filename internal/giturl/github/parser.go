@@ -4,144 +4,199 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
 )
 
 // URL is a github-style URL to a vcs resource hosted by github SCM.
 type URL struct {
-	repoURL *url.URL
-	path    string
-	version string
+	repoURL   *url.URL
+	path      string
+	version   string
+	isArchive bool
 }
 
 const (
 	defaultScheme = "https"
 	defaultHost   = "github.com"
 	rawHost       = "raw.githubusercontent.com"
+	codeloadHost  = "codeload.github.com"
 )
 
 // Parse a github URL.
 func Parse(githubURL *url.URL) (*URL, error) {
-	u := &url.URL{}
-	*u = *githubURL // shallow clone
-
-	if u.Scheme == "" {
-		u.Scheme = defaultScheme
-	}
+	u := common.NormalizeURL(githubURL, defaultScheme, defaultHost)
+	isRaw := strings.HasPrefix(u.Host, "raw")
+	isCodeload := u.Host == codeloadHost
+	pth, parts := common.SplitEscapedPath(u)
 
-	if u.Hostname() == "" {
-		if u.Port() == "" {
-			u.Host = defaultHost
-		} else {
-			u.Host = defaultHost + ":" + u.Port()
-		}
+	if len(parts) < common.RepoIndex {
+		return nil, fmt.Errorf("expected the URL path component to contain at least %d parts, but got %q: %w", common.RepoIndex, pth, ErrGithub)
 	}
 
-	u.Host = strings.ToLower(u.Host)
-	isRaw := strings.HasPrefix(strings.ToLower(u.Host), "raw")
-	pth := strings.Trim(u.Path, "/")
-
-	const (
-		repoIndex = 2
-		refIndex  = 4
-	)
-
-	parts := strings.Split(pth, "/")
-	if len(parts) < repoIndex {
-		return nil, fmt.Errorf("expected the URL path component to contain at least %d parts, but got %q: %w", repoIndex, pth, ErrGithub)
-	}
-
-	repo := strings.Join(parts[:repoIndex], "/")
-	repo = strings.TrimSuffix(repo, ".git")
+	isEntireRepo := len(parts) == common.RepoIndex
+	repo, repoVersion, parts := common.SplitRepo(parts, isEntireRepo && !isRaw && !isCodeload)
 	u.Path = repo
+	u.RawPath = ""
 
-	if len(parts) == repoIndex {
+	if isEntireRepo {
 		if isRaw {
 			return nil, fmt.Errorf(`expected raw content URL path to contain "refs" but got empty path: %w`, ErrGithub)
 		}
 
+		if isCodeload {
+			return nil, fmt.Errorf(`expected archive URL path to contain a ref but got empty path: %w`, ErrGithub)
+		}
+
 		// entire repo
-		u.RawFragment = ""
-		u.Fragment = ""
-		u.RawQuery = ""
+		common.ClearQuery(u)
 
 		gh := &URL{
 			repoURL: u,
 			path:    "/",
-			version: "",
+			version: repoVersion,
 		}
 
 		return gh, nil
 	}
 
-	parts = parts[repoIndex:]
+	if isCodeload || strings.EqualFold(parts[0], "archive") {
+		return parseArchive(u, parts, isCodeload)
+	}
 
 	var (
-		ref    string
-		isTree bool
+		ref      string
+		repoPath string
+		err      error
 	)
 
 	if isRaw {
-		discriminator := strings.ToLower(parts[0])
-		switch discriminator {
-		case "refs":
-			const neededPartsForRaw = 3
-			if len(parts) < neededPartsForRaw {
-				return nil, fmt.Errorf(`expected raw content URL path to contain at least %d parts but got %q: %w`, neededPartsForRaw, pth, ErrGithub)
-			}
-			// skip parts[1] (e.g. "heads")
-			ref = parts[2]
-			parts = parts[3:]
-
-		case "blob", "tree": // not sure how github behaves if there is a branch or a tag called "blob" or "tree"...
-			return nil, fmt.Errorf(`expected raw content URL path to contain "refs" but got %q in %q: %w`, parts[0], pth, ErrGithub)
-		default:
-			// parts[0] is the ref
-			const neededPartsForRaw = 2
-			if len(parts) < neededPartsForRaw {
-				return nil, fmt.Errorf(`expected raw content URL path to contain at least %d parts but got %q: %w`, neededPartsForRaw, pth, ErrGithub)
-			}
-			ref = parts[0]
-			parts = parts[1:]
-		}
+		ref, repoPath, err = parseRaw(pth, parts)
 	} else {
-		const neededPartsForBlob = 2
-		if len(parts) < neededPartsForBlob {
-			return nil, fmt.Errorf(`expected URL path to contain at least %d parts but got %q: %w`, neededPartsForBlob, pth, ErrGithub)
-		}
+		ref, repoPath, err = layout.ParseRef(pth, parts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	common.ClearQuery(u)
 
-		switch strings.ToLower(parts[0]) {
-		case "blob":
-		case "tree":
-			isTree = true
-		default:
-			return nil, fmt.Errorf(`expected URL path to contain "blob" or "tree" but got %q in %q: %w`, parts[0], pth, ErrGithub)
+	gh := &URL{
+		repoURL: u,
+		path:    repoPath,
+		version: ref,
+	}
+
+	return gh, nil
+}
+
+// layout describes github URLs to browse a repository: "blob", "tree" or "commit", then the ref.
+var layout = common.Layout{
+	Keywords: map[string]common.Keyword{
+		"blob": {},
+		"tree": {IsTree: true},
+		// e.g. /commit/<sha> or /commits/main: the whole tree at this ref.
+		// Any trailing path only filters the commit history on github, so it is ignored.
+		"commit":  {IsTree: true, IgnorePath: true},
+		"commits": {IsTree: true, IgnorePath: true},
+	},
+	Rejected: map[string]string{
+		"compare": "compare URLs designate a range of commits, not a single ref",
+	},
+	Err: ErrGithub,
+}
+
+// parseRaw parses the path of a raw content URL, after the repo part.
+//
+// Examples:
+//
+//   - https://raw.githubusercontent.com/fredbi/go-vcsfetch/refs/heads/master/README.md
+//   - https://raw.githubusercontent.com/fredbi/go-vcsfetch/master/README.md
+func parseRaw(pth string, parts []string) (ref, repoPath string, err error) {
+	discriminator := strings.ToLower(parts[0])
+	switch discriminator {
+	case "refs":
+		const neededPartsForRaw = 3
+		if len(parts) < neededPartsForRaw {
+			return "", "", fmt.Errorf(`expected raw content URL path to contain at least %d parts but got %q: %w`, neededPartsForRaw, pth, ErrGithub)
 		}
-		ref = parts[1]
-		parts = parts[2:]
+		// skip parts[1] (e.g. "heads")
+		ref = parts[2]
+		parts = parts[3:]
+
+	case "blob", "tree": // not sure how github behaves if there is a branch or a tag called "blob" or "tree"...
+		return "", "", fmt.Errorf(`expected raw content URL path to contain "refs" but got %q in %q: %w`, parts[0], pth, ErrGithub)
+	default:
+		// parts[0] is the ref
+		const neededPartsForRaw = 2
+		if len(parts) < neededPartsForRaw {
+			return "", "", fmt.Errorf(`expected raw content URL path to contain at least %d parts but got %q: %w`, neededPartsForRaw, pth, ErrGithub)
+		}
+		ref = parts[0]
+		parts = parts[1:]
 	}
 
 	if len(parts) == 0 {
-		if !isTree {
-			return nil, fmt.Errorf(`expected URL path to contain at least %d parts in %q: %w`, refIndex, pth, ErrGithub)
+		return "", "", fmt.Errorf(`expected raw content URL path to contain a path to a file in %q: %w`, pth, ErrGithub)
+	}
+
+	return ref, strings.Join(parts, "/"), nil
+}
+
+// parseArchive parses the path of a repository archive URL, after the repo part.
+//
+// Examples:
+//
+//   - https://github.com/fredbi/go-vcsfetch/archive/refs/tags/v1.0.0.tar.gz
+//   - https://github.com/fredbi/go-vcsfetch/archive/master.zip
+//   - https://codeload.github.com/fredbi/go-vcsfetch/tar.gz/refs/tags/v1.0.0
+func parseArchive(u *url.URL, parts []string, isCodeload bool) (*URL, error) {
+	const neededPartsForArchive = 2
+	if len(parts) < neededPartsForArchive {
+		return nil, fmt.Errorf(`expected archive URL path to contain a ref but got %q: %w`, strings.Join(parts, "/"), ErrGithub)
+	}
+
+	ref := strings.Join(parts[1:], "/")
+	if isCodeload {
+		u.Host = defaultHost // the archive is served by codeload, the repo is on github.com
+	} else {
+		var isSupported bool
+		for _, ext := range archiveExtensions {
+			var found bool
+			if ref, found = strings.CutSuffix(ref, ext); found {
+				isSupported = true
+				break
+			}
 		}
 
-		parts = []string{"/"}
+		if !isSupported {
+			return nil, fmt.Errorf(`expected archive URL path to end with one of %v but got %q: %w`, archiveExtensions, ref, ErrGithub)
+		}
 	}
 
-	repoPath := strings.Join(parts, "/")
-	u.RawFragment = ""
-	u.Fragment = ""
-	u.RawQuery = ""
+	for _, prefix := range []string{"refs/tags/", "refs/heads/"} {
+		if trimmed, found := strings.CutPrefix(ref, prefix); found {
+			ref = trimmed
+			break
+		}
+	}
 
-	gh := &URL{
-		repoURL: u,
-		path:    repoPath,
-		version: ref,
+	if ref == "" {
+		return nil, fmt.Errorf(`expected archive URL path to contain a ref: %w`, ErrGithub)
 	}
 
-	return gh, nil
+	common.ClearQuery(u)
+
+	return &URL{
+		repoURL:   u,
+		path:      "/",
+		version:   ref,
+		isArchive: true,
+	}, nil
 }
 
+var archiveExtensions = []string{".tar.gz", ".zip"}
+
 // RepoURL yields the base URL of the vcs repository,
 // e.g. https://github.com/fredbi/go-vcsfetcher
 func (gh *URL) RepoURL() *url.URL {
@@ -159,3 +214,9 @@ func (gh *URL) Version() string {
 func (gh *URL) Path() string {
 	return gh.path
 }
+
+// IsArchive indicates if the URL designates an archive of the entire repository,
+// e.g. https://github.com/fredbi/go-vcsfetcher/archive/refs/tags/v1.0.0.tar.gz
+func (gh *URL) IsArchive() bool {
+	return gh.isArchive
+}
@@ -26,6 +26,30 @@ func TestGithubURLParser(t *testing.T) {
 	})
 }
 
+func TestGithubURLParserDefaultPort(t *testing.T) {
+	t.Parallel()
+
+	for _, pair := range [][2]string{
+		{"https://github.com/fredbi/go-vcsfetch", "https://github.com:443/fredbi/go-vcsfetch"},
+		{"https://github.com/fredbi/go-vcsfetch/blob/master/README.md", "https://GitHub.com:443/fredbi/go-vcsfetch/blob/master/README.md"},
+		{"ssh://git@github.com/fredbi/go-vcsfetch", "ssh://git@github.com:22/fredbi/go-vcsfetch"},
+	} {
+		t.Run(fmt.Sprintf("%s and %s should yield the same repo URL", pair[0], pair[1]), func(t *testing.T) {
+			u1, err := url.Parse(pair[0])
+			require.NoError(t, err)
+			u2, err := url.Parse(pair[1])
+			require.NoError(t, err)
+
+			res1, err := Parse(u1)
+			require.NoError(t, err)
+			res2, err := Parse(u2)
+			require.NoError(t, err)
+
+			require.Equal(t, res1.RepoURL().String(), res2.RepoURL().String())
+		})
+	}
+}
+
 func testShouldParseURL(tc testCase) func(*testing.T) {
 	return func(t *testing.T) {
 		u, err := url.Parse(tc.url)
@@ -87,6 +111,36 @@ func parserTestCasesValid(_ *testing.T) iter.Seq[testCase] {
 				version: "v2.1",
 				path:    "pkg/doc",
 			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch/blob/release%2F2024.01/pkg/doc.go",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "release/2024.01",
+				path:    "pkg/doc.go",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch/blob/0123456789abcdef0123456789abcdef01234567/pkg/doc.go#L12",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "0123456789abcdef0123456789abcdef01234567",
+				path:    "pkg/doc.go",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch/blob/0123456789abcdef0123456789abcdef01234567/README.md?plain=1#L10-L20",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "0123456789abcdef0123456789abcdef01234567",
+				path:    "README.md",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch/commit/0123456789abcdef0123456789abcdef01234567",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "0123456789abcdef0123456789abcdef01234567",
+				path:    "/",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch/commits/main/pkg/doc",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "main",
+				path:    "/",
+			},
 			{
 				url:     "https://raw.githubusercontent.com/fredbi/go-vcsfetch/refs/heads/master/README.md",
 				repo:    "https://raw.githubusercontent.com/fredbi/go-vcsfetch",
@@ -125,13 +179,13 @@ func parserTestCasesValid(_ *testing.T) iter.Seq[testCase] {
 			},
 			{
 				url:     "https://github.com:443/fredbi/go-vcsfetch/tree/v2.1/pkg/doc",
-				repo:    "https://github.com:443/fredbi/go-vcsfetch",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
 				version: "v2.1",
 				path:    "pkg/doc",
 			},
 			{
 				url:     "https://github.com:443/fredbi/go-vcsfetch",
-				repo:    "https://github.com:443/fredbi/go-vcsfetch",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
 				version: "",
 				path:    "/",
 			},
@@ -165,6 +219,60 @@ func parserTestCasesValid(_ *testing.T) iter.Seq[testCase] {
 				version: "v2.1",
 				path:    "LICENSE",
 			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch@v1.2.3",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "v1.2.3",
+				path:    "/",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch@main",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "main",
+				path:    "/",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch.git@v1.2.3",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "v1.2.3",
+				path:    "/",
+			},
+			{
+				url:     "fredbi/go-vcsfetch@v1.2.3",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "v1.2.3",
+				path:    "/",
+			},
+			{
+				url:     "ssh://git@github.com/fredbi/go-vcsfetch@main",
+				repo:    "ssh://git@github.com/fredbi/go-vcsfetch",
+				version: "main",
+				path:    "/",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch/archive/refs/tags/v1.0.0.tar.gz",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "v1.0.0",
+				path:    "/",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch/archive/refs/heads/feature/x.zip",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "feature/x",
+				path:    "/",
+			},
+			{
+				url:     "https://github.com/fredbi/go-vcsfetch/archive/master.tar.gz",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "master",
+				path:    "/",
+			},
+			{
+				url:     "https://codeload.github.com/fredbi/go-vcsfetch/tar.gz/refs/tags/v1.0.0",
+				repo:    "https://github.com/fredbi/go-vcsfetch",
+				version: "v1.0.0",
+				path:    "/",
+			},
 			// TODO: escaped paths
 		},
 	)
@@ -179,6 +287,15 @@ func parserTestCasesInvalid(_ *testing.T) iter.Seq[testCase] {
 			{
 				url: "https://github.com/fredbi/go-vcsfetch/refs/HEAD/pkg/doc.go",
 			},
+			{
+				url: "https://github.com/fredbi/go-vcsfetch/compare/v1.0.0...v1.1.0",
+			},
+			{
+				url: "https://github.com/fredbi/go-vcsfetch/compare",
+			},
+			{
+				url: "https://github.com/fredbi/go-vcsfetch/commit",
+			},
 			{
 				url: "https://raw.githubusercontent.com/fredbi/go-vcsfetch/blob/heads/master/README.md",
 			},
@@ -200,6 +317,15 @@ func parserTestCasesInvalid(_ *testing.T) iter.Seq[testCase] {
 			{
 				url: "https://github.com/fredbi/go-vcsfetch/blob/master/",
 			},
+			{
+				url: "https://github.com/fredbi/go-vcsfetch/archive",
+			},
+			{
+				url: "https://github.com/fredbi/go-vcsfetch/archive/refs/tags/v1.0.0.tar.bz2",
+			},
+			{
+				url: "https://codeload.github.com/fredbi/go-vcsfetch",
+			},
 		},
 	)
 }
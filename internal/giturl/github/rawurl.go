@@ -18,21 +18,74 @@ type Locator interface {
 	Version() string
 }
 
+// RawSupportsHEAD tells that raw.githubusercontent.com resolves "HEAD" to the default branch.
+const RawSupportsHEAD = true
+
 // Raw returns the raw.githubusercontent URL for a [Locator] hosted on github.com.
 //
 // Only https url's are supported.
 //
-// For Github Enterprise, there is no way to guess the host: this only works on github.com
+// For Github Enterprise, there is no way to guess the host: this only works on github.com.
+// See [EnterpriseRaw] for hosts known to be Github Enterprise instances.
 //
 // Examples:
 //
 //   - https://raw.githubusercontent.com/fredbi/go-vcsfetch/refs/heads/master/README.md
 //   - https://raw.githubusercontent.com/fredbi/go-vcsfetch/master/README.md
 func Raw(locator Locator) (*url.URL, error) {
+	repo, version, pth, err := rawComponents(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	host := repo.Hostname()
+	if host == defaultHost || host == rawHost {
+		u := &url.URL{}
+		*u = *repo // shallow clone
+		u.Host = rawHost
+		u.Path = path.Join(u.Path, version, pth)
+		u.Fragment = ""
+		u.RawFragment = ""
+
+		return u, nil
+	}
+
+	return nil, fmt.Errorf("no way to guess the raw content host for github not hosted by github.com: %q: %w", host, ErrGithub)
+}
+
+// EnterpriseRaw returns the raw-content URL for a [Locator] hosted on a Github Enterprise instance.
+//
+// Github Enterprise serves raw content from the "raw" path of the repository on the same host.
+// Depending on the configuration of the instance, this path redirects to a dedicated "raw" subdomain.
+//
+// Only https url's are supported.
+//
+// Example:
+//
+//   - https://github.example.com/owner/repo/raw/master/README.md
+func EnterpriseRaw(locator Locator) (*url.URL, error) {
+	repo, version, pth, err := rawComponents(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &url.URL{}
+	*u = *repo // shallow clone
+	u.Path = path.Join("/", u.Path, "raw", version, pth)
+	u.RawPath = ""
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u, nil
+}
+
+// rawComponents checks that a [Locator] may be converted to a raw-content URL,
+// and yields the repository URL, the version (defaults to "HEAD") and the path to the file.
+func rawComponents(locator Locator) (*url.URL, string, string, error) {
 	repo := locator.RepoURL()
 	pth := strings.Trim(locator.Path(), "/")
 	if pth == "" {
-		return nil, fmt.Errorf("returning a raw content url requires a non empty path to a file: %w", ErrGithub)
+		return nil, "", "", fmt.Errorf("returning a raw content url requires a non empty path to a file: %w", ErrGithub)
 	}
 
 	version := locator.Version()
@@ -43,23 +96,12 @@ func Raw(locator Locator) (*url.URL, error) {
 	scheme, _ := strings.CutSuffix(repo.Scheme, "+git")
 
 	if scheme != "https" {
-		return nil, fmt.Errorf("returning a raw content url requires a https URL scheme: %w", ErrGithub)
+		return nil, "", "", fmt.Errorf("returning a raw content url requires a https URL scheme: %w", ErrGithub)
 	}
 
 	if port := repo.Port(); port != "" && port != "443" {
-		return nil, fmt.Errorf("returning a raw content url requires a https URL with standard port (443 or unspecified): %w", ErrGithub)
+		return nil, "", "", fmt.Errorf("returning a raw content url requires a https URL with standard port (443 or unspecified): %w", ErrGithub)
 	}
 
-	host := repo.Hostname()
-	if host == defaultHost || host == rawHost {
-		u := repo
-		u.Host = "raw.githubusercontent.com"
-		u.Path = path.Join(u.Path, version, pth)
-		u.Fragment = ""
-		u.RawFragment = ""
-
-		return u, nil
-	}
-
-	return nil, fmt.Errorf("no way to guess the raw content host for github not hosted by github.com: %q: %w", host, ErrGithub)
+	return repo, version, pth, nil
 }
@@ -46,6 +46,25 @@ func TestRawEdgeCases(t *testing.T) {
 		require.Errorf(t, err, "expected an empty path to return an error")
 	})
 
+	t.Run("should NOT alter the locator", func(t *testing.T) {
+		const rawURL = "https://raw.githubusercontent.com/owner/repo/refs/heads/master/README.md"
+
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		locator, err := Parse(u)
+		require.NoError(t, err)
+		repoURL := locator.RepoURL().String()
+
+		first, err := Raw(locator)
+		require.NoError(t, err)
+		require.Equal(t, "https://raw.githubusercontent.com/owner/repo/master/README.md", first.String())
+		require.Equal(t, repoURL, locator.RepoURL().String())
+
+		second, err := Raw(locator)
+		require.NoError(t, err)
+		require.Equal(t, first.String(), second.String())
+	})
+
 	t.Run("should convert URL with empty version to raw", func(t *testing.T) {
 		const emptyVersion = "https://github.com/owner/repo/tree/v2.1/file"
 
@@ -67,6 +86,41 @@ func TestRawEdgeCases(t *testing.T) {
 	})
 }
 
+func TestEnterpriseRaw(t *testing.T) {
+	t.Parallel()
+
+	for location, expected := range map[string]string{
+		"https://git.example.com/owner/repo/blob/master/README.md":       "https://git.example.com/owner/repo/raw/master/README.md",
+		"https://git.example.com/owner/repo/tree/v1.2.3/docs/guide.md":   "https://git.example.com/owner/repo/raw/v1.2.3/docs/guide.md",
+		"https://git.example.com/owner/repo/blob/main/README.md#L10-L20": "https://git.example.com/owner/repo/raw/main/README.md",
+	} {
+		t.Run(location, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(location)
+			require.NoError(t, err)
+			locator, err := Parse(u)
+			require.NoError(t, err)
+
+			raw, err := EnterpriseRaw(locator)
+			require.NoError(t, err)
+			require.Equal(t, expected, raw.String())
+		})
+	}
+
+	t.Run("should NOT convert a non-https URL to raw", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := url.Parse("http://git.example.com/owner/repo/blob/master/README.md")
+		require.NoError(t, err)
+		locator, err := Parse(u)
+		require.NoError(t, err)
+
+		_, err = EnterpriseRaw(locator)
+		require.ErrorIs(t, err, ErrGithub)
+	})
+}
+
 func testShouldRaw(tc testCase) func(*testing.T) {
 	return func(t *testing.T) {
 		u, err := url.Parse(tc.url)
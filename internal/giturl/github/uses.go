@@ -0,0 +1,58 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
+)
+
+// ParseUses parses a GitHub Actions "uses" reference, like "owner/repo[/path]@ref".
+//
+// The repository is hosted on github.com, unless a base URL is provided, e.g. for a Github Enterprise host.
+// The optional path designates a folder of the repository, e.g. an action in a repository holding several actions.
+//
+// Examples:
+//
+//   - actions/checkout@v4
+//   - github/codeql-action/init@v3
+//
+// Local actions (e.g. "./.github/actions/build") and docker images (e.g. "docker://alpine:3.20") are not supported.
+func ParseUses(uses string, base *url.URL) (*URL, error) {
+	if strings.HasPrefix(uses, ".") || strings.Contains(uses, "://") {
+		return nil, fmt.Errorf("expected a reference to an action in a repository, like owner/repo@ref, but got %q: %w", uses, ErrGithub)
+	}
+
+	reference, ref, _ := strings.Cut(uses, "@")
+	if ref == "" {
+		return nil, fmt.Errorf("expected a reference to an action with an explicit ref, like owner/repo@ref, but got %q: %w", uses, ErrGithub)
+	}
+
+	pth, parts := common.SplitPath(reference)
+	if len(parts) < common.RepoIndex {
+		return nil, fmt.Errorf("expected the reference to contain at least %d parts, but got %q: %w", common.RepoIndex, pth, ErrGithub)
+	}
+
+	repo, _, rest := common.SplitRepo(parts, false)
+
+	u := &url.URL{Scheme: defaultScheme, Host: defaultHost}
+	if base != nil {
+		u = common.NormalizeURL(base, defaultScheme, defaultHost)
+		common.ClearQuery(u)
+	}
+	u.Path = strings.TrimPrefix(path.Join(u.Path, repo), "/")
+	u.RawPath = ""
+
+	repoPath := "/"
+	if len(rest) > 0 {
+		repoPath = strings.Join(rest, "/")
+	}
+
+	return &URL{
+		repoURL: u,
+		path:    repoPath,
+		version: ref,
+	}, nil
+}
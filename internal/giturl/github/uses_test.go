@@ -0,0 +1,55 @@
+package github
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestParseUses(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uses    string
+		repo    string
+		path    string
+		version string
+	}{
+		{uses: "actions/checkout@v4", repo: "https://github.com/actions/checkout", path: "/", version: "v4"},
+		{uses: "github/codeql-action/init@v3", repo: "https://github.com/github/codeql-action", path: "init", version: "v3"},
+		{uses: "owner/repo/path/to/action@releases/v1", repo: "https://github.com/owner/repo", path: "path/to/action", version: "releases/v1"},
+		{uses: "actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683", repo: "https://github.com/actions/checkout", path: "/", version: "11bd71901bbe5b1630ceea73d27597364c9af683"},
+	} {
+		t.Run("should parse "+tc.uses, func(t *testing.T) {
+			gh, err := ParseUses(tc.uses, nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.repo, gh.RepoURL().String())
+			require.Equal(t, tc.path, gh.Path())
+			require.Equal(t, tc.version, gh.Version())
+		})
+	}
+
+	t.Run("should resolve against a base URL", func(t *testing.T) {
+		base, err := url.Parse("https://ghe.example.com:443/?x=y")
+		require.NoError(t, err)
+
+		gh, err := ParseUses("actions/checkout@v4", base)
+		require.NoError(t, err)
+		require.Equal(t, "https://ghe.example.com/actions/checkout", gh.RepoURL().String())
+	})
+
+	for _, uses := range []string{
+		"actions/checkout",
+		"actions/checkout@",
+		"actions@v4",
+		"./.github/actions/build",
+		"docker://alpine:3.20",
+		"",
+	} {
+		t.Run("should NOT parse "+uses, func(t *testing.T) {
+			_, err := ParseUses(uses, nil)
+			require.ErrorIs(t, err, ErrGithub)
+		})
+	}
+}
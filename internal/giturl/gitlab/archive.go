@@ -0,0 +1,39 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ZipArchive returns the URL to download a zip archive of the entire repository
+// for a [Locator] hosted on any gitlab SCM instance.
+//
+// An explicit version is required. All the entries of the archive are located under a top-level folder,
+// e.g. "go-vcsfetch-v1.0.0/".
+//
+// Example:
+//
+//   - https://gitlab.com/fredbi/go-vcsfetch/-/archive/v1.0.0/go-vcsfetch-v1.0.0.zip
+func ZipArchive(locator Locator) (*url.URL, error) {
+	version := locator.Version()
+	if version == "" {
+		return nil, fmt.Errorf("returning an archive url requires an explicit version: %w", ErrGitlab)
+	}
+
+	repo := locator.RepoURL()
+	scheme, _ := strings.CutPrefix(repo.Scheme, "git+")
+	if scheme != "https" && scheme != "http" {
+		return nil, fmt.Errorf("returning an archive url requires a http or https URL scheme: %w", ErrGitlab)
+	}
+
+	repoPath := strings.TrimSuffix(repo.Path, ".git")
+	name := path.Base(repoPath) + "-" + strings.ReplaceAll(version, "/", "-") + ".zip"
+
+	return &url.URL{
+		Scheme: scheme,
+		Host:   repo.Host,
+		Path:   path.Join("/", repoPath, "-", "archive", version, name),
+	}, nil
+}
@@ -0,0 +1,58 @@
+package gitlab
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestZipArchive(t *testing.T) {
+	t.Parallel()
+
+	for input, expected := range map[string]string{
+		"https://gitlab.com/fredbi/go-vcsfetch/-/tree/v1.0.0":         "https://gitlab.com/fredbi/go-vcsfetch/-/archive/v1.0.0/go-vcsfetch-v1.0.0.zip",
+		"https://gitlab.com/fredbi/go-vcsfetch/-/blob/main/README.md": "https://gitlab.com/fredbi/go-vcsfetch/-/archive/main/go-vcsfetch-main.zip",
+	} {
+		u, err := url.Parse(input)
+		require.NoError(t, err)
+
+		gl, err := Parse(u)
+		require.NoError(t, err, input)
+
+		archive, err := ZipArchive(gl)
+		if expected == "" {
+			require.ErrorIs(t, err, ErrGitlab, input)
+
+			continue
+		}
+
+		require.NoError(t, err, input)
+		require.Equal(t, expected, archive.String())
+	}
+
+	t.Run("should name the archive of a branch with slashes", func(t *testing.T) {
+		archive, err := ZipArchive(archiveLocator{repo: &url.URL{Scheme: "https", Host: "gitlab.example.com", Path: "/group/repo.git"}, version: "feature/x"})
+		require.NoError(t, err)
+		require.Equal(t, "https://gitlab.example.com/group/repo/-/archive/feature/x/repo-feature-x.zip", archive.String())
+	})
+
+	t.Run("should require an explicit version", func(t *testing.T) {
+		_, err := ZipArchive(archiveLocator{repo: &url.URL{Scheme: "https", Host: "gitlab.com", Path: "/fredbi/go-vcsfetch"}})
+		require.ErrorIs(t, err, ErrGitlab)
+	})
+
+	t.Run("should require a http or https URL", func(t *testing.T) {
+		_, err := ZipArchive(archiveLocator{repo: &url.URL{Scheme: "ssh", Host: "gitlab.com", Path: "/fredbi/go-vcsfetch"}, version: "v1.0.0"})
+		require.ErrorIs(t, err, ErrGitlab)
+	})
+}
+
+type archiveLocator struct {
+	repo    *url.URL
+	version string
+}
+
+func (l archiveLocator) RepoURL() *url.URL { return l.repo }
+func (l archiveLocator) Path() string      { return "" }
+func (l archiveLocator) Version() string   { return l.version }
@@ -0,0 +1,83 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
+)
+
+// listingPageSize is the maximum number of entries per page allowed by the gitlab API.
+const listingPageSize = 100
+
+// Listing returns the URL of the gitlab repository tree API that lists a folder, for a [Locator] hosted
+// on any gitlab SCM instance.
+//
+// Only https URL's are supported.
+//
+// Example:
+//
+//   - https://gitlab.com/api/v4/projects/fredbi%2Fgo-vcsfetch/repository/tree?path=docs&per_page=100&ref=master
+func Listing(locator Locator) (*url.URL, error) {
+	repo := locator.RepoURL()
+	scheme, _ := strings.CutPrefix(repo.Scheme, "git+")
+	if scheme != "https" {
+		return nil, fmt.Errorf("returning a listing url requires a https URL scheme: %w", ErrGitlab)
+	}
+
+	project := strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git")
+	query := url.Values{
+		"per_page": []string{strconv.Itoa(listingPageSize)},
+	}
+	if pth := strings.Trim(locator.Path(), "/"); pth != "" {
+		query.Set("path", pth)
+	}
+	if version := locator.Version(); version != "" {
+		query.Set("ref", version)
+	}
+
+	return &url.URL{
+		Scheme:   scheme,
+		Host:     repo.Host,
+		Path:     "/api/v4/projects/" + project + "/repository/tree",
+		RawPath:  "/api/v4/projects/" + url.PathEscape(project) + "/repository/tree",
+		RawQuery: query.Encode(),
+	}, nil
+}
+
+type treeEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// DecodeListing decodes the response of the gitlab repository tree API for a folder.
+//
+// The gitlab API does not report the size of files.
+//
+// A full page of results is rejected, since the listing is then possibly truncated.
+func DecodeListing(r io.Reader) ([]common.DirEntry, error) {
+	var tree []treeEntry
+	if err := json.NewDecoder(r).Decode(&tree); err != nil {
+		return nil, fmt.Errorf("unexpected response from the repository tree API: %w: %w", err, ErrGitlab)
+	}
+
+	if len(tree) >= listingPageSize {
+		return nil, fmt.Errorf("the listing is possibly truncated to %d entries: %w", listingPageSize, ErrGitlab)
+	}
+
+	entries := make([]common.DirEntry, 0, len(tree))
+	for _, entry := range tree {
+		entries = append(entries, common.DirEntry{
+			Name:  entry.Name,
+			Path:  entry.Path,
+			IsDir: entry.Type == "tree",
+		})
+	}
+
+	return entries, nil
+}
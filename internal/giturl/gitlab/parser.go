@@ -1,9 +1,10 @@
 package gitlab
 
 import (
-	"fmt"
 	"net/url"
 	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
 )
 
 // URL is a gitlab-style URL to a vcs resource hosted by gitlab SCM.
@@ -11,109 +12,78 @@ type URL struct {
 	repoURL *url.URL
 	path    string
 	version string
+	refType RefType
 }
 
+// RefType tells if the ref of a gitlab URL is a branch or a tag, as indicated by the "ref_type" query parameter.
+type RefType string
+
+const (
+	// RefTypeUnknown is a ref that may be a branch or a tag.
+	RefTypeUnknown RefType = ""
+	// RefTypeHeads is a branch.
+	RefTypeHeads RefType = "heads"
+	// RefTypeTags is a tag.
+	RefTypeTags RefType = "tags"
+)
+
+const refTypeParam = "ref_type"
+
 const (
 	defaultScheme = "https"
 	defaultHost   = "gitlab.com"
 )
 
+// config describes gitlab URLs: a "-" separator, then "blob", "raw", "tree" or "commit", then the ref.
+//
+// Projects may be nested in subgroups, e.g. "group/subgroup/project", and their full path
+// may be escaped, like in the gitlab API, e.g. "group%2Fsubgroup%2Fproject".
+var config = common.Config{
+	DefaultScheme: defaultScheme,
+	DefaultHost:   defaultHost,
+	RepoVersion:   true,
+	NestedRepo:    true,
+	Layout: common.Layout{
+		Separator: "-",
+		Keywords: map[string]common.Keyword{
+			"blob": {},
+			"raw":  {},
+			"tree": {IsTree: true},
+			// e.g. /-/commit/<sha> or /-/commits/main: the whole tree at this ref.
+			// Any trailing path only filters the commit history on gitlab, so it is ignored.
+			"commit":  {IsTree: true, IgnorePath: true},
+			"commits": {IsTree: true, IgnorePath: true},
+		},
+		Rejected: map[string]string{
+			"compare": "compare URLs designate a range of commits, not a single ref",
+		},
+		Err: ErrGitlab,
+	},
+}
+
 // Parse a gitlab URL.
 func Parse(gitlabURL *url.URL) (*URL, error) {
-	u := &url.URL{}
-	*u = *gitlabURL // shallow clone
-
-	if u.Scheme == "" {
-		u.Scheme = defaultScheme
-	} else {
-		u.Scheme, _ = strings.CutPrefix(u.Scheme, "git+")
-	}
-
-	if u.Hostname() == "" {
-		if u.Port() == "" {
-			u.Host = defaultHost
-		} else {
-			u.Host = defaultHost + ":" + u.Port()
-		}
-	}
-
-	u.Host = strings.ToLower(u.Host)
-	pth := strings.Trim(u.Path, "/")
-
-	const (
-		repoIndex = 2
-		refIndex  = 4
-	)
-
-	parts := strings.Split(pth, "/")
-	if len(parts) < repoIndex {
-		return nil, fmt.Errorf("expected the URL path component to contain at least %d parts, but got %q: %w", refIndex, pth, ErrGitlab)
-	}
-
-	repo := strings.Join(parts[:repoIndex], "/")
-	repo = strings.TrimSuffix(repo, ".git")
-	u.Path = repo
-
-	if len(parts) == repoIndex || len(parts) == repoIndex+1 && parts[repoIndex] == "-" {
-		// entire repo
-		u.RawFragment = ""
-		u.Fragment = ""
-		u.RawQuery = ""
-
-		gh := &URL{
-			repoURL: u,
-			path:    "/",
-			version: "",
-		}
-
-		return gh, nil
-	}
-
-	parts = parts[repoIndex:]
-	if parts[0] != "-" {
-		return nil, fmt.Errorf(`expected URL path to contain a "-" separator: %w`, ErrGitlab)
+	parsed, err := common.Parse(gitlabURL, config)
+	if err != nil {
+		return nil, err
 	}
 
-	parts = parts[1:]
+	u := parsed.RepoURL
+	u.Scheme, _ = strings.CutPrefix(u.Scheme, "git+")
 
-	var (
-		ref    string
-		isTree bool
-	)
-
-	const neededPartsAfterDash = 2
-	if len(parts) < neededPartsAfterDash {
-		return nil, fmt.Errorf(`expected URL path to contain at least 2 parts but got %q: %w`, pth, ErrGitlab)
-	}
-
-	switch strings.ToLower(parts[0]) {
-	case "blob", "raw":
-	case "tree":
-		isTree = true
-	default:
-		return nil, fmt.Errorf(`expected URL path to contain "blob" or "tree" but got %q in %q: %w`, parts[0], pth, ErrGitlab)
+	gh := &URL{
+		repoURL: u,
+		path:    parsed.Path,
+		version: parsed.Version,
 	}
 
-	ref = parts[1]
-	parts = parts[2:]
-
-	if len(parts) == 0 {
-		if !isTree {
-			return nil, fmt.Errorf(`expected URL path to contain at least %d parts in %q: %w`, refIndex, pth, ErrGitlab)
+	if parsed.Version != "" {
+		// newer gitlab instances disambiguate a branch and a tag sharing the same name
+		switch refType := RefType(gitlabURL.Query().Get(refTypeParam)); refType {
+		case RefTypeHeads, RefTypeTags:
+			gh.refType = refType
+		default:
 		}
-
-		parts = []string{"/"}
-	}
-
-	repoPath := strings.Join(parts, "/")
-	u.RawFragment = ""
-	u.Fragment = ""
-	u.RawQuery = ""
-
-	gh := &URL{
-		repoURL: u,
-		path:    repoPath,
-		version: ref,
 	}
 
 	return gh, nil
@@ -136,3 +106,11 @@ func (gh *URL) Version() string {
 func (gh *URL) Path() string {
 	return gh.path
 }
+
+// RefType yields the type of the ref, as indicated by the "ref_type" query parameter, e.g. "tags" in
+// https://gitlab.com/fredbi/go-vcsfetcher/-/raw/v1.0.0/README.md?ref_type=tags
+//
+// This is [RefTypeUnknown] if the URL does not specify a ref type.
+func (gh *URL) RefType() RefType {
+	return gh.refType
+}
@@ -1,6 +1,7 @@
 package gitlab
 
 import (
+	"fmt"
 	"net/url"
 	"testing"
 
@@ -66,13 +67,25 @@ func TestGitlabURLParser(t *testing.T) {
 			},
 			{
 				url:     "https://gitlab.com:443/fredbi/go-vcsfetch/-/tree/v2.1/pkg/doc",
-				repo:    "https://gitlab.com:443/fredbi/go-vcsfetch",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
 				version: "v2.1",
 				path:    "pkg/doc",
 			},
+			{
+				url:     "https://gitlab.com/fredbi/go-vcsfetch/-/commit/0123456789abcdef0123456789abcdef01234567",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
+				version: "0123456789abcdef0123456789abcdef01234567",
+				path:    "/",
+			},
+			{
+				url:     "https://gitlab.com/fredbi/go-vcsfetch/-/commits/main/pkg/doc",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
+				version: "main",
+				path:    "/",
+			},
 			{
 				url:     "https://gitlab.com:443/fredbi/go-vcsfetch",
-				repo:    "https://gitlab.com:443/fredbi/go-vcsfetch",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
 				version: "",
 				path:    "/",
 			},
@@ -88,7 +101,72 @@ func TestGitlabURLParser(t *testing.T) {
 				version: "",
 				path:    "/",
 			},
-			// TODO: escaped paths
+			{
+				url:     "https://gitlab.com/fredbi/go-vcsfetch@v1.2.3",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
+				version: "v1.2.3",
+				path:    "/",
+			},
+			{
+				url:     "https://gitlab.com/fredbi/go-vcsfetch@main",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
+				version: "main",
+				path:    "/",
+			},
+			{
+				url:     "https://gitlab.com/fredbi/go-vcsfetch.git@v1.2.3",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
+				version: "v1.2.3",
+				path:    "/",
+			},
+			{
+				url:     "fredbi/go-vcsfetch@main",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
+				version: "main",
+				path:    "/",
+			},
+			{
+				url:     "https://gitlab.com/group/subgroup/project/-/blob/main/docs/README.md",
+				repo:    "https://gitlab.com/group/subgroup/project",
+				version: "main",
+				path:    "docs/README.md",
+			},
+			{
+				url:     "https://gitlab.com/group%2Fsubgroup%2Fproject/-/blob/main/docs/README.md",
+				repo:    "https://gitlab.com/group/subgroup/project",
+				version: "main",
+				path:    "docs/README.md",
+			},
+			{
+				url:     "https://gitlab.com/fredbi%2Fgo-vcsfetch/-/raw/release%2F2024.01/README.md",
+				repo:    "https://gitlab.com/fredbi/go-vcsfetch",
+				version: "release/2024.01",
+				path:    "README.md",
+			},
+			{
+				url:     "ssh://git@gitlab.com/group/subgroup/project.git/-/tree/v2.1/pkg/doc",
+				repo:    "ssh://git@gitlab.com/group/subgroup/project",
+				version: "v2.1",
+				path:    "pkg/doc",
+			},
+			{
+				url:     "https://gitlab.com/group/subgroup/project/-/",
+				repo:    "https://gitlab.com/group/subgroup/project",
+				version: "",
+				path:    "/",
+			},
+			{
+				url:     "https://gitlab.com/group%2Fsubgroup%2Fproject",
+				repo:    "https://gitlab.com/group/subgroup/project",
+				version: "",
+				path:    "/",
+			},
+			{
+				url:     "https://gitlab.com/group%2Fsubgroup%2Fproject@v1.2.3",
+				repo:    "https://gitlab.com/group/subgroup/project",
+				version: "v1.2.3",
+				path:    "/",
+			},
 		} {
 			u, err := url.Parse(tc.url)
 			require.NoErrorf(t, err,
@@ -143,6 +221,18 @@ func TestGitlabURLParser(t *testing.T) {
 			{
 				url: "https://gitlab.com/fredbi/go-vcsfetch/-/blob/master",
 			},
+			{
+				url: "https://gitlab.com/fredbi/go-vcsfetch/-/compare/v1.0.0...v1.1.0",
+			},
+			{
+				url: "https://gitlab.com/fredbi/go-vcsfetch/-/commit",
+			},
+			{
+				url: "https://gitlab.com/fredbi%2F/-/blob/main/README.md",
+			},
+			{
+				url: "https://gitlab.com/group/subgroup/project/blob/main/README.md",
+			},
 		} {
 			u, err := url.Parse(tc.url)
 			require.NoErrorf(t, err, "test is wrongly configured: expected a valid URL string, but got: %q: %v", tc.url, err)
@@ -153,9 +243,92 @@ func TestGitlabURLParser(t *testing.T) {
 	})
 }
 
+func TestGitlabURLParserDefaultPort(t *testing.T) {
+	t.Parallel()
+
+	for _, pair := range [][2]string{
+		{"https://gitlab.com/fredbi/go-vcsfetch", "https://gitlab.com:443/fredbi/go-vcsfetch"},
+		{"https://gitlab.com/fredbi/go-vcsfetch/-/blob/master/README.md", "https://GitLab.com:443/fredbi/go-vcsfetch/-/blob/master/README.md"},
+		{"ssh://git@gitlab.com/fredbi/go-vcsfetch", "ssh://git@gitlab.com:22/fredbi/go-vcsfetch"},
+	} {
+		t.Run(fmt.Sprintf("%s and %s should yield the same repo URL", pair[0], pair[1]), func(t *testing.T) {
+			u1, err := url.Parse(pair[0])
+			require.NoError(t, err)
+			u2, err := url.Parse(pair[1])
+			require.NoError(t, err)
+
+			res1, err := Parse(u1)
+			require.NoError(t, err)
+			res2, err := Parse(u2)
+			require.NoError(t, err)
+
+			require.Equal(t, res1.RepoURL().String(), res2.RepoURL().String())
+		})
+	}
+}
+
 type testCase struct {
 	url     string
 	repo    string
 	version string
 	path    string
 }
+
+func TestGitlabRefType(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		url         string
+		wantVersion string
+		wantType    RefType
+		wantRaw     string
+	}{
+		{
+			url:         "https://gitlab.com/fredbi/go-vcsfetch/-/raw/v1.0.0/README.md?ref_type=tags",
+			wantVersion: "v1.0.0",
+			wantType:    RefTypeTags,
+			wantRaw:     "https://gitlab.com/fredbi/go-vcsfetch/-/raw/v1.0.0/README.md?ref_type=tags",
+		},
+		{
+			url:         "https://gitlab.com/fredbi/go-vcsfetch/-/blob/v1.0.0/docs/README.md?ref_type=heads",
+			wantVersion: "v1.0.0",
+			wantType:    RefTypeHeads,
+			wantRaw:     "https://gitlab.com/fredbi/go-vcsfetch/-/raw/v1.0.0/docs/README.md?ref_type=heads",
+		},
+		{
+			url:         "https://gitlab.com/fredbi/go-vcsfetch/-/raw/v1.0.0/README.md",
+			wantVersion: "v1.0.0",
+			wantType:    RefTypeUnknown,
+			wantRaw:     "https://gitlab.com/fredbi/go-vcsfetch/-/raw/v1.0.0/README.md",
+		},
+		{
+			url:         "https://gitlab.com/fredbi/go-vcsfetch/-/raw/v1.0.0/README.md?ref_type=commits",
+			wantVersion: "v1.0.0",
+			wantType:    RefTypeUnknown,
+			wantRaw:     "https://gitlab.com/fredbi/go-vcsfetch/-/raw/v1.0.0/README.md",
+		},
+		{
+			url:         "https://gitlab.com/fredbi/go-vcsfetch/-/raw/HEAD/README.md?ref_type=heads",
+			wantVersion: "HEAD",
+			wantType:    RefTypeHeads,
+			wantRaw:     "https://gitlab.com/fredbi/go-vcsfetch/-/raw/HEAD/README.md",
+		},
+	} {
+		t.Run(tc.url, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.url)
+			require.NoError(t, err)
+
+			gl, err := Parse(u)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantVersion, gl.Version())
+			require.Equal(t, tc.wantType, gl.RefType())
+			require.Empty(t, gl.RepoURL().RawQuery)
+
+			raw, err := Raw(gl)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRaw, raw.String())
+		})
+	}
+}
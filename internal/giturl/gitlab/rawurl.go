@@ -12,11 +12,23 @@ type Locator interface {
 	Version() string
 }
 
+// RefTyper is a [Locator] that knows if its version is a branch or a tag.
+type RefTyper interface {
+	RefType() RefType
+}
+
+// RawSupportsHEAD tells that the raw endpoint of gitlab resolves "HEAD" to the default branch.
+const RawSupportsHEAD = true
+
 // Raw returns the raw URL for a [Locator] hosted on any gitlab SCM instance.
 //
 // Example:
 //
 //   - https://gitlab.com/fredbi/go-vcsfetch/-/raw/release/README.md
+//
+// When the [Locator] is a [RefTyper] with a known ref type, the "ref_type" query parameter is set, e.g.
+//
+//   - https://gitlab.com/fredbi/go-vcsfetch/-/raw/v1.0.0/README.md?ref_type=tags
 func Raw(locator Locator) (*url.URL, error) {
 	pth := locator.Path()
 	if pth == "" {
@@ -28,10 +40,16 @@ func Raw(locator Locator) (*url.URL, error) {
 		version = "HEAD"
 	}
 
-	u := locator.RepoURL()
+	u := &url.URL{}
+	*u = *locator.RepoURL() // shallow clone
 	u.Path = path.Join(u.Path, "-", "raw", version, locator.Path())
+	u.RawQuery = ""
 	u.Fragment = ""
 	u.RawFragment = ""
 
+	if typer, ok := locator.(RefTyper); ok && typer.RefType() != RefTypeUnknown && version != "HEAD" {
+		u.RawQuery = url.Values{refTypeParam: []string{string(typer.RefType())}}.Encode()
+	}
+
 	return u, nil
 }
@@ -3,13 +3,16 @@ package giturl
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
-	"strings"
 
 	"github.com/fredbi/go-vcsfetch/internal/giturl/bitbucket"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
 	"github.com/fredbi/go-vcsfetch/internal/giturl/gitea"
 	"github.com/fredbi/go-vcsfetch/internal/giturl/github"
 	"github.com/fredbi/go-vcsfetch/internal/giturl/gitlab"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/sourcehut"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
 )
 
 // Provider represents a SCM platform with a proprietary git-url format.
@@ -22,6 +25,10 @@ const (
 	ProviderAzure     Provider = "azure"
 	ProviderBitBucket Provider = "bitbucket"
 	ProviderGitea     Provider = "gitea"
+	ProviderSourcehut Provider = "sourcehut"
+
+	// ProviderCodeCommit is AWS CodeCommit.
+	ProviderCodeCommit Provider = "codecommit"
 )
 
 func (p Provider) String() string {
@@ -37,43 +44,85 @@ type Locator interface {
 
 // AutoDetect tries to determine the [Provider] that corresponds to a given [url.URL].
 //
-// Detection is rather crude and based on the host in the URL.
+// Detection is rather crude and based on the host in the URL, normalized to lower case.
 //
-// It may not work for SCMs deployed on-premises.
+// It may not work for SCMs deployed on-premises, unless a matcher for such hosts has been
+// registered with [RegisterProvider].
 func AutoDetect(u *url.URL) (Provider, Locator, error) {
-	host := strings.ToLower(u.Host)
-
-	switch {
-	case strings.Contains(host, ProviderGithub.String()):
-		locator, err := github.Parse(u)
-
-		return ProviderGithub, locator, err
-	case strings.Contains(host, ProviderGitlab.String()):
-		locator, err := gitlab.Parse(u)
-		return ProviderGitlab, locator, err
-	case strings.Contains(host, ProviderAzure.String()):
-		return ProviderAzure, nil, fmt.Errorf("url=%q: %w: %w", u.String(), ErrNotImplementedProvider, ErrProvider) // TODO: azure devops git-url
-	case strings.Contains(host, ProviderBitBucket.String()):
-		locator, err := bitbucket.Parse(u)
-		return ProviderBitBucket, locator, err
-	case strings.Contains(host, ProviderGitea.String()):
-		locator, err := gitea.Parse(u)
-		return ProviderGitea, locator, err
-	default:
-		return ProviderUnknown, nil, fmt.Errorf("url=%q: %w: %w", u.String(), ErrUnknownProvider, ErrProvider)
+	provider := detectProvider(u.Host)
+	locator, err := parse(provider, u)
+
+	return provider, locator, err
+}
+
+// ParseAs parses an URL with the parser of a given [Provider], regardless of the host in the URL.
+//
+// This is an escape hatch for SCMs deployed on-premises, which host is not detected by [AutoDetect].
+func ParseAs(provider Provider, u *url.URL) (Locator, error) {
+	if !IsKnownProvider(provider) {
+		return nil, fmt.Errorf("cannot parse as provider %q: %w: %w", provider, ErrUnknownProvider, ErrProvider)
 	}
+
+	return parse(provider, u)
+}
+
+// RawOption customizes the raw-content URLs yielded by [Raw].
+type RawOption func(*rawOptions)
+
+type rawOptions struct {
+	defaultRef            string
+	githubEnterpriseHosts []string
+}
+
+// RawDefaultRef sets the ref emitted in raw-content URLs for a [Locator] without a version, e.g. "main".
+//
+// By default, providers emit "HEAD", which is not accepted by the raw-content endpoint of all hosts.
+func RawDefaultRef(ref string) RawOption {
+	return func(o *rawOptions) {
+		o.defaultRef = ref
+	}
+}
+
+// RawGithubEnterpriseHosts declares the hosts of Github Enterprise instances, which serve raw content
+// from a "raw" path on the same host, e.g. https://git.example.com/owner/repo/raw/main/README.md.
+//
+// The Github Enterprise host set by the GHE_HOST environment variable is always recognized.
+func RawGithubEnterpriseHosts(hosts ...string) RawOption {
+	return func(o *rawOptions) {
+		o.githubEnterpriseHosts = append(o.githubEnterpriseHosts, hosts...)
+	}
+}
+
+// defaultRefLocator is a [Locator] without a version, which yields the default ref set by [RawDefaultRef].
+type defaultRefLocator struct {
+	Locator
+
+	version string
+}
+
+func (l *defaultRefLocator) Version() string {
+	return l.version
 }
 
 // Raw transforms a [Locator] into a raw-content URL to retrieve a vcs resource from well-known SCM providers.
 //
 // This allows to bypass the use of git and is usually faster (uses HTTP GET, not git).
-func Raw(locator Locator) (*url.URL, error) {
-	provider, _, err := AutoDetect(locator.RepoURL())
-	if err != nil {
-		return nil, err
+func Raw(locator Locator, opts ...RawOption) (*url.URL, error) {
+	var o rawOptions
+	for _, apply := range opts {
+		apply(&o)
 	}
 
-	switch provider {
+	if o.defaultRef != "" && locator.Version() == "" {
+		locator = &defaultRefLocator{Locator: locator, version: o.defaultRef}
+	}
+
+	// the repo URL of a locator is not parsed again: only the host tells the provider
+	if IsGithubEnterpriseHost(locator.RepoURL().Host, o.githubEnterpriseHosts...) {
+		return github.EnterpriseRaw(locator)
+	}
+
+	switch provider := detectProvider(locator.RepoURL().Host); provider {
 	case ProviderGithub:
 		return github.Raw(locator)
 	case ProviderGitlab:
@@ -84,7 +133,121 @@ func Raw(locator Locator) (*url.URL, error) {
 		return nil, errors.Join(ErrNotImplementedProvider, ErrProvider) // TODO: azure devops git-url
 	case ProviderBitBucket:
 		return bitbucket.Raw(locator)
+	case ProviderSourcehut:
+		return sourcehut.Raw(locator)
+	case ProviderCodeCommit:
+		return nil, errors.Join(ErrNotImplementedProvider, ErrProvider) // CodeCommit does not serve raw content
+	default:
+		return nil, fmt.Errorf("url=%q: %w: %w", redact.URL(locator.RepoURL()), ErrUnknownProvider, ErrProvider)
+	}
+}
+
+// RawSupportsHEAD tells if the raw-content URL yielded by [Raw] for a repository resolves "HEAD",
+// or an empty version, to the default branch of this repository.
+//
+// When it does not, the version should be resolved to the default branch before calling [Raw].
+func RawSupportsHEAD(repoURL *url.URL, opts ...RawOption) bool {
+	var o rawOptions
+	for _, apply := range opts {
+		apply(&o)
+	}
+
+	if IsGithubEnterpriseHost(repoURL.Host, o.githubEnterpriseHosts...) {
+		return github.RawSupportsHEAD
+	}
+
+	switch provider := detectProvider(repoURL.Host); provider {
+	case ProviderGithub:
+		return github.RawSupportsHEAD
+	case ProviderGitlab:
+		return gitlab.RawSupportsHEAD
+	case ProviderGitea:
+		return gitea.RawSupportsHEAD
+	case ProviderBitBucket:
+		return bitbucket.RawSupportsHEAD
+	case ProviderSourcehut:
+		return sourcehut.RawSupportsHEAD
+	default:
+		return false
+	}
+}
+
+// Archive transforms a [Locator] into an URL to download an archive of the entire repository at the
+// version of the locator, from well-known SCM providers.
+//
+// This allows to bypass the use of git when cloning.
+//
+// This is currently only supported for repositories hosted on github.com.
+func Archive(locator Locator) (*url.URL, error) {
+	switch provider := detectProvider(locator.RepoURL().Host); provider {
+	case ProviderGithub:
+		return github.Archive(locator)
+	default:
+		return nil, fmt.Errorf("archive for provider %v: %w: %w", provider, ErrNotImplementedProvider, ErrProvider)
+	}
+}
+
+// ZipArchive transforms a [Locator] into an URL to download a zip archive of the entire repository at the
+// version of the locator, from well-known SCM providers.
+//
+// This is currently only supported for repositories hosted on github.com and gitlab instances.
+func ZipArchive(locator Locator) (*url.URL, error) {
+	switch provider := detectProvider(locator.RepoURL().Host); provider {
+	case ProviderGithub:
+		return github.ZipArchive(locator)
+	case ProviderGitlab:
+		return gitlab.ZipArchive(locator)
+	default:
+		return nil, fmt.Errorf("zip archive for provider %v: %w: %w", provider, ErrNotImplementedProvider, ErrProvider)
+	}
+}
+
+// Contents transforms a [Locator] into an URL to the API of a well-known SCM provider which retrieves a file.
+//
+// This is currently only supported for repositories hosted on github.com.
+func Contents(locator Locator) (*url.URL, error) {
+	switch provider := detectProvider(locator.RepoURL().Host); provider {
+	case ProviderGithub:
+		return github.Contents(locator)
 	default:
-		return nil, fmt.Errorf("url=%q: %w: %w", locator.RepoURL().String(), ErrUnknownProvider, ErrProvider)
+		return nil, fmt.Errorf("contents for provider %v: %w: %w", provider, ErrNotImplementedProvider, ErrProvider)
 	}
 }
+
+// DirEntry describes an entry of a folder, as listed by the API of a SCM provider.
+type DirEntry = common.DirEntry
+
+// ListingDecoder decodes the response of the listing API of a SCM provider.
+type ListingDecoder func(io.Reader) ([]DirEntry, error)
+
+// Listing transforms a [Locator] designating a folder into an URL to the API of a well-known SCM provider
+// which lists the content of this folder, and yields the decoder for the response of this API.
+//
+// This is currently supported for repositories hosted on github.com, gitlab and gitea instances.
+func Listing(locator Locator) (*url.URL, ListingDecoder, error) {
+	var (
+		u      *url.URL
+		decode ListingDecoder
+		err    error
+	)
+
+	switch provider := detectProvider(locator.RepoURL().Host); provider {
+	case ProviderGithub:
+		u, err = github.Listing(locator)
+		decode = github.DecodeListing
+	case ProviderGitlab:
+		u, err = gitlab.Listing(locator)
+		decode = gitlab.DecodeListing
+	case ProviderGitea:
+		u, err = gitea.Listing(locator)
+		decode = gitea.DecodeListing
+	default:
+		return nil, nil, fmt.Errorf("listing for provider %v: %w: %w", provider, ErrNotImplementedProvider, ErrProvider)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u, decode, nil
+}
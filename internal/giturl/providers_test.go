@@ -49,6 +49,355 @@ func testSingleURL(tc testURL) func(*testing.T) {
 	}
 }
 
+func TestParseAs(t *testing.T) {
+	t.Parallel()
+
+	u := mustParseURL(t, "https://code.corp.example/owner/repo/-/raw/v1.0.0/README.md")
+
+	t.Run("should parse regardless of the host", func(t *testing.T) {
+		locator, err := ParseAs(ProviderGitlab, u)
+		require.NoError(t, err)
+		require.Equal(t, "https://code.corp.example/owner/repo", locator.RepoURL().String())
+		require.Equal(t, "v1.0.0", locator.Version())
+		require.Equal(t, "README.md", locator.Path())
+	})
+
+	t.Run("should reject an unknown provider", func(t *testing.T) {
+		for _, provider := range []Provider{ProviderUnknown, "gitforge"} {
+			_, err := ParseAs(provider, u)
+			require.ErrorIs(t, err, ErrUnknownProvider)
+		}
+	})
+}
+
+func TestAutoDetectTrailingSlash(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{
+		// repo-only URLs
+		"https://github.com/owner/repo",
+		"https://github.com/owner/repo@v1.2.3",
+		"https://gitlab.com/owner/repo",
+		"https://gitlab.com/owner/repo/-",
+		"https://gitlab.com/owner/repo@v1.2.3",
+		"https://gitea.com/owner/repo",
+		"https://codeberg.org/owner/repo",
+		"https://bitbucket.org/owner/repo",
+		"https://git.sr.ht/~owner/repo",
+		// blob URLs
+		"https://github.com/owner/repo/blob/main/docs/README.md",
+		"https://raw.githubusercontent.com/owner/repo/main/docs/README.md",
+		"https://gitlab.com/owner/repo/-/blob/main/docs/README.md",
+		"https://gitea.com/owner/repo/src/branch/main/docs/README.md",
+		"https://bitbucket.org/owner/repo/src/main/docs/README.md",
+		"https://git.sr.ht/~owner/repo/blob/main/docs/README.md",
+		// tree URLs
+		"https://github.com/owner/repo/tree/main/docs",
+		"https://gitlab.com/owner/repo/-/tree/main/docs",
+	} {
+		t.Run(fmt.Sprintf("%s with trailing slash should parse like without", input), func(t *testing.T) {
+			t.Parallel()
+
+			wantProvider, want, err := AutoDetect(mustParseURL(t, input))
+			require.NoError(t, err)
+
+			for _, suffix := range []string{"/", "//"} {
+				provider, locator, err := AutoDetect(mustParseURL(t, input+suffix))
+				require.NoError(t, err)
+				require.Equal(t, wantProvider, provider)
+				require.Equal(t, want.RepoURL().String(), locator.RepoURL().String())
+				require.Equal(t, want.Version(), locator.Version())
+				require.Equal(t, want.Path(), locator.Path())
+			}
+		})
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	t.Parallel()
+
+	// the matcher only knows about the lower case host: the host must be normalized to match
+	registerProvider(t, ProviderGitlab, func(host string) bool {
+		return host == "git.mixedcase.example.com"
+	})
+
+	t.Run("matcher should receive a lower case host", func(t *testing.T) {
+		provider, locator, err := AutoDetect(mustParseURL(t, "https://Git.MixedCase.Example.COM/owner/repo/-/blob/main/README.md"))
+		require.NoError(t, err)
+		require.Equal(t, ProviderGitlab, provider)
+		require.Equal(t, "main", locator.Version())
+		require.Equal(t, "README.md", locator.Path())
+	})
+
+	t.Run("should not register an unknown provider", func(t *testing.T) {
+		_, err := RegisterProvider(Provider("gogs"), func(string) bool { return true })
+		require.ErrorIs(t, err, ErrUnknownProvider)
+		require.ErrorIs(t, err, ErrProvider)
+	})
+
+	t.Run("should not register a nil matcher", func(t *testing.T) {
+		_, err := RegisterProvider(ProviderGitea, nil)
+		require.ErrorIs(t, err, ErrProvider)
+	})
+
+	t.Run("should NOT serve raw content from a registered github host unless declared as Github Enterprise", func(t *testing.T) {
+		registerProvider(t, ProviderGithub, func(host string) bool {
+			return host == "git.enterprise.example"
+		})
+
+		provider, locator, err := AutoDetect(mustParseURL(t, "https://git.enterprise.example/owner/repo/blob/main/README.md"))
+		require.NoError(t, err)
+		require.Equal(t, ProviderGithub, provider)
+
+		_, err = Raw(locator)
+		require.Error(t, err)
+
+		raw, err := Raw(locator, RawGithubEnterpriseHosts("git.enterprise.example"))
+		require.NoError(t, err)
+		require.Equal(t, "https://git.enterprise.example/owner/repo/raw/main/README.md", raw.String())
+	})
+
+	t.Run("should unregister a matcher", func(t *testing.T) {
+		unregister, err := RegisterProvider(ProviderGitea, func(host string) bool {
+			return host == "git.unregistered.example"
+		})
+		require.NoError(t, err)
+		require.Equal(t, ProviderGitea, detectProvider("git.unregistered.example"))
+
+		unregister()
+		require.Equal(t, ProviderUnknown, detectProvider("git.unregistered.example"))
+	})
+}
+
+// registerProvider registers a host matcher for the duration of a test.
+func registerProvider(t *testing.T, provider Provider, matcher HostMatcher) {
+	t.Helper()
+
+	unregister, err := RegisterProvider(provider, matcher)
+	require.NoError(t, err)
+	t.Cleanup(unregister)
+}
+
+func TestGithubEnterpriseHosts(t *testing.T) {
+	t.Parallel()
+
+	declared := RawGithubEnterpriseHosts("https://GHE.Corp.Example/")
+
+	t.Run("should serve raw content from a declared Github Enterprise host", func(t *testing.T) {
+		locator, err := ParseAs(ProviderGithub, mustParseURL(t, "https://ghe.corp.example/owner/repo/blob/main/docs/README.md"))
+		require.NoError(t, err)
+		require.Equal(t, "https://ghe.corp.example/owner/repo", locator.RepoURL().String())
+		require.Equal(t, "main", locator.Version())
+		require.Equal(t, "docs/README.md", locator.Path())
+
+		raw, err := Raw(locator, declared)
+		require.NoError(t, err)
+		require.Equal(t, "https://ghe.corp.example/owner/repo/raw/main/docs/README.md", raw.String())
+		require.True(t, RawSupportsHEAD(locator.RepoURL(), declared))
+	})
+
+	t.Run("should NOT affect the detection of undeclared hosts", func(t *testing.T) {
+		provider, _, _ := AutoDetect(mustParseURL(t, "https://ghe.corp.example/owner/repo/blob/main/docs/README.md"))
+		require.Equal(t, ProviderUnknown, provider)
+		require.False(t, RawSupportsHEAD(mustParseURL(t, "https://ghe.corp.example/owner/repo")))
+	})
+
+	t.Run("should match a declared host on any port", func(t *testing.T) {
+		require.True(t, IsGithubEnterpriseHost("ghe.corp.example:8443", "https://GHE.Corp.Example/"))
+		require.False(t, IsGithubEnterpriseHost("other.corp.example", "https://GHE.Corp.Example/"))
+		require.False(t, IsGithubEnterpriseHost("ghe.corp.example", " "))
+	})
+}
+
+func TestTrustedProvider(t *testing.T) {
+	t.Parallel()
+
+	registerProvider(t, ProviderGitea, func(host string) bool {
+		return host == "git.trusted.example"
+	})
+
+	for _, tc := range []struct {
+		host     string
+		provider Provider
+		trusted  bool
+	}{
+		{host: "github.com", provider: ProviderGithub, trusted: true},
+		{host: "GitLab.com:443", provider: ProviderGitlab, trusted: true},
+		{host: "bitbucket.org", provider: ProviderBitBucket, trusted: true},
+		{host: "dev.azure.com", provider: ProviderAzure, trusted: true},
+		{host: "codeberg.org", provider: ProviderGitea, trusted: true},
+		{host: "git.trusted.example", provider: ProviderGitea, trusted: true},
+		{host: "github.attacker.com", provider: ProviderUnknown},
+		{host: "attacker-github.com", provider: ProviderUnknown},
+		{host: "git.example.com", provider: ProviderUnknown},
+	} {
+		provider, trusted := TrustedProvider(tc.host)
+		require.Equal(t, tc.trusted, trusted, tc.host)
+		require.Equal(t, tc.provider, provider, tc.host)
+	}
+}
+
+// TestGithubEnterpriseHostEnv is not parallel, since it alters the environment.
+func TestGithubEnterpriseHostEnv(t *testing.T) {
+	const location = "https://git.env.example/owner/repo/blob/v1.0.0/README.md"
+
+	provider, _, _ := AutoDetect(mustParseURL(t, location))
+	require.Equal(t, ProviderUnknown, provider)
+
+	t.Setenv(GithubEnterpriseHostEnv, "git.env.example")
+
+	provider, locator, err := AutoDetect(mustParseURL(t, location))
+	require.NoError(t, err)
+	require.Equal(t, ProviderGithub, provider)
+
+	raw, err := Raw(locator)
+	require.NoError(t, err)
+	require.Equal(t, "https://git.env.example/owner/repo/raw/v1.0.0/README.md", raw.String())
+
+	provider, trusted := TrustedProvider("git.env.example")
+	require.True(t, trusted)
+	require.Equal(t, ProviderGithub, provider)
+
+	providers := Providers()
+	idx := slices.IndexFunc(providers, func(info ProviderInfo) bool { return info.Name == ProviderGithub })
+	require.GreaterOrEqual(t, idx, 0)
+	require.Contains(t, providers[idx].Hosts, "git.env.example")
+}
+
+func TestRawDefaultRef(t *testing.T) {
+	t.Parallel()
+
+	for location, expected := range map[string]string{
+		"https://github.com/owner/repo":               "https://raw.githubusercontent.com/owner/repo/main/README.md",
+		"https://gitlab.com/owner/repo":               "https://gitlab.com/owner/repo/-/raw/main/README.md",
+		"https://gitea.com/owner/repo":                "https://gitea.com/owner/repo/raw/branch/main/README.md",
+		"https://bitbucket.org/workspace/repo":        "https://bitbucket.org/workspace/repo/raw/main/README.md",
+		"https://github.com/owner/repo/blob/v1.0.0/x": "https://raw.githubusercontent.com/owner/repo/v1.0.0/README.md",
+	} {
+		t.Run(location, func(t *testing.T) {
+			t.Parallel()
+
+			_, locator, err := AutoDetect(mustParseURL(t, location))
+			require.NoError(t, err)
+			locator = &testLocator{Locator: locator, path: "README.md"}
+
+			raw, err := Raw(locator, RawDefaultRef("main"))
+			require.NoError(t, err)
+			require.Equal(t, expected, raw.String())
+		})
+	}
+
+	t.Run("should emit HEAD by default", func(t *testing.T) {
+		t.Parallel()
+
+		_, locator, err := AutoDetect(mustParseURL(t, "https://github.com/owner/repo"))
+		require.NoError(t, err)
+
+		raw, err := Raw(&testLocator{Locator: locator, path: "README.md"})
+		require.NoError(t, err)
+		require.Equal(t, "https://raw.githubusercontent.com/owner/repo/HEAD/README.md", raw.String())
+	})
+}
+
+// testLocator overrides the path of a [Locator].
+type testLocator struct {
+	Locator
+
+	path string
+}
+
+func (l *testLocator) Path() string {
+	return l.path
+}
+
+func TestRawSupportsHEAD(t *testing.T) {
+	t.Parallel()
+
+	for repo, expected := range map[string]bool{
+		"https://github.com/owner/repo":            true,
+		"https://gitlab.com/owner/repo":            true,
+		"https://bitbucket.org/workspace/repo":     true,
+		"https://bitbucket.example.com/scm/P/repo": true,
+		"https://gitea.com/owner/repo":             false,
+		"https://codeberg.org/owner/repo":          false,
+		"https://git.sr.ht/~owner/repo":            false,
+		"https://git.example.com/owner/repo":       false,
+	} {
+		t.Run(repo, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, expected, RawSupportsHEAD(mustParseURL(t, repo)))
+		})
+	}
+}
+
+func TestListing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should yield listing API URLs", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"https://github.com/fredbi/go-vcsfetch/tree/master/docs":      "https://api.github.com/repos/fredbi/go-vcsfetch/contents/docs?ref=master",
+			"https://github.com/fredbi/go-vcsfetch":                       "https://api.github.com/repos/fredbi/go-vcsfetch/contents",
+			"https://gitlab.com/fredbi/go-vcsfetch/-/tree/v1.0.0/docs":    "https://gitlab.com/api/v4/projects/fredbi%2Fgo-vcsfetch/repository/tree?path=docs&per_page=100&ref=v1.0.0",
+			"https://gitea.com/fredbi/go-vcsfetch/src/branch/master/docs": "https://gitea.com/api/v1/repos/fredbi/go-vcsfetch/contents/docs?ref=master",
+			"https://codeberg.org/owner/repo/src/branch/main/docs":        "https://codeberg.org/api/v1/repos/owner/repo/contents/docs?ref=main",
+		} {
+			_, locator, err := AutoDetect(mustParseURL(t, input))
+			require.NoError(t, err)
+
+			u, decode, err := Listing(locator)
+			require.NoError(t, err)
+			require.NotNil(t, decode)
+			require.Equal(t, expected, u.String())
+		}
+	})
+
+	t.Run("should not yield a listing API URL", func(t *testing.T) {
+		for _, input := range []string{
+			"https://bitbucket.org/workspace/repo/src/main/docs",
+			"ssh://git@github.com/fredbi/go-vcsfetch/tree/master/docs",
+		} {
+			_, locator, err := AutoDetect(mustParseURL(t, input))
+			require.NoError(t, err)
+
+			_, _, err = Listing(locator)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestContents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should yield contents API URLs", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"https://github.com/fredbi/go-vcsfetch/blob/master/docs/README.md": "https://api.github.com/repos/fredbi/go-vcsfetch/contents/docs/README.md?ref=master",
+			"https://github.com/fredbi/go-vcsfetch/blob/HEAD/README.md":        "https://api.github.com/repos/fredbi/go-vcsfetch/contents/README.md",
+		} {
+			_, locator, err := AutoDetect(mustParseURL(t, input))
+			require.NoError(t, err)
+
+			u, err := Contents(locator)
+			require.NoError(t, err)
+			require.Equal(t, expected, u.String())
+		}
+	})
+
+	t.Run("should not yield a contents API URL", func(t *testing.T) {
+		for _, input := range []string{
+			"https://github.com/fredbi/go-vcsfetch",
+			"https://gitlab.com/fredbi/go-vcsfetch/-/blob/v1.0.0/README.md",
+			"ssh://git@github.com/fredbi/go-vcsfetch/blob/master/README.md",
+		} {
+			_, locator, err := AutoDetect(mustParseURL(t, input))
+			require.NoError(t, err)
+
+			_, err = Contents(locator)
+			require.Error(t, err)
+		}
+	})
+}
+
 type testURL struct {
 	u                *url.URL
 	expectedProvider Provider
@@ -65,6 +414,30 @@ func testURLs(t *testing.T) iter.Seq[testURL] {
 				u:                mustParseURL(t, "https://github.big-corporation.com/big-repo/blob/tree/master/README.md"),
 				expectedProvider: ProviderGithub,
 			},
+			{
+				u:                mustParseURL(t, "https://codeberg.org/owner/repo/src/branch/main/README.md"),
+				expectedProvider: ProviderGitea,
+			},
+			{
+				u:                mustParseURL(t, "https://codeberg.org:443/owner/repo/src/branch/main/README.md"),
+				expectedProvider: ProviderGitea,
+			},
+			{
+				u:                mustParseURL(t, "https://git.sr.ht/~owner/repo/tree/master/item/README.md"),
+				expectedProvider: ProviderSourcehut,
+			},
+			{
+				u:                mustParseURL(t, "https://sourcehut.example.com/~owner/repo/blob/master/README.md"),
+				expectedProvider: ProviderSourcehut,
+			},
+			{
+				u:                mustParseURL(t, "https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo"),
+				expectedProvider: ProviderCodeCommit,
+			},
+			{
+				u:                mustParseURL(t, "https://us-east-1.console.aws.amazon.com/codesuite/codecommit/repositories/my-repo/browse/refs/heads/main/--/README.md"),
+				expectedProvider: ProviderCodeCommit,
+			},
 			{
 				u:                mustParseURL(t, "https://chez.com/big-repo/blob/tree/master/README.md"),
 				expectedProvider: ProviderUnknown,
@@ -83,3 +456,74 @@ func mustParseURL(t *testing.T, str string) *url.URL {
 
 	return u
 }
+
+func TestProviders(t *testing.T) {
+	t.Parallel()
+
+	registerProvider(t, ProviderGitea, func(host string) bool {
+		return host == "git.providers.example"
+	})
+
+	providers := Providers()
+	byName := make(map[Provider]ProviderInfo, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name] = provider
+	}
+	require.Len(t, byName, len(builtinProviders))
+
+	t.Run("github should support raw content and APIs", func(t *testing.T) {
+		github := byName[ProviderGithub]
+		require.True(t, github.SupportsRaw())
+		require.Equal(t, RawPath, github.Raw)
+		require.True(t, github.RawSupportsHEAD)
+		require.True(t, github.SupportsSSH)
+		require.True(t, github.SupportsArchive)
+		require.True(t, github.SupportsContents)
+		require.True(t, github.SupportsListing)
+		require.Contains(t, github.Hosts, "*github*")
+	})
+
+	t.Run("azure should not support raw content yet", func(t *testing.T) {
+		azure := byName[ProviderAzure]
+		require.False(t, azure.SupportsRaw())
+		require.False(t, azure.SupportsSSH)
+		require.Equal(t, []string{"*azure*"}, azure.Hosts)
+	})
+
+	t.Run("should report registered matchers", func(t *testing.T) {
+		require.GreaterOrEqual(t, byName[ProviderGitea].RegisteredMatchers, 1)
+		require.Contains(t, byName[ProviderGitea].Hosts, "codeberg.org")
+	})
+
+	t.Run("should describe the features of the registry", func(t *testing.T) {
+		samples := map[Provider]string{
+			ProviderGithub:     "https://github.com/owner/repo/blob/main/README.md",
+			ProviderGitlab:     "https://gitlab.com/owner/repo/-/blob/main/README.md",
+			ProviderAzure:      "https://dev.azure.com/owner/project/_git/repo?path=/README.md&version=GBmain",
+			ProviderBitBucket:  "https://bitbucket.org/owner/repo/src/main/README.md",
+			ProviderGitea:      "https://gitea.com/owner/repo/src/branch/main/README.md",
+			ProviderSourcehut:  "https://git.sr.ht/~owner/repo/tree/main/item/README.md",
+			ProviderCodeCommit: "https://git-codecommit.us-east-1.amazonaws.com/v1/repos/repo@main",
+		}
+
+		for _, provider := range providers {
+			sample, ok := samples[provider.Name]
+			require.True(t, ok, "missing sample for %v", provider.Name)
+
+			detected, locator, err := AutoDetect(mustParseURL(t, sample))
+			require.NoError(t, err)
+			require.Equal(t, provider.Name, detected)
+			locator = &testLocator{Locator: locator, path: "README.md"}
+
+			_, err = Raw(locator)
+			require.Equal(t, provider.SupportsRaw(), err == nil, "raw for %v", provider.Name)
+			_, err = Archive(locator)
+			require.Equal(t, provider.SupportsArchive, err == nil, "archive for %v", provider.Name)
+			_, err = Contents(locator)
+			require.Equal(t, provider.SupportsContents, err == nil, "contents for %v", provider.Name)
+			_, _, err = Listing(locator)
+			require.Equal(t, provider.SupportsListing, err == nil, "listing for %v", provider.Name)
+			require.Equal(t, provider.SupportsRaw() && provider.RawSupportsHEAD, RawSupportsHEAD(locator.RepoURL()), "raw HEAD for %v", provider.Name)
+		}
+	})
+}
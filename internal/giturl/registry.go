@@ -0,0 +1,379 @@
+package giturl
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/azure"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/bitbucket"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/codecommit"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/gitea"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/github"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/gitlab"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/sourcehut"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+)
+
+// HostMatcher tells if a host belongs to a [Provider].
+//
+// The host passed to a matcher is normalized to lower case, so matchers should only compare
+// against lower case strings. The host may include a port, e.g. "git.example.com:8443".
+type HostMatcher func(host string) bool
+
+type registration struct {
+	provider Provider
+	match    HostMatcher
+
+	// hosts describe the hosts recognized by a built-in matcher
+	hosts []string
+	// features supported by a built-in provider
+	features ProviderInfo
+}
+
+var registry = struct {
+	mx      sync.RWMutex
+	entries []*registration
+}{}
+
+// builtinProviders detect well-known providers whenever the provider's name appears in the host.
+var builtinProviders = []registration{
+	{
+		provider: ProviderGithub, match: hostGithub,
+		hosts: []string{"*github*"},
+		features: ProviderInfo{
+			Raw: RawPath, RawSupportsHEAD: github.RawSupportsHEAD, SupportsSSH: true,
+			SupportsArchive: true, SupportsContents: true, SupportsListing: true,
+		},
+	},
+	{
+		provider: ProviderGitlab, match: hostContains(ProviderGitlab),
+		hosts: []string{"*gitlab*"},
+		features: ProviderInfo{
+			Raw: RawPath, RawSupportsHEAD: gitlab.RawSupportsHEAD, SupportsSSH: true, SupportsListing: true,
+		},
+	},
+	{
+		// TODO: azure raw content is served by the Items API
+		provider: ProviderAzure, match: hostContains(ProviderAzure),
+		hosts: []string{"*azure*"},
+	},
+	{
+		provider: ProviderBitBucket, match: hostContains(ProviderBitBucket),
+		hosts: []string{"*bitbucket*"},
+		features: ProviderInfo{
+			Raw: RawPath, RawSupportsHEAD: bitbucket.RawSupportsHEAD, SupportsSSH: true,
+		},
+	},
+	{
+		provider: ProviderGitea, match: hostGitea,
+		hosts: append([]string{"*gitea*"}, giteaHosts...),
+		features: ProviderInfo{
+			Raw: RawPath, RawSupportsHEAD: gitea.RawSupportsHEAD, SupportsSSH: true, SupportsListing: true,
+		},
+	},
+	{
+		provider: ProviderSourcehut, match: hostSourcehut,
+		hosts: []string{"*sourcehut*", "sr.ht", "*.sr.ht"},
+		features: ProviderInfo{
+			Raw: RawPath, RawSupportsHEAD: sourcehut.RawSupportsHEAD, SupportsSSH: true,
+		},
+	},
+	{
+		provider: ProviderCodeCommit, match: codecommit.IsHost,
+		hosts: []string{"git-codecommit.*.amazonaws.com", "git-codecommit-fips.*.amazonaws.com", "git-codecommit.*.amazonaws.com.cn", "*.console.aws.amazon.com"},
+		features: ProviderInfo{
+			SupportsSSH: true,
+		},
+	},
+}
+
+// RawKind tells how a provider serves the raw content of files.
+type RawKind string
+
+const (
+	// RawNone means that raw content is not supported for the provider: files are retrieved using git.
+	RawNone RawKind = ""
+	// RawPath is a raw-content URL made of path segments, e.g. https://raw.githubusercontent.com/owner/repo/ref/file.
+	RawPath RawKind = "path"
+	// RawAPI is a raw-content URL to the API of the provider, with query parameters.
+	RawAPI RawKind = "api"
+)
+
+// ProviderInfo describes a well-known [Provider] and the features supported for the repositories it hosts.
+type ProviderInfo struct {
+	// Name of the provider.
+	Name Provider
+
+	// Hosts describe the hosts detected as this provider by the built-in detection,
+	// e.g. "*github*" for any host which name contains "github".
+	//
+	// The Github Enterprise host set by the GHE_HOST environment variable is included.
+	Hosts []string
+
+	// RegisteredMatchers is the number of host matchers registered for this provider with [RegisterProvider].
+	RegisteredMatchers int
+
+	// Raw tells how [Raw] yields raw-content URLs for this provider.
+	Raw RawKind
+
+	// RawSupportsHEAD tells if the raw-content URLs of this provider resolve "HEAD" (see [RawSupportsHEAD]).
+	RawSupportsHEAD bool
+
+	// SupportsSSH tells if ssh URLs are recognized for this provider.
+	SupportsSSH bool
+
+	// SupportsArchive tells if [Archive] yields archive URLs for this provider.
+	SupportsArchive bool
+
+	// SupportsContents tells if [Contents] yields contents API URLs for this provider.
+	SupportsContents bool
+
+	// SupportsListing tells if [Listing] yields listing API URLs for this provider.
+	SupportsListing bool
+}
+
+// SupportsRaw tells if [Raw] yields raw-content URLs for this provider.
+func (p ProviderInfo) SupportsRaw() bool {
+	return p.Raw != RawNone
+}
+
+// Providers describes all the well-known providers, in the order of the built-in detection.
+//
+// The description reflects the hosts registered with [RegisterProvider] and the GHE_HOST environment variable.
+func Providers() []ProviderInfo {
+	registry.mx.RLock()
+	registered := make(map[Provider]int, len(registry.entries))
+	for _, entry := range registry.entries {
+		registered[entry.provider]++
+	}
+	registry.mx.RUnlock()
+
+	providers := make([]ProviderInfo, 0, len(builtinProviders))
+	for _, builtin := range builtinProviders {
+		info := builtin.features
+		info.Name = builtin.provider
+		info.Hosts = slices.Clone(builtin.hosts)
+		info.RegisteredMatchers = registered[builtin.provider]
+
+		if fromEnv := NormalizeHost(os.Getenv(GithubEnterpriseHostEnv)); fromEnv != "" && builtin.provider == ProviderGithub {
+			info.Hosts = append(info.Hosts, fromEnv)
+		}
+
+		providers = append(providers, info)
+	}
+
+	return providers
+}
+
+func hostContains(provider Provider) HostMatcher {
+	return func(host string) bool {
+		return strings.Contains(host, provider.String())
+	}
+}
+
+// GithubEnterpriseHostEnv is the environment variable which designates a Github Enterprise host
+// for the whole process.
+const GithubEnterpriseHostEnv = "GHE_HOST"
+
+// hostGithub matches github instances, including the Github Enterprise host set by the GHE_HOST environment variable.
+func hostGithub(host string) bool {
+	return hostContains(ProviderGithub)(host) || IsGithubEnterpriseHost(host)
+}
+
+// IsGithubEnterpriseHost tells if a host is set by the GHE_HOST environment variable,
+// or is one of the declared Github Enterprise hosts, e.g. "git.example.com".
+//
+// A declared host without a port matches this host on any port.
+func IsGithubEnterpriseHost(host string, declared ...string) bool {
+	host = strings.ToLower(host)
+	hostname, _, _ := strings.Cut(host, ":")
+	matches := func(candidate string) bool {
+		candidate = NormalizeHost(candidate)
+
+		return candidate != "" && (candidate == host || candidate == hostname)
+	}
+
+	return matches(os.Getenv(GithubEnterpriseHostEnv)) || slices.ContainsFunc(declared, matches)
+}
+
+// NormalizeHost yields the lower case host of a Github Enterprise host declaration.
+//
+// The host may include a port. A scheme and a trailing slash are tolerated, e.g. "https://git.example.com/".
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if _, after, found := strings.Cut(host, "://"); found {
+		host = after
+	}
+	host, _, _ = strings.Cut(host, "/")
+
+	return host
+}
+
+// giteaHosts are well-known public gitea instances which host name does not contain "gitea".
+//
+// Other gitea-compatible hosts may be added with [RegisterProvider].
+var giteaHosts = []string{
+	"codeberg.org",
+}
+
+// hostGitea matches gitea instances, including the well-known instances listed in giteaHosts.
+func hostGitea(host string) bool {
+	hostname, _, _ := strings.Cut(host, ":")
+
+	return hostContains(ProviderGitea)(host) || slices.Contains(giteaHosts, hostname)
+}
+
+// hostSourcehut matches sourcehut instances, which are usually hosted under the "sr.ht" domain, e.g. "git.sr.ht".
+func hostSourcehut(host string) bool {
+	hostname, _, _ := strings.Cut(host, ":")
+
+	return hostContains(ProviderSourcehut)(host) || hostname == "sr.ht" || strings.HasSuffix(hostname, ".sr.ht")
+}
+
+// RegisterProvider associates hosts recognized by a [HostMatcher] with a well-known [Provider].
+//
+// This is useful for self-hosted instances which host name does not contain the name of the provider,
+// e.g. "git.example.com" for an on-premises gitlab.
+//
+// Registered matchers are evaluated in the order of registration, before the built-in detection.
+//
+// The host passed to the matcher is always normalized to lower case.
+//
+// The returned function removes the matcher from the registry, e.g. to scope a registration to a test.
+func RegisterProvider(provider Provider, matcher HostMatcher) (unregister func(), err error) {
+	if matcher == nil {
+		return nil, fmt.Errorf("a host matcher is required to register provider %v: %w", provider, ErrProvider)
+	}
+
+	if !IsKnownProvider(provider) {
+		return nil, fmt.Errorf("cannot register provider %q: %w: %w", provider, ErrUnknownProvider, ErrProvider)
+	}
+
+	registry.mx.Lock()
+	defer registry.mx.Unlock()
+
+	entry := &registration{provider: provider, match: matcher}
+	registry.entries = append(registry.entries, entry)
+
+	return func() {
+		registry.mx.Lock()
+		defer registry.mx.Unlock()
+
+		registry.entries = slices.DeleteFunc(registry.entries, func(registered *registration) bool {
+			return registered == entry
+		})
+	}, nil
+}
+
+// IsKnownProvider tells if a [Provider] is one of the well-known providers.
+func IsKnownProvider(provider Provider) bool {
+	for _, builtin := range builtinProviders {
+		if builtin.provider == provider {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectProvider yields the first provider which matches the host, or [ProviderUnknown].
+func detectProvider(host string) Provider {
+	host = strings.ToLower(host)
+
+	if provider, ok := registeredProvider(host); ok {
+		return provider
+	}
+
+	for _, builtin := range builtinProviders {
+		if builtin.match(host) {
+			return builtin.provider
+		}
+	}
+
+	return ProviderUnknown
+}
+
+// canonicalHosts are the hosts of the public instances of well-known providers.
+var canonicalHosts = map[string]Provider{
+	"github.com":    ProviderGithub,
+	"gitlab.com":    ProviderGitlab,
+	"bitbucket.org": ProviderBitBucket,
+	"dev.azure.com": ProviderAzure,
+}
+
+// TrustedProvider yields the [Provider] of a host which is known for sure to belong to this provider:
+// the public instance of a well-known provider (e.g. "github.com"), a well-known public gitea instance,
+// a host recognized by a matcher registered with [RegisterProvider] or the Github Enterprise host set by the
+// GHE_HOST environment variable.
+//
+// Unlike [AutoDetect], it never guesses the provider from the host name: "github.attacker.example" is not trusted.
+func TrustedProvider(host string) (Provider, bool) {
+	host = strings.ToLower(host)
+	hostname, _, _ := strings.Cut(host, ":")
+
+	if provider, ok := canonicalHosts[hostname]; ok {
+		return provider, true
+	}
+
+	if slices.Contains(giteaHosts, hostname) {
+		return ProviderGitea, true
+	}
+
+	if provider, ok := registeredProvider(host); ok {
+		return provider, true
+	}
+
+	if IsGithubEnterpriseHost(host) {
+		return ProviderGithub, true
+	}
+
+	return ProviderUnknown, false
+}
+
+// registeredProvider yields the provider of the first matcher registered with [RegisterProvider] which matches the host.
+func registeredProvider(host string) (Provider, bool) {
+	registry.mx.RLock()
+	defer registry.mx.RUnlock()
+
+	for _, entry := range registry.entries {
+		if entry.match(host) {
+			return entry.provider, true
+		}
+	}
+
+	return ProviderUnknown, false
+}
+
+func parse(provider Provider, u *url.URL) (Locator, error) {
+	switch provider {
+	case ProviderGithub:
+		return asLocator(github.Parse(u))
+	case ProviderGitlab:
+		return asLocator(gitlab.Parse(u))
+	case ProviderBitBucket:
+		return asLocator(bitbucket.Parse(u))
+	case ProviderGitea:
+		return asLocator(gitea.Parse(u))
+	case ProviderAzure:
+		return asLocator(azure.Parse(u))
+	case ProviderSourcehut:
+		return asLocator(sourcehut.Parse(u))
+	case ProviderCodeCommit:
+		return asLocator(codecommit.Parse(u))
+	default:
+		return nil, fmt.Errorf("url=%q: %w: %w", redact.URL(u), ErrUnknownProvider, ErrProvider)
+	}
+}
+
+// asLocator avoids returning a typed nil [Locator] on error.
+func asLocator[T Locator](locator T, err error) (Locator, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return locator, nil
+}
@@ -0,0 +1,13 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcehut
+
+type sourcehutError string
+
+func (e sourcehutError) Error() string {
+	return string(e)
+}
+
+// ErrSourcehut is a sentinel error for all errors that originate from this package.
+const ErrSourcehut sourcehutError = "sourcehut error"
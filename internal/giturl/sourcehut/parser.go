@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcehut
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
+)
+
+// URL is a sourcehut-style URL to a vcs resource hosted by sourcehut.
+type URL struct {
+	repoURL *url.URL
+	path    string
+	version string
+}
+
+const (
+	defaultScheme = "https"
+	defaultHost   = "git.sr.ht"
+
+	namespacePrefix = "~"
+)
+
+// config describes sourcehut URLs: "tree" then the ref and "item" before the path, or "blob" then the ref and the path.
+var config = common.Config{
+	DefaultScheme: defaultScheme,
+	DefaultHost:   defaultHost,
+	Layout: common.Layout{
+		Keywords: map[string]common.Keyword{
+			"tree": {IsTree: true, PathMarker: "item"},
+			"blob": {},
+		},
+		Err: ErrSourcehut,
+	},
+}
+
+// Parse a sourcehut URL.
+//
+// Sourcehut URL formats:
+//   - Browse: https://git.sr.ht/~{owner}/{repo}/tree/{ref}/item/{path}
+//   - Raw: https://git.sr.ht/~{owner}/{repo}/blob/{ref}/{path}
+//   - Repo: https://git.sr.ht/~{owner}/{repo}
+//
+// Repositories live in the namespace of their owner, prefixed by "~": the prefix is added to the owner if missing.
+func Parse(sourcehutURL *url.URL) (*URL, error) {
+	parsed, err := common.Parse(sourcehutURL, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo := strings.TrimPrefix(parsed.RepoURL.Path, "/"); !strings.HasPrefix(repo, namespacePrefix) {
+		parsed.RepoURL.Path = "/" + namespacePrefix + repo
+	}
+
+	sh := &URL{
+		repoURL: parsed.RepoURL,
+		path:    parsed.Path,
+		version: parsed.Version,
+	}
+
+	return sh, nil
+}
+
+// RepoURL yields the base URL of the vcs repository,
+// e.g. https://git.sr.ht/~sircmpwn/scdoc
+func (sh *URL) RepoURL() *url.URL {
+	return sh.repoURL
+}
+
+// Version yields the ref identifying the desired version of a file,
+// e.g. "master" in https://git.sr.ht/~sircmpwn/scdoc/tree/master/item/README.md
+func (sh *URL) Version() string {
+	return sh.version
+}
+
+// Path yields the file path relative to the repository,
+// e.g. "README.md" in https://git.sr.ht/~sircmpwn/scdoc/tree/master/item/README.md
+func (sh *URL) Path() string {
+	return sh.path
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcehut
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+type testCase struct {
+	url     string
+	repo    string
+	version string
+	path    string
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		wantRepo    string
+		wantVersion string
+		wantPath    string
+		wantErr     bool
+	}{
+		{
+			name:        "git.sr.ht repo only",
+			input:       "https://git.sr.ht/~owner/repo",
+			wantRepo:    "https://git.sr.ht/~owner/repo",
+			wantVersion: "",
+			wantPath:    "/",
+			wantErr:     false,
+		},
+		{
+			name:        "git.sr.ht tree with ref and file",
+			input:       "https://git.sr.ht/~owner/repo/tree/master/item/README.md",
+			wantRepo:    "https://git.sr.ht/~owner/repo",
+			wantVersion: "master",
+			wantPath:    "README.md",
+			wantErr:     false,
+		},
+		{
+			name:        "git.sr.ht tree with ref only",
+			input:       "https://git.sr.ht/~owner/repo/tree/v1.0.0",
+			wantRepo:    "https://git.sr.ht/~owner/repo",
+			wantVersion: "v1.0.0",
+			wantPath:    "/",
+			wantErr:     false,
+		},
+		{
+			name:        "git.sr.ht blob with ref and file",
+			input:       "https://git.sr.ht/~owner/repo/blob/main/path/to/file.go",
+			wantRepo:    "https://git.sr.ht/~owner/repo",
+			wantVersion: "main",
+			wantPath:    "path/to/file.go",
+			wantErr:     false,
+		},
+		{
+			name:        "git.sr.ht blob with commit",
+			input:       "https://git.sr.ht/~owner/repo/blob/abc123/file.txt",
+			wantRepo:    "https://git.sr.ht/~owner/repo",
+			wantVersion: "abc123",
+			wantPath:    "file.txt",
+			wantErr:     false,
+		},
+		{
+			name:        "owner without ~ prefix",
+			input:       "https://git.sr.ht/owner/repo/blob/main/file.txt",
+			wantRepo:    "https://git.sr.ht/~owner/repo",
+			wantVersion: "main",
+			wantPath:    "file.txt",
+			wantErr:     false,
+		},
+		{
+			name:        "self-hosted sourcehut instance",
+			input:       "https://git.example.com/~owner/repo/tree/develop/item/code.js",
+			wantRepo:    "https://git.example.com/~owner/repo",
+			wantVersion: "develop",
+			wantPath:    "code.js",
+			wantErr:     false,
+		},
+		{
+			name:        "repo with .git suffix",
+			input:       "https://git.sr.ht/~owner/repo.git/blob/main/file",
+			wantRepo:    "https://git.sr.ht/~owner/repo",
+			wantVersion: "main",
+			wantPath:    "file",
+			wantErr:     false,
+		},
+		{
+			name:    "invalid - missing owner/repo",
+			input:   "https://git.sr.ht/~owner",
+			wantErr: true,
+		},
+		{
+			name:    "invalid - wrong discriminator",
+			input:   "https://git.sr.ht/~owner/repo/src/branch/main/file",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.input)
+			require.NoError(t, err)
+
+			got, err := Parse(u)
+
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, got)
+			require.Equal(t, tc.wantRepo, got.RepoURL().String())
+			require.Equal(t, tc.wantVersion, got.Version())
+			require.Equal(t, tc.wantPath, got.Path())
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcehut
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Locator redefines locally the common minimal locator interface.
+//
+// This avoids cross-dependencies between repos.
+//
+// This package exposes [URL] as an implementation for sourcehut.
+type Locator interface {
+	RepoURL() *url.URL
+	Path() string
+	Version() string
+}
+
+// RawSupportsHEAD tells that the blob endpoint of sourcehut is not documented to resolve "HEAD":
+// the version is resolved to the default branch first.
+const RawSupportsHEAD = false
+
+// Raw returns the raw content URL for a [Locator] hosted on a sourcehut instance.
+//
+// Sourcehut serves the raw content of files on its "blob" route.
+//
+// Only https URL's are supported.
+//
+// For self-hosted instances, this only works for instances accessible via
+// standard https (port 443 or unspecified).
+//
+// Examples:
+//
+//   - https://git.sr.ht/~sircmpwn/scdoc/blob/master/README.md
+//   - https://git.sr.ht/~owner/repo/blob/v1.0.0/docs/api.md
+func Raw(locator Locator) (*url.URL, error) {
+	repo := locator.RepoURL()
+	pth := strings.Trim(locator.Path(), "/")
+	if pth == "" {
+		return nil, fmt.Errorf("returning a raw content url requires a non empty path to a file: %w", ErrSourcehut)
+	}
+
+	version := locator.Version()
+	if version == "" {
+		version = "HEAD"
+	}
+
+	scheme, _ := strings.CutSuffix(repo.Scheme, "+git")
+
+	if scheme != "https" {
+		return nil, fmt.Errorf("returning a raw content url requires a https URL scheme: %w", ErrSourcehut)
+	}
+
+	if port := repo.Port(); port != "" && port != "443" {
+		return nil, fmt.Errorf("returning a raw content url requires a https URL with standard port (443 or unspecified): %w", ErrSourcehut)
+	}
+
+	u := &url.URL{}
+	*u = *repo // shallow clone
+
+	// sourcehut raw URL format: /~{owner}/{repo}/blob/{ref}/{path}
+	u.Path = path.Join(u.Path, "blob", version, pth)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u, nil
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package sourcehut
+
+import (
+	"iter"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestRaw(t *testing.T) {
+	t.Parallel()
+
+	t.Run("with valid raw URLs", func(t *testing.T) {
+		for tc := range rawTestCasesValid(t) {
+			t.Run("should convert to raw", testShouldRaw(tc))
+		}
+	})
+
+	t.Run("with non-raw URLs", func(t *testing.T) {
+		for tc := range rawTestCasesInvalid(t) {
+			t.Run("should NOT convert to raw", testShouldNotRaw(tc))
+		}
+	})
+}
+
+func TestRawEdgeCases(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should NOT convert URL with empty file path to raw", func(t *testing.T) {
+		const emptyPath = "https://git.sr.ht/~owner/repo/"
+
+		u, err := url.Parse(emptyPath)
+		require.NoErrorf(t, err,
+			"test is wrongly configured: expected a valid URL string, but got: %q: %v",
+			emptyPath, err,
+		)
+		raw, err := Parse(u)
+		require.NoErrorf(t, err,
+			"test is wrongly configured: expected a valid sourcehut URL string, but got: %q: %v",
+			emptyPath, err,
+		)
+
+		_, err = Raw(raw)
+		require.Errorf(t, err, "expected an empty path to return an error")
+	})
+
+	t.Run("should convert URL with empty version to raw", func(t *testing.T) {
+		const emptyVersion = "https://git.sr.ht/~owner/repo/tree/main/item/file"
+
+		u, err := url.Parse(emptyVersion)
+		require.NoErrorf(t, err,
+			"test is wrongly configured: expected a valid URL string, but got: %q: %v",
+			emptyVersion, err,
+		)
+		raw, err := Parse(u)
+		require.NoErrorf(t, err,
+			"test is wrongly configured: expected a valid sourcehut URL string, but got: %q: %v",
+			emptyVersion, err,
+		)
+		raw.version = "" // force empty version
+
+		v, err := Raw(raw)
+		require.NoErrorf(t, err, "expected an empty version to be supported")
+		require.Contains(t, v.String(), "HEAD")
+	})
+}
+
+func testShouldRaw(tc testCase) func(*testing.T) {
+	return func(t *testing.T) {
+		u, err := url.Parse(tc.url)
+		require.NoErrorf(t, err,
+			"test is wrongly configured: expected a valid URL string, but got: %q: %v",
+			tc.url, err,
+		)
+
+		raw, err := Parse(u)
+		require.NoErrorf(t, err,
+			"test is wrongly configured: expected a valid sourcehut locator string, but got: %q: %v",
+			tc.url, err,
+		)
+
+		res, err := Raw(raw)
+		require.NoErrorf(t, err, "unexpected error: %v for %v", err, u)
+		require.NotEmpty(t, res.String())
+	}
+}
+
+func testShouldNotRaw(tc testCase) func(*testing.T) {
+	return func(t *testing.T) {
+		u, err := url.Parse(tc.url)
+		require.NoErrorf(t, err,
+			"test is wrongly configured: expected a valid URL string, but got: %q: %v",
+			tc.url, err,
+		)
+
+		raw, err := Parse(u)
+		require.NoErrorf(t, err,
+			"test is wrongly configured: expected a valid sourcehut locator string, but got: %q: %v",
+			tc.url, err,
+		)
+
+		res, err := Raw(raw)
+		require.Errorf(t, err, "expected error for %v", u)
+		require.Nil(t, res)
+	}
+}
+
+func rawTestCasesValid(_ *testing.T) iter.Seq[testCase] {
+	return slices.Values(
+		[]testCase{
+			{
+				url:     "https://git.sr.ht/~fredbi/go-vcsfetch/tree/master/item/README.md",
+				repo:    "https://git.sr.ht/~fredbi/go-vcsfetch",
+				version: "master",
+				path:    "README.md",
+			},
+			{
+				url:     "https://git.sr.ht/~fredbi/go-vcsfetch/blob/master/README.md",
+				repo:    "https://git.sr.ht/~fredbi/go-vcsfetch",
+				version: "master",
+				path:    "README.md",
+			},
+			{
+				url:     "https://git.sr.ht/~fredbi/go-vcsfetch/tree/v1.0.0/item/LICENSE",
+				repo:    "https://git.sr.ht/~fredbi/go-vcsfetch",
+				version: "v1.0.0",
+				path:    "LICENSE",
+			},
+			{
+				url:     "https://git.sr.ht/~fredbi/go-vcsfetch/blob/abc123def/file.txt",
+				repo:    "https://git.sr.ht/~fredbi/go-vcsfetch",
+				version: "abc123def",
+				path:    "file.txt",
+			},
+			{
+				url:     "https://git.sr.ht/~owner/repo/tree/develop/item/internal/util.go",
+				repo:    "https://git.sr.ht/~owner/repo",
+				version: "develop",
+				path:    "internal/util.go",
+			},
+			{
+				url:     "https://git.sr.ht/~owner/repo.git/blob/main/file.go",
+				repo:    "https://git.sr.ht/~owner/repo",
+				version: "main",
+				path:    "file.go",
+			},
+		},
+	)
+}
+
+func rawTestCasesInvalid(_ *testing.T) iter.Seq[testCase] {
+	return slices.Values(
+		[]testCase{
+			{
+				url:     "https://git.sr.ht/~owner/repo",
+				repo:    "https://git.sr.ht/~owner/repo",
+				version: "",
+				path:    "/",
+			},
+			{
+				url:     "https://git.sr.ht/~owner/repo/tree/main",
+				repo:    "https://git.sr.ht/~owner/repo",
+				version: "main",
+				path:    "/",
+			},
+			{
+				url:     "ssh://git@git.sr.ht/~owner/repo/blob/main/file.go",
+				repo:    "ssh://git@git.sr.ht/~owner/repo",
+				version: "main",
+				path:    "file.go",
+			},
+			{
+				url:     "https://git.example.com:8443/~owner/repo/blob/main/config.yaml",
+				repo:    "https://git.example.com:8443/~owner/repo",
+				version: "main",
+				path:    "config.yaml",
+			},
+		},
+	)
+}
@@ -0,0 +1,347 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+// Package testrepo builds local git repositories to be used as test fixtures.
+//
+// Fixtures are served over the file:// transport, so tests may exercise the git code path without network access.
+package testrepo
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Repo is a git repository created in a temporary directory.
+type Repo struct {
+	*gogit.Repository
+
+	Dir string
+	t   testing.TB
+
+	signKey *openpgp.Entity
+	signer  gogit.Signer
+}
+
+// New initializes an empty git repository in a temporary directory.
+//
+// The repository accepts fetches by commit hash, like most git hosting platforms do.
+func New(t testing.TB) *Repo {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("could not init test repo: %v", err)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatalf("could not read test repo config: %v", err)
+	}
+	cfg.Raw.Section("uploadpack").SetOption("allowReachableSHA1InWant", "true")
+	if err = repo.SetConfig(cfg); err != nil {
+		t.Fatalf("could not write test repo config: %v", err)
+	}
+
+	return &Repo{
+		Repository: repo,
+		Dir:        dir,
+		t:          t,
+	}
+}
+
+// Commit writes files (path: content) to the worktree and commits them.
+func (r *Repo) Commit(files map[string]string, message string) plumbing.Hash {
+	r.t.Helper()
+
+	return r.CommitAt(files, message, time.Now())
+}
+
+// CommitAt writes files (path: content) to the worktree and commits them, with a given commit date.
+func (r *Repo) CommitAt(files map[string]string, message string, when time.Time) plumbing.Hash {
+	r.t.Helper()
+
+	wt, err := r.Worktree()
+	if err != nil {
+		r.t.Fatalf("could not get test repo worktree: %v", err)
+	}
+
+	for name, content := range files {
+		pth := filepath.Join(r.Dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+			r.t.Fatalf("could not create folder for %q: %v", name, err)
+		}
+
+		if err = os.WriteFile(pth, []byte(content), 0o600); err != nil {
+			r.t.Fatalf("could not write %q: %v", name, err)
+		}
+
+		if _, err = wt.Add(name); err != nil {
+			r.t.Fatalf("could not add %q: %v", name, err)
+		}
+	}
+
+	return r.commit(wt, message, when)
+}
+
+// Symlink commits a symbolic link at path name, which points to target.
+//
+// The test is skipped if the platform does not support symbolic links.
+func (r *Repo) Symlink(name, target, message string) plumbing.Hash {
+	r.t.Helper()
+
+	wt, err := r.Worktree()
+	if err != nil {
+		r.t.Fatalf("could not get test repo worktree: %v", err)
+	}
+
+	pth := filepath.Join(r.Dir, filepath.FromSlash(name))
+	if err = os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		r.t.Fatalf("could not create folder for %q: %v", name, err)
+	}
+
+	if err = os.Symlink(target, pth); err != nil {
+		r.t.Skipf("could not create symbolic link %q: %v", name, err)
+	}
+
+	if _, err = wt.Add(name); err != nil {
+		r.t.Fatalf("could not add %q: %v", name, err)
+	}
+
+	return r.commit(wt, message, time.Now())
+}
+
+// AddSubmodule registers a submodule at path name, pinned at a commit of the sub repository, and commits it.
+//
+// Several submodules may be added to the same repository.
+func (r *Repo) AddSubmodule(name string, sub *Repo, hash plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+
+	return r.AddSubmoduleURL(name, sub.URL(), hash)
+}
+
+// AddSubmoduleURL registers a submodule at path name, pinned at a commit of the repository served at subURL,
+// and commits it.
+func (r *Repo) AddSubmoduleURL(name string, subURL *url.URL, hash plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+
+	wt, err := r.Worktree()
+	if err != nil {
+		r.t.Fatalf("could not get test repo worktree: %v", err)
+	}
+
+	modulesFile := filepath.Join(r.Dir, ".gitmodules")
+	modules, err := os.ReadFile(modulesFile)
+	if err != nil && !os.IsNotExist(err) {
+		r.t.Fatalf("could not read .gitmodules: %v", err)
+	}
+
+	modules = fmt.Appendf(modules, "[submodule %q]\n\tpath = %s\n\turl = %s\n", name, name, subURL)
+	if err = os.WriteFile(modulesFile, modules, 0o600); err != nil {
+		r.t.Fatalf("could not write .gitmodules: %v", err)
+	}
+
+	if _, err = wt.Add(".gitmodules"); err != nil {
+		r.t.Fatalf("could not add .gitmodules: %v", err)
+	}
+
+	idx, err := r.Storer.Index()
+	if err != nil {
+		r.t.Fatalf("could not read test repo index: %v", err)
+	}
+
+	idx.Entries = append(idx.Entries, &index.Entry{
+		Name: name,
+		Hash: hash,
+		Mode: filemode.Submodule,
+	})
+	if err = r.Storer.SetIndex(idx); err != nil {
+		r.t.Fatalf("could not write test repo index: %v", err)
+	}
+
+	return r.commit(wt, "add submodule "+name, time.Now())
+}
+
+func (r *Repo) commit(wt *gogit.Worktree, message string, when time.Time) plumbing.Hash {
+	r.t.Helper()
+
+	hash, err := wt.Commit(message, &gogit.CommitOptions{
+		Author:  signature(when),
+		SignKey: r.signKey,
+		Signer:  r.signer,
+	})
+	if err != nil {
+		r.t.Fatalf("could not commit: %v", err)
+	}
+
+	return hash
+}
+
+// Tag creates a lightweight tag on a commit.
+func (r *Repo) Tag(name string, hash plumbing.Hash) {
+	r.t.Helper()
+
+	if _, err := r.CreateTag(name, hash, nil); err != nil {
+		r.t.Fatalf("could not create tag %q: %v", name, err)
+	}
+}
+
+// SignWith signs subsequent commits and annotated tags with an OpenPGP key.
+//
+// A nil key disables signing.
+func (r *Repo) SignWith(key *openpgp.Entity) {
+	r.signKey = key
+}
+
+// SignWithSigner signs subsequent commits with a custom signer, e.g. to produce signatures in another format than OpenPGP.
+//
+// A nil signer disables signing.
+func (r *Repo) SignWithSigner(signer gogit.Signer) {
+	r.signer = signer
+}
+
+// AnnotatedTag creates an annotated tag on a commit.
+func (r *Repo) AnnotatedTag(name string, hash plumbing.Hash, message string) {
+	r.t.Helper()
+
+	if _, err := r.CreateTag(name, hash, &gogit.CreateTagOptions{
+		Tagger:  signature(time.Now()),
+		Message: message,
+		SignKey: r.signKey,
+	}); err != nil {
+		r.t.Fatalf("could not create annotated tag %q: %v", name, err)
+	}
+}
+
+// AddNote attaches a note to a commit, under the default notes ref "refs/notes/commits".
+//
+// Notes are stored flat, i.e. without splitting hashes into folders. An existing note for this commit is replaced.
+func (r *Repo) AddNote(hash plumbing.Hash, note string) {
+	r.t.Helper()
+
+	const notesRef = plumbing.ReferenceName("refs/notes/commits")
+
+	blob := r.Storer.NewEncodedObject()
+	blob.SetType(plumbing.BlobObject)
+	w, err := blob.Writer()
+	if err != nil {
+		r.t.Fatalf("could not write note: %v", err)
+	}
+	if _, err = io.WriteString(w, note); err != nil {
+		r.t.Fatalf("could not write note: %v", err)
+	}
+	if err = w.Close(); err != nil {
+		r.t.Fatalf("could not write note: %v", err)
+	}
+	blobHash, err := r.Storer.SetEncodedObject(blob)
+	if err != nil {
+		r.t.Fatalf("could not store note: %v", err)
+	}
+
+	entries := []object.TreeEntry{{Name: hash.String(), Mode: filemode.Regular, Hash: blobHash}}
+	var parents []plumbing.Hash
+	if ref, err := r.Reference(notesRef, true); err == nil {
+		parents = append(parents, ref.Hash())
+		previous, err := r.CommitObject(ref.Hash())
+		if err != nil {
+			r.t.Fatalf("could not resolve notes commit: %v", err)
+		}
+		tree, err := previous.Tree()
+		if err != nil {
+			r.t.Fatalf("could not resolve notes tree: %v", err)
+		}
+		for _, entry := range tree.Entries {
+			if entry.Name != hash.String() {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	slices.SortFunc(entries, func(a, b object.TreeEntry) int { return strings.Compare(a.Name, b.Name) })
+
+	treeHash := r.storeObject(&object.Tree{Entries: entries})
+	commitHash := r.storeObject(&object.Commit{
+		Author:       *signature(time.Now()),
+		Committer:    *signature(time.Now()),
+		Message:      "Notes added by 'git notes add'",
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	})
+
+	if err = r.Storer.SetReference(plumbing.NewHashReference(notesRef, commitHash)); err != nil {
+		r.t.Fatalf("could not update notes ref: %v", err)
+	}
+}
+
+// storeObject encodes a tree or commit object into the storage of the repository.
+func (r *Repo) storeObject(obj object.Object) plumbing.Hash {
+	r.t.Helper()
+
+	encoded := r.Storer.NewEncodedObject()
+	if err := obj.Encode(encoded); err != nil {
+		r.t.Fatalf("could not encode object: %v", err)
+	}
+
+	hash, err := r.Storer.SetEncodedObject(encoded)
+	if err != nil {
+		r.t.Fatalf("could not store object: %v", err)
+	}
+
+	return hash
+}
+
+// Bare clones the repository into a new bare repository and returns its directory.
+func (r *Repo) Bare() string {
+	r.t.Helper()
+
+	dir := filepath.Join(r.t.TempDir(), "repo.git")
+	bare, err := gogit.PlainClone(dir, true, &gogit.CloneOptions{URL: r.Dir, Tags: gogit.AllTags})
+	if err != nil {
+		r.t.Fatalf("could not clone bare test repo: %v", err)
+	}
+
+	cfg, err := bare.Config()
+	if err != nil {
+		r.t.Fatalf("could not read bare test repo config: %v", err)
+	}
+	cfg.Raw.Section("uploadpack").SetOption("allowReachableSHA1InWant", "true")
+	if err = bare.SetConfig(cfg); err != nil {
+		r.t.Fatalf("could not write bare test repo config: %v", err)
+	}
+
+	return dir
+}
+
+// URL yields the file:// URL of the repository.
+func (r *Repo) URL() *url.URL {
+	pth := filepath.ToSlash(r.Dir)
+	if !strings.HasPrefix(pth, "/") {
+		pth = "/" + pth // e.g. windows volume
+	}
+
+	return &url.URL{
+		Scheme: "file",
+		Path:   pth,
+	}
+}
+
+func signature(when time.Time) *object.Signature {
+	return &object.Signature{
+		Name:  "test",
+		Email: "test@example.com",
+		When:  when,
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/fredbi/go-vcsfetch/internal/git"
+)
+
+// LatestCommit yields the hash of the commit at the tip of a branch of a remote repository,
+// e.g. to detect changes cheaply.
+//
+// Only the refs advertised by the remote are listed: no git object is fetched and nothing is checked out.
+//
+// An empty branch resolves to the default branch of the repository.
+//
+// The hash is returned as a hexadecimal string, like [CloneResult].Hash.
+func (f *Fetcher) LatestCommit(ctx context.Context, repoURL *url.URL, branch string) (string, error) {
+	if repoURL == nil {
+		return "", fmt.Errorf("a repository URL is required: %w", ErrVCS)
+	}
+
+	if rewritten, ok := f.rewriteRepoURL(repoURL); ok {
+		repoURL = rewritten
+	}
+
+	gitOptions := f.toInternalGitOptions()
+	f.authForRepo(ctx, repoURL).applyToGit(gitOptions)
+
+	hash, err := git.NewRepo(repoURL, gitOptions).LatestCommit(ctx, branch)
+	if err != nil {
+		return "", gitError(err)
+	}
+
+	return hash.String(), nil
+}
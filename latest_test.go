@@ -0,0 +1,68 @@
+package vcsfetch
+
+import (
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestLatestCommit(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Commit(map[string]string{"file.txt": "v1"}, "initial commit")
+	tip := repo.Commit(map[string]string{"file.txt": "v2"}, "second commit")
+
+	fetcher := NewFetcher(FetchWithGitSkipAutoDetect(true))
+
+	t.Run("should yield the tip of a branch", func(t *testing.T) {
+		t.Parallel()
+
+		hash, err := fetcher.LatestCommit(t.Context(), repo.URL(), "master")
+		require.NoError(t, err)
+		require.Equal(t, tip.String(), hash)
+	})
+
+	t.Run("should yield the tip of the default branch", func(t *testing.T) {
+		t.Parallel()
+
+		hash, err := fetcher.LatestCommit(t.Context(), repo.URL(), "")
+		require.NoError(t, err)
+		require.Equal(t, tip.String(), hash)
+	})
+
+	t.Run("should not resolve a tag or an unknown branch", func(t *testing.T) {
+		t.Parallel()
+
+		tagged := testrepo.New(t)
+		tagged.Tag("v1.0.0", tagged.Commit(map[string]string{"file.txt": "v1"}, "initial commit"))
+
+		for _, branch := range []string{"v1.0.0", "no-such-branch"} {
+			_, err := fetcher.LatestCommit(t.Context(), tagged.URL(), branch)
+			require.ErrorIs(t, err, ErrVCS)
+		}
+	})
+
+	t.Run("should not fetch any object", func(t *testing.T) {
+		t.Parallel()
+
+		// the ref advertisement alone is needed: a commit which objects are missing is still resolved
+		hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+		dangling := testrepo.New(t)
+		dangling.Commit(map[string]string{"file.txt": "v1"}, "initial commit")
+		require.NoError(t, dangling.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("dangling"), hash)))
+
+		latest, err := fetcher.LatestCommit(t.Context(), dangling.URL(), "dangling")
+		require.NoError(t, err)
+		require.Equal(t, hash.String(), latest)
+	})
+
+	t.Run("should require a repository URL", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := fetcher.LatestCommit(t.Context(), nil, "master")
+		require.ErrorIs(t, err, ErrVCS)
+	})
+}
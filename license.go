@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// licenseCandidates lists the license file names, in order of preference.
+var licenseCandidates = []string{
+	"LICENSE",
+	"LICENSE.md",
+	"LICENSE.txt",
+	"COPYING",
+}
+
+// licenseSniffSize is the size of the beginning of a license file used to guess its SPDX identifier.
+const licenseSniffSize = 4096
+
+// licensePatterns associates SPDX license identifiers to phrases that identify the license text.
+//
+// All phrases must be found, in lower case and with normalized spaces.
+// Patterns are tried in order: more specific licenses come first, e.g. LGPL before GPL.
+var licensePatterns = []struct {
+	id      string
+	phrases []string
+}{
+	{id: "Apache-2.0", phrases: []string{"apache license", "version 2.0"}},
+	{id: "MPL-2.0", phrases: []string{"mozilla public license", "2.0"}},
+	{id: "AGPL-3.0-only", phrases: []string{"gnu affero general public license", "version 3"}},
+	{id: "LGPL-3.0-only", phrases: []string{"gnu lesser general public license", "version 3"}},
+	{id: "LGPL-2.1-only", phrases: []string{"gnu lesser general public license", "version 2.1"}},
+	{id: "GPL-3.0-only", phrases: []string{"gnu general public license", "version 3"}},
+	{id: "GPL-2.0-only", phrases: []string{"gnu general public license", "version 2"}},
+	{id: "MIT", phrases: []string{"permission is hereby granted, free of charge"}},
+	{id: "ISC", phrases: []string{"permission to use, copy, modify, and/or distribute this software"}},
+	{id: "BSD-3-Clause", phrases: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{id: "BSD-2-Clause", phrases: []string{"redistribution and use in source and binary forms"}},
+	{id: "Unlicense", phrases: []string{"free and unencumbered software released into the public domain"}},
+}
+
+// FetchLicense fetches the license file of a repository at a given version.
+//
+// Common license file names are tried in order: LICENSE, LICENSE.md, LICENSE.txt, COPYING.
+// The content of the first file found is copied to the passed [io.Writer] and its name is returned.
+//
+// FetchLicense also returns a best-effort guess of the SPDX identifier of the license, e.g. "Apache-2.0",
+// based on the beginning of the license text. The identifier is empty when the license is not recognized.
+//
+// An empty version resolves to the default branch of the repository.
+//
+// If none of these files exists, FetchLicense fails with [ErrFileNotFound].
+func (f *Fetcher) FetchLicense(ctx context.Context, w io.Writer, repoURL *url.URL, version string) (name, spdxID string, err error) {
+	sniffer := &headWriter{limit: licenseSniffSize}
+
+	name, err = f.fetchFirst(ctx, io.MultiWriter(w, sniffer), repoURL, version, licenseCandidates)
+	if err != nil {
+		return "", "", err
+	}
+
+	return name, guessLicense(sniffer.String()), nil
+}
+
+// guessLicense yields the SPDX identifier of a license text, or an empty string if it is not recognized.
+func guessLicense(text string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+
+	for _, pattern := range licensePatterns {
+		if containsAll(normalized, pattern.phrases) {
+			return pattern.id
+		}
+	}
+
+	return ""
+}
+
+func containsAll(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if !strings.Contains(text, phrase) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// headWriter retains the first bytes written to it, up to its limit, and discards the rest.
+type headWriter struct {
+	strings.Builder
+
+	limit int
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	if remaining := h.limit - h.Len(); remaining > 0 {
+		_, _ = h.Builder.Write(p[:min(len(p), remaining)])
+	}
+
+	return len(p), nil
+}
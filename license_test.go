@@ -0,0 +1,80 @@
+package vcsfetch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+const apacheLicense = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/
+
+   TERMS AND CONDITIONS FOR USE, REPRODUCTION, AND DISTRIBUTION
+`
+
+func TestFetchLicense(t *testing.T) {
+	t.Parallel()
+
+	fetcher := NewFetcher(FetchWithGitSkipAutoDetect(true))
+
+	t.Run("should fetch an Apache-2.0 LICENSE", func(t *testing.T) {
+		repo := testrepo.New(t)
+		repo.Tag("v1.0.0", repo.Commit(map[string]string{
+			"LICENSE": apacheLicense,
+			"main.go": "package main",
+		}, "initial commit"))
+		w := new(bytes.Buffer)
+
+		name, spdxID, err := fetcher.FetchLicense(t.Context(), w, repo.URL(), "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "LICENSE", name)
+		require.Equal(t, "Apache-2.0", spdxID)
+		require.Equal(t, apacheLicense, w.String())
+	})
+
+	t.Run("should fall back on COPYING, with an unrecognized license", func(t *testing.T) {
+		repo := testrepo.New(t)
+		repo.Tag("v1.0.0", repo.Commit(map[string]string{
+			"COPYING": "all rights reserved",
+		}, "initial commit"))
+		w := new(bytes.Buffer)
+
+		name, spdxID, err := fetcher.FetchLicense(t.Context(), w, repo.URL(), "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "COPYING", name)
+		require.Empty(t, spdxID)
+		require.Equal(t, "all rights reserved", w.String())
+	})
+
+	t.Run("should fail when there is no license", func(t *testing.T) {
+		repo := testrepo.New(t)
+		repo.Tag("v1.0.0", repo.Commit(map[string]string{
+			"main.go": "package main",
+		}, "initial commit"))
+
+		_, _, err := fetcher.FetchLicense(t.Context(), new(bytes.Buffer), repo.URL(), "v1.0.0")
+		require.ErrorIs(t, err, ErrFileNotFound)
+	})
+}
+
+func TestGuessLicense(t *testing.T) {
+	t.Parallel()
+
+	for text, expected := range map[string]string{
+		apacheLicense: "Apache-2.0",
+		"MIT License\n\nPermission is hereby granted, free of charge, to any person": "MIT",
+		"GNU LESSER GENERAL PUBLIC LICENSE\n   Version 3, 29 June 2007":              "LGPL-3.0-only",
+		"GNU GENERAL PUBLIC LICENSE\n   Version 2, June 1991":                        "GPL-2.0-only",
+		"Redistribution and use in source and binary forms, with or without modification, are permitted. " +
+			"Neither the name of the copyright holder": "BSD-3-Clause",
+		"Redistribution and use in source and binary forms": "BSD-2-Clause",
+		"Mozilla Public License Version 2.0":                "MPL-2.0",
+		"Copyright (c) 2025 nobody. All rights reserved.":   "",
+	} {
+		require.Equal(t, expected, guessLicense(text), text)
+	}
+}
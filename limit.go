@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"io"
+)
+
+// countingWriter is an [io.Writer] that counts the bytes written.
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+
+	return n, err
+}
+
+// limitedWriter is an [io.Writer] that fails with [ErrMaxBytesExceeded] once a maximum number of bytes
+// has been written.
+type limitedWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+// limitWriter caps the number of bytes written to w. A limit <= 0 means no limit.
+func limitWriter(w io.Writer, limit int64) io.Writer {
+	if limit <= 0 {
+		return w
+	}
+
+	return &limitedWriter{w: w, remaining: limit}
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= l.remaining {
+		n, err := l.w.Write(p)
+		l.remaining -= int64(n)
+
+		return n, err
+	}
+
+	// write up to the limit, then fail
+	n, err := l.w.Write(p[:l.remaining])
+	l.remaining -= int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	return n, ErrMaxBytesExceeded
+}
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"errors"
+	"io"
+	"slices"
+)
+
+// LineEndings tells how the line endings of the fetched content are normalized.
+//
+// See [FetchWithLineEndings].
+type LineEndings uint8
+
+const (
+	// LineEndingsPreserve leaves the content byte-exact.
+	LineEndingsPreserve LineEndings = iota
+	// LineEndingsLF converts CRLF line endings to LF.
+	LineEndingsLF
+	// LineEndingsCRLF converts LF line endings to CRLF.
+	LineEndingsCRLF
+)
+
+const lineEndingsBufferSize = 32 * 1024
+
+// contentTransforms yields the transforms applied to the fetched content: the transforms set with
+// [FetchWithTransform], then the normalization of line endings.
+func (o fetchOptions) contentTransforms() []func(io.Reader) (io.Reader, error) {
+	transforms := o.transforms
+
+	if o.lineEndings != LineEndingsPreserve {
+		transforms = append(slices.Clip(transforms), func(r io.Reader) (io.Reader, error) {
+			return newLineEndingsReader(r, o.lineEndings), nil
+		})
+	}
+
+	if o.trailingNewline {
+		transforms = append(slices.Clip(transforms), func(r io.Reader) (io.Reader, error) {
+			return &trailingNewlineReader{r: r}, nil
+		})
+	}
+
+	return transforms
+}
+
+// lineEndingsReader converts line endings while streaming.
+//
+// Lone CR characters are left unchanged.
+type lineEndingsReader struct {
+	r      io.Reader
+	toCRLF bool
+	buf    []byte
+	out    []byte
+	next   []byte
+	err    error
+
+	pendingCR bool // to LF: a CR is held until we know if it is followed by LF
+	lastCR    bool // to CRLF: the last byte read is CR
+}
+
+func newLineEndingsReader(r io.Reader, normalize LineEndings) *lineEndingsReader {
+	return &lineEndingsReader{
+		r:      r,
+		toCRLF: normalize == LineEndingsCRLF,
+		buf:    make([]byte, lineEndingsBufferSize),
+	}
+}
+
+func (l *lineEndingsReader) Read(p []byte) (int, error) {
+	for len(l.next) == 0 {
+		if l.err != nil {
+			return 0, l.err
+		}
+
+		n, err := l.r.Read(l.buf)
+		l.out = l.out[:0]
+		if l.toCRLF {
+			l.toCRLFEndings(l.buf[:n])
+		} else {
+			l.toLFEndings(l.buf[:n])
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) && l.pendingCR {
+				l.out = append(l.out, '\r')
+				l.pendingCR = false
+			}
+			l.err = err
+		}
+		l.next = l.out
+	}
+
+	n := copy(p, l.next)
+	l.next = l.next[n:]
+
+	return n, nil
+}
+
+func (l *lineEndingsReader) toLFEndings(chunk []byte) {
+	for _, c := range chunk {
+		if l.pendingCR {
+			l.pendingCR = false
+			if c != '\n' {
+				l.out = append(l.out, '\r')
+			}
+		}
+
+		if c == '\r' {
+			l.pendingCR = true
+
+			continue
+		}
+
+		l.out = append(l.out, c)
+	}
+}
+
+func (l *lineEndingsReader) toCRLFEndings(chunk []byte) {
+	for _, c := range chunk {
+		if c == '\n' && !l.lastCR {
+			l.out = append(l.out, '\r')
+		}
+
+		l.out = append(l.out, c)
+		l.lastCR = c == '\r'
+	}
+}
+
+// trailingNewlineReader appends a newline to non-empty content which does not end with one.
+//
+// The newline is CRLF if the last line ending of the content is CRLF.
+type trailingNewlineReader struct {
+	r      io.Reader
+	seen   bool
+	last   byte
+	crlf   bool
+	suffix []byte
+	done   bool
+}
+
+func (t *trailingNewlineReader) Read(p []byte) (int, error) {
+	if t.done {
+		if len(t.suffix) == 0 {
+			return 0, io.EOF
+		}
+
+		n := copy(p, t.suffix)
+		t.suffix = t.suffix[n:]
+
+		return n, nil
+	}
+
+	n, err := t.r.Read(p)
+	for i := range n {
+		if p[i] == '\n' {
+			t.crlf = t.last == '\r'
+		}
+		t.last = p[i]
+		t.seen = true
+	}
+
+	if !errors.Is(err, io.EOF) {
+		return n, err
+	}
+
+	t.done = true
+	if t.seen && t.last != '\n' {
+		t.suffix = []byte{'\n'}
+		if t.crlf {
+			t.suffix = []byte{'\r', '\n'}
+		}
+	}
+
+	if n == 0 {
+		return t.Read(p)
+	}
+
+	// report io.EOF once the newline is appended
+	return n, nil
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestLineEndings(t *testing.T) {
+	t.Parallel()
+
+	const mixed = "first\r\nsecond\nthird\rfourth\r\n\r\nlast\r"
+
+	for _, tc := range []struct {
+		name      string
+		normalize LineEndings
+		input     string
+		want      string
+	}{
+		{"CRLF to LF", LineEndingsLF, mixed, "first\nsecond\nthird\rfourth\n\nlast\r"},
+		{"LF to CRLF", LineEndingsCRLF, mixed, "first\r\nsecond\r\nthird\rfourth\r\n\r\nlast\r"},
+		{"empty to LF", LineEndingsLF, "", ""},
+		{"empty to CRLF", LineEndingsCRLF, "", ""},
+	} {
+		t.Run("should convert "+tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			content, err := io.ReadAll(newLineEndingsReader(strings.NewReader(tc.input), tc.normalize))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, string(content))
+
+			// line endings split across reads
+			content, err = io.ReadAll(newLineEndingsReader(iotest.OneByteReader(strings.NewReader(tc.input)), tc.normalize))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, string(content))
+		})
+	}
+
+	t.Run("should stream without buffering the whole content", func(t *testing.T) {
+		t.Parallel()
+
+		large := strings.Repeat("line\r\n", 3*lineEndingsBufferSize)
+		r := newLineEndingsReader(strings.NewReader(large), LineEndingsLF)
+
+		require.NoError(t, iotest.TestReader(r, []byte(strings.Repeat("line\n", 3*lineEndingsBufferSize))))
+		require.Len(t, r.buf, lineEndingsBufferSize)
+	})
+
+	t.Run("should append a trailing newline", func(t *testing.T) {
+		t.Parallel()
+
+		for input, want := range map[string]string{
+			"":              "",
+			"last":          "last\n",
+			"last\n":        "last\n",
+			"first\r\nlast": "first\r\nlast\r\n",
+			"first\nlast\r": "first\nlast\r\n",
+		} {
+			content, err := io.ReadAll(&trailingNewlineReader{r: iotest.OneByteReader(strings.NewReader(input))})
+			require.NoError(t, err)
+			require.Equal(t, want, string(content))
+		}
+	})
+}
+
+func TestFetcherLineEndings(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{"docs/windows.txt": "title\r\n\r\nunix line\nwindows line\r\nno newline"}, "initial commit"))
+	locator := fixtureLocator(repo, "docs/windows.txt", "v1.0.0")
+
+	t.Run("should fetch byte-exact content by default", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		require.NoError(t, NewFetcher().FetchLocator(t.Context(), w, locator))
+		require.Equal(t, "title\r\n\r\nunix line\nwindows line\r\nno newline", w.String())
+	})
+
+	t.Run("should normalize CRLF to LF", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		require.NoError(t, NewFetcher(FetchWithLineEndings(LineEndingsLF)).FetchLocator(t.Context(), w, locator))
+		require.Equal(t, "title\n\nunix line\nwindows line\nno newline", w.String())
+	})
+
+	t.Run("should normalize LF to CRLF and append a trailing newline", func(t *testing.T) {
+		fetcher := NewFetcher(FetchWithLineEndings(LineEndingsCRLF), FetchWithTrailingNewline(true))
+
+		w := new(bytes.Buffer)
+		require.NoError(t, fetcher.FetchLocator(t.Context(), w, locator))
+		require.Equal(t, "title\r\n\r\nunix line\r\nwindows line\r\nno newline\r\n", w.String())
+	})
+
+	t.Run("should normalize after other transforms", func(t *testing.T) {
+		upper := func(r io.Reader) (io.Reader, error) {
+			content, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+
+			return bytes.NewReader(bytes.ToUpper(content)), nil
+		}
+		fetcher := NewFetcher(FetchWithTransform(upper), FetchWithLineEndings(LineEndingsLF), FetchWithTrailingNewline(true))
+
+		w := new(bytes.Buffer)
+		require.NoError(t, fetcher.FetchLocator(t.Context(), w, locator))
+		require.Equal(t, "TITLE\n\nUNIX LINE\nWINDOWS LINE\nNO NEWLINE\n", w.String())
+	})
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/download"
+	"github.com/fredbi/go-vcsfetch/internal/git"
+	"github.com/fredbi/go-vcsfetch/internal/giturl"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+)
+
+// DirEntry describes an entry of a folder in a vcs repository.
+//
+// See [Fetcher.ListDir].
+type DirEntry struct {
+	// Name is the base name of the entry.
+	Name string
+
+	// Path is the path of the entry, relative to the root of the repository.
+	Path string
+
+	// IsDir indicates that the entry is a folder.
+	IsDir bool
+
+	// Size is the size in bytes of a file.
+	//
+	// This is 0 for folders, and for files listed by an API that does not report sizes (e.g. gitlab).
+	Size int64
+}
+
+// ListDir lists the entries of a folder from a vcs location string, e.g. to render a folder
+// before fetching files.
+//
+// The string argument must be a valid URL designating a folder, e.g. https://github.com/fredbi/go-vcsfetch/tree/master/docs.
+//
+// The listing API of the SCM provider is used when available (github.com, gitlab, gitea).
+// Otherwise, or whenever this API fails (e.g. because of rate limiting or for a private repository),
+// the folder is retrieved using a git sparse checkout.
+func (f *Fetcher) ListDir(ctx context.Context, location string) ([]DirEntry, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("expected a valid URL: %w: %w", redact.Error(err), ErrVCS)
+	}
+
+	locator, err := f.locatorFromURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.ListDirLocator(ctx, locator)
+}
+
+// ListDirLocator lists the entries of a folder specified by a [Locator].
+//
+// See [Fetcher.ListDir].
+func (f *Fetcher) ListDirLocator(ctx context.Context, locator Locator) ([]DirEntry, error) {
+	locator = f.withBaseDir(f.rewriteLocator(locator))
+	auth := f.authForRepo(ctx, locator.RepoURL())
+
+	if listingURL, decode, ok := f.mayUseListing(locator, auth); ok {
+		entries, err := f.listFromAPI(ctx, listingURL, decode)
+		if err == nil {
+			return entries, nil
+		}
+		// fall back to git
+	}
+
+	return f.listFromGit(ctx, locator, auth)
+}
+
+func (f *Fetcher) mayUseListing(locator Locator, auth *basicAuth) (*url.URL, giturl.ListingDecoder, bool) {
+	if !f.mayBypassGit(locator) {
+		return nil, nil, false
+	}
+
+	// listing APIs require other credentials than git: private repositories are listed using git
+	if auth != nil || locator.HasAuth() {
+		return nil, nil, false
+	}
+
+	listingURL, decode, err := giturl.Listing(locator)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return listingURL, decode, true
+}
+
+func (f *Fetcher) listFromAPI(ctx context.Context, listingURL *url.URL, decode giturl.ListingDecoder) ([]DirEntry, error) {
+	var buf bytes.Buffer
+	if err := download.Content(ctx, listingURL, &buf, f.toInternalDownloadOptions()); err != nil {
+		return nil, err
+	}
+
+	listing, err := decode(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]DirEntry, 0, len(listing))
+	for _, entry := range listing {
+		entries = append(entries, DirEntry{
+			Name:  entry.Name,
+			Path:  entry.Path,
+			IsDir: entry.IsDir,
+			Size:  entry.Size,
+		})
+	}
+
+	return entries, nil
+}
+
+func (f *Fetcher) listFromGit(ctx context.Context, locator Locator, auth *basicAuth) ([]DirEntry, error) {
+	gitOptions := f.toInternalGitOptions()
+	auth.applyToGit(gitOptions)
+
+	dir := strings.Trim(path.Clean("/"+locator.Path()), "/")
+	var filter []string
+	if dir == "" {
+		dir = "."
+	} else {
+		filter = []string{dir}
+	}
+
+	repo := git.NewRepo(locator.RepoURL(), gitOptions)
+	fsys, _, err := repo.Clone(ctx, locator.Version(), &git.CloneOptions{SparseFilter: filter})
+	if err != nil {
+		return nil, gitError(err)
+	}
+
+	dirEntries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("could not list folder %q: %w: %w: %w", dir, redact.Error(err), ErrFileNotFound, ErrVCS)
+		}
+
+		return nil, fmt.Errorf("could not list folder %q: %w: %w", dir, redact.Error(err), ErrVCS)
+	}
+
+	entries := make([]DirEntry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dir == "." && dirEntry.Name() == ".git" {
+			continue
+		}
+
+		entry := DirEntry{
+			Name:  dirEntry.Name(),
+			Path:  path.Join(strings.TrimPrefix(dir, "."), dirEntry.Name()),
+			IsDir: dirEntry.IsDir(),
+		}
+
+		if !entry.IsDir {
+			info, err := dirEntry.Info()
+			if err != nil {
+				return nil, fmt.Errorf("could not stat %q: %w: %w", entry.Path, err, ErrVCS)
+			}
+			entry.Size = info.Size()
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
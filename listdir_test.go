@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetcherListDir(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should list a folder using git", func(t *testing.T) {
+		t.Parallel()
+
+		repo := testrepo.New(t)
+		repo.Tag("v1.0.0", repo.Commit(map[string]string{
+			"README.md":        "readme",
+			"docs/api.md":      "api doc",
+			"docs/sub/deep.md": "deep",
+		}, "initial commit"))
+		fetcher := NewFetcher()
+
+		entries, err := fetcher.ListDirLocator(t.Context(), fixtureLocator(repo, "docs", "v1.0.0"))
+		require.NoError(t, err)
+		require.Equal(t, []DirEntry{
+			{Name: "api.md", Path: "docs/api.md", Size: int64(len("api doc"))},
+			{Name: "sub", Path: "docs/sub", IsDir: true},
+		}, entries)
+
+		entries, err = fetcher.ListDirLocator(t.Context(), fixtureLocator(repo, "/", "v1.0.0"))
+		require.NoError(t, err)
+		require.Equal(t, []DirEntry{
+			{Name: "README.md", Path: "README.md", Size: int64(len("readme"))},
+			{Name: "docs", Path: "docs", IsDir: true},
+		}, entries)
+
+		_, err = fetcher.ListDirLocator(t.Context(), fixtureLocator(repo, "nowhere", "v1.0.0"))
+		require.ErrorIs(t, err, ErrFileNotFound)
+		require.ErrorIs(t, err, ErrVCS)
+	})
+
+	t.Run("should list a folder using the github API", func(t *testing.T) {
+		t.Parallel()
+
+		transport := newStubTransport(func(req *http.Request) *http.Response {
+			if req.URL.String() != "https://api.github.com/repos/fredbi/go-vcsfetch/contents/docs?ref=master" {
+				return stubResponse(http.StatusNotFound, "")
+			}
+
+			return stubResponse(http.StatusOK, `[
+				{"name":"api.md","path":"docs/api.md","type":"file","size":7},
+				{"name":"sub","path":"docs/sub","type":"dir","size":0}
+			]`)
+		})
+		fetcher := NewFetcher(FetchWithHTTPClient(&http.Client{Transport: transport}))
+
+		entries, err := fetcher.ListDir(t.Context(), "https://github.com/fredbi/go-vcsfetch/tree/master/docs")
+		require.NoError(t, err)
+		require.Len(t, transport.Requests(), 1)
+		require.Equal(t, []DirEntry{
+			{Name: "api.md", Path: "docs/api.md", Size: 7},
+			{Name: "sub", Path: "docs/sub", IsDir: true},
+		}, entries)
+	})
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const fileTransport = "file"
+
+// localGitLocator builds a [GitLocator] from a scheme-less, host-less URL that points to a local git repository,
+// e.g. "/srv/git/repo.git" or "./repo".
+//
+// Like for go modules, the repository may be suffixed with a version, and followed by a path in the repository,
+// e.g. "/srv/git/repo.git@v1.2.3/docs/README.md".
+//
+// It returns false if the URL does not designate an existing local git repository.
+func localGitLocator(u *url.URL) (*GitLocator, bool) {
+	if u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return nil, false
+	}
+
+	segments := strings.Split(u.Path, "/")
+
+	// the longest prefix that designates a git repository wins
+	for i := len(segments); i > 0; i-- {
+		last, version, _ := strings.Cut(segments[i-1], "@")
+		if last == "" {
+			continue
+		}
+
+		dir := strings.Join(append(segments[:i-1:i-1], last), "/")
+		if !isLocalGitDir(filepath.FromSlash(dir)) {
+			continue
+		}
+
+		pth, err := absSlashPath(dir)
+		if err != nil {
+			return nil, false
+		}
+
+		subPath := strings.Join(segments[i:], "/")
+		if subPath == "" {
+			subPath = "/"
+		}
+
+		return &GitLocator{
+			repo: &url.URL{
+				Scheme: fileTransport,
+				Path:   pth,
+			},
+			Transport: fileTransport,
+			RepoPath:  pth,
+			Ref:       version,
+			SubPath:   subPath,
+		}, true
+	}
+
+	return nil, false
+}
+
+// localRepoPath resolves the location of a local repository designated by a file URL to an absolute, slash-separated path.
+//
+// Relative paths come with a "." or ".." host, e.g. "file://./local/repo", and are resolved against the current working directory.
+func localRepoPath(host, pth string) (string, error) {
+	switch host {
+	case "", "localhost":
+	case ".", "..":
+		pth = host + pth
+	default:
+		return "", fmt.Errorf("a file URL should designate a local path, but got host %q: %w", host, ErrVCS)
+	}
+
+	abs, err := absSlashPath(pth)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve local path %q: %w: %w", pth, err, ErrVCS)
+	}
+
+	return abs, nil
+}
+
+// absSlashPath converts a slash-separated path to an absolute path, suitable for the path of a file URL.
+func absSlashPath(pth string) (string, error) {
+	abs, err := filepath.Abs(filepath.FromSlash(pth))
+	if err != nil {
+		return "", err
+	}
+
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs // e.g. windows volume
+	}
+
+	return abs, nil
+}
+
+// isLocalGitDir tells if a local directory holds a git repository, either with a ".git" folder or as a bare repository.
+func isLocalGitDir(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+
+	head, err := os.Stat(filepath.Join(dir, "HEAD"))
+	if err != nil || !head.Mode().IsRegular() {
+		return false
+	}
+
+	objects, err := os.Stat(filepath.Join(dir, "objects"))
+
+	return err == nil && objects.IsDir()
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// localWorkingTree yields the directory of a local working tree when the file of a locator may be read directly
+// from disk (see [FetchWithLocalFiles]).
+//
+// Only file:// URLs without a version qualify: the working tree is not versioned.
+// Bare git directories are always fetched using git.
+func (f *Fetcher) localWorkingTree(locator Locator) (string, bool) {
+	if !f.localFiles || f.requireSigned || f.notes || f.referenceName != "" || locator.Version() != "" {
+		return "", false
+	}
+
+	u := locator.RepoURL()
+	if u == nil || u.Scheme != fileTransport || (u.Host != "" && u.Host != "localhost") {
+		return "", false
+	}
+
+	pth := u.Path
+	if len(pth) > 2 && pth[0] == '/' && pth[2] == ':' {
+		pth = pth[1:] // e.g. windows volume "/C:/..."
+	}
+	dir := filepath.FromSlash(pth)
+
+	if !isWorkingTree(dir) {
+		return "", false
+	}
+
+	return dir, true
+}
+
+// isWorkingTree tells if a local directory is a git working tree, or a plain directory, rather than a bare git directory.
+func isWorkingTree(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+
+	return !isLocalGitDir(dir) // otherwise, a bare git directory
+}
+
+// fetchLocalFile copies a file from a local working tree to w.
+//
+// The file is opened with an [os.Root], so that neither relative paths nor symbolic links may escape the working tree.
+// The content of the .git directory is never read.
+func (f *Fetcher) fetchLocalFile(w io.Writer, dir, file string) (*FetchResult, error) {
+	name := path.Clean(strings.TrimPrefix(file, "/"))
+	if name == ".git" || strings.HasPrefix(name, ".git/") {
+		return nil, fmt.Errorf("cannot read %q from the git directory of %q: %w", file, dir, ErrVCS)
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not open local working tree %q: %w: %w", dir, err, ErrVCS)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	local, err := root.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("could not find %q in local working tree %q: %w: %w: %w", file, dir, err, ErrFileNotFound, ErrVCS)
+		}
+
+		return nil, fmt.Errorf("could not open %q in local working tree %q: %w: %w", file, dir, err, ErrVCS)
+	}
+	defer func() {
+		_ = local.Close()
+	}()
+
+	info, err := local.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("could not open %q in local working tree %q: %w: %w", file, dir, err, ErrVCS)
+	}
+
+	if info.IsDir() {
+		return nil, fmt.Errorf("%q is a directory in local working tree %q: %w", file, dir, ErrVCS)
+	}
+
+	if _, err = io.Copy(w, local); err != nil {
+		return nil, fmt.Errorf("could not read %q in local working tree %q: %w: %w", file, dir, err, ErrVCS)
+	}
+
+	result := &FetchResult{Source: FetchSourceLocal}
+	if f.manifest {
+		result.Files = []string{name}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetcherLocalFiles(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{"README.md": "committed"}, "initial"))
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "README.md"), []byte("uncommitted"), 0o600))
+
+	plain := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(plain, "docs"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(plain, "docs", "guide.md"), []byte("guide"), 0o600))
+	outside := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(plain, "escape")))
+
+	plainURL := &url.URL{Scheme: "file", Path: filepath.ToSlash(plain)}
+	fetcher := NewFetcher(FetchWithLocalFiles(true))
+
+	t.Run("should read a plain file from a local directory", func(t *testing.T) {
+		t.Parallel()
+
+		w := new(bytes.Buffer)
+		result, err := fetcher.FetchLocatorWithResult(t.Context(), w, repoLocator(plainURL, "", "docs/guide.md"))
+		require.NoError(t, err)
+		require.Equal(t, "guide", w.String())
+		require.Equal(t, FetchSourceLocal, result.Source)
+	})
+
+	t.Run("should read the working tree of a git repo", func(t *testing.T) {
+		t.Parallel()
+
+		w := new(bytes.Buffer)
+		result, err := fetcher.FetchLocatorWithResult(t.Context(), w, fixtureLocator(repo, "README.md", ""))
+		require.NoError(t, err)
+		require.Equal(t, "uncommitted", w.String())
+		require.Equal(t, FetchSourceLocal, result.Source)
+	})
+
+	t.Run("should use git when a version is specified", func(t *testing.T) {
+		t.Parallel()
+
+		w := new(bytes.Buffer)
+		result, err := fetcher.FetchLocatorWithResult(t.Context(), w, fixtureLocator(repo, "README.md", "v1.0.0"))
+		require.NoError(t, err)
+		require.Equal(t, "committed", w.String())
+		require.NotEqual(t, FetchSourceLocal, result.Source)
+	})
+
+	t.Run("should use git for a bare git directory", func(t *testing.T) {
+		t.Parallel()
+
+		bareURL := &url.URL{Scheme: "file", Path: filepath.ToSlash(repo.Bare())}
+		w := new(bytes.Buffer)
+		result, err := fetcher.FetchLocatorWithResult(t.Context(), w, repoLocator(bareURL, "", "README.md"))
+		require.NoError(t, err)
+		require.Equal(t, "committed", w.String())
+		require.NotEqual(t, FetchSourceLocal, result.Source)
+	})
+
+	t.Run("should use git when local files are not allowed", func(t *testing.T) {
+		t.Parallel()
+
+		w := new(bytes.Buffer)
+		require.NoError(t, NewFetcher().FetchLocator(t.Context(), w, fixtureLocator(repo, "README.md", "")))
+		require.Equal(t, "committed", w.String())
+	})
+
+	t.Run("should report a missing file", func(t *testing.T) {
+		t.Parallel()
+
+		err := fetcher.FetchLocator(t.Context(), new(bytes.Buffer), repoLocator(plainURL, "", "missing.md"))
+		require.ErrorIs(t, err, ErrFileNotFound)
+	})
+
+	for _, file := range []string{"../secret", "escape", ".git/config"} {
+		t.Run("should not read outside the working tree: "+file, func(t *testing.T) {
+			t.Parallel()
+
+			var locator Locator = repoLocator(plainURL, "", file)
+			if file == ".git/config" {
+				locator = fixtureLocator(repo, file, "")
+			}
+
+			w := new(bytes.Buffer)
+			require.Error(t, fetcher.FetchLocator(t.Context(), w, locator))
+			require.Empty(t, w.String())
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// FetchWithMirrors sets an ordered list of mirror hosts, e.g. "mirror.example.com" or "mirror.example.com:8443",
+// to fail over to whenever the host of a repository is unreachable.
+//
+// When a fetch fails on a network error, e.g. a refused connection, a failed DNS lookup or a timeout,
+// it is retried against each mirror in turn, with the host of the repository URL replaced by the mirror host.
+// The path and the version of the file are unchanged.
+//
+// A fetch that fails for another reason, e.g. because the file does not exist or the access is denied,
+// does not fail over. Neither does a fetch that has already written some content.
+//
+// By default, there is no mirror.
+func FetchWithMirrors(hosts []string) FetchOption {
+	return func(o *fetchOptions) {
+		o.mirrors = slices.Clone(hosts)
+	}
+}
+
+// fetchWithMirrors fetches a locator, and fails over to the mirrors set by [FetchWithMirrors]
+// whenever the host of the repository is unreachable.
+func (f *Fetcher) fetchWithMirrors(ctx context.Context, w io.Writer, locator Locator) (*FetchResult, error) {
+	if len(f.mirrors) == 0 {
+		return f.fetchLocator(ctx, w, locator)
+	}
+
+	cw := &countingWriter{w: w}
+	result, err := f.fetchLocator(ctx, cw, locator)
+	lastErr := err
+
+	for _, host := range f.mirrors {
+		if lastErr == nil || cw.written > 0 || ctx.Err() != nil || !isUnreachable(lastErr) {
+			break
+		}
+
+		result, lastErr = f.fetchLocator(ctx, cw, mirrorLocator(locator, host))
+		if lastErr == nil {
+			return result, nil
+		}
+
+		err = errors.Join(err, fmt.Errorf("mirror %q: %w", host, lastErr))
+	}
+
+	if lastErr != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// mirrorLocator yields a [Locator] to the same file in the repository hosted by a mirror.
+func mirrorLocator(locator Locator, host string) Locator {
+	u := *locator.RepoURL()
+	u.Host = strings.TrimSuffix(host, "/")
+
+	return &rewrittenLocator{
+		Locator: locator,
+		repoURL: &u,
+	}
+}
+
+// isUnreachable tells if an error is caused by a failure to reach a remote host over the network,
+// as opposed to an error reported by the remote host.
+func isUnreachable(err error) bool {
+	var (
+		opErr      *net.OpError
+		dnsErr     *net.DNSError
+		netErr     net.Error
+		unexpected *plumbing.UnexpectedError
+	)
+
+	switch {
+	case errors.As(err, &opErr), errors.As(err, &dnsErr):
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	case errors.As(err, &unexpected):
+		// the go-git transport does not unwrap the errors of the http client
+		return isUnreachable(unexpected.Err)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetcherMirrors(t *testing.T) {
+	t.Parallel()
+
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("this test requires the git binary to serve a repository over http")
+	}
+
+	repo := testrepo.New(t)
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{"docs/hello.txt": "hello world\n"}, "initial commit"))
+
+	// the mirror serves the repository over the git smart HTTP protocol
+	var mirrorRequests atomic.Int64
+	backend := &cgi.Handler{
+		Path: gitBin,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + filepath.Dir(repo.Dir),
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorRequests.Add(1)
+		backend.ServeHTTP(w, r)
+	}))
+	t.Cleanup(mirror.Close)
+	mirrorHost := mustParseTestURL(t, mirror.URL).Host
+
+	// the primary host is down
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	downHost := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	locatorOn := func(host, pth string) *MockLocator {
+		return &MockLocator{
+			RepoURLFunc: func() *url.URL {
+				return mustParseTestURL(t, fmt.Sprintf("http://%s/%s", host, filepath.Base(repo.Dir)))
+			},
+			PathFunc:    func() string { return pth },
+			VersionFunc: func() string { return "v1.0.0" },
+		}
+	}
+
+	t.Run("should fail when the primary host is down", func(t *testing.T) {
+		err := NewFetcher().FetchLocator(t.Context(), new(bytes.Buffer), locatorOn(downHost, "docs/hello.txt"))
+		require.ErrorIs(t, err, ErrVCS)
+		require.True(t, isUnreachable(err))
+	})
+
+	t.Run("should fail over to a mirror when the primary host is down", func(t *testing.T) {
+		fetcher := NewFetcher(FetchWithMirrors([]string{downHost, mirrorHost}))
+
+		w := new(bytes.Buffer)
+		require.NoError(t, fetcher.FetchLocator(t.Context(), w, locatorOn(downHost, "docs/hello.txt")))
+		require.Equal(t, "hello world\n", w.String())
+	})
+
+	t.Run("should not fail over when the file does not exist", func(t *testing.T) {
+		// the primary host is up, but does not hold the file
+		primary := httptest.NewServer(backend)
+		t.Cleanup(primary.Close)
+		fetcher := NewFetcher(FetchWithMirrors([]string{mirrorHost}))
+
+		before := mirrorRequests.Load()
+		err := fetcher.FetchLocator(t.Context(), new(bytes.Buffer), locatorOn(mustParseTestURL(t, primary.URL).Host, "docs/missing.txt"))
+		require.ErrorIs(t, err, ErrFileNotFound)
+		require.Equal(t, before, mirrorRequests.Load())
+	})
+
+	t.Run("should report the errors of all mirrors", func(t *testing.T) {
+		fetcher := NewFetcher(FetchWithMirrors([]string{downHost}))
+
+		err := fetcher.FetchLocator(t.Context(), new(bytes.Buffer), locatorOn(downHost, "docs/hello.txt"))
+		require.ErrorIs(t, err, ErrVCS)
+		require.ErrorContains(t, err, "mirror")
+	})
+}
+
+func TestIsUnreachable(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, isUnreachable(&url.Error{Op: "Get", URL: "http://example.com", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}))
+	require.True(t, isUnreachable(fmt.Errorf("wrapped: %w", &net.DNSError{Err: "no such host", Name: "example.invalid"})))
+	require.False(t, isUnreachable(&url.Error{Op: "Get", URL: "http://example.com", Err: errors.New("stopped after 10 redirects")}))
+	require.False(t, isUnreachable(ErrFileNotFound))
+}
+
+func mustParseTestURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+
+	return u
+}
@@ -4,12 +4,23 @@
 package vcsfetch
 
 import (
+	"crypto/x509"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/fredbi/go-vcsfetch/internal/download"
 	"github.com/fredbi/go-vcsfetch/internal/git"
+	"github.com/fredbi/go-vcsfetch/internal/giturl"
+	"github.com/fredbi/go-vcsfetch/internal/giturl/codecommit"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 )
 
 func optionsWithDefaults[O any, T ~func(*O)](opts []T) O {
@@ -73,6 +84,18 @@ func FetchWithExactTag(exact bool) FetchOption {
 	}
 }
 
+// FetchWithCanonicalTags considers semver tags that only differ by a leading "v" as the same tag,
+// when matching tags exactly with [FetchWithExactTag].
+//
+// Example:
+// with tags "v0.2.0" and "0.2.0", both "v0.2.0" and "0.2.0" resolve to "v0.2.0".
+// With only the tag "0.2.0", "v0.2.0" resolves to "0.2.0".
+func FetchWithCanonicalTags(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		withGitCanonicalTags(enabled)(&o.gitOptions)
+	}
+}
+
 // FetchWithRequireVersion tells the [Fetcher] to check that the fetched location
 // comes with an explicit version. No default to HEAD is applied.
 func FetchWithRequireVersion(required bool) FetchOption {
@@ -103,6 +126,160 @@ func FetchWithSkipRawURL(skipped bool) FetchOption {
 	}
 }
 
+// FetchWithHTTPClient sets the [http.Client] used to download raw content from SCM platforms.
+//
+// By default, a client shared by all fetchers is used, which keeps idle connections for reuse across fetches
+// and attempts HTTP/2 (see [FetchWithMaxIdleConnsPerHost] and [FetchWithHTTP2]).
+//
+// Idle connections held by this client are released by [Fetcher.Close].
+func FetchWithHTTPClient(client *http.Client) FetchOption {
+	return func(o *fetchOptions) {
+		withHTTPClient(client)(&o.locOptions)
+	}
+}
+
+// FetchWithGitLabDeployToken authenticates fetches from GitLab repositories using a deploy token.
+//
+// GitLab deploy tokens are passed as HTTP basic authentication credentials, with the deploy token username
+// and the token as password. They apply to both raw-content downloads and git over http(s).
+//
+// Credentials are only sent to repositories hosted on gitlab.com, or on a self-hosted GitLab instance
+// registered with [RegisterProvider]: a host which name merely contains "gitlab" never receives them.
+func FetchWithGitLabDeployToken(username, token string) FetchOption {
+	return func(o *fetchOptions) {
+		o.gitlabDeployToken = &basicAuth{
+			username: username,
+			password: token,
+		}
+	}
+}
+
+// FetchWithGiteaToken authenticates fetches from Gitea repositories (e.g. codeberg.org) using an access token.
+//
+// The token is passed with the header "Authorization: token {token}" for raw-content downloads,
+// and as HTTP basic authentication password for git over http(s).
+//
+// Credentials are only sent to repositories hosted on a well-known public Gitea instance, such as codeberg.org,
+// or on a self-hosted Gitea instance registered with [RegisterProvider]: a host which name merely contains "gitea"
+// never receives them.
+func FetchWithGiteaToken(token string) FetchOption {
+	return func(o *fetchOptions) {
+		o.giteaToken = giteaAuth(token)
+	}
+}
+
+// FetchWithBitbucketAppPassword authenticates fetches from Bitbucket repositories using an app password.
+//
+// App passwords are passed as HTTP basic authentication credentials, with the username of the Bitbucket account.
+// They apply to both raw-content downloads and git over http(s).
+//
+// Credentials are only sent to repositories hosted on bitbucket.org, or on a self-hosted Bitbucket instance
+// registered with [RegisterProvider]: a host which name merely contains "bitbucket" never receives them.
+func FetchWithBitbucketAppPassword(username, appPassword string) FetchOption {
+	return func(o *fetchOptions) {
+		o.bitbucketAppPassword = &basicAuth{
+			username: username,
+			password: appPassword,
+		}
+	}
+}
+
+// FetchWithAWSCredentials authenticates fetches from AWS CodeCommit repositories over https.
+//
+// Git requests are signed with AWS Signature Version 4, like with the credential helper of the AWS CLI.
+// The session token is only required for temporary credentials, e.g. obtained by assuming a role.
+//
+// Credentials are only used for repositories detected as hosted by CodeCommit,
+// e.g. https://git-codecommit.us-east-1.amazonaws.com/v1/repos/my-repo.
+func FetchWithAWSCredentials(accessKeyID, secretAccessKey, sessionToken string) FetchOption {
+	return func(o *fetchOptions) {
+		o.awsCredentials = &codecommit.Credentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+		}
+	}
+}
+
+// FetchWithCredentialHelper obtains credentials for https repositories from the git credential helpers
+// configured in the user's git config, as "git credential fill" does.
+//
+// The credentials provided by the helper are used as HTTP basic authentication for both raw-content downloads
+// and git over https. Credentials configured explicitly, e.g. with [FetchWithGitLabDeployToken], take precedence.
+//
+// This requires the git command to be installed. Credentials are never prompted for.
+func FetchWithCredentialHelper(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		o.credentialHelper = enabled
+	}
+}
+
+// FetchWithAuthFromEnv authenticates fetches with the token set in the environment for the provider
+// of the repository, which is convenient in CI:
+//
+//   - GITHUB_TOKEN for github (including GitHub Enterprise hosts)
+//   - GITLAB_TOKEN for gitlab
+//   - BITBUCKET_TOKEN for bitbucket
+//   - GITEA_TOKEN for gitea
+//   - AZURE_DEVOPS_PAT for Azure DevOps
+//
+// A token is only sent to the public instance of its provider (github.com, gitlab.com, bitbucket.org,
+// dev.azure.com, or a well-known public gitea instance such as codeberg.org), or to the self-hosted instances
+// registered with [RegisterProvider] or declared with [FetchWithGitHubEnterpriseHost]. Hosts which name merely
+// contains the name of a provider, e.g. "github.example.com", never receive a token. Tokens are passed as HTTP basic authentication
+// for git over https, and with the header expected by the provider for raw-content downloads,
+// e.g. "Authorization: Bearer {token}" for github or gitlab. These headers are not forwarded when a download
+// is redirected to another host.
+//
+// Credentials configured explicitly, e.g. with [FetchWithGitLabDeployToken], take precedence
+// over the environment, which takes precedence over [FetchWithCredentialHelper].
+func FetchWithAuthFromEnv(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		o.authFromEnv = enabled
+	}
+}
+
+// FetchWithLocalFiles allows the [Fetcher] to read files directly from a local working tree, designated by
+// a file:// URL without a version, e.g. "file:///home/user/project".
+//
+// This serves the content of the working tree, including uncommitted changes, rather than the content committed
+// in the repository. Since this trusts local paths, this is disabled by default.
+//
+// Bare git directories, and locators specifying a version, are always fetched using git.
+// Paths may not escape the working tree, not even through symbolic links.
+func FetchWithLocalFiles(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		o.localFiles = enabled
+	}
+}
+
+// GithubMediaType is a media type of the github contents API, which tells the representation of the fetched file.
+type GithubMediaType string
+
+const (
+	// GithubMediaTypeRaw retrieves the raw content of the file.
+	GithubMediaTypeRaw GithubMediaType = "application/vnd.github.raw+json"
+
+	// GithubMediaTypeJSON retrieves a JSON document with the metadata of the file and its base64-encoded content.
+	GithubMediaTypeJSON GithubMediaType = "application/vnd.github+json"
+
+	// GithubMediaTypeHTML retrieves the file rendered as HTML, e.g. for a markdown file.
+	GithubMediaTypeHTML GithubMediaType = "application/vnd.github.html+json"
+)
+
+// FetchWithGithubContentsAPI fetches files hosted on github.com using the github contents API (api.github.com)
+// rather than raw.githubusercontent.com, in the representation given by the media type.
+//
+// The media type defaults to [GithubMediaTypeRaw]. Use [GithubMediaTypeJSON] to retrieve the metadata of the file.
+//
+// Unauthenticated requests to the github API are rate-limited: a fetch denied by the API fails with [ErrAccessDenied].
+func FetchWithGithubContentsAPI(enabled bool, mediaType GithubMediaType) FetchOption {
+	return func(o *fetchOptions) {
+		o.githubContentsAPI = enabled
+		o.githubMediaType = mediaType
+	}
+}
+
 // FetchWithAllowPrereleases includes pre-releases in semver tag resolution.
 //
 // By default pre-releases are ignored.
@@ -110,7 +287,7 @@ func FetchWithSkipRawURL(skipped bool) FetchOption {
 // This option is disabled when using [FetchWithExactTag].
 //
 // Example:
-// for tag "v2", with pre-releases allowed, "v1.3.0-rc1" is a valid candidate.
+// for tag "v2", with pre-releases allowed, "v2.3.0-rc1" is a valid candidate.
 func FetchWithAllowPrereleases(allowed bool) FetchOption {
 	return func(o *fetchOptions) {
 		withGitAllowPrereleases(allowed)(&o.gitOptions)
@@ -119,6 +296,15 @@ func FetchWithAllowPrereleases(allowed bool) FetchOption {
 
 // FetchWithRecurseSubmodules resolves submodules when fetching.
 //
+// When enabled, a file located inside a git submodule is fetched from the submodule repository,
+// at the commit pinned by the parent repository. Nested submodules are resolved recursively.
+//
+// Raw-content download is not used when this option is enabled, since SCM raw URLs do not follow submodules.
+//
+// Credentials and additional certificate authorities are only used for submodules served with the same scheme,
+// host and port as the parent repository. Submodules designating a local repository (e.g. file://) are rejected,
+// unless the parent repository is local too.
+//
 // By default, git submodules are not updated.
 func FetchWithRecurseSubmodules(enabled bool) FetchOption {
 	return func(o *fetchOptions) {
@@ -126,9 +312,463 @@ func FetchWithRecurseSubmodules(enabled bool) FetchOption {
 	}
 }
 
+// FetchWithRequireSignedCommit requires the fetched commit to carry a valid OpenPGP signature.
+//
+// The signature must be verified by a key from the keyring set with [FetchWithKeyRing].
+// A valid signature on an annotated tag is also accepted when fetching this tag.
+//
+// Only OpenPGP signatures are supported: objects signed with SSH or X.509 keys (e.g. with git's gpg.format
+// set to "ssh" or "x509") cannot be verified, and fail with [ErrUnsupportedSignature].
+//
+// Raw-content download is not used when this option is enabled, since SCM raw URLs do not allow
+// for such a verification.
+//
+// By default, signatures are not verified.
+func FetchWithRequireSignedCommit(required bool) FetchOption {
+	return func(o *fetchOptions) {
+		withGitRequireSignedCommit(required)(&o.gitOptions)
+	}
+}
+
+// FetchWithKeyRing sets the ASCII-armored OpenPGP keyring used to verify signatures,
+// when using [FetchWithRequireSignedCommit].
+func FetchWithKeyRing(armoredKeyRing string) FetchOption {
+	return func(o *fetchOptions) {
+		withGitKeyRing(armoredKeyRing)(&o.gitOptions)
+	}
+}
+
+// FetchWithLargeFileThreshold sets the size in bytes above which a file retrieved using git is streamed
+// from the git object store, rather than checked out in the worktree.
+//
+// This avoids holding several copies of a large file in memory. The fetched content is the same.
+//
+// By default, files larger than 16 MiB are streamed. A negative threshold disables streaming.
+func FetchWithLargeFileThreshold(threshold int64) FetchOption {
+	return func(o *fetchOptions) {
+		withGitLargeFileThreshold(threshold)(&o.gitOptions)
+	}
+}
+
+// FetchWithManifest reports the list of the files materialized by a fetch in [FetchResult].Files,
+// e.g. for auditing or cache invalidation.
+//
+// With a sparse checkout, only the requested file is materialized.
+//
+// By default, the list of files is not reported.
+func FetchWithManifest(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		withGitManifest(enabled)(&o.gitOptions)
+	}
+}
+
+// FetchWithDateRefs resolves versions expressed as a date, e.g. "@{2024-01-01}",
+// to the last commit on the default branch committed at or before this date.
+//
+// Dates are formatted like "2024-01-01", "2024-01-01 12:00:00" or "2024-01-01T12:00:00+02:00".
+// Dates without a time zone are interpreted as UTC.
+//
+// This requires fetching and walking the history of the default branch, so raw-content download is not used for such versions.
+//
+// By default, dates are not resolved.
+func FetchWithDateRefs(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		withGitDateRefs(enabled)(&o.gitOptions)
+	}
+}
+
+// FetchWithAllTags fetches all the tags of the repository along with the fetched commit,
+// e.g. to have the full set of tags available in the git repository kept by [FetchWithBackingDir].
+//
+// Tags are not needed to resolve a version, even a semver constraint: versions are resolved from the refs
+// advertised by the remote, and only the objects of the resolved ref are fetched.
+// Since tags are fetched with git, raw-content download is not used when this option is enabled.
+//
+// By default, tags are not fetched.
+func FetchWithAllTags(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		withGitAllTags(enabled)(&o.gitOptions)
+	}
+}
+
+// FetchWithNotes retrieves the git note attached to the fetched commit under "refs/notes/commits",
+// and reports it in [FetchResult].Note.
+//
+// Notes are never transferred by default by git: the notes ref is fetched explicitly,
+// so raw-content download is not used when this option is enabled.
+//
+// By default, notes are not retrieved.
+func FetchWithNotes(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		withGitNotes(enabled)(&o.gitOptions)
+	}
+}
+
+// FetchWithTransform applies a transformation to the fetched content before it is copied to the destination
+// [io.Writer], e.g. to decrypt or render a template on the fly.
+//
+// The transform receives the content as an [io.Reader] and returns the transformed content.
+// An error returned by the transform, or when reading from the transformed content, aborts the fetch.
+//
+// Transforms apply whether the content is retrieved from a raw-content URL or using git.
+// When this option is repeated, transforms are chained in the order of declaration.
+//
+// A nil transform is ignored.
+func FetchWithTransform(transform func(io.Reader) (io.Reader, error)) FetchOption {
+	return func(o *fetchOptions) {
+		if transform == nil {
+			return
+		}
+
+		o.transforms = append(o.transforms, transform)
+	}
+}
+
+// FetchWithLineEndings normalizes the line endings of the fetched content, e.g. to convert the CRLF line endings
+// of files authored on Windows to LF.
+//
+// The content is converted while streaming, without being buffered. Lone CR characters are left unchanged.
+// The normalization applies after the transforms set with [FetchWithTransform].
+//
+// By default, the content is byte-exact ([LineEndingsPreserve]).
+func FetchWithLineEndings(normalize LineEndings) FetchOption {
+	return func(o *fetchOptions) {
+		o.lineEndings = normalize
+	}
+}
+
+// FetchWithTrailingNewline appends a newline to fetched content which does not end with one.
+//
+// The appended newline is CRLF whenever the last line of the content ends with CRLF.
+// Empty content is left unchanged.
+//
+// By default, the content is byte-exact.
+func FetchWithTrailingNewline(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		o.trailingNewline = enabled
+	}
+}
+
+// FetchWithRawDefaultRef sets the ref used in raw-content URLs for locations that do not specify a version,
+// e.g. "main", for hosts which do not accept "HEAD" in raw-content URLs.
+//
+// When set, the default branch of a repository is no longer resolved with git before a raw-content download.
+//
+// By default, "HEAD" is used whenever the SCM resolves it, and the default branch is resolved otherwise.
+func FetchWithRawDefaultRef(ref string) FetchOption {
+	return func(o *fetchOptions) {
+		o.rawDefaultRef = ref
+	}
+}
+
+// FetchWithMaxBytes limits the size of the fetched content.
+//
+// A fetch that would copy more than maxBytes to the destination [io.Writer] fails with [ErrMaxBytesExceeded].
+// The limit applies to the content before any transform set by [FetchWithTransform].
+//
+// When retrieving content using git, the limit caps the bytes written, but not the size of the git objects
+// transferred to perform the checkout.
+//
+// By default (or with maxBytes <= 0), the size is not limited.
+func FetchWithMaxBytes(maxBytes int64) FetchOption {
+	return func(o *fetchOptions) {
+		o.maxBytes = maxBytes
+	}
+}
+
+// FetchWithRejectEmpty fails a fetch that retrieves an empty file with [ErrEmptyContent],
+// e.g. for pipelines which treat an empty template as missing.
+//
+// The content is not buffered: only the number of bytes copied to the destination [io.Writer] is tracked.
+// The check applies to the content before any transform set by [FetchWithTransform].
+//
+// By default, empty files are fetched without error.
+func FetchWithRejectEmpty(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		o.rejectEmpty = enabled
+	}
+}
+
+// FetchWithOverallTimeout limits the total duration of a fetch, whether the content is retrieved
+// from a raw-content URL or using git.
+//
+// A fetch that does not complete in time fails with [ErrTimeout].
+//
+// By default (or with timeout <= 0), the duration is only limited by the [context.Context] passed to the [Fetcher].
+func FetchWithOverallTimeout(timeout time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.overallTimeout = timeout
+	}
+}
+
+// FetchWithDialTimeout limits the time spent establishing a connection to the server of a raw-content URL,
+// including the TLS handshake.
+//
+// Unlike [FetchWithOverallTimeout], it does not limit the time spent transferring content, so that a large file
+// may still be downloaded from a server that is quick to reach. It fails fast on unreachable servers.
+//
+// NOTE: this option applies to raw-content downloads, with a client which transport is an [*http.Transport].
+// It does not apply to git operations.
+//
+// By default (or with timeout <= 0), the dial timeout of the [http.Client] is used.
+func FetchWithDialTimeout(timeout time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.dialTimeout = timeout
+	}
+}
+
+// FetchWithMaxIdleConnsPerHost sets the maximum number of idle connections kept per host for reuse by raw-content downloads.
+//
+// Raising this limit avoids establishing new connections when many files are fetched concurrently from the same SCM platform.
+//
+// NOTE: this option applies to raw-content downloads, with a client which transport is an [*http.Transport].
+// It does not apply to git operations.
+//
+// By default (or with n <= 0), 16 idle connections are kept per host by the default client,
+// and the setting of a client set with [FetchWithHTTPClient] is left unchanged.
+func FetchWithMaxIdleConnsPerHost(n int) FetchOption {
+	return func(o *fetchOptions) {
+		o.maxIdleConnsPerHost = n
+	}
+}
+
+// FetchWithHTTP2 attempts HTTP/2 for raw-content downloads, even with a client which transport is customized
+// (e.g. with [FetchWithCABundle] or a client set with [FetchWithHTTPClient]).
+//
+// With HTTP/2, concurrent downloads from the same SCM platform are multiplexed over a single connection.
+//
+// NOTE: this option applies to raw-content downloads, with a client which transport is an [*http.Transport].
+// It does not apply to git operations.
+//
+// The default client always attempts HTTP/2. By default, the setting of a client set with [FetchWithHTTPClient] is left unchanged.
+func FetchWithHTTP2(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		o.forceHTTP2 = enabled
+	}
+}
+
+// FetchWithResponseHeaderTimeout limits the time spent waiting for the headers of the response
+// to a raw-content download, once the request is sent.
+//
+// Unlike [FetchWithOverallTimeout], it does not limit the time spent reading the body of the response.
+//
+// NOTE: this option applies to raw-content downloads, with a client which transport is an [*http.Transport].
+// It does not apply to git operations.
+//
+// By default (or with timeout <= 0), the response header timeout of the [http.Client] is used.
+func FetchWithResponseHeaderTimeout(timeout time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.responseHeaderTimeout = timeout
+	}
+}
+
+// FetchWithFollowGitRedirects retries a failed git fetch against the new location of a repository
+// that has been moved, e.g. after a renaming or a transfer to another owner.
+//
+// When enabled and a git fetch over http or https fails, the [Fetcher] looks for a redirect response from
+// the git smart HTTP endpoint of the repository, and retries with the new location.
+// At most 5 redirects are followed. Redirects to a scheme other than http or https are not followed,
+// nor are redirects from https to http, nor redirects to another host when credentials are configured.
+//
+// By default, failed git fetches are not retried.
+func FetchWithFollowGitRedirects(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		o.followGitRedirects = enabled
+	}
+}
+
+// FetchWithRemoteName sets the name of the git remote, e.g. "upstream".
+//
+// This name is only visible in the local repository, e.g. when using [FetchWithBackingDir].
+//
+// By default, the remote is named "origin".
+func FetchWithRemoteName(name string) FetchOption {
+	return func(o *fetchOptions) {
+		withGitRemoteName(name)(&o.gitOptions)
+	}
+}
+
+// FetchWithFallbackRemote sets the URL of a secondary git remote, e.g. a mirror or a fork,
+// which is tried whenever git fails to fetch from the repository.
+//
+// A fetch is not tried again when the file does not exist at the requested version.
+// The fallback remote only applies to git operations, not to raw-content downloads.
+//
+// By default, there is no fallback remote.
+func FetchWithFallbackRemote(fallbackURL *url.URL) FetchOption {
+	return func(o *fetchOptions) {
+		withGitFallbackURL(fallbackURL)(&o.gitOptions)
+	}
+}
+
+// FetchWithObjectCacheDir keeps the git objects fetched from a repository in a persistent cache, located in dir.
+//
+// The cache is reused by later fetches from the same repository, e.g. to fetch another file or another version,
+// which then only retrieve missing objects. The cache may be shared by several fetchers, and it is never pruned.
+//
+// The cache only applies to git operations carried out in memory, i.e. not with [FetchWithBackingDir].
+//
+// By default, there is no object cache.
+func FetchWithObjectCacheDir(dir string) FetchOption {
+	return func(o *fetchOptions) {
+		withGitObjectCacheDir(dir)(&o.gitOptions)
+	}
+}
+
+// FetchWithDumbHTTP enables fetching from legacy git servers which only serve the dumb HTTP protocol,
+// i.e. a repository served as static files.
+//
+// Such servers are detected by their response to the ref discovery, at the cost of an extra request to the server.
+// Only the tree of the fetched commit is retrieved, not its history.
+//
+// By default, only the smart HTTP protocol is supported.
+func FetchWithDumbHTTP(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		withGitDumbHTTP(enabled)(&o.gitOptions)
+	}
+}
+
+// GitProtocol is a version of the git wire protocol.
+type GitProtocol string
+
+const (
+	// GitProtocolAuto lets git negotiate the protocol version with the server.
+	GitProtocolAuto GitProtocol = GitProtocol(git.ProtocolAuto)
+	// GitProtocolV0 is the original git wire protocol.
+	GitProtocolV0 GitProtocol = GitProtocol(git.ProtocolV0)
+	// GitProtocolV1 is the original git wire protocol, with an initial version announcement.
+	GitProtocolV1 GitProtocol = GitProtocol(git.ProtocolV1)
+	// GitProtocolV2 is the version 2 of the git wire protocol.
+	GitProtocolV2 GitProtocol = GitProtocol(git.ProtocolV2)
+)
+
+// FetchWithGitProtocol forces the version of the git wire protocol, for compatibility with servers
+// which misbehave when the version is negotiated.
+//
+// The version is passed to the installed git command, e.g. when extracting a file with "git archive".
+// The go-git implementation always speaks [GitProtocolV0]: servers that enforce v2 are reported with
+// [ErrUnsupportedProtocol].
+//
+// By default, the version is negotiated ([GitProtocolAuto]).
+//
+// NOTE: [FetchWithGitProtocol] panics if the version is not one of the [GitProtocol] constants.
+func FetchWithGitProtocol(version GitProtocol) FetchOption {
+	if !git.ProtocolVersion(version).IsValid() {
+		panic(fmt.Errorf("invalid git protocol version: %q: %w", version, ErrVCS))
+	}
+
+	return func(o *fetchOptions) {
+		withGitProtocol(git.ProtocolVersion(version))(&o.gitOptions)
+	}
+}
+
+// FetchWithReferenceName forces the full name of the ref to fetch, e.g. "refs/heads/release" or "refs/tags/v1.2.3",
+// for callers who know exactly which ref they want.
+//
+// The version of the fetched locators is then ignored: the remote ref with exactly this name is fetched,
+// without matching branches, tags or semver constraints. This avoids any ambiguity, e.g. between a branch
+// and a tag with the same name. Raw-content URLs are not used.
+//
+// NOTE: [FetchWithReferenceName] panics if the name is not a valid full ref name, starting with "refs/".
+func FetchWithReferenceName(name string) FetchOption {
+	refName := plumbing.ReferenceName(name)
+	if !strings.HasPrefix(name, "refs/") || refName.Validate() != nil {
+		panic(fmt.Errorf("invalid full reference name: %q: %w", name, ErrVCS))
+	}
+
+	return func(o *fetchOptions) {
+		withGitReferenceName(refName)(&o.gitOptions)
+	}
+}
+
+// FetchWithRefSpecs fetches additional refspecs along with the fetched commit,
+// e.g. "+refs/notes/*:refs/notes/*" or "+refs/keep-around/*:refs/keep-around/*".
+//
+// This is useful to pull notes, keep-around refs or refs in custom namespaces into the git repository
+// kept by [FetchWithBackingDir]. Since refs are fetched with git, raw-content download is not used
+// when refspecs are set.
+//
+// NOTE: [FetchWithRefSpecs] panics if a refspec is not a valid fetch refspec, like "[+]<src>:<dst>".
+func FetchWithRefSpecs(refSpecs ...string) FetchOption {
+	specs := mustRefSpecs(refSpecs)
+
+	return func(o *fetchOptions) {
+		withGitRefSpecs(specs)(&o.gitOptions)
+	}
+}
+
+// FetchWithPreferHTTPS rewrites the URL of repositories accessed over ssh into https URLs to the same host,
+// e.g. in environments where only outbound https is allowed.
+//
+// Both ssh URLs (e.g. "ssh://git@github.com/owner/repo") and scp-like locations passed to [Fetcher.Fetch]
+// (e.g. "git@github.com:owner/repo/blob/v1.2.3/README.md") are rewritten, dropping the ssh user and port.
+// The rewrite applies after the rules set by [FetchWithInsteadOf].
+//
+// NOTE: ssh keys do not authenticate over https. Private repositories require a token for the https URL,
+// e.g. with [FetchWithAuthFromEnv] or [FetchWithCredentialHelper].
+func FetchWithPreferHTTPS(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		withPreferHTTPS(enabled)(&o.locOptions)
+	}
+}
+
+// FetchWithInsteadOf rewrites the URL of repositories starting with any of the insteadOf prefixes,
+// replacing this prefix by base, like the git configuration "url.<base>.insteadOf".
+//
+// This allows to transparently redirect fetches to another location, e.g. an internal mirror:
+//
+//	FetchWithInsteadOf("https://mirror.example.com/github/", "https://github.com/")
+//
+// The rewritten URL applies to all the requests performed to retrieve a resource,
+// including raw-content downloads. Like with git, the longest matching prefix wins when several rules apply.
+//
+// Since the [Fetcher] never pushes, there is no equivalent to "url.<base>.pushInsteadOf".
+func FetchWithInsteadOf(base string, insteadOf ...string) FetchOption {
+	return func(o *fetchOptions) {
+		withURLRewrite(base, insteadOf...)(&o.locOptions)
+	}
+}
+
+// FetchWithOutputMode sets the permission bits of the files written by [Fetcher.FetchToFile],
+// e.g. 0600 for secrets.
+//
+// By default, files are written with mode 0644.
+func FetchWithOutputMode(mode os.FileMode) FetchOption {
+	return func(o *fetchOptions) {
+		o.outputMode = mode.Perm()
+	}
+}
+
 type fetchOptions struct {
 	gitOptions
 	locOptions
+
+	transforms           []func(io.Reader) (io.Reader, error)
+	lineEndings          LineEndings
+	trailingNewline      bool
+	gitlabDeployToken    *basicAuth
+	giteaToken           *basicAuth
+	bitbucketAppPassword *basicAuth
+	awsCredentials       *codecommit.Credentials
+	credentialHelper     bool
+	authFromEnv          bool
+	localFiles           bool
+	maxBytes             int64
+	rejectEmpty          bool
+	rawDefaultRef        string
+	mirrors              []string
+	overallTimeout       time.Duration
+	followGitRedirects   bool
+	outputMode           os.FileMode
+	newExecutor          fetchExecutorFactory
+	githubContentsAPI    bool
+	githubMediaType      GithubMediaType
+	baseDir              string
+	scratchDir           string
+	scratchThreshold     int64
+	scratchQuota         int64
+
+	githubEnterpriseHosts []string
 }
 
 // CloneOption configures a [Cloner] with optional behavior.
@@ -139,6 +779,7 @@ type CloneOption func(*cloneOptions)
 //
 // If dir is empty, the default is given by [os.MkDirTemp] using "vcsclone" as the pattern.
 // In this case, [CloneWithBackingDir] panics if it can't create a temporary directory.
+// The temporary directory is removed by [Cloner.Close].
 //
 // When using [CloneWithBackingDir] with a non-empty directory, the cloned content
 // will not be removed after usage and left up to the caller to leave it or clean it if needed.
@@ -178,6 +819,18 @@ func CloneWithExactTag(exact bool) CloneOption {
 	}
 }
 
+// CloneWithCanonicalTags considers semver tags that only differ by a leading "v" as the same tag,
+// when matching tags exactly with [CloneWithExactTag].
+//
+// Example:
+// with tags "v0.2.0" and "0.2.0", both "v0.2.0" and "0.2.0" resolve to "v0.2.0".
+// With only the tag "0.2.0", "v0.2.0" resolves to "0.2.0".
+func CloneWithCanonicalTags(enabled bool) CloneOption {
+	return func(o *cloneOptions) {
+		withGitCanonicalTags(enabled)(&o.gitOptions)
+	}
+}
+
 // CloneWithRequireVersion tells the [Cloner] to check that the cloned location
 // comes with an explicit version. No default to HEAD is applied.
 func CloneWithRequireVersion(required bool) CloneOption {
@@ -207,15 +860,31 @@ func CloneWithGitLocatorOptions(opts ...GitLocatorOption) CloneOption {
 // This option is disabled when using [CloneWithExactTag].
 //
 // Example:
-// for tag "v2", with pre-releases allowed, "v1.3.0-rc1" is a valid candidate.
+// for tag "v2", with pre-releases allowed, "v2.3.0-rc1" is a valid candidate.
 func CloneWithAllowPrereleases(allowed bool) CloneOption {
 	return func(o *cloneOptions) {
 		withGitAllowPrereleases(allowed)(&o.gitOptions)
 	}
 }
 
+// CloneWithHTTPClient sets the [http.Client] used to download repository archives from SCM platforms.
+//
+// By default, a client shared by all cloners is used, which keeps idle connections for reuse and attempts HTTP/2.
+func CloneWithHTTPClient(client *http.Client) CloneOption {
+	return func(o *cloneOptions) {
+		withHTTPClient(client)(&o.locOptions)
+	}
+}
+
 // CloneWithRecurseSubmodules resolves submodules when cloning.
 //
+// When enabled, submodules are cloned at the commit pinned by the parent repository, into their folder of the clone.
+// Nested submodules are resolved recursively. Submodules are fetched in parallel: see [CloneWithSubmoduleConcurrency].
+//
+// Credentials and additional certificate authorities are only used for submodules served with the same scheme,
+// host and port as the parent repository. Submodules designating a local repository (e.g. file://) are rejected,
+// unless the parent repository is local too.
+//
 // By default, git submodules are not updated.
 func CloneWithRecurseSubmodules(enabled bool) CloneOption {
 	return func(o *cloneOptions) {
@@ -223,6 +892,80 @@ func CloneWithRecurseSubmodules(enabled bool) CloneOption {
 	}
 }
 
+// CloneWithSubmoduleConcurrency limits the number of submodules fetched in parallel,
+// when cloning with [CloneWithRecurseSubmodules].
+//
+// The limit applies to the submodules of each repository: nested submodules are fetched with the same limit.
+// A limit of 1 fetches submodules one at a time.
+//
+// By default, at most 4 submodules are fetched in parallel.
+func CloneWithSubmoduleConcurrency(limit int) CloneOption {
+	return func(o *cloneOptions) {
+		o.submoduleConcurrency = limit
+	}
+}
+
+// CloneWithRemoteName sets the name of the git remote, e.g. "upstream".
+//
+// See [FetchWithRemoteName].
+func CloneWithRemoteName(name string) CloneOption {
+	return func(o *cloneOptions) {
+		withGitRemoteName(name)(&o.gitOptions)
+	}
+}
+
+// CloneWithFallbackRemote sets the URL of a secondary git remote, e.g. a mirror or a fork,
+// which is tried whenever git fails to clone the repository.
+//
+// See [FetchWithFallbackRemote].
+func CloneWithFallbackRemote(fallbackURL *url.URL) CloneOption {
+	return func(o *cloneOptions) {
+		withGitFallbackURL(fallbackURL)(&o.gitOptions)
+	}
+}
+
+// CloneWithInsteadOf rewrites the URL of repositories starting with any of the insteadOf prefixes,
+// replacing this prefix by base, like the git configuration "url.<base>.insteadOf".
+//
+// See [FetchWithInsteadOf].
+func CloneWithInsteadOf(base string, insteadOf ...string) CloneOption {
+	return func(o *cloneOptions) {
+		withURLRewrite(base, insteadOf...)(&o.locOptions)
+	}
+}
+
+// CloneWithAllTags fetches all the tags of the repository along with the cloned commit.
+//
+// See [FetchWithAllTags].
+func CloneWithAllTags(enabled bool) CloneOption {
+	return func(o *cloneOptions) {
+		withGitAllTags(enabled)(&o.gitOptions)
+	}
+}
+
+// CloneWithRefSpecs fetches additional refspecs along with the cloned commit.
+//
+// See [FetchWithRefSpecs].
+//
+// NOTE: [CloneWithRefSpecs] panics if a refspec is not a valid fetch refspec, like "[+]<src>:<dst>".
+func CloneWithRefSpecs(refSpecs ...string) CloneOption {
+	specs := mustRefSpecs(refSpecs)
+
+	return func(o *cloneOptions) {
+		withGitRefSpecs(specs)(&o.gitOptions)
+	}
+}
+
+// CloneWithDateRefs resolves versions expressed as a date, e.g. "@{2024-01-01}",
+// to the last commit on the default branch committed at or before this date.
+//
+// See [FetchWithDateRefs].
+func CloneWithDateRefs(enabled bool) CloneOption {
+	return func(o *cloneOptions) {
+		withGitDateRefs(enabled)(&o.gitOptions)
+	}
+}
+
 // CloneWithSparseFilter instructs the cloning to be performed only on the specified directories or files.
 func CloneWithSparseFilter(filter ...string) CloneOption {
 	return func(o *cloneOptions) {
@@ -230,6 +973,45 @@ func CloneWithSparseFilter(filter ...string) CloneOption {
 	}
 }
 
+// CloneWithZipArchive clones repositories hosted on github.com or gitlab by downloading a zip archive
+// of the repository at the requested version, rather than cloning with git.
+//
+// This is faster for whole-repository snapshots, when no git history is needed.
+// Since archives do not report the resolved commit, [CloneResult].Hash is not set.
+//
+// The archive is not used with a sparse filter, with submodules, when signatures are verified,
+// when the repository URL holds credentials, or when the version requires to look up the refs of the repository
+// (e.g. an incomplete semver "v1.2"). The clone then falls back to git.
+//
+// At most 1 GiB of files are extracted from an archive.
+//
+// By default, repositories are cloned with git, unless the location designates an archive, e.g. a github tarball.
+func CloneWithZipArchive(enabled bool) CloneOption {
+	return func(o *cloneOptions) {
+		o.zipArchive = enabled
+	}
+}
+
+// CloneWithCacheDir keeps the cloned worktrees in a persistent cache located in dir, as compressed archives.
+//
+// A later clone of the same repository, at a ref which resolves to the same commit, is restored from the cache
+// without fetching from the remote. A pinned commit hash is restored without any network call. Whenever the ref
+// resolves to another commit, e.g. a branch has moved, the repository is cloned again and the cached clone is replaced.
+//
+// Clones with submodules (see [CloneWithRecurseSubmodules]), or with symbolic links, are not cached.
+// A cached clone which cannot be restored, e.g. a corrupted archive, is discarded and the repository is cloned again.
+//
+// NOTE: cached clones are keyed by the repository URL without credentials, and pinned commits are restored
+// without contacting the remote. Anyone who can read or share the cache directory may therefore read the content
+// of cached private repositories without any credentials: do not share a cache directory across trust boundaries.
+//
+// By default, there is no clone cache.
+func CloneWithCacheDir(dir string) CloneOption {
+	return func(o *cloneOptions) {
+		withGitCloneCacheDir(dir)(&o.gitOptions)
+	}
+}
+
 // SPDXOption is an option to parse a SPDX locator URL.
 type SPDXOption func(*spdxOptions)
 
@@ -280,11 +1062,53 @@ func GitWithRequiredVersion(required bool) GitLocatorOption {
 	}
 }
 
+// GitWithKeepGitSuffix tells the [GitLocator] parser to preserve a trailing ".git" in the repository URL,
+// e.g. for self-hosted setups that require it in the clone URL, or with repositories which actual name ends with ".git".
+//
+// Locations are recognized in the same way, whether the suffix is present or not.
+//
+// By default, a trailing ".git" is stripped from the repository URL.
+func GitWithKeepGitSuffix(keep bool) GitLocatorOption {
+	return func(o *gitLocatorOptions) {
+		o.keepGitSuffix = keep
+	}
+}
+
+// GitWithProvider forces the [GitLocator] parser to use the URL format of a SCM provider,
+// regardless of the host in the URL, e.g. "gitlab" for a gitlab instance hosted on "code.example.com".
+//
+// Supported providers are "github", "gitlab", "gitea", "bitbucket", "azure", "sourcehut" and "codecommit".
+//
+// Only the parsing of the location is affected. To also download raw content and send provider-specific
+// credentials to such a host, register it with [RegisterProvider].
+//
+// NOTE: [GitWithProvider] panics if the provider is not one of the supported providers.
+func GitWithProvider(provider string) GitLocatorOption {
+	p := giturl.Provider(provider)
+	if !giturl.IsKnownProvider(p) {
+		panic(fmt.Errorf("unknown provider: %q: %w", provider, ErrVCS))
+	}
+
+	return func(o *gitLocatorOptions) {
+		o.provider = p
+	}
+}
+
+// gitWithGithubEnterpriseHost tells the [GitLocator] parser to parse URLs on a GitHub Enterprise host as github URLs.
+func gitWithGithubEnterpriseHost(host string) GitLocatorOption {
+	return func(o *gitLocatorOptions) {
+		o.githubEnterpriseHosts = append(o.githubEnterpriseHosts, host)
+	}
+}
+
 type cloneOptions struct {
 	gitOptions
 	locOptions
 
-	sparseFilter []string
+	sparseFilter         []string
+	submoduleConcurrency int
+	zipArchive           bool
+	archiveMaxSize       int64 // overrides archive.DefaultMaxSize, e.g. in tests
 }
 
 type gitOption func(*gitOptions)
@@ -292,11 +1116,29 @@ type gitOption func(*gitOptions)
 type gitOptions struct {
 	isFSBacked        bool
 	dir               string
+	isTempDir         bool // dir is a temporary directory created by the options
 	gitSkipAutodetect bool
 	debug             bool
 	resolveExactTag   bool
+	canonicalTags     bool
 	allowPrereleases  bool
 	recurseSubModules bool
+	requireSigned     bool
+	armoredKeyRing    string
+	largeFileSize     int64
+	manifest          bool
+	dateRefs          bool
+	notes             bool
+	allTags           bool
+	remoteName        string
+	fallbackURL       *url.URL
+	objectCacheDir    string
+	cloneCacheDir     string
+	dumbHTTP          bool
+	protocolVersion   git.ProtocolVersion
+	referenceName     plumbing.ReferenceName
+	refSpecs          []config.RefSpec
+	caBundle          []byte
 	// auth TODO
 }
 
@@ -305,8 +1147,21 @@ type locOption func(*locOptions)
 type locOptions struct {
 	requireVersion bool
 	skipRawURL     bool
+	httpClient     *http.Client
 	spdxOpts       []SPDXOption
 	gitLocOpts     []GitLocatorOption
+	urlRewrites    []urlRewrite
+	preferHTTPS    bool
+
+	caBundle              []byte
+	caBundleWithoutSystem bool
+	downloadClient        *http.Client                   // httpClient, trusting caBundle with timeouts
+	systemCertPool        func() (*x509.CertPool, error) // defaults to x509.SystemCertPool
+
+	dialTimeout           time.Duration
+	responseHeaderTimeout time.Duration
+	maxIdleConnsPerHost   int
+	forceHTTP2            bool
 }
 
 type spdxOptions struct {
@@ -315,6 +1170,10 @@ type spdxOptions struct {
 
 type gitLocatorOptions struct {
 	commonLocOptions
+
+	keepGitSuffix         bool
+	provider              giturl.Provider
+	githubEnterpriseHosts []string
 }
 
 type commonLocOption func(*commonLocOptions)
@@ -338,8 +1197,10 @@ func withGitBackingDir(enabled bool, dir string) gitOption {
 				panic(fmt.Errorf("could not created temporary folder to clone: %w: %w", err, ErrVCS))
 			}
 			o.dir = tempDir
+			o.isTempDir = true
 		} else {
 			o.dir = dir
+			o.isTempDir = false
 		}
 	}
 }
@@ -362,6 +1223,12 @@ func withGitResolveExactTag(exact bool) gitOption {
 	}
 }
 
+func withGitCanonicalTags(enabled bool) gitOption {
+	return func(o *gitOptions) {
+		o.canonicalTags = enabled
+	}
+}
+
 func withGitAllowPrereleases(allowed bool) gitOption {
 	return func(o *gitOptions) {
 		o.allowPrereleases = allowed
@@ -374,6 +1241,111 @@ func withGitRecurseSubModules(enabled bool) gitOption {
 	}
 }
 
+func withGitRemoteName(name string) gitOption {
+	return func(o *gitOptions) {
+		o.remoteName = name
+	}
+}
+
+func withGitFallbackURL(fallbackURL *url.URL) gitOption {
+	return func(o *gitOptions) {
+		o.fallbackURL = fallbackURL
+	}
+}
+
+func withGitObjectCacheDir(dir string) gitOption {
+	return func(o *gitOptions) {
+		o.objectCacheDir = dir
+	}
+}
+
+func withGitCloneCacheDir(dir string) gitOption {
+	return func(o *gitOptions) {
+		o.cloneCacheDir = dir
+	}
+}
+
+func withGitDumbHTTP(enabled bool) gitOption {
+	return func(o *gitOptions) {
+		o.dumbHTTP = enabled
+	}
+}
+
+func withGitProtocol(version git.ProtocolVersion) gitOption {
+	return func(o *gitOptions) {
+		o.protocolVersion = version
+	}
+}
+
+func withGitReferenceName(name plumbing.ReferenceName) gitOption {
+	return func(o *gitOptions) {
+		o.referenceName = name
+	}
+}
+
+func withGitRefSpecs(refSpecs []config.RefSpec) gitOption {
+	return func(o *gitOptions) {
+		o.refSpecs = append(slices.Clip(o.refSpecs), refSpecs...) // never share the array with a copy of the options
+	}
+}
+
+// mustRefSpecs validates fetch refspecs.
+func mustRefSpecs(refSpecs []string) []config.RefSpec {
+	specs := make([]config.RefSpec, 0, len(refSpecs))
+	for _, refSpec := range refSpecs {
+		spec := config.RefSpec(refSpec)
+		if err := spec.Validate(); err != nil || spec.IsDelete() {
+			panic(fmt.Errorf("invalid fetch refspec: %q: %w", refSpec, ErrVCS))
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs
+}
+
+func withGitRequireSignedCommit(required bool) gitOption {
+	return func(o *gitOptions) {
+		o.requireSigned = required
+	}
+}
+
+func withGitKeyRing(armoredKeyRing string) gitOption {
+	return func(o *gitOptions) {
+		o.armoredKeyRing = armoredKeyRing
+	}
+}
+
+func withGitLargeFileThreshold(threshold int64) gitOption {
+	return func(o *gitOptions) {
+		o.largeFileSize = threshold
+	}
+}
+
+func withGitManifest(enabled bool) gitOption {
+	return func(o *gitOptions) {
+		o.manifest = enabled
+	}
+}
+
+func withGitDateRefs(enabled bool) gitOption {
+	return func(o *gitOptions) {
+		o.dateRefs = enabled
+	}
+}
+
+func withGitAllTags(enabled bool) gitOption {
+	return func(o *gitOptions) {
+		o.allTags = enabled
+	}
+}
+
+func withGitNotes(enabled bool) gitOption {
+	return func(o *gitOptions) {
+		o.notes = enabled
+	}
+}
+
 func withSPDXOptions(opts ...SPDXOption) locOption {
 	return func(o *locOptions) {
 		o.spdxOpts = append(o.spdxOpts, opts...)
@@ -386,6 +1358,20 @@ func withGitLocatorOptions(opts ...GitLocatorOption) locOption {
 	}
 }
 
+func withURLRewrite(base string, insteadOf ...string) locOption {
+	return func(o *locOptions) {
+		for _, prefix := range insteadOf {
+			o.urlRewrites = append(o.urlRewrites, urlRewrite{base: base, insteadOf: prefix})
+		}
+	}
+}
+
+func withPreferHTTPS(enabled bool) locOption {
+	return func(o *locOptions) {
+		o.preferHTTPS = enabled
+	}
+}
+
 func withRequiredLocVersion(required bool) locOption {
 	return func(o *locOptions) {
 		o.requireVersion = required
@@ -398,6 +1384,12 @@ func withSkipRawURL(skipped bool) locOption {
 	}
 }
 
+func withHTTPClient(client *http.Client) locOption {
+	return func(o *locOptions) {
+		o.httpClient = client
+	}
+}
+
 func withRootURL[T string | *url.URL | url.URL](root T) commonLocOption {
 	return func(o *commonLocOptions) {
 		var v any = root
@@ -405,7 +1397,7 @@ func withRootURL[T string | *url.URL | url.URL](root T) commonLocOption {
 		case string:
 			u, err := url.Parse(value)
 			if err != nil {
-				panic(fmt.Errorf("invalid URL string passed as parameter: %q: %w: %w", value, err, ErrVCS))
+				panic(fmt.Errorf("invalid URL string passed as parameter: %q: %w: %w", redact.String(value), redact.Error(err), ErrVCS))
 			}
 			o.rootURL = u
 		case *url.URL:
@@ -422,22 +1414,92 @@ func withRequiredVersion(required bool) commonLocOption {
 	}
 }
 
+// rawOptions yields the options to build raw-content URLs.
+func (o fetchOptions) rawOptions() []giturl.RawOption {
+	var opts []giturl.RawOption
+	if o.rawDefaultRef != "" {
+		opts = append(opts, giturl.RawDefaultRef(o.rawDefaultRef))
+	}
+
+	if len(o.githubEnterpriseHosts) > 0 {
+		opts = append(opts, giturl.RawGithubEnterpriseHosts(o.githubEnterpriseHosts...))
+	}
+
+	return opts
+}
+
+// githubAccept yields the media type requested from the github contents API.
+func (o fetchOptions) githubAccept() GithubMediaType {
+	if o.githubMediaType == "" {
+		return GithubMediaTypeRaw
+	}
+
+	return o.githubMediaType
+}
+
+// withDownloadClient derives once the [http.Client] used for downloads from the configured one,
+// with the CA bundle, timeouts and connections settings.
+func (o *locOptions) withDownloadClient() {
+	o.withTLS()
+	o.downloadClient = download.WithTimeouts(o.downloadClient, o.dialTimeout, o.responseHeaderTimeout)
+	o.downloadClient = download.WithConnections(o.downloadClient, o.maxIdleConnsPerHost, o.forceHTTP2)
+}
+
 func (o locOptions) toInternalDownloadOptions() *download.Options {
-	return &download.Options{}
+	return &download.Options{
+		Client: o.downloadClient,
+	}
+}
+
+// toInternalGitOptions yields the options of the git layer, which retrieves files from dumb HTTP remotes
+// with the same client as raw-content downloads.
+func (o fetchOptions) toInternalGitOptions() *git.Options {
+	opts := o.gitOptions.toInternalGitOptions()
+	opts.HTTPClient = o.downloadClient
+
+	return opts
+}
+
+// toInternalGitOptions yields the options of the git layer, like [fetchOptions.toInternalGitOptions].
+func (o cloneOptions) toInternalGitOptions() *git.Options {
+	opts := o.gitOptions.toInternalGitOptions()
+	opts.HTTPClient = o.downloadClient
+
+	return opts
 }
 
 func (o gitOptions) toInternalGitOptions() *git.Options {
 	return &git.Options{
-		IsFSBacked:        o.isFSBacked,
-		Dir:               o.dir,
-		GitSkipAutoDetect: o.gitSkipAutodetect,
-		Debug:             o.debug,
-		ResolveExactTag:   o.resolveExactTag,
+		IsFSBacked:          o.isFSBacked,
+		Dir:                 o.dir,
+		GitSkipAutoDetect:   o.gitSkipAutodetect,
+		Debug:               o.debug,
+		ResolveExactTag:     o.resolveExactTag,
+		CanonicalTags:       o.canonicalTags,
+		RecurseSubModules:   o.recurseSubModules,
+		AllowPreReleases:    o.allowPrereleases,
+		RequireSignedCommit: o.requireSigned,
+		ArmoredKeyRing:      o.armoredKeyRing,
+		LargeFileThreshold:  o.largeFileSize,
+		Manifest:            o.manifest,
+		DateRefs:            o.dateRefs,
+		Notes:               o.notes,
+		RemoteName:          o.remoteName,
+		FallbackURL:         o.fallbackURL,
+		CABundle:            o.caBundle,
+		ObjectCacheDir:      o.objectCacheDir,
+		CloneCacheDir:       o.cloneCacheDir,
+		DumbHTTP:            o.dumbHTTP,
+		ProtocolVersion:     o.protocolVersion,
+		ReferenceName:       o.referenceName,
+		FetchTags:           o.allTags,
+		ExtraRefSpecs:       o.refSpecs,
 	}
 }
 
 func (o cloneOptions) toInternalGitCloneOptions() *git.CloneOptions {
 	return &git.CloneOptions{
-		SparseFilter: o.sparseFilter,
+		SparseFilter:         o.sparseFilter,
+		SubmoduleConcurrency: o.submoduleConcurrency,
 	}
 }
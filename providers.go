@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl"
+)
+
+// RegisterProvider associates the hosts recognized by a matcher with a well-known SCM provider.
+//
+// This is useful for self-hosted instances which host name does not contain the name of the provider,
+// e.g. "git.example.com" for an on-premises gitlab.
+//
+// Supported providers are "github", "gitlab", "gitea", "bitbucket", "azure", "sourcehut" and "codecommit".
+//
+// The host passed to the matcher is normalized to lower case and may include a port.
+// Registered matchers take precedence over the built-in detection.
+//
+// To download raw content from a GitHub Enterprise instance, declare its host with [FetchWithGitHubEnterpriseHost].
+//
+// NOTE: matchers are registered for the whole process, not for a single [Fetcher] or [Cloner].
+func RegisterProvider(provider string, matcher func(host string) bool) error {
+	if _, err := giturl.RegisterProvider(giturl.Provider(provider), matcher); err != nil {
+		return fmt.Errorf("could not register provider: %w: %w", err, ErrVCS)
+	}
+
+	return nil
+}
+
+// FetchWithGitHubEnterpriseHost declares the host of a GitHub Enterprise instance, e.g. "git.example.com".
+//
+// URLs on this host are parsed as github URLs, even though the host name does not contain "github",
+// and raw content is downloaded from the "raw" path served by GitHub Enterprise,
+// e.g. https://git.example.com/owner/repo/raw/main/README.md. With [FetchWithAuthFromEnv],
+// the GITHUB_TOKEN is sent to this host.
+//
+// The host is only known to the [Fetcher] configured with this option. A GitHub Enterprise host may
+// also be set for the whole process with the GHE_HOST environment variable.
+//
+// The host may include a port. A scheme and a trailing slash are tolerated, e.g. "https://git.example.com/".
+//
+// NOTE: [FetchWithGitHubEnterpriseHost] panics if the host is empty.
+func FetchWithGitHubEnterpriseHost(host string) FetchOption {
+	normalized := giturl.NormalizeHost(host)
+	if normalized == "" {
+		panic(fmt.Errorf("invalid GitHub Enterprise host %q: %w", host, ErrVCS))
+	}
+
+	return func(o *fetchOptions) {
+		if slices.Contains(o.githubEnterpriseHosts, normalized) {
+			return
+		}
+
+		o.githubEnterpriseHosts = append(o.githubEnterpriseHosts, normalized)
+		withGitLocatorOptions(gitWithGithubEnterpriseHost(normalized))(&o.locOptions)
+	}
+}
+
+// ProviderInfo describes a well-known SCM provider and the features supported for the repositories it hosts.
+//
+// See [Providers].
+type ProviderInfo struct {
+	// Name of the provider, e.g. "github".
+	Name string
+
+	// Hosts describe the hosts detected as this provider, e.g. "*github*" for any host which name contains "github".
+	//
+	// The GitHub Enterprise host set by the GHE_HOST environment variable is included,
+	// but not the hosts declared with [FetchWithGitHubEnterpriseHost].
+	Hosts []string
+
+	// RegisteredMatchers is the number of host matchers registered for this provider with [RegisterProvider].
+	RegisteredMatchers int
+
+	// SupportsRaw tells if files are downloaded from a raw-content URL, bypassing git.
+	SupportsRaw bool
+
+	// RawFromAPI tells if the raw content is served by the API of the provider, rather than by a raw-content path.
+	RawFromAPI bool
+
+	// RawSupportsHEAD tells if the raw-content URLs resolve an unspecified version to the default branch.
+	// When they don't, the default branch is first resolved using git.
+	RawSupportsHEAD bool
+
+	// SupportsSSH tells if ssh URLs are recognized for this provider.
+	SupportsSSH bool
+
+	// SupportsArchive tells if repository archives are downloaded from the provider when cloning, bypassing git.
+	SupportsArchive bool
+
+	// SupportsContents tells if files may be retrieved from the contents API of the provider (see [FetchWithGithubContentsAPI]).
+	SupportsContents bool
+
+	// SupportsListing tells if folders are listed using the API of the provider (see [Fetcher.ListDir]).
+	SupportsListing bool
+}
+
+// Providers describes the well-known SCM providers, in the order of detection.
+//
+// The description reflects the hosts registered with [RegisterProvider] and the GHE_HOST environment variable.
+func Providers() []ProviderInfo {
+	providers := giturl.Providers()
+	infos := make([]ProviderInfo, 0, len(providers))
+
+	for _, provider := range providers {
+		infos = append(infos, ProviderInfo{
+			Name:               provider.Name.String(),
+			Hosts:              provider.Hosts,
+			RegisteredMatchers: provider.RegisteredMatchers,
+			SupportsRaw:        provider.SupportsRaw(),
+			RawFromAPI:         provider.Raw == giturl.RawAPI,
+			RawSupportsHEAD:    provider.RawSupportsHEAD,
+			SupportsSSH:        provider.SupportsSSH,
+			SupportsArchive:    provider.SupportsArchive,
+			SupportsContents:   provider.SupportsContents,
+			SupportsListing:    provider.SupportsListing,
+		})
+	}
+
+	return infos
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+)
+
+// readmeCandidates lists the README file names, in order of preference.
+var readmeCandidates = []string{
+	"README.md",
+	"README.rst",
+	"README",
+	"docs/README.md",
+}
+
+// FetchReadme fetches the README file of a repository at a given version.
+//
+// Common README file names are tried in order: README.md, README.rst, README, docs/README.md.
+// The content of the first file found is copied to the passed [io.Writer] and its name is returned.
+//
+// An empty version resolves to the default branch of the repository.
+//
+// If none of these files exists, FetchReadme fails with [ErrFileNotFound].
+func (f *Fetcher) FetchReadme(ctx context.Context, w io.Writer, repoURL *url.URL, version string) (string, error) {
+	return f.fetchFirst(ctx, w, repoURL, version, readmeCandidates)
+}
+
+// fetchFirst fetches the first existing file among candidates.
+//
+// It returns the name of the file that was found.
+func (f *Fetcher) fetchFirst(ctx context.Context, w io.Writer, repoURL *url.URL, version string, candidates []string) (string, error) {
+	if repoURL == nil {
+		return "", fmt.Errorf("a repository URL is required: %w", ErrVCS)
+	}
+
+	for _, candidate := range candidates {
+		// the file is not found before any content gets written, so it is safe to try again on the same writer
+		err := f.FetchLocator(ctx, w, repoLocator(repoURL, version, candidate))
+		if err == nil {
+			return candidate, nil
+		}
+
+		if !errors.Is(err, ErrFileNotFound) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("none of %v found in %v: %w: %w", candidates, redact.URL(repoURL), ErrFileNotFound, ErrVCS)
+}
+
+// repoLocator builds a [GitLocator] for a file in a repository.
+func repoLocator(repoURL *url.URL, version, pth string) *GitLocator {
+	u := *repoURL
+
+	var provider string
+	if p, _, err := giturl.AutoDetect(&u); err == nil {
+		provider = string(p)
+	}
+
+	return &GitLocator{
+		repo:      &u,
+		Provider:  provider,
+		Transport: u.Scheme,
+		Host:      u.Host,
+		Ref:       version,
+		SubPath:   pth,
+	}
+}
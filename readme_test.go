@@ -0,0 +1,78 @@
+package vcsfetch
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetchReadme(t *testing.T) {
+	t.Parallel()
+
+	fetcher := NewFetcher(FetchWithGitSkipAutoDetect(true))
+
+	t.Run("should fetch README.md", func(t *testing.T) {
+		repo := testrepo.New(t)
+		repo.Tag("v1.0.0", repo.Commit(map[string]string{
+			"README.md":      "# readme",
+			"docs/README.md": "# docs",
+			"main.go":        "package main",
+		}, "initial commit"))
+		w := new(bytes.Buffer)
+
+		name, err := fetcher.FetchReadme(t.Context(), w, repo.URL(), "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "README.md", name)
+		require.Equal(t, "# readme", w.String())
+	})
+
+	t.Run("should fall back on other README names", func(t *testing.T) {
+		repo := testrepo.New(t)
+		repo.Tag("v1.0.0", repo.Commit(map[string]string{
+			"docs/README.md": "# docs",
+		}, "initial commit"))
+		w := new(bytes.Buffer)
+
+		name, err := fetcher.FetchReadme(t.Context(), w, repo.URL(), "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "docs/README.md", name)
+		require.Equal(t, "# docs", w.String())
+	})
+
+	t.Run("should fail when there is no README", func(t *testing.T) {
+		repo := testrepo.New(t)
+		repo.Tag("v1.0.0", repo.Commit(map[string]string{
+			"main.go": "package main",
+		}, "initial commit"))
+		w := new(bytes.Buffer)
+
+		_, err := fetcher.FetchReadme(t.Context(), w, repo.URL(), "v1.0.0")
+		require.ErrorIs(t, err, ErrFileNotFound)
+		require.ErrorIs(t, err, ErrVCS)
+		require.Empty(t, w.String())
+	})
+
+	t.Run("should try README names on raw-content URLs", func(t *testing.T) {
+		transport := newStubTransport(func(req *http.Request) *http.Response {
+			if req.URL.Path == "/fredbi/go-vcsfetch/v1.0.0/README.rst" {
+				return stubResponse(http.StatusOK, "readme")
+			}
+
+			return stubResponse(http.StatusNotFound, "")
+		})
+		rawFetcher := NewFetcher(FetchWithHTTPClient(&http.Client{Transport: transport}))
+		u, err := url.Parse("https://github.com/fredbi/go-vcsfetch")
+		require.NoError(t, err)
+		w := new(bytes.Buffer)
+
+		name, err := rawFetcher.FetchReadme(t.Context(), w, u, "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, "README.rst", name)
+		require.Equal(t, "readme", w.String())
+		require.Len(t, transport.Requests(), 2)
+	})
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxGitRedirects is the maximum number of redirects followed with [FetchWithFollowGitRedirects].
+const maxGitRedirects = 5
+
+const infoRefsPath = "/info/refs"
+
+// gitRedirect probes the git smart HTTP endpoint of a repository and yields the new location of the repository
+// if the server responds with a redirect.
+//
+// The redirect is not followed: we only look at the Location header of the response.
+func (f *Fetcher) gitRedirect(ctx context.Context, repoURL *url.URL, auth *basicAuth) (*url.URL, bool) {
+	if repoURL.Scheme != "http" && repoURL.Scheme != "https" {
+		return nil, false
+	}
+
+	probeURL := *repoURL
+	probeURL.Path = strings.TrimSuffix(probeURL.Path, "/") + infoRefsPath
+	probeURL.RawPath = ""
+	probeURL.RawQuery = "service=git-upload-pack"
+	probeURL.Fragment = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL.String(), nil)
+	if err != nil {
+		return nil, false
+	}
+	if auth != nil {
+		req.SetBasicAuth(auth.username, auth.password)
+	}
+
+	client := &http.Client{}
+	if f.downloadClient != nil {
+		*client = *f.downloadClient
+	}
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	_ = resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil, false
+	}
+
+	location, err := resp.Location()
+	if err != nil {
+		return nil, false
+	}
+
+	if location.Scheme != "http" && location.Scheme != "https" {
+		// never downgrade to a local or unsupported transport
+		return nil, false
+	}
+
+	if repoURL.Scheme == "https" && location.Scheme != "https" {
+		// never downgrade from https to plain http, with or without credentials
+		return nil, false
+	}
+
+	if auth != nil && !strings.EqualFold(location.Host, repoURL.Host) {
+		// never send credentials to another host
+		return nil, false
+	}
+
+	movedURL := *location
+	movedURL.Path = strings.TrimSuffix(strings.TrimSuffix(movedURL.Path, "/"), infoRefsPath)
+	movedURL.RawPath = ""
+	movedURL.RawQuery = ""
+	movedURL.Fragment = ""
+
+	if movedURL.String() == repoURL.String() {
+		return nil, false
+	}
+
+	return &movedURL, true
+}
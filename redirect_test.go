@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetcherFollowGitRedirects(t *testing.T) {
+	t.Parallel()
+
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("this test requires the git binary to serve a repository over http")
+	}
+
+	repo := testrepo.New(t)
+	hash := repo.Commit(map[string]string{"docs/hello.txt": "hello world\n"}, "initial commit")
+	repo.Tag("v1.0.0", hash)
+
+	// the new location of the repo is served over the git smart HTTP protocol
+	newServer := httptest.NewServer(&cgi.Handler{
+		Path: gitBin,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + filepath.Dir(repo.Dir),
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	})
+	t.Cleanup(newServer.Close)
+	newRepoURL := newServer.URL + "/" + filepath.Base(repo.Dir)
+
+	// the old location redirects to the new repo page, not to the git endpoint
+	oldServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, newRepoURL, http.StatusMovedPermanently)
+	}))
+	t.Cleanup(oldServer.Close)
+
+	locator := &MockLocator{
+		RepoURLFunc: func() *url.URL {
+			u, _ := url.Parse(oldServer.URL + "/old/repo")
+
+			return u
+		},
+		PathFunc:    func() string { return "docs/hello.txt" },
+		VersionFunc: func() string { return "v1.0.0" },
+	}
+
+	t.Run("should fail on a moved repo", func(t *testing.T) {
+		t.Parallel()
+
+		w := new(bytes.Buffer)
+		require.ErrorIs(t, NewFetcher().FetchLocator(t.Context(), w, locator), ErrVCS)
+		require.Empty(t, w.String())
+	})
+
+	t.Run("should follow the redirect of a moved repo", func(t *testing.T) {
+		t.Parallel()
+
+		w := new(bytes.Buffer)
+		require.NoError(t, NewFetcher(FetchWithFollowGitRedirects(true)).FetchLocator(t.Context(), w, locator))
+		require.Equal(t, "hello world\n", w.String())
+	})
+
+	t.Run("should not send credentials to another host", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher := NewFetcher(FetchWithFollowGitRedirects(true))
+		movedURL, ok := fetcher.gitRedirect(t.Context(), locator.RepoURL(), &basicAuth{username: "user", password: "secret"})
+		require.False(t, ok)
+		require.Nil(t, movedURL)
+	})
+
+	t.Run("should not downgrade from https to http", func(t *testing.T) {
+		t.Parallel()
+
+		secureServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, newRepoURL, http.StatusMovedPermanently)
+		}))
+		t.Cleanup(secureServer.Close)
+
+		secureURL, err := url.Parse(secureServer.URL + "/old/repo")
+		require.NoError(t, err)
+
+		fetcher := NewFetcher(FetchWithFollowGitRedirects(true), FetchWithHTTPClient(secureServer.Client()))
+		movedURL, ok := fetcher.gitRedirect(t.Context(), secureURL, nil)
+		require.False(t, ok)
+		require.Nil(t, movedURL)
+	})
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+// FetchSource tells which path served the content of a fetch.
+type FetchSource string
+
+const (
+	// FetchSourceRaw is a download from the raw-content URL or the contents API of the SCM, bypassing git.
+	FetchSourceRaw FetchSource = "raw"
+	// FetchSourceGit is a fetch from the remote, using the go-git implementation.
+	FetchSourceGit FetchSource = "git"
+	// FetchSourceGitArchive is a fetch using "git archive" with the installed git command.
+	FetchSourceGitArchive FetchSource = "git-archive"
+	// FetchSourceDumbHTTP is a fetch from a remote served over the dumb HTTP protocol (see [FetchWithDumbHTTP]).
+	FetchSourceDumbHTTP FetchSource = "dumb-http"
+	// FetchSourceCache is a fetch using the go-git implementation, which found all the objects needed locally,
+	// e.g. in the object cache (see [FetchWithObjectCacheDir]).
+	FetchSourceCache FetchSource = "cache"
+	// FetchSourceLocal is a file read directly from a local working tree (see [FetchWithLocalFiles]).
+	FetchSourceLocal FetchSource = "local"
+)
+
+// FetchResult reports about a completed fetch.
+//
+// See [Fetcher.FetchLocatorWithResult].
+type FetchResult struct {
+	// Files lists the paths of the files materialized by the fetch, relative to the root of the repository.
+	//
+	// Only populated when using [FetchWithManifest]. When the content is retrieved without a checkout
+	// (e.g. from a raw-content URL), this is the fetched file only.
+	Files []string
+
+	// Note is the git note attached to the fetched commit.
+	//
+	// Only populated when using [FetchWithNotes].
+	Note string
+
+	// Source tells which path served the content, e.g. to find out why a fetch was slow.
+	Source FetchSource
+}
+
+// CloneResult reports about a completed clone.
+//
+// See [Cloner.Result].
+type CloneResult struct {
+	// ShortName is the short name of the resolved ref, e.g. "v1.2.3" or "master".
+	ShortName string
+
+	// Hash is the hash of the checked out commit.
+	//
+	// This is empty when the repository is retrieved from an archive served by the SCM, rather than using git.
+	Hash string
+
+	// Objects is the number of git objects retrieved by the clone.
+	Objects int
+
+	// Sparse indicates that a sparse filter set by [CloneWithSparseFilter] was applied.
+	Sparse bool
+
+	// Cached indicates that the clone was restored from the cache set by [CloneWithCacheDir],
+	// in which case no git object is retrieved.
+	Cached bool
+}
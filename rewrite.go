@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"net/url"
+	"strings"
+)
+
+// urlRewrite is a rule to rewrite the URL of a repository, like the git configuration "url.<base>.insteadOf".
+type urlRewrite struct {
+	base      string
+	insteadOf string
+}
+
+// rewriteURL rewrites an URL starting with the prefix of a rule.
+//
+// Like with git, the longest matching prefix wins. It returns false if no rule applies.
+func rewriteURL(rules []urlRewrite, u *url.URL) (*url.URL, bool) {
+	if len(rules) == 0 {
+		return nil, false
+	}
+
+	original := u.String()
+	var match *urlRewrite
+	for i, rule := range rules {
+		if !strings.HasPrefix(original, rule.insteadOf) {
+			continue
+		}
+
+		if match == nil || len(rule.insteadOf) > len(match.insteadOf) {
+			match = &rules[i]
+		}
+	}
+
+	if match == nil {
+		return nil, false
+	}
+
+	rewritten, err := url.Parse(match.base + strings.TrimPrefix(original, match.insteadOf))
+	if err != nil {
+		return nil, false
+	}
+
+	return rewritten, true
+}
+
+// sshToHTTPS rewrites the URL of a repository accessed over ssh into an https URL to the same host,
+// e.g. "ssh://git@github.com/owner/repo" into "https://github.com/owner/repo".
+//
+// The ssh user and port are dropped. It returns false if the URL does not use the ssh transport.
+func sshToHTTPS(u *url.URL) (*url.URL, bool) {
+	if scheme, _ := strings.CutPrefix(u.Scheme, "git+"); scheme != "ssh" {
+		return nil, false
+	}
+
+	host := u.Hostname()
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+
+	return &url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     "/" + strings.TrimPrefix(u.Path, "/"),
+		RawQuery: u.RawQuery,
+		Fragment: u.Fragment,
+	}, true
+}
+
+// scpLikeURL converts an scp-like location (e.g. "git@github.com:owner/repo.git") into an ssh URL.
+//
+// It returns false if the location is not scp-like.
+func scpLikeURL(location string) (*url.URL, bool) {
+	if strings.Contains(location, "://") {
+		return nil, false
+	}
+
+	userHost, repoPath, isSCP := strings.Cut(location, ":")
+	if !isSCP || userHost == "" || strings.Contains(userHost, "/") {
+		return nil, false
+	}
+
+	u, err := url.Parse("ssh://" + userHost + "/" + strings.TrimPrefix(repoPath, "/"))
+	if err != nil {
+		return nil, false
+	}
+
+	return u, true
+}
+
+// rewriteRepoURL applies the rules set by [FetchWithInsteadOf] or [CloneWithInsteadOf] to the URL of a repository,
+// then rewrites ssh URLs to https if [FetchWithPreferHTTPS] is enabled.
+//
+// It returns false if the URL is not rewritten.
+func (o locOptions) rewriteRepoURL(u *url.URL) (*url.URL, bool) {
+	rewritten, ok := rewriteURL(o.urlRewrites, u)
+	if !ok {
+		rewritten = u
+	}
+
+	if o.preferHTTPS {
+		if httpsURL, isSSH := sshToHTTPS(rewritten); isSSH {
+			return httpsURL, true
+		}
+	}
+
+	return rewritten, ok
+}
+
+// rewriteLocator applies the rules set by [FetchWithInsteadOf] or [CloneWithInsteadOf] to the repository
+// URL of a [Locator], as well as [FetchWithPreferHTTPS].
+func (o locOptions) rewriteLocator(locator Locator) Locator {
+	rewritten, ok := o.rewriteRepoURL(locator.RepoURL())
+	if !ok {
+		return locator
+	}
+
+	return &rewrittenLocator{
+		Locator: locator,
+		repoURL: rewritten,
+	}
+}
+
+// rewrittenLocator is a [Locator] with a rewritten repository URL.
+type rewrittenLocator struct {
+	Locator
+
+	repoURL *url.URL
+}
+
+func (l *rewrittenLocator) RepoURL() *url.URL {
+	u := *l.repoURL
+
+	return &u
+}
+
+func (l *rewrittenLocator) IsLocal() bool {
+	return l.repoURL.Scheme == fileTransport
+}
+
+func (l *rewrittenLocator) HasAuth() bool {
+	_, isSet := l.repoURL.User.Password()
+
+	return isSet
+}
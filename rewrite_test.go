@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestRewriteURL(t *testing.T) {
+	t.Parallel()
+
+	rules := []urlRewrite{
+		{base: "https://mirror.example.com/", insteadOf: "https://github.com/"},
+		{base: "https://mirror.example.com/private/", insteadOf: "https://github.com/private-org/"},
+		{base: "https://github.com/", insteadOf: "gh:"},
+	}
+
+	for input, expected := range map[string]string{
+		"https://github.com/fredbi/go-vcsfetch":       "https://mirror.example.com/fredbi/go-vcsfetch",
+		"https://github.com/private-org/repo":         "https://mirror.example.com/private/repo", // longest prefix wins
+		"gh:fredbi/go-vcsfetch":                       "https://github.com/fredbi/go-vcsfetch",
+		"https://gitlab.com/fredbi/go-vcsfetch":       "",
+		"https://github.community/fredbi/go-vcsfetch": "",
+	} {
+		u, err := url.Parse(input)
+		require.NoError(t, err)
+
+		rewritten, ok := rewriteURL(rules, u)
+		if expected == "" {
+			require.False(t, ok, input)
+
+			continue
+		}
+
+		require.True(t, ok, input)
+		require.Equal(t, expected, rewritten.String())
+	}
+}
+
+func TestFetcherInsteadOf(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should download raw content from the rewritten location", func(t *testing.T) {
+		t.Parallel()
+
+		transport := newStubTransport(func(*http.Request) *http.Response {
+			return stubResponse(http.StatusOK, "mirrored")
+		})
+		fetcher := NewFetcher(
+			FetchWithHTTPClient(&http.Client{Transport: transport}),
+			FetchWithInsteadOf("https://gitlab.com/mirror/", "https://github.com/"),
+		)
+
+		w := new(bytes.Buffer)
+		require.NoError(t, fetcher.Fetch(t.Context(), w, "https://github.com/fredbi/go-vcsfetch/blob/master/README.md"))
+		require.Equal(t, "mirrored", w.String())
+
+		requests := transport.Requests()
+		require.Len(t, requests, 1)
+		require.Equal(t, "https://gitlab.com/mirror/fredbi/go-vcsfetch/-/raw/master/README.md", requests[0].URL.String())
+	})
+
+	repo := testrepo.New(t)
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{"docs/README.md": "from the mirror"}, "initial commit"))
+	mirror := "file://" + filepath.ToSlash(repo.Dir)
+
+	t.Run("should fetch with git from the rewritten location", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher := NewFetcher(
+			FetchWithGitSkipAutoDetect(true),
+			FetchWithInsteadOf(mirror, "https://github.com/fredbi/go-vcsfetch"),
+		)
+
+		w := new(bytes.Buffer)
+		require.NoError(t, fetcher.Fetch(t.Context(), w, "https://github.com/fredbi/go-vcsfetch/blob/v1.0.0/docs/README.md"))
+		require.Equal(t, "from the mirror", w.String())
+	})
+
+	t.Run("should clone from the rewritten location", func(t *testing.T) {
+		t.Parallel()
+
+		cloner := NewCloner(
+			CloneWithGitSkipAutoDetect(true),
+			CloneWithInsteadOf(mirror, "https://github.com/fredbi/go-vcsfetch"),
+		)
+		require.NoError(t, cloner.CloneRepo(t.Context(), "https://github.com/fredbi/go-vcsfetch/tree/v1.0.0"))
+
+		content, err := fs.ReadFile(cloner.FS(), "docs/README.md")
+		require.NoError(t, err)
+		require.Equal(t, "from the mirror", string(content))
+
+		// the clone is still known by its original location
+		w := new(bytes.Buffer)
+		require.NoError(t, cloner.FetchFromClone(t.Context(), w, "https://github.com/fredbi/go-vcsfetch/blob/v1.0.0/docs/README.md"))
+		require.Equal(t, "from the mirror", w.String())
+	})
+}
+
+func TestSSHToHTTPS(t *testing.T) {
+	t.Parallel()
+
+	for input, expected := range map[string]string{
+		"ssh://git@github.com/fredbi/go-vcsfetch":      "https://github.com/fredbi/go-vcsfetch",
+		"git+ssh://git@github.com/fredbi/go-vcsfetch":  "https://github.com/fredbi/go-vcsfetch",
+		"ssh://git@gitlab.example.com:2222/group/repo": "https://gitlab.example.com/group/repo",
+		"https://github.com/fredbi/go-vcsfetch":        "",
+		"git://github.com/fredbi/go-vcsfetch":          "",
+		"file:///srv/git/repo.git":                     "",
+	} {
+		u, err := url.Parse(input)
+		require.NoError(t, err)
+
+		rewritten, ok := sshToHTTPS(u)
+		if expected == "" {
+			require.False(t, ok, input)
+
+			continue
+		}
+
+		require.True(t, ok, input)
+		require.Equal(t, expected, rewritten.String())
+	}
+
+	t.Run("scp-like locations are converted to ssh URLs", func(t *testing.T) {
+		u, ok := scpLikeURL("git@github.com:fredbi/go-vcsfetch/blob/v1.0.0/README.md")
+		require.True(t, ok)
+		require.Equal(t, "ssh://git@github.com/fredbi/go-vcsfetch/blob/v1.0.0/README.md", u.String())
+
+		_, ok = scpLikeURL("https://github.com/fredbi/go-vcsfetch")
+		require.False(t, ok)
+		_, ok = scpLikeURL("./owner/repo:file")
+		require.False(t, ok)
+	})
+}
+
+func TestFetcherPreferHTTPS(t *testing.T) {
+	t.Parallel()
+
+	const expectedURL = "https://raw.githubusercontent.com/fredbi/go-vcsfetch/v1.0.0/README.md"
+
+	for _, location := range []string{
+		"ssh://git@github.com/fredbi/go-vcsfetch/blob/v1.0.0/README.md",
+		"git@github.com:fredbi/go-vcsfetch/blob/v1.0.0/README.md",
+	} {
+		t.Run("should fetch "+location+" over https", func(t *testing.T) {
+			t.Parallel()
+
+			transport := newStubTransport(func(*http.Request) *http.Response {
+				return stubResponse(http.StatusOK, "over https")
+			})
+			fetcher := NewFetcher(
+				FetchWithHTTPClient(&http.Client{Transport: transport}),
+				FetchWithPreferHTTPS(true),
+			)
+
+			w := new(bytes.Buffer)
+			require.NoError(t, fetcher.Fetch(t.Context(), w, location))
+			require.Equal(t, "over https", w.String())
+
+			requests := transport.Requests()
+			require.Len(t, requests, 1)
+			require.Equal(t, expectedURL, requests[0].URL.String())
+		})
+	}
+
+	t.Run("should not rewrite ssh URLs by default", func(t *testing.T) {
+		t.Parallel()
+
+		fetcher := NewFetcher()
+		err := fetcher.Fetch(t.Context(), new(bytes.Buffer), "git@github.com:fredbi/go-vcsfetch/blob/v1.0.0/README.md")
+		require.ErrorIs(t, err, ErrVCS)
+
+		locator, err := fetcher.locatorFromURL(mustParseTestURL(t, "ssh://git@github.com/fredbi/go-vcsfetch/blob/v1.0.0/README.md"))
+		require.NoError(t, err)
+		require.Equal(t, "ssh", fetcher.rewriteLocator(locator).RepoURL().Scheme)
+	})
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+)
+
+// FetchWithScratch holds the content retrieved by [Fetcher.FetchReader] in memory up to threshold bytes,
+// then spills it to a temporary file in dir, so that unexpectedly large files do not balloon memory.
+//
+// The temporary file is limited to quota bytes: larger content fails with [ErrScratchQuotaExceeded].
+// A quota of 0 means no limit. If dir is empty, the default directory for temporary files is used (see [os.TempDir]).
+//
+// The temporary file is removed when the returned reader is closed, or as soon as the fetch fails.
+//
+// Files retrieved using git are checked out in memory first: use [FetchWithLargeFileThreshold] to stream
+// large files directly from the git object store.
+//
+// By default, content is held in memory.
+//
+// NOTE: [FetchWithScratch] panics if the threshold or the quota is negative.
+func FetchWithScratch(dir string, threshold, quota int64) FetchOption {
+	if threshold < 0 || quota < 0 {
+		panic(fmt.Errorf("invalid scratch threshold (%d) or quota (%d): expected non-negative values: %w", threshold, quota, ErrVCS))
+	}
+
+	return func(o *fetchOptions) {
+		o.scratchDir = dir
+		o.scratchThreshold = threshold
+		o.scratchQuota = quota
+	}
+}
+
+// FetchReader fetches a single file from a vcs location string, like [Fetcher.Fetch], and returns its content
+// as an [io.ReadCloser].
+//
+// The content is held in memory, unless it spills to disk as configured by [FetchWithScratch].
+// The caller must close the reader to relinquish these resources.
+//
+// Options passed to [Fetcher.FetchReader] apply to this call only, on top of the options of the [Fetcher].
+func (f *Fetcher) FetchReader(ctx context.Context, location string, opts ...FetchOption) (io.ReadCloser, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("expected a valid URL: %w: %w", redact.Error(err), ErrVCS)
+	}
+
+	f = f.withCallOptions(opts)
+	locator, err := f.locatorFromURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	scratch := &scratchBuffer{
+		dir:       f.scratchDir,
+		threshold: f.scratchThreshold,
+		quota:     f.scratchQuota,
+	}
+
+	if err = f.FetchLocator(ctx, scratch, locator); err != nil {
+		scratch.discard()
+
+		return nil, err
+	}
+
+	return scratch.reader()
+}
+
+// scratchBuffer is an [io.Writer] which holds content in memory up to a threshold,
+// then spills it to a temporary file limited to a quota.
+//
+// A threshold of 0 never spills to disk.
+type scratchBuffer struct {
+	dir       string
+	threshold int64
+	quota     int64
+
+	mem  bytes.Buffer
+	file *os.File
+	size int64
+}
+
+func (s *scratchBuffer) Write(p []byte) (int, error) {
+	if s.file == nil && s.threshold > 0 && int64(s.mem.Len()+len(p)) > s.threshold {
+		if err := s.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	if s.file == nil {
+		n, _ := s.mem.Write(p)
+		s.size += int64(n)
+
+		return n, nil
+	}
+
+	if s.quota > 0 && s.size+int64(len(p)) > s.quota {
+		return 0, fmt.Errorf("content exceeds the scratch quota of %d bytes: %w: %w", s.quota, ErrScratchQuotaExceeded, ErrVCS)
+	}
+
+	n, err := s.file.Write(p)
+	s.size += int64(n)
+
+	return n, err
+}
+
+// spill moves the content held in memory to a temporary file.
+func (s *scratchBuffer) spill() error {
+	file, err := os.CreateTemp(s.dir, "vcsfetch-scratch-*")
+	if err != nil {
+		return fmt.Errorf("could not create scratch file: %w: %w", err, ErrVCS)
+	}
+	s.file = file
+
+	if s.quota > 0 && int64(s.mem.Len()) > s.quota {
+		return fmt.Errorf("content exceeds the scratch quota of %d bytes: %w: %w", s.quota, ErrScratchQuotaExceeded, ErrVCS)
+	}
+
+	if _, err = s.mem.WriteTo(file); err != nil {
+		return fmt.Errorf("could not write scratch file: %w: %w", err, ErrVCS)
+	}
+	s.mem = bytes.Buffer{} // relinquish memory
+
+	return nil
+}
+
+// reader yields the content held by the buffer.
+func (s *scratchBuffer) reader() (io.ReadCloser, error) {
+	if s.file == nil {
+		return io.NopCloser(bytes.NewReader(s.mem.Bytes())), nil
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		s.discard()
+
+		return nil, fmt.Errorf("could not read scratch file: %w: %w", err, ErrVCS)
+	}
+
+	return &scratchFile{File: s.file}, nil
+}
+
+// discard removes the temporary file, if any.
+func (s *scratchBuffer) discard() {
+	if s.file == nil {
+		return
+	}
+
+	_ = (&scratchFile{File: s.file}).Close()
+	s.file = nil
+}
+
+// scratchFile is a temporary file, removed when closed.
+type scratchFile struct {
+	*os.File
+}
+
+func (f *scratchFile) Close() error {
+	return errors.Join(f.File.Close(), os.Remove(f.Name()))
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetchReader(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("0123456789abcdef", 4096) // 64 KiB
+	repo := testrepo.New(t)
+	repo.Commit(map[string]string{
+		"small.txt": "small",
+		"large.txt": large,
+	}, "initial commit")
+
+	const threshold = 1024
+
+	t.Run("should hold small content in memory", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		r, err := NewFetcher(FetchWithScratch(dir, threshold, 0)).FetchReader(t.Context(), repo.Dir+"@master/small.txt")
+		require.NoError(t, err)
+		requireScratchFiles(t, dir, 0)
+
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "small", string(content))
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("should spill large content to disk above the threshold", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		r, err := NewFetcher(FetchWithScratch(dir, threshold, 0)).FetchReader(t.Context(), repo.Dir+"@master/large.txt")
+		require.NoError(t, err)
+		requireScratchFiles(t, dir, 1)
+
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, large, string(content))
+
+		require.NoError(t, r.Close())
+		requireScratchFiles(t, dir, 0)
+	})
+
+	t.Run("should hold large content in memory by default", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := NewFetcher().FetchReader(t.Context(), repo.Dir+"@master/large.txt")
+		require.NoError(t, err)
+		_, isFile := r.(*scratchFile)
+		require.False(t, isFile)
+
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, large, string(content))
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("should fail and clean up when the content exceeds the quota", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		_, err := NewFetcher(FetchWithScratch(dir, threshold, 4*threshold)).FetchReader(t.Context(), repo.Dir+"@master/large.txt")
+		require.ErrorIs(t, err, ErrScratchQuotaExceeded)
+		require.ErrorIs(t, err, ErrVCS)
+		requireScratchFiles(t, dir, 0)
+	})
+
+	t.Run("should reject negative settings", func(t *testing.T) {
+		t.Parallel()
+
+		require.Panics(t, func() { _ = FetchWithScratch("", -1, 0) })
+		require.Panics(t, func() { _ = FetchWithScratch("", 0, -1) })
+	})
+}
+
+func requireScratchFiles(t *testing.T, dir string, expected int) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, expected)
+}
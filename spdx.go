@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/giturl/common"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
 )
 
 var _ Locator = &SPDXLocator{}
@@ -68,8 +71,11 @@ var _ Locator = &SPDXLocator{}
 //     Therefore schemes such as "git+https" and "https" are equivalent.
 //   - "username:password" credentials
 //   - hostname port
-//   - query parameters in URL are ignored but tolerated
+//   - query parameters in URL are ignored but tolerated, including after the fragment,
+//     e.g. git+https://github.com/fredbi/go-vcsfetch@v1.0.0#README.md?plain=1
 //   - the absence of an explicit reference provided with "@" will be resolved as the head of the default branch
+//   - with the "file" transport, the repository is a local path. Relative paths are resolved against
+//     the current working directory, e.g. git+file://./local/repo@main#file or git+file:///srv/git/repo@main#file
 //
 // Optionally, the [SPDXLocator] may support SCM-specific shorthands using "git repo slugs":
 //
@@ -96,7 +102,7 @@ func ParseSPDXLocator(location string, opts ...SPDXOption) (*SPDXLocator, error)
 
 	u, err := url.Parse(location)
 	if err != nil {
-		return nil, fmt.Errorf("a SPDX locator should be a valid URL: %w: %w", err, ErrVCS)
+		return nil, fmt.Errorf("a SPDX locator should be a valid URL: %w: %w", redact.Error(err), ErrVCS)
 	}
 
 	return SPDXLocatorFromURL(u, opts...)
@@ -110,36 +116,49 @@ func SPDXLocatorFromURL(u *url.URL, opts ...SPDXOption) (*SPDXLocator, error) {
 	)
 	o := optionsWithDefaults(opts)
 
+	if err := checkTransport(u); err != nil {
+		return nil, err
+	}
+
 	if u.Path == "" {
 		return nil, fmt.Errorf("SPDX locator requires an URL path: %w", ErrVCS)
 	}
-	if u.Fragment == "" {
+	// query parameters are ignored: those following the fragment are parsed as part of it
+	subPath, err := spdxSubPath(u)
+	if err != nil {
+		return nil, err
+	}
+	if subPath == "" {
 		return nil, fmt.Errorf("SPDX locator requires an URL fragment to specify a single file: %w", ErrVCS)
 	}
 
 	// scheme analysis
 	var tool, transport string
 	parts := strings.SplitN(u.Scheme, "+", schemeParts)
-	if len(parts) > 0 {
-		tool = parts[0]
-		transport = parts[1]
-	} else {
-		tool = "git"
-		transport = u.Scheme
+	if len(parts) != schemeParts || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("SPDX locator requires a scheme like <vcs_tool>+<transport>, e.g. git+https, but got %q: %w", u.Scheme, ErrVCS)
 	}
+	tool = parts[0]
+	transport = parts[1]
 
 	var repoPath, ref string
 	parts = strings.SplitN(u.Path, "@", repoParts)
-	if len(parts) > 0 {
-		repoPath = parts[0]
+	repoPath = parts[0]
+	if len(parts) == repoParts {
 		ref = parts[1]
-	} else {
-		repoPath = u.Path
 	}
 	if o.requireVersion && ref == "" {
 		return nil, fmt.Errorf("a non-empty version is required: %w", ErrVCS)
 	}
 
+	host := common.NormalizeHost(transport, u.Host)
+	if transport == fileTransport {
+		if repoPath, err = localRepoPath(u.Host, repoPath); err != nil {
+			return nil, err
+		}
+		host = ""
+	}
+
 	var userinfo url.Userinfo
 	if u.User != nil {
 		userinfo = *(u.User)
@@ -149,21 +168,40 @@ func SPDXLocatorFromURL(u *url.URL, opts ...SPDXOption) (*SPDXLocator, error) {
 		Userinfo:  userinfo,
 		Tool:      tool,
 		Transport: transport,
-		Host:      u.Host,
+		Host:      host,
 		RepoPath:  repoPath,
 		Ref:       ref,
-		SubPath:   u.Fragment,
+		SubPath:   subPath,
 	}, nil
 }
 
+// spdxSubPath yields the path designated by the fragment of a SPDX locator, without any query parameters,
+// e.g. "README.md" for "#README.md?plain=1".
+//
+// An escaped question mark ("%3F") remains part of the path.
+func spdxSubPath(u *url.URL) (string, error) {
+	fragment, _, _ := strings.Cut(u.EscapedFragment(), "?")
+	subPath, err := url.PathUnescape(fragment)
+	if err != nil {
+		return "", fmt.Errorf("SPDX locator requires a valid URL fragment: %w: %w", redact.Error(err), ErrVCS)
+	}
+
+	return subPath, nil
+}
+
 func (l *SPDXLocator) RepoURL() *url.URL {
 	u := &url.URL{
 		Scheme: l.Transport,
-		User:   &l.Userinfo,
 		Host:   l.Host,
 		Path:   l.RepoPath,
 	}
 
+	if password, isSet := l.Password(); isSet {
+		u.User = url.UserPassword(l.Username(), password)
+	} else if username := l.Username(); username != "" {
+		u.User = url.User(username)
+	}
+
 	return u
 }
 
@@ -176,7 +214,7 @@ func (l *SPDXLocator) Path() string {
 }
 
 func (l *SPDXLocator) IsLocal() bool {
-	return l.Transport == "file"
+	return l.Transport == fileTransport
 }
 
 func (l *SPDXLocator) HasAuth() bool {
@@ -191,6 +229,17 @@ func (l *SPDXLocator) String() string {
 	}
 	u.Path += "@" + l.Version()
 	u.Fragment = l.Path()
+	u.RawFragment = strings.ReplaceAll(u.EscapedFragment(), "?", "%3F") // a question mark in the path is not a query
 
 	return u.String()
 }
+
+// WithVersion yields a copy of the [SPDXLocator], for the same repository and path at another version.
+//
+// This is convenient to fetch the same file at several versions, without parsing the location again.
+func (l *SPDXLocator) WithVersion(version string) Locator {
+	pinned := *l
+	pinned.Ref = version
+
+	return &pinned
+}
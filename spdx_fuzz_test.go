@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"net/url"
+	"testing"
+)
+
+// FuzzSPDXParse asserts that the SPDX locator parser never panics, whatever the input.
+//
+// The seed corpus in testdata/fuzz is made of the locators used by the unit tests.
+func FuzzSPDXParse(f *testing.F) {
+	f.Fuzz(func(t *testing.T, input string) {
+		u, err := url.Parse(input)
+		if err != nil {
+			return
+		}
+
+		locator, err := SPDXLocatorFromURL(u)
+		if err != nil {
+			return
+		}
+
+		if locator.RepoURL() == nil {
+			t.Fatalf("expected a non-nil repo URL for %q", input)
+		}
+
+		_ = locator.String()
+	})
+}
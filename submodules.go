@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/git"
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+	"github.com/go-git/go-git/v5/config"
+)
+
+const gitModulesFile = ".gitmodules"
+
+// Submodule describes a git submodule declared in the .gitmodules file of a repository.
+type Submodule struct {
+	// Name of the submodule.
+	Name string
+
+	// Path of the submodule, relative to the root of the repository.
+	Path string
+
+	// URL of the repository of the submodule.
+	//
+	// Relative URLs (e.g. "../other.git") are resolved against the URL of the parent repository,
+	// and scp-like URLs (e.g. "git@github.com:owner/repo.git") are converted into ssh URLs.
+	URL string
+
+	// Branch tracked by the submodule, if declared.
+	Branch string
+}
+
+// ListSubmodules lists the submodules declared by a repository at a given version, e.g. to decide whether
+// to fetch files with [FetchWithRecurseSubmodules].
+//
+// Only the .gitmodules file of the repository is fetched: submodules are not fetched.
+// Submodules are sorted by path.
+//
+// An empty version resolves to the default branch of the repository.
+// A repository without a .gitmodules file has no submodules.
+func (f *Fetcher) ListSubmodules(ctx context.Context, repoURL *url.URL, version string) ([]Submodule, error) {
+	if repoURL == nil {
+		return nil, fmt.Errorf("a repository URL is required: %w", ErrVCS)
+	}
+
+	var buf bytes.Buffer
+	if err := f.FetchLocator(ctx, &buf, repoLocator(repoURL, version, gitModulesFile)); err != nil {
+		if errors.Is(err, ErrFileNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	modules := config.NewModules()
+	if err := modules.Unmarshal(buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("invalid %s in %v: %w: %w", gitModulesFile, redact.URL(repoURL), err, ErrVCS)
+	}
+
+	submodules := make([]Submodule, 0, len(modules.Submodules))
+	for _, module := range modules.Submodules {
+		submoduleURL, err := git.ResolveSubmoduleURL(repoURL, module.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid submodule %q in %v: %w: %w", module.Name, redact.URL(repoURL), redact.Error(err), ErrVCS)
+		}
+
+		submodules = append(submodules, Submodule{
+			Name:   module.Name,
+			Path:   path.Clean(module.Path),
+			URL:    submoduleURL.String(),
+			Branch: module.Branch,
+		})
+	}
+
+	slices.SortFunc(submodules, func(a, b Submodule) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return submodules, nil
+}
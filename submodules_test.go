@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetcherListSubmodules(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Tag("v0.1.0", repo.Commit(map[string]string{"README.md": "no submodule"}, "initial"))
+	repo.Tag("v1.0.0", repo.Commit(map[string]string{
+		".gitmodules": `[submodule "vendor/lib"]
+	path = vendor/lib
+	url = ../lib.git
+	branch = stable
+[submodule "docs"]
+	path = docs
+	url = https://example.com/org/docs.git
+`,
+	}, "add submodules"))
+
+	t.Run("should list the submodules declared at a version", func(t *testing.T) {
+		t.Parallel()
+
+		submodules, err := NewFetcher().ListSubmodules(t.Context(), repo.URL(), "v1.0.0")
+		require.NoError(t, err)
+		require.Len(t, submodules, 2)
+
+		require.Equal(t, Submodule{Name: "docs", Path: "docs", URL: "https://example.com/org/docs.git"}, submodules[0])
+		require.Equal(t, "vendor/lib", submodules[1].Name)
+		require.Equal(t, "vendor/lib", submodules[1].Path)
+		require.Equal(t, "stable", submodules[1].Branch)
+		require.True(t, strings.HasSuffix(submodules[1].URL, "/lib.git"))
+		require.NotContains(t, submodules[1].URL, "..")
+	})
+
+	t.Run("should list no submodule when the repository has no .gitmodules", func(t *testing.T) {
+		t.Parallel()
+
+		submodules, err := NewFetcher().ListSubmodules(t.Context(), repo.URL(), "v0.1.0")
+		require.NoError(t, err)
+		require.Empty(t, submodules)
+	})
+
+	t.Run("should require a repository URL", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewFetcher().ListSubmodules(t.Context(), nil, "")
+		require.ErrorIs(t, err, ErrVCS)
+	})
+}
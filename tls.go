@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	"github.com/fredbi/go-vcsfetch/internal/download"
+)
+
+// FetchWithCABundle trusts the certificate authorities of a PEM-encoded bundle over https,
+// e.g. the CA of a self-hosted SCM.
+//
+// The bundle applies to git operations and to raw-content downloads. By default, it is trusted
+// together with the system roots: see [FetchWithCABundleFromSystem].
+//
+// NOTE: [FetchWithCABundle] panics if the bundle does not contain any valid PEM certificate.
+func FetchWithCABundle(pemCerts []byte) FetchOption {
+	apply := withCABundle(pemCerts)
+
+	return func(o *fetchOptions) {
+		apply(&o.locOptions)
+		o.gitOptions.caBundle = o.locOptions.caBundle
+	}
+}
+
+// FetchWithCABundleFromSystem tells if the CA bundle set with [FetchWithCABundle] is appended to the system roots
+// (enabled), or replaces them (disabled).
+//
+// NOTE: git operations always trust the system roots in addition to the CA bundle, since the underlying git
+// implementation does not support replacing them. Disabling this option only restricts raw-content downloads
+// to the CA bundle.
+//
+// By default, the CA bundle is appended to the system roots.
+func FetchWithCABundleFromSystem(enabled bool) FetchOption {
+	return func(o *fetchOptions) {
+		o.caBundleWithoutSystem = !enabled
+	}
+}
+
+// CloneWithCABundle trusts the certificate authorities of a PEM-encoded bundle over https.
+//
+// See [FetchWithCABundle].
+func CloneWithCABundle(pemCerts []byte) CloneOption {
+	apply := withCABundle(pemCerts)
+
+	return func(o *cloneOptions) {
+		apply(&o.locOptions)
+		o.gitOptions.caBundle = o.locOptions.caBundle
+	}
+}
+
+// CloneWithCABundleFromSystem tells if the CA bundle set with [CloneWithCABundle] is appended to the system roots
+// (enabled), or replaces them (disabled).
+//
+// See [FetchWithCABundleFromSystem].
+func CloneWithCABundleFromSystem(enabled bool) CloneOption {
+	return func(o *cloneOptions) {
+		o.caBundleWithoutSystem = !enabled
+	}
+}
+
+func withCABundle(pemCerts []byte) locOption {
+	if !x509.NewCertPool().AppendCertsFromPEM(pemCerts) {
+		panic(fmt.Errorf("invalid CA bundle: expected at least one PEM-encoded certificate: %w", ErrVCS))
+	}
+	pemCerts = bytes.Clone(pemCerts)
+
+	return func(o *locOptions) {
+		o.caBundle = pemCerts
+	}
+}
+
+// withTLS derives the [http.Client] used for downloads, so that it trusts the CA bundle.
+//
+// The transport of the client is cloned once, so connections are reused across downloads.
+// A client which transport is not an [*http.Transport] is left unchanged.
+func (o *locOptions) withTLS() {
+	o.downloadClient = o.httpClient
+	if len(o.caBundle) == 0 {
+		return
+	}
+
+	client := o.httpClient
+	if client == nil {
+		client = download.DefaultClient()
+	}
+
+	var transport *http.Transport
+	switch rt := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return
+	}
+
+	roots := x509.NewCertPool()
+	if !o.caBundleWithoutSystem {
+		systemCertPool := o.systemCertPool
+		if systemCertPool == nil {
+			systemCertPool = x509.SystemCertPool
+		}
+
+		if system, err := systemCertPool(); err == nil && system != nil {
+			roots = system.Clone()
+		}
+	}
+	roots.AppendCertsFromPEM(o.caBundle)
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	transport.TLSClientConfig.RootCAs = roots
+
+	derived := *client // shallow clone
+	derived.Transport = transport
+	o.downloadClient = &derived
+}
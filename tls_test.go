@@ -0,0 +1,149 @@
+package vcsfetch
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetcherCABundle(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	systemServer := httptest.NewTLSServer(handler)
+	t.Cleanup(systemServer.Close)
+	bundleServer, bundle := newTLSServerWithCA(t, handler)
+
+	// the certificate of systemServer stands for the system roots
+	systemRoots := x509.NewCertPool()
+	systemRoots.AddCert(systemServer.Certificate())
+	withSystemRoots := func(o *fetchOptions) {
+		o.systemCertPool = func() (*x509.CertPool, error) { return systemRoots, nil }
+	}
+
+	get := func(t *testing.T, f *Fetcher, server *httptest.Server) error {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := f.downloadClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	t.Run("should trust the CA bundle together with the system roots", func(t *testing.T) {
+		fetcher := NewFetcher(withSystemRoots, FetchWithCABundle(bundle))
+		t.Cleanup(func() { _ = fetcher.Close() })
+
+		require.NoError(t, get(t, fetcher, systemServer))
+		require.NoError(t, get(t, fetcher, bundleServer))
+		require.Equal(t, bundle, fetcher.toInternalGitOptions().CABundle)
+	})
+
+	t.Run("should trust only the CA bundle", func(t *testing.T) {
+		fetcher := NewFetcher(withSystemRoots, FetchWithCABundle(bundle), FetchWithCABundleFromSystem(false))
+		t.Cleanup(func() { _ = fetcher.Close() })
+
+		require.Error(t, get(t, fetcher, systemServer))
+		require.NoError(t, get(t, fetcher, bundleServer))
+	})
+
+	t.Run("should apply the CA bundle as a per-call option", func(t *testing.T) {
+		fetcher := NewFetcher(withSystemRoots)
+		t.Cleanup(func() { _ = fetcher.Close() })
+
+		require.Nil(t, fetcher.downloadClient)
+		call := fetcher.withCallOptions([]FetchOption{FetchWithCABundle(bundle), FetchWithCABundleFromSystem(false)})
+		require.Error(t, get(t, call, systemServer))
+		require.NoError(t, get(t, call, bundleServer))
+	})
+
+	t.Run("should preserve the settings of a custom http client", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{MaxIdleConns: 7}}
+		fetcher := NewFetcher(FetchWithHTTPClient(client), FetchWithCABundle(bundle))
+
+		transport, ok := fetcher.downloadClient.Transport.(*http.Transport)
+		require.True(t, ok)
+		require.Equal(t, 7, transport.MaxIdleConns)
+		require.NotSame(t, client.Transport, transport)
+		if original := client.Transport.(*http.Transport).TLSClientConfig; original != nil {
+			require.Nil(t, original.RootCAs)
+		}
+	})
+
+	t.Run("should reject an invalid CA bundle", func(t *testing.T) {
+		for _, option := range []func(){
+			func() { _ = FetchWithCABundle([]byte("not a certificate")) },
+			func() { _ = CloneWithCABundle(nil) },
+		} {
+			func() {
+				defer func() {
+					err, ok := recover().(error)
+					require.True(t, ok)
+					require.ErrorIs(t, err, ErrVCS)
+				}()
+
+				option()
+			}()
+		}
+	})
+
+	t.Run("should apply the CA bundle to clones", func(t *testing.T) {
+		cloner := NewCloner(CloneWithCABundle(bundle), CloneWithCABundleFromSystem(false))
+
+		require.Equal(t, bundle, cloner.toInternalGitOptions().CABundle)
+		require.NotNil(t, cloner.downloadClient)
+	})
+}
+
+// newTLSServerWithCA starts a https server with a self-signed certificate, distinct from the one
+// of [httptest.NewTLSServer], and returns this certificate as a PEM bundle.
+func newTLSServerWithCA(t *testing.T, handler http.Handler) (*httptest.Server, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"go-vcsfetch test CA"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	defaultOutputMode os.FileMode = 0o644
+	outputDirMode     os.FileMode = 0o755
+)
+
+// FetchToFile fetches a single file from a vcs location string, and writes it to a local file.
+//
+// The file is written atomically: the content is fetched into a temporary file in the same folder,
+// which is renamed to the destination only once the fetch has completed. A failed fetch leaves
+// any existing destination file untouched.
+//
+// Missing parent folders are created with mode 0755. The mode of the file is set by [FetchWithOutputMode],
+// and defaults to 0644.
+func (f *Fetcher) FetchToFile(ctx context.Context, location, destination string) (err error) {
+	dir := filepath.Dir(destination)
+	if err = os.MkdirAll(dir, outputDirMode); err != nil {
+		return fmt.Errorf("could not create folder %q: %w: %w", dir, err, ErrVCS)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(destination)+".*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary file in %q: %w: %w", dir, err, ErrVCS)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = f.Fetch(ctx, tmp, location); err != nil {
+		return err
+	}
+
+	if err = tmp.Chmod(f.outputFileMode()); err != nil {
+		return fmt.Errorf("could not set the mode of %q: %w: %w", destination, err, ErrVCS)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("could not write %q: %w: %w", destination, err, ErrVCS)
+	}
+
+	if err = os.Rename(tmp.Name(), destination); err != nil {
+		return fmt.Errorf("could not write %q: %w: %w", destination, err, ErrVCS)
+	}
+
+	return nil
+}
+
+func (f *Fetcher) outputFileMode() os.FileMode {
+	if f.outputMode == 0 {
+		return defaultOutputMode
+	}
+
+	return f.outputMode
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetchToFile(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Commit(map[string]string{"docs/README.md": "content"}, "initial commit")
+
+	t.Run("should write the fetched file, creating parent folders", func(t *testing.T) {
+		t.Parallel()
+
+		destination := filepath.Join(t.TempDir(), "a", "b", "README.md")
+		require.NoError(t, NewFetcher().FetchToFile(t.Context(), repo.Dir+"@master/docs/README.md", destination))
+
+		content, err := os.ReadFile(destination)
+		require.NoError(t, err)
+		require.Equal(t, "content", string(content))
+	})
+
+	t.Run("should leave the destination untouched on failure", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		destination := filepath.Join(dir, "README.md")
+		require.NoError(t, os.WriteFile(destination, []byte("previous"), 0o600))
+
+		err := NewFetcher().FetchToFile(t.Context(), repo.Dir+"@master/docs/missing.md", destination)
+		require.ErrorIs(t, err, ErrVCS)
+
+		content, err := os.ReadFile(destination)
+		require.NoError(t, err)
+		require.Equal(t, "previous", string(content))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1, "the temporary file should be removed")
+	})
+}
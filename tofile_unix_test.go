@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix
+
+package vcsfetch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fredbi/go-vcsfetch/internal/testrepo"
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestFetchToFileMode(t *testing.T) {
+	t.Parallel()
+
+	repo := testrepo.New(t)
+	repo.Commit(map[string]string{"secret.txt": "secret"}, "initial commit")
+	location := repo.Dir + "@master/secret.txt"
+
+	for _, tc := range []struct {
+		name     string
+		opts     []FetchOption
+		expected os.FileMode
+	}{
+		{name: "should default to 0644", expected: 0o644},
+		{name: "should apply the requested mode", opts: []FetchOption{FetchWithOutputMode(0o600)}, expected: 0o600},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join(t.TempDir(), "parent")
+			destination := filepath.Join(dir, "secret.txt")
+			require.NoError(t, NewFetcher(tc.opts...).FetchToFile(t.Context(), location, destination))
+
+			info, err := os.Stat(destination)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, info.Mode().Perm())
+
+			info, err = os.Stat(dir)
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(0o755), info.Mode().Perm()&^umask(t))
+		})
+	}
+}
+
+// umask yields the bits of the process umask, which restrict the mode of created folders.
+func umask(t *testing.T) os.FileMode {
+	t.Helper()
+
+	probe := filepath.Join(t.TempDir(), "probe")
+	require.NoError(t, os.Mkdir(probe, 0o777))
+
+	info, err := os.Stat(probe)
+	require.NoError(t, err)
+
+	return 0o777 &^ info.Mode().Perm()
+}
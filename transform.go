@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"fmt"
+	"io"
+)
+
+// transformWriter wraps an [io.Writer] so that the content written goes through a chain of transforms
+// before reaching the destination.
+//
+// Transforms run in a separate goroutine, reading from a pipe fed by the writer.
+//
+// The returned wait function must be called once the source is exhausted (or failed) to flush the transforms.
+// It returns the source error, if any, or the transform error.
+func transformWriter(w io.Writer, transforms []func(io.Reader) (io.Reader, error)) (io.Writer, func(error) error) {
+	if len(transforms) == 0 {
+		return w, func(err error) error { return err }
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+
+	go func() {
+		var (
+			r   io.Reader = pr
+			err error
+		)
+
+		for _, transform := range transforms {
+			if r, err = transform(r); err != nil {
+				break
+			}
+		}
+
+		if err == nil {
+			_, err = io.Copy(w, r)
+		}
+
+		if err != nil {
+			// abort: the source gets this error on its next write
+			_ = pr.CloseWithError(err)
+		} else {
+			// the transform may not consume all its input: drain the pipe to unblock the source
+			_, _ = io.Copy(io.Discard, pr)
+		}
+
+		done <- err
+	}()
+
+	wait := func(srcErr error) error {
+		_ = pw.CloseWithError(srcErr) // a nil error signals io.EOF to the transforms
+		err := <-done
+
+		if srcErr != nil {
+			return srcErr
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not transform content: %w: %w", err, ErrVCS)
+		}
+
+		return nil
+	}
+
+	return pw, wait
+}
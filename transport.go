@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// checkTransport rejects URLs using a git remote helper, i.e. "<transport>::<address>",
+// e.g. "ext::ssh -i key host %S repo" or "gcrypt::https://host/repo".
+//
+// Remote helpers are not supported by the underlying git implementation.
+func checkTransport(u *url.URL) error {
+	if u.Scheme == "" || !strings.HasPrefix(u.Opaque, ":") {
+		return nil
+	}
+
+	helper, _ := strings.CutPrefix(u.Scheme, "git+")
+
+	return fmt.Errorf("git remote helper %q is not supported: %w: %w", helper+"::", ErrUnsupportedTransport, ErrVCS)
+}
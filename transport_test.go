@@ -0,0 +1,48 @@
+package vcsfetch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestUnsupportedTransport(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		location string
+		helper   string
+	}{
+		{"ext::ssh -i key host %S repo", "ext::"},
+		{"gcrypt::https://github.com/fredbi/go-vcsfetch@v1.0.0#README.md", "gcrypt::"},
+		{"fd::17/repo", "fd::"},
+		{"git+ext::ssh host repo", "ext::"},
+	} {
+		t.Run("should reject "+tc.location, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseGitLocator(tc.location)
+			require.ErrorIs(t, err, ErrUnsupportedTransport)
+			require.ErrorIs(t, err, ErrVCS)
+			require.ErrorContains(t, err, tc.helper)
+
+			_, err = ParseSPDXLocator(tc.location)
+			require.ErrorIs(t, err, ErrUnsupportedTransport)
+			require.ErrorIs(t, err, ErrVCS)
+			require.ErrorContains(t, err, tc.helper)
+
+			err = NewFetcher().Fetch(t.Context(), new(bytes.Buffer), tc.location)
+			require.ErrorIs(t, err, ErrUnsupportedTransport)
+			require.ErrorIs(t, err, ErrVCS)
+		})
+	}
+
+	t.Run("should accept regular transports", func(t *testing.T) {
+		_, err := ParseGitLocator("https://github.com/fredbi/go-vcsfetch/blob/master/README.md")
+		require.NoError(t, err)
+
+		_, err = ParseSPDXLocator("git+ssh://git@github.com/fredbi/go-vcsfetch@master#README.md")
+		require.NoError(t, err)
+	})
+}
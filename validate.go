@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/fredbi/go-vcsfetch/internal/redact"
+)
+
+// supportedSchemes are the URL schemes of the repositories that may be fetched.
+var supportedSchemes = map[string]struct{}{
+	"http":        {},
+	"https":       {},
+	"ssh":         {},
+	"git":         {},
+	fileTransport: {},
+}
+
+// ValidateLocation checks that a location designates a file that a [Fetcher] may fetch, e.g. to lint a configuration.
+//
+// The location is parsed like with [Fetcher.Fetch], as a SPDX locator or as a git URL, then structural requirements
+// are checked: a supported transport, a recognized provider, a path to a file and, with [FetchWithRequireVersion],
+// a version. URL rewrites set with [FetchWithInsteadOf] are applied.
+//
+// No network call is performed: the repository, the version or the file may still not exist.
+//
+// The returned error tells the reason why the location would fail to be fetched.
+// Errors of this kind match [ErrInvalidLocation] and [ErrVCS].
+func ValidateLocation(location string, opts ...FetchOption) error {
+	if location == "" {
+		return fmt.Errorf("empty location: %w: %w", ErrInvalidLocation, ErrVCS)
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return fmt.Errorf("expected a valid URL: %w: %w: %w", redact.Error(err), ErrInvalidLocation, ErrVCS)
+	}
+
+	f := NewFetcher(opts...)
+	locator, err := f.locatorFromURL(u)
+	if err != nil {
+		return fmt.Errorf("%w: %w", err, ErrInvalidLocation)
+	}
+	locator = f.rewriteLocator(locator)
+
+	repoURL := locator.RepoURL()
+	if repoURL == nil || repoURL.Host == "" && repoURL.Scheme != fileTransport {
+		return fmt.Errorf("no repository in %q: %w: %w", redact.String(location), ErrInvalidLocation, ErrVCS)
+	}
+
+	scheme, _ := strings.CutPrefix(repoURL.Scheme, "git+")
+	if _, ok := supportedSchemes[scheme]; !ok {
+		return fmt.Errorf("unsupported URL scheme %q in %q: %w: %w", repoURL.Scheme, redact.String(location), ErrInvalidLocation, ErrVCS)
+	}
+
+	if pth := strings.Trim(path.Clean("/"+locator.Path()), "/"); pth == "" {
+		return fmt.Errorf("no file to fetch in %q: %w: %w", redact.String(location), ErrInvalidLocation, ErrVCS)
+	}
+
+	if f.requireVersion && locator.Version() == "" {
+		return fmt.Errorf("an explicit version is required, but %q does not specify a version: %w: %w", redact.String(location), ErrInvalidLocation, ErrVCS)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: Copyright 2025 Frédéric BIDON
+// SPDX-License-Identifier: Apache-2.0
+
+package vcsfetch
+
+import (
+	"testing"
+
+	"github.com/go-openapi/testify/v2/require"
+)
+
+func TestValidateLocation(t *testing.T) {
+	t.Parallel()
+
+	for _, location := range []string{
+		"https://github.com/fredbi/go-vcsfetch/blob/v1.0.0/README.md",
+		"git+https://github.com/fredbi/go-vcsfetch@v1.0.0#docs/README.md",
+		"https://gitlab.com/fredbi/go-vcsfetch/-/blob/main/README.md",
+	} {
+		t.Run("should validate "+location, func(t *testing.T) {
+			t.Parallel()
+
+			require.NoError(t, ValidateLocation(location))
+		})
+	}
+
+	for _, tc := range []struct {
+		name     string
+		location string
+		opts     []FetchOption
+		reason   string
+		expected error
+	}{
+		{name: "empty location", location: "", reason: "empty location"},
+		{name: "invalid URL", location: "https://github.com/%zz", reason: "valid URL"},
+		{name: "remote helper", location: "ext::ssh -i key host/repo", expected: ErrUnsupportedTransport},
+		{name: "unknown provider", location: "https://example.com/owner/repo/README.md", reason: "not a SPDX locator or a recognized git URL"},
+		{name: "unsupported scheme", location: "git+ftp://github.com/fredbi/go-vcsfetch@v1.0.0#README.md", reason: "unsupported URL scheme"},
+		{name: "no file", location: "git+https://github.com/fredbi/go-vcsfetch@v1.0.0", reason: "no file to fetch"},
+		{
+			name:     "missing required version",
+			location: "git+https://github.com/fredbi/go-vcsfetch#README.md",
+			opts:     []FetchOption{FetchWithRequireVersion(true)},
+			reason:   "explicit version is required",
+		},
+	} {
+		t.Run("should report "+tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateLocation(tc.location, tc.opts...)
+			require.ErrorIs(t, err, ErrInvalidLocation)
+			require.ErrorIs(t, err, ErrVCS)
+			if tc.reason != "" {
+				require.ErrorContains(t, err, tc.reason)
+			}
+			if tc.expected != nil {
+				require.ErrorIs(t, err, tc.expected)
+			}
+		})
+	}
+
+	t.Run("should apply URL rewrites", func(t *testing.T) {
+		t.Parallel()
+
+		const location = "https://github.com/fredbi/go-vcsfetch/blob/v1.0.0/README.md"
+		err := ValidateLocation(location, FetchWithInsteadOf("ftp://mirror.example.com/", "https://github.com/"))
+		require.ErrorIs(t, err, ErrInvalidLocation)
+		require.ErrorContains(t, err, "unsupported URL scheme")
+	})
+}